@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// accessLogFlushInterval bounds how much of the access log a crash can lose
+const accessLogFlushInterval = time.Second
+
+// accessLog appends one line per HTTP request to a file for --access-log. Writes
+// are buffered and guarded by a mutex, and flushed every accessLogFlushInterval
+// so lines reach the file even while requests stop; the file is rotated once it
+// passes maxSize.
+type accessLog struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	file    *os.File
+	w       *bufio.Writer
+	size    int64
+	done    chan struct{}
+	stop    sync.Once
+}
+
+// openAccessLog opens path for appending, creating it if needed. maxSize of 0 never rotates.
+func openAccessLog(path string, maxSize int64) (*accessLog, error) {
+	l := &accessLog{path: path, maxSize: maxSize, done: make(chan struct{})}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	go l.flushLoop()
+	return l, nil
+}
+
+// flushLoop flushes the buffer on a ticker until the log is closed
+func (l *accessLog) flushLoop() {
+	ticker := time.NewTicker(accessLogFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+			l.mu.Lock()
+			if l.file != nil {
+				l.w.Flush()
+			}
+			l.mu.Unlock()
+		}
+	}
+}
+
+// open (re)opens the log file, picking up its current size
+func (l *accessLog) open() error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	l.file = file
+	l.w = bufio.NewWriter(file)
+	l.size = info.Size()
+	return nil
+}
+
+// rotate moves the full log aside under a timestamped name and starts a new one,
+// so no earlier lines are lost
+func (l *accessLog) rotate() error {
+	if err := l.w.Flush(); err != nil {
+		return err
+	}
+	if err := l.file.Close(); err != nil {
+		l.file = nil
+		return err
+	}
+	// A failed rename keeps appending to the same file rather than losing lines
+	rotated := l.path + "." + time.Now().UTC().Format("20060102-150405.000000000")
+	renameErr := os.Rename(l.path, rotated)
+	if err := l.open(); err != nil {
+		l.file = nil
+		return err
+	}
+	return renameErr
+}
+
+// write appends one line, rotating first when it would pass maxSize
+func (l *accessLog) write(line string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return
+	}
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️ Failed to rotate access log: %v\n", err)
+			if l.file == nil {
+				return
+			}
+		}
+	}
+	n, _ := l.w.WriteString(line)
+	l.size += int64(n)
+}
+
+// Close flushes and closes the log. It is safe to call more than once and on a nil log.
+func (l *accessLog) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.stop.Do(func() { close(l.done) })
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.w.Flush()
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	l.file = nil
+	return err
+}
+
+// closeOnInterrupt flushes and closes the log when the run is interrupted
+func (l *accessLog) closeOnInterrupt() {
+	onInterrupt(func() { l.Close() })
+}
+
+// accessEntry is one request on its way into the access log
+type accessEntry struct {
+	started time.Time
+	method  string
+	url     string
+	retry   int
+	worker  string
+	bytes   int64
+}
+
+// workerKey carries the number of the thread scrape a request belongs to in a context
+type workerKey struct{}
+
+// withWorker tags ctx with the next thread scrape number, which the access log
+// records so the requests of one thread can be picked out
+func (fs *ForumScraperGo) withWorker(ctx context.Context) context.Context {
+	return context.WithValue(ctx, workerKey{}, atomic.AddInt64(&fs.threadSeq, 1))
+}
+
+// startAccess begins an access log entry for req, or returns nil without --access-log
+func (fs *ForumScraperGo) startAccess(ctx context.Context, req *http.Request) *accessEntry {
+	if fs.accessLog == nil {
+		return nil
+	}
+	entry := &accessEntry{started: time.Now(), method: req.Method, url: req.URL.String(), worker: "-"}
+	if worker, ok := ctx.Value(workerKey{}).(int64); ok {
+		entry.worker = fmt.Sprint(worker)
+	}
+	if attempt, ok := ctx.Value(attemptKey{}).(int); ok {
+		entry.retry = attempt
+	}
+	return entry
+}
+
+// finishAccess writes the entry with its status ("-" when the request failed before a
+// response arrived) and the bytes received on the wire
+func (fs *ForumScraperGo) finishAccess(entry *accessEntry, status string) {
+	if entry == nil {
+		return
+	}
+	fs.accessLog.write(fmt.Sprintf("[%s] \"%s %s\" %s %d %dms retry=%d worker=%s\n",
+		entry.started.UTC().Format(time.RFC3339Nano), entry.method, entry.url, status,
+		atomic.LoadInt64(&entry.bytes), time.Since(entry.started).Milliseconds(), entry.retry, entry.worker))
+}
+
+// accessBody writes a response's access log entry when its body is closed, once
+// the bytes received are known
+type accessBody struct {
+	io.ReadCloser
+	fs     *ForumScraperGo
+	entry  *accessEntry
+	status string
+	once   sync.Once
+}
+
+func (b *accessBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.fs.finishAccess(b.entry, b.status) })
+	return err
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccessLogFlushesWhileIdle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	log, err := openAccessLog(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+
+	log.write("first line\n")
+	deadline := time.Now().Add(3 * accessLogFlushInterval)
+	for time.Now().Before(deadline) {
+		if data, _ := os.ReadFile(path); string(data) == "first line\n" {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("line not flushed within %v of the last request", 3*accessLogFlushInterval)
+}
+
+func TestAccessLogCloseTwice(t *testing.T) {
+	log, err := openAccessLog(filepath.Join(t.TempDir(), "access.log"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAccessLogRecordsRetryNumber(t *testing.T) {
+	fastRetries(t)
+	server, _ := flakyTopicServer(t, 1, http.StatusServiceUnavailable)
+	path := filepath.Join(t.TempDir(), "access.log")
+	log, err := openAccessLog(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scraper := NewForumScraper("phpbb", 0, WithRetries(2))
+	scraper.statusOut = io.Discard
+	scraper.accessLog = log
+
+	if _, err := scraper.scrapeThread(server.URL+"/viewtopic.php?f=2&t=101", fixtureMaxPosts); err != nil {
+		t.Fatal(err)
+	}
+	log.Close()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), data)
+	}
+	for i, status := range []string{"503", "200"} {
+		if want := fmt.Sprintf("\" %s ", status); !strings.Contains(lines[i], want) {
+			t.Errorf("line %d = %q, want status %s", i+1, lines[i], status)
+		}
+		if want := fmt.Sprintf(" retry=%d ", i); !strings.Contains(lines[i], want) {
+			t.Errorf("line %d = %q, want retry=%d", i+1, lines[i], i)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// archiveTemplates are the pages of the --format html archive. html/template
+// escapes everything scraped, so post text is shown as text and never as markup.
+//
+//go:embed templates/archive/*.html
+var archiveTemplates embed.FS
+
+var archiveTemplate = template.Must(template.ParseFS(archiveTemplates, "templates/archive/*.html"))
+
+// uncategorized is the index heading for threads without a category
+const uncategorized = "Uncategorized"
+
+// archiveAttachment is an attachment as the archive links it
+type archiveAttachment struct {
+	Name    string
+	Href    string
+	IsImage bool
+}
+
+// archivePost is a post as the archive shows it
+type archivePost struct {
+	Number      int
+	Author      string
+	Timestamp   string
+	Content     string
+	Accepted    bool
+	Edited      bool
+	EditedAt    string
+	Attachments []archiveAttachment
+}
+
+// archiveThreadPage is the data for one thread page
+type archiveThreadPage struct {
+	Title          string
+	URL            string
+	Category       string
+	CategoryAnchor string
+	Author         string
+	CreatedAt      string
+	Language       string
+	Truncated      bool
+	ScrapedAt      string
+	Posts          []archivePost
+}
+
+// archiveIndexThread is one thread in the index
+type archiveIndexThread struct {
+	Title      string
+	Page       string
+	Posts      int
+	Author     string
+	LastPostAt string
+}
+
+// archiveCategory is one category section of the index
+type archiveCategory struct {
+	Name    string
+	Anchor  string
+	Threads []archiveIndexThread
+}
+
+// archiveIndexPage is the data for index.html
+type archiveIndexPage struct {
+	Title        string
+	ScrapedAt    string
+	TotalThreads int
+	TotalPosts   int
+	Categories   []*archiveCategory
+}
+
+// threadCategoryName is the heading a thread is listed under in exports
+func threadCategoryName(thread *ForumThread) string {
+	if thread.Category == "" {
+		return uncategorized
+	}
+	return thread.Category
+}
+
+// categoryGroup is the threads of one category, in their output order
+type categoryGroup struct {
+	name    string
+	threads []*ForumThread
+}
+
+// groupByCategory groups threads by category, sorted by name with uncategorized
+// threads last
+func groupByCategory(threads []*ForumThread) []*categoryGroup {
+	var groups []*categoryGroup
+	byName := make(map[string]*categoryGroup)
+	for _, thread := range threads {
+		name := threadCategoryName(thread)
+		group, exists := byName[name]
+		if !exists {
+			group = &categoryGroup{name: name}
+			byName[name] = group
+			groups = append(groups, group)
+		}
+		group.threads = append(group.threads, thread)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i].name, groups[j].name
+		if (a == uncategorized) != (b == uncategorized) {
+			return b == uncategorized
+		}
+		return strings.ToLower(a) < strings.ToLower(b)
+	})
+	return groups
+}
+
+// exportTarget orders threads as saveResults does and resolves where an export
+// named name goes: like the results file, without its extension, in the output
+// directory unless name is a path
+func (fs *ForumScraperGo) exportTarget(threads []*ForumThread, name string) ([]*ForumThread, string) {
+	sortThreads(threads)
+	if fs.sortBy != "" {
+		threads = fs.rankThreads(threads)
+	}
+	if name == "" {
+		name = fs.resultsFilename(threads, fs.now())
+	}
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	if filepath.Base(name) == name {
+		return threads, filepath.Join(fs.outputDir, name)
+	}
+	return threads, name
+}
+
+// jsonlExportTarget is exportTarget for a JSON-lines export: the path gets a
+// .jsonl extension, and - stays - for stdout
+func (fs *ForumScraperGo) jsonlExportTarget(threads []*ForumThread, name string) ([]*ForumThread, string) {
+	if name == "-" {
+		threads, _ = fs.exportTarget(threads, "")
+		return threads, "-"
+	}
+	threads, base := fs.exportTarget(threads, name)
+	return threads, base + ".jsonl"
+}
+
+// writeJSONLExport writes an export with write, to stdout when path is -
+func writeJSONLExport(path string, write func(io.Writer) error) error {
+	if path != "-" {
+		return writeFileAtomicFunc(path, 0644, write)
+	}
+	out := bufio.NewWriter(os.Stdout)
+	if err := write(out); err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// archiveAttachments links a post's attachments from a page in pageDir: saved
+// copies by relative path when --download-attachments kept them, the original URL otherwise
+func archiveAttachments(attachments []Attachment, pageDir string) []archiveAttachment {
+	var linked []archiveAttachment
+	for _, attachment := range attachments {
+		href := attachment.URL
+		if attachment.LocalPath != "" {
+			if local, err := filepath.Abs(attachment.LocalPath); err == nil {
+				if rel, err := filepath.Rel(pageDir, local); err == nil {
+					href = filepath.ToSlash(rel)
+				}
+			}
+		}
+		linked = append(linked, archiveAttachment{
+			Name:    attachment.Name,
+			Href:    href,
+			IsImage: strings.HasPrefix(attachment.MimeGuess, "image/"),
+		})
+	}
+	return linked
+}
+
+// saveHTMLArchive writes threads as a static site for --format html: one page per
+// thread under threads/ and an index.html grouping them by category. The archive
+// directory is named like the results file would be, without its extension.
+func (fs *ForumScraperGo) saveHTMLArchive(threads []*ForumThread, name string) (string, error) {
+	threads, dir := fs.exportTarget(threads, name)
+	pageDir, err := filepath.Abs(filepath.Join(dir, "threads"))
+	if err != nil {
+		return "", err
+	}
+
+	index := archiveIndexPage{Title: fmt.Sprintf("%s threads", fs.platform), ScrapedAt: fs.now().Format(time.RFC3339), TotalThreads: len(threads)}
+	categories := make(map[string]*archiveCategory)
+	// Anchors are numbered so any category name makes a valid fragment
+	for i, group := range groupByCategory(threads) {
+		categories[group.name] = &archiveCategory{Name: group.name, Anchor: fmt.Sprintf("category-%d", i+1)}
+		index.Categories = append(index.Categories, categories[group.name])
+	}
+
+	for i, thread := range threads {
+		section := categories[threadCategoryName(thread)]
+		page := archiveThreadPage{
+			Title:          thread.Title,
+			URL:            thread.URL,
+			Category:       thread.Category,
+			CategoryAnchor: section.Anchor,
+			Author:         thread.Author,
+			CreatedAt:      thread.CreatedAt,
+			Language:       thread.Language,
+			Truncated:      thread.Truncated,
+			ScrapedAt:      thread.ScrapedAt.Format(time.RFC3339),
+		}
+		for _, post := range thread.Posts {
+			page.Posts = append(page.Posts, archivePost{
+				Number:      post.PostNumber,
+				Author:      post.Author,
+				Timestamp:   post.Timestamp,
+				Content:     post.Content,
+				Accepted:    post.IsAcceptedAnswer,
+				Edited:      post.Edited,
+				EditedAt:    post.EditedAt,
+				Attachments: archiveAttachments(post.Attachments, pageDir),
+			})
+		}
+
+		var buf bytes.Buffer
+		if err := archiveTemplate.ExecuteTemplate(&buf, "thread.html", page); err != nil {
+			return "", err
+		}
+		pageName := fmt.Sprintf("%04d.html", i+1)
+		if err := writeFileAtomic(filepath.Join(dir, "threads", pageName), buf.Bytes(), 0644); err != nil {
+			return "", err
+		}
+
+		section.Threads = append(section.Threads, archiveIndexThread{
+			Title:      thread.Title,
+			Page:       "threads/" + pageName,
+			Posts:      len(thread.Posts),
+			Author:     thread.Author,
+			LastPostAt: thread.LastPostAt,
+		})
+		index.TotalPosts += len(thread.Posts)
+	}
+
+	var buf bytes.Buffer
+	if err := archiveTemplate.ExecuteTemplate(&buf, "index.html", index); err != nil {
+		return "", err
+	}
+	indexPath := filepath.Join(dir, "index.html")
+	if err := writeFileAtomic(indexPath, buf.Bytes(), 0644); err != nil {
+		return "", err
+	}
+	fs.statusf("💾 HTML archive saved to: %s\n", indexPath)
+	return indexPath, nil
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// defaultMaxAttachmentSize caps each file --download-attachments saves
+const defaultMaxAttachmentSize = 10 << 20
+
+// Attachment is a file attached to a post
+type Attachment struct {
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	SizeBytes *int64 `json:"size_bytes,omitempty"`
+	MimeGuess string `json:"mime_guess,omitempty"`
+	// LocalPath is where --download-attachments saved the file
+	LocalPath string `json:"local_path,omitempty"`
+}
+
+// AttachmentMarkup describes how a platform lists a post's attachments
+type AttachmentMarkup struct {
+	// ItemSelector matches one attachment within the post
+	ItemSelector string
+	// LinkSelector finds the file link (or image) within an item; empty when the item is the link
+	LinkSelector string
+	// NameSelector finds the file name within an item; the link text, image alt or URL otherwise
+	NameSelector string
+}
+
+// sizeLabel matches sizes such as "(123.4 KB)", "1,024 bytes" and "2.1 MiB"
+var sizeLabel = regexp.MustCompile(`(?i)(\d[\d,]*(?:\.\d+)?)\s*(bytes|b|kib|kb|mib|mb|gib|gb)\b`)
+
+// parseSizeLabel reads the first size in text, counting KB and KiB alike as 1024
+// bytes as forum software does; nil when there is none
+func parseSizeLabel(text string) *int64 {
+	matches := sizeLabel.FindStringSubmatch(text)
+	if matches == nil {
+		return nil
+	}
+	number, err := strconv.ParseFloat(strings.ReplaceAll(matches[1], ",", ""), 64)
+	if err != nil {
+		return nil
+	}
+	multiplier := 1.0
+	switch strings.ToLower(matches[2]) {
+	case "kb", "kib":
+		multiplier = 1 << 10
+	case "mb", "mib":
+		multiplier = 1 << 20
+	case "gb", "gib":
+		multiplier = 1 << 30
+	}
+	size := int64(number * multiplier)
+	return &size
+}
+
+// guessMime guesses a MIME type from the extension of name, or of the URL path
+func guessMime(name, rawURL string) string {
+	ext := path.Ext(name)
+	if ext == "" {
+		if u, err := url.Parse(rawURL); err == nil {
+			ext = path.Ext(u.Path)
+		}
+	}
+	if ext == "" {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(mime.TypeByExtension(strings.ToLower(ext)))
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// followingText returns the text directly after a selection, where Discourse
+// puts an upload's size
+func followingText(s *goquery.Selection) string {
+	if len(s.Nodes) == 0 {
+		return ""
+	}
+	if next := s.Nodes[0].NextSibling; next != nil && next.Type == html.TextNode {
+		return next.Data
+	}
+	return ""
+}
+
+// extractAttachments lists the attachments in a post element, resolved against threadURL
+func extractAttachments(selection *goquery.Selection, markup *AttachmentMarkup, threadURL string) []Attachment {
+	if markup == nil {
+		return nil
+	}
+	var attachments []Attachment
+	seen := make(map[string]bool)
+	findSelector(selection, markup.ItemSelector).Each(func(i int, item *goquery.Selection) {
+		link := item
+		if markup.LinkSelector != "" {
+			link = findSelector(item, markup.LinkSelector).First()
+		}
+		href, exists := link.Attr("href")
+		if !exists {
+			href, exists = link.Attr("src")
+		}
+		if !exists {
+			return
+		}
+		absolute, err := resolveURL(threadURL, href)
+		if err != nil || seen[absolute] {
+			return
+		}
+		seen[absolute] = true
+
+		var name string
+		if markup.NameSelector != "" {
+			name = strings.TrimSpace(findSelector(item, markup.NameSelector).First().Text())
+		}
+		if name == "" {
+			name = strings.TrimSpace(link.Text())
+		}
+		if name == "" {
+			name, _ = link.Attr("alt")
+		}
+		if name == "" {
+			if u, err := url.Parse(absolute); err == nil {
+				name = path.Base(u.Path)
+			}
+		}
+		name = validUTF8(name)
+
+		attachments = append(attachments, Attachment{
+			Name:      name,
+			URL:       absolute,
+			SizeBytes: parseSizeLabel(item.Text() + " " + followingText(item)),
+			MimeGuess: guessMime(name, absolute),
+		})
+	})
+	return attachments
+}
+
+// attachmentDir is the per-thread directory attachments are saved under
+func (fs *ForumScraperGo) attachmentDir(thread *ForumThread) string {
+	id := thread.ThreadID
+	if id == "" {
+		id = contentHash(normalizeURL(thread.URL))[:12]
+	}
+	return filepath.Join(fs.downloadDir, sanitizeFilename(hostOf(thread.URL)+"-"+id))
+}
+
+// downloadAttachments saves the attachments of a thread's posts for --download-attachments,
+// recording each saved file's path. Failures are reported and leave LocalPath empty;
+// they never fail the thread.
+func (fs *ForumScraperGo) downloadAttachments(thread *ForumThread) {
+	if fs.downloadDir == "" {
+		return
+	}
+	dir := fs.attachmentDir(thread)
+	used := make(map[string]bool)
+	for i := range thread.Posts {
+		for j := range thread.Posts[i].Attachments {
+			attachment := &thread.Posts[i].Attachments[j]
+			if fs.budget.exhausted() {
+				return
+			}
+			if attachment.SizeBytes != nil && *attachment.SizeBytes > fs.maxAttachmentSize {
+				fs.statusf("⚠️ Skipping attachment %s: %d bytes is over the size cap\n", attachment.Name, *attachment.SizeBytes)
+				continue
+			}
+
+			// Repeated names in one thread get a numeric prefix
+			name := sanitizeFilename(filepath.Base(attachment.Name))
+			if name == "" || name == "." || name == string(filepath.Separator) {
+				name = "attachment"
+			}
+			for n := 2; used[name]; n++ {
+				name = fmt.Sprintf("%d-%s", n, sanitizeFilename(filepath.Base(attachment.Name)))
+			}
+			used[name] = true
+
+			localPath := filepath.Join(dir, name)
+			if err := fs.downloadFile(attachment.URL, localPath); err != nil {
+				fs.statusf("⚠️ Failed to download attachment %s: %v\n", attachment.URL, err)
+				continue
+			}
+			attachment.LocalPath = localPath
+		}
+	}
+}
+
+// errAttachmentTooLarge stops a download that passes --max-attachment-size
+var errAttachmentTooLarge = errors.New("attachment is over the size cap")
+
+// downloadFile saves rawURL to localPath with the usual rate limiting, removing
+// the partial file when the download fails or passes the size cap
+func (fs *ForumScraperGo) downloadFile(rawURL, localPath string) (err error) {
+	// Rate limiting
+	fs.politeWait(rawURL)
+
+	resp, err := fs.doRequestContext(fs.runContext(), rawURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := os.MkdirAll(filepath.Dir(localPath), 0o755); err != nil {
+		return err
+	}
+	file, err := os.Create(localPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(localPath)
+		}
+	}()
+
+	written, err := io.Copy(file, io.LimitReader(resp.Body, fs.maxAttachmentSize+1))
+	if err != nil {
+		return err
+	}
+	if written > fs.maxAttachmentSize {
+		return errAttachmentTooLarge
+	}
+	return nil
+}
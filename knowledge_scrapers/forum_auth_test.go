@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDiscourseTokenNeedsUser(t *testing.T) {
+	scraper := NewForumScraper("discourse", 0, WithBearerToken("key"))
+	if err := scraper.checkTokenUser(); err == nil {
+		t.Fatal("a Discourse token without --token-user was accepted")
+	}
+
+	req, _ := http.NewRequest("GET", "https://meta.example.com/latest.json", nil)
+	scraper.setAuth(req)
+	if user := req.Header.Get("Api-Username"); user != "" {
+		t.Errorf("sent Api-Username %q without --token-user", user)
+	}
+}
+
+func TestTokenUserHeader(t *testing.T) {
+	tests := []struct {
+		platform, user           string
+		wantHeader, wantUsername string
+	}{
+		{"discourse", "archiver", "Api-Key", "archiver"},
+		// NodeBB-style platforms take a standard bearer token and name no account
+		{"generic", "", "Authorization", ""},
+	}
+	for _, tt := range tests {
+		scraper := NewForumScraper(tt.platform, 0, WithBearerToken("key"), WithTokenUser(tt.user))
+		if err := scraper.checkTokenUser(); err != nil {
+			t.Errorf("%s: %v", tt.platform, err)
+		}
+		req, _ := http.NewRequest("GET", "https://forum.example.com/", nil)
+		scraper.setAuth(req)
+		if req.Header.Get(tt.wantHeader) == "" {
+			t.Errorf("%s: token not sent in %s", tt.platform, tt.wantHeader)
+		}
+		if user := req.Header.Get("Api-Username"); user != tt.wantUsername {
+			t.Errorf("%s: Api-Username %q, want %q", tt.platform, user, tt.wantUsername)
+		}
+	}
+}
+
+// authRecorder serves page, recording the credentials each request carried by URL
+type authRecorder struct {
+	page        string
+	contentType string
+
+	mu      sync.Mutex
+	headers map[string]http.Header
+}
+
+func (rec *authRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec.mu.Lock()
+	rec.headers[r.URL.RequestURI()] = r.Header.Clone()
+	rec.mu.Unlock()
+	w.Header().Set("Content-Type", rec.contentType)
+	io.WriteString(w, rec.page)
+}
+
+func TestAuthSentOnThreadPages(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join(fixturesDir, "phpbb/viewtopic.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pagination := `<div class="pagination"><a href="./viewtopic.php?f=2&amp;t=101&amp;start=3">2</a> <a href="./viewtopic.php?f=2&amp;t=101&amp;start=6">3</a></div></body>`
+	rec := &authRecorder{
+		page:        strings.Replace(string(data), "</body>", pagination, 1),
+		contentType: "text/html; charset=utf-8",
+		headers:     make(map[string]http.Header),
+	}
+	server := httptest.NewServer(rec)
+	t.Cleanup(server.Close)
+
+	scraper := NewForumScraper("phpbb", 0, WithBasicAuth("archiver", "secret"))
+	scraper.statusOut = io.Discard
+	scraper.maxPagesPerThread = 3
+	if _, err := scraper.scrapeThread(server.URL+"/viewtopic.php?f=2&t=101", fixtureMaxPosts); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, page := range []string{"/viewtopic.php?f=2&t=101", "/viewtopic.php?f=2&t=101&start=3", "/viewtopic.php?f=2&t=101&start=6"} {
+		header, fetched := rec.headers[page]
+		if !fetched {
+			t.Errorf("%s was not fetched", page)
+			continue
+		}
+		user, pass, ok := (&http.Request{Header: header}).BasicAuth()
+		if !ok || user != "archiver" || pass != "secret" {
+			t.Errorf("%s: basic auth %q:%q, want archiver:secret", page, user, pass)
+		}
+	}
+}
+
+func TestAuthSentOnAPIRequests(t *testing.T) {
+	tests := []struct {
+		platform, user string
+		want           map[string]string
+	}{
+		{"discourse", "archiver", map[string]string{"Api-Key": "key", "Api-Username": "archiver"}},
+		{"generic", "", map[string]string{"Authorization": "Bearer key"}},
+	}
+	for _, tt := range tests {
+		rec := &authRecorder{page: `{"topic_list":{"topics":[]}}`, contentType: "application/json", headers: make(map[string]http.Header)}
+		server := httptest.NewServer(rec)
+		t.Cleanup(server.Close)
+
+		scraper := NewForumScraper(tt.platform, 0, WithBearerToken("key"), WithTokenUser(tt.user))
+		scraper.statusOut = io.Discard
+		var listing map[string]interface{}
+		if err := scraper.fetchJSON(context.Background(), server.URL+"/latest.json?page=1", &listing); err != nil {
+			t.Fatalf("%s: %v", tt.platform, err)
+		}
+		header := rec.headers["/latest.json?page=1"]
+		for name, value := range tt.want {
+			if got := header.Get(name); got != value {
+				t.Errorf("%s: %s %q, want %q", tt.platform, name, got, value)
+			}
+		}
+	}
+}
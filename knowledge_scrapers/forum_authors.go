@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// authorMaxCategories caps the categories kept for one author, so a prolific
+// author on a board with thousands of subforums stays a bounded record
+const authorMaxCategories = 50
+
+// AuthorProfile is one record of --emit-authors: what a run saw of one author on
+// one host. With --anonymize-authors the author is the hashed identity.
+type AuthorProfile struct {
+	Host          string     `json:"host"`
+	Author        string     `json:"author"`
+	Posts         int        `json:"posts"`
+	Threads       int        `json:"threads"`
+	FirstSeen     *time.Time `json:"first_seen,omitempty"`
+	LastSeen      *time.Time `json:"last_seen,omitempty"`
+	AvgPostLength float64    `json:"avg_post_length"`
+	LikesReceived int        `json:"likes_received"`
+	Categories    []string   `json:"categories,omitempty"`
+}
+
+// authorKey identifies an author: the same name on two boards is two people
+type authorKey struct{ host, author string }
+
+// authorEntry is an author's running totals
+type authorEntry struct {
+	profile    AuthorProfile
+	runes      int
+	categories map[string]bool
+}
+
+// authorAccumulator builds author profiles as threads complete. Each author
+// costs a fixed record plus at most authorMaxCategories category names; no posts
+// or thread URLs are kept.
+type authorAccumulator struct {
+	mu      sync.Mutex
+	authors map[authorKey]*authorEntry
+}
+
+// add folds one thread's posts into their authors' profiles. Posts without an
+// author are left out.
+func (a *authorAccumulator) add(thread *ForumThread) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.authors == nil {
+		a.authors = make(map[authorKey]*authorEntry)
+	}
+
+	host := hostOf(thread.URL)
+	inThread := make(map[string]bool)
+	for _, post := range thread.Posts {
+		if post.Author == "" {
+			continue
+		}
+		key := authorKey{host, post.Author}
+		entry, exists := a.authors[key]
+		if !exists {
+			entry = &authorEntry{profile: AuthorProfile{Host: host, Author: post.Author}, categories: make(map[string]bool)}
+			a.authors[key] = entry
+		}
+		profile := &entry.profile
+		profile.Posts++
+		if !inThread[post.Author] {
+			inThread[post.Author] = true
+			profile.Threads++
+		}
+		entry.runes += utf8.RuneCountInString(post.Content)
+		if post.LikesCount != nil {
+			profile.LikesReceived += *post.LikesCount
+		}
+		if t := parseLastMod(post.Timestamp); t != nil {
+			if profile.FirstSeen == nil || t.Before(*profile.FirstSeen) {
+				profile.FirstSeen = t
+			}
+			if profile.LastSeen == nil || t.After(*profile.LastSeen) {
+				profile.LastSeen = t
+			}
+		}
+		category := post.ForumCategory
+		if category == "" {
+			category = thread.Category
+		}
+		if category != "" && len(entry.categories) < authorMaxCategories {
+			entry.categories[category] = true
+		}
+	}
+}
+
+// profiles returns every author's profile, most posts first (ties by host and author)
+func (a *authorAccumulator) profiles() []AuthorProfile {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	profiles := make([]AuthorProfile, 0, len(a.authors))
+	for _, entry := range a.authors {
+		profile := entry.profile
+		if profile.Posts > 0 {
+			profile.AvgPostLength = float64(entry.runes) / float64(profile.Posts)
+		}
+		for category := range entry.categories {
+			profile.Categories = append(profile.Categories, category)
+		}
+		sort.Strings(profile.Categories)
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		if profiles[i].Posts != profiles[j].Posts {
+			return profiles[i].Posts > profiles[j].Posts
+		}
+		if profiles[i].Host != profiles[j].Host {
+			return profiles[i].Host < profiles[j].Host
+		}
+		return profiles[i].Author < profiles[j].Author
+	})
+	return profiles
+}
+
+// writeAuthorProfiles writes profiles to w: one JSON object per line when jsonl,
+// otherwise an indented array
+func writeAuthorProfiles(w io.Writer, profiles []AuthorProfile, jsonl bool) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	if jsonl {
+		for _, profile := range profiles {
+			if err := encoder.Encode(profile); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(profiles)
+}
+
+// saveAuthorProfiles writes the run's author profiles for --emit-authors, as
+// JSONL when the file name ends in .jsonl. A bare file name goes in the output
+// directory.
+func (fs *ForumScraperGo) saveAuthorProfiles() {
+	if fs.authorsPath == "" {
+		return
+	}
+	path := fs.authorsPath
+	if filepath.Base(path) == path {
+		path = filepath.Join(fs.outputDir, path)
+	}
+	profiles := fs.authors.profiles()
+	jsonl := strings.HasSuffix(strings.ToLower(path), ".jsonl")
+	err := writeFileAtomicFunc(path, 0644, func(w io.Writer) error {
+		return writeAuthorProfiles(w, profiles, jsonl)
+	})
+	if err != nil {
+		fs.statusf("⚠️ Failed to write --emit-authors: %v\n", err)
+		return
+	}
+	fs.statusf("👥 Author profiles saved to: %s (%d authors)\n", path, len(profiles))
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// The authors fixture is a results file of several authors across two hosts,
+// checked against the --emit-authors profiles it should produce
+const (
+	authorsFixtureResults = "authors/results.jsonl"
+	authorsFixtureGolden  = "authors/golden.json"
+)
+
+func TestAuthorsFixture(t *testing.T) {
+	var accumulator authorAccumulator
+	for _, thread := range readFixtureThreads(t, authorsFixtureResults) {
+		accumulator.add(thread)
+	}
+	var encoded bytes.Buffer
+	if err := writeAuthorProfiles(&encoded, accumulator.profiles(), false); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, authorsFixtureGolden, encoded.Bytes())
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// parseBandwidth parses a --max-bandwidth value in bytes per second, with an optional
+// k, m or g suffix (powers of 1024) such as 500k or 2m
+func parseBandwidth(value string) (int64, error) {
+	bytesPerSecond, err := parseByteSize(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid bandwidth %q (want bytes per second, e.g. 500k or 2m)", value)
+	}
+	return bytesPerSecond, nil
+}
+
+// parseByteSize parses a positive byte count with an optional k, m or g suffix
+// (powers of 1024) such as 500k or 2m
+func parseByteSize(value string) (int64, error) {
+	text := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(value)), "b")
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(text, "k"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(text, "m"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(text, "g"):
+		multiplier = 1 << 30
+	}
+	if multiplier > 1 {
+		text = text[:len(text)-1]
+	}
+	number, err := strconv.ParseFloat(text, 64)
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("invalid size %q (want bytes, e.g. 500k or 2m)", value)
+	}
+	return int64(number * float64(multiplier)), nil
+}
+
+// bandwidthLimiter is a token bucket shared by every response body, so concurrent
+// downloads split the configured rate between them
+type bandwidthLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // bytes per second
+	tokens float64
+	last   time.Time
+}
+
+// newBandwidthLimiter returns a limiter allowing bytesPerSecond, starting with a
+// tenth of a second's worth of tokens
+func newBandwidthLimiter(bytesPerSecond int64) *bandwidthLimiter {
+	return &bandwidthLimiter{rate: float64(bytesPerSecond), tokens: float64(bytesPerSecond) / 10, last: time.Now()}
+}
+
+// chunk is the most one Read may take at a time, so readers interleave fairly
+func (l *bandwidthLimiter) chunk() int {
+	size := int(l.rate / 10)
+	if size < 512 {
+		size = 512
+	}
+	if size > 32<<10 {
+		size = 32 << 10
+	}
+	return size
+}
+
+// take spends n bytes of tokens, sleeping until the bucket has covered them
+func (l *bandwidthLimiter) take(n int) {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if burst := l.rate / 10; l.tokens > burst {
+		l.tokens = burst
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	deficit := -l.tokens
+	l.mu.Unlock()
+
+	if deficit > 0 {
+		time.Sleep(time.Duration(deficit / l.rate * float64(time.Second)))
+	}
+}
+
+// throttledReader reads through a shared bandwidthLimiter
+type throttledReader struct {
+	r       io.Reader
+	limiter *bandwidthLimiter
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if chunk := t.limiter.chunk(); len(p) > chunk {
+		p = p[:chunk]
+	}
+	n, err := t.r.Read(p)
+	if n > 0 {
+		t.limiter.take(n)
+	}
+	return n, err
+}
+
+// throttle wraps a response body in the bandwidth limiter when --max-bandwidth is set
+func (fs *ForumScraperGo) throttle(body io.Reader) io.Reader {
+	if fs.bandwidth == nil {
+		return body
+	}
+	return &throttledReader{r: body, limiter: fs.bandwidth}
+}
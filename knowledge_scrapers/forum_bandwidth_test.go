@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMaxBandwidthSharedAcrossDownloads(t *testing.T) {
+	const (
+		size      = 32 << 10
+		downloads = 4
+		rate      = 64 << 10
+	)
+	body := strings.Repeat("x", size)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	scraper := NewForumScraper("phpbb", 0, WithMaxBandwidth(rate))
+	scraper.statusOut = io.Discard
+
+	started := time.Now()
+	var wg sync.WaitGroup
+	errs := make(chan error, downloads)
+	for i := 0; i < downloads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := scraper.doRequestContext(scraper.runContext(), server.URL+"/file")
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer resp.Body.Close()
+			n, err := io.Copy(io.Discard, resp.Body)
+			if err == nil && n != size {
+				t.Errorf("read %d bytes, want %d", n, size)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	elapsed := time.Since(started)
+
+	// Past the initial tenth of a second's burst, every byte waits on the shared bucket
+	total := float64(size * downloads)
+	burst := float64(rate) / 10
+	want := time.Duration((total - burst) / rate * float64(time.Second))
+	if elapsed < want {
+		t.Errorf("%d downloads of %d bytes at %d B/s took %v, want at least %v", downloads, size, rate, elapsed, want)
+	}
+	if got := scraper.stats.BytesTransferred; got != int64(total) {
+		t.Errorf("BytesTransferred = %d, want %d", got, int64(total))
+	}
+}
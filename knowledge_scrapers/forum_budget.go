@@ -0,0 +1,85 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// exitBudgetStopped is the exit status of a run cut short by --max-total-posts,
+// --max-requests or --deadline; results gathered so far are still saved
+const exitBudgetStopped = 3
+
+// runBudget enforces run-wide limits. Counters are atomic so every worker shares them;
+// once any limit trips, no new request starts and in-flight threads are left to finish.
+type runBudget struct {
+	maxPosts    int64
+	maxRequests int64
+	deadline    time.Time
+	// parent holds limits shared with other runs, as serve's jobs share the
+	// server's; spending counts against both, and either running out stops the run
+	parent *runBudget
+
+	posts    int64
+	requests int64
+
+	tripOnce sync.Once
+	tripped  int32
+	reason   string
+}
+
+// exhausted reports whether the budget or its parent has tripped
+func (b *runBudget) exhausted() bool {
+	return atomic.LoadInt32(&b.tripped) == 1 || (b.parent != nil && b.parent.exhausted())
+}
+
+// trip records the first budget to run out and logs it once
+func (fs *ForumScraperGo) tripBudget(reason string) {
+	fs.tripBudgetOf(fs.budget, reason)
+}
+
+func (fs *ForumScraperGo) tripBudgetOf(b *runBudget, reason string) {
+	b.tripOnce.Do(func() {
+		b.reason = reason
+		atomic.StoreInt32(&b.tripped, 1)
+		fs.statusf("🛑 Budget exhausted (%s): finishing in-flight threads, starting no new requests\n", reason)
+	})
+}
+
+// spendRequest claims one request from the budget, failing with ErrBudgetExhausted
+// once the request cap or deadline has been reached
+func (fs *ForumScraperGo) spendRequest() error {
+	if fs.budget.exhausted() {
+		return ErrBudgetExhausted
+	}
+	for b := fs.budget; b != nil; b = b.parent {
+		if !b.deadline.IsZero() && time.Now().After(b.deadline) {
+			fs.tripBudgetOf(b, "deadline")
+			return ErrBudgetExhausted
+		}
+		if b.maxRequests > 0 && atomic.AddInt64(&b.requests, 1) > b.maxRequests {
+			fs.tripBudgetOf(b, "max-requests")
+			return ErrBudgetExhausted
+		}
+	}
+	return nil
+}
+
+// spendPosts adds a finished thread's posts to the total, tripping the post budget when it is reached
+func (fs *ForumScraperGo) spendPosts(n int) {
+	for b := fs.budget; b != nil; b = b.parent {
+		if b.maxPosts > 0 && atomic.AddInt64(&b.posts, int64(n)) >= b.maxPosts {
+			fs.tripBudgetOf(b, "max-total-posts")
+		}
+	}
+}
+
+// budgetStopReason returns which budget stopped the run, or "" if none did
+func (fs *ForumScraperGo) budgetStopReason() string {
+	for b := fs.budget; b != nil; b = b.parent {
+		if atomic.LoadInt32(&b.tripped) == 1 {
+			return b.reason
+		}
+	}
+	return ""
+}
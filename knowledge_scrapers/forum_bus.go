@@ -0,0 +1,375 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// busBufferSize is how many messages wait for the broker before further ones
+	// go straight to the spill file, so a slow broker never holds up scraping
+	busBufferSize = 1024
+	// busBatchSize caps the messages handed to the broker in one publish
+	busBatchSize = 100
+	// busRetryInterval is how long a sink whose broker failed spills everything
+	// before trying the broker again
+	busRetryInterval = 30 * time.Second
+)
+
+// Bus acknowledgement levels for --bus-acks
+const (
+	busAcksAll  = "all"
+	busAcksOne  = "one"
+	busAcksNone = "none"
+)
+
+// busMessage is one message for a message bus. Key is the thread key, so every
+// message about a thread lands on the same partition.
+type busMessage struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// busPublisher sends messages to a broker. Publish returns once the broker has
+// acknowledged them at the configured level.
+type busPublisher interface {
+	Publish(ctx context.Context, messages []busMessage) error
+	Close() error
+}
+
+// newKafkaPublisher and newNATSPublisher connect to a broker. They are only set in
+// builds with the kafka or nats tag, so the default binary carries no client.
+var (
+	newKafkaPublisher func(brokers []string, topic, acks string) (busPublisher, error)
+	newNATSPublisher  func(url, subject, acks string) (busPublisher, error)
+)
+
+// splitBusTarget splits a --kafka or --nats value at its last slash into the
+// broker address and the topic or subject
+func splitBusTarget(target string) (string, string, error) {
+	i := strings.LastIndex(target, "/")
+	if i <= 0 || i == len(target)-1 {
+		return "", "", fmt.Errorf("%q is not <address>/<topic>", target)
+	}
+	return target[:i], target[i+1:], nil
+}
+
+// busSink publishes threads to a message bus, one message per post or with
+// perThread one per thread. Messages queue in a bounded buffer and go to the
+// spill file when it is full or the broker fails; spilled messages are resent
+// once the broker is back, including on the next run, so each is delivered at
+// least once.
+type busSink struct {
+	name      string
+	publisher busPublisher
+	perThread bool
+	spillPath string
+	status    io.Writer
+
+	queue chan busMessage
+	// wake tells run that messages were queued or the queue closed
+	wake chan struct{}
+	done chan struct{}
+
+	// spillMu guards the spill file; spilled says it may hold messages
+	spillMu sync.Mutex
+	spilled bool
+	// sendingMu guards sending, the batch being published
+	sendingMu sync.Mutex
+	sending   []busMessage
+	// offline is set while the broker is failing and offlineUntil is when it is
+	// next tried (run goroutine only)
+	offline      bool
+	offlineUntil time.Time
+}
+
+func newBusSink(name string, publisher busPublisher, perThread bool, spillPath string, status io.Writer) *busSink {
+	s := &busSink{
+		name:      name,
+		publisher: publisher,
+		perThread: perThread,
+		spillPath: spillPath,
+		status:    status,
+		queue:     make(chan busMessage, busBufferSize),
+		wake:      make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	// A run that stopped mid-resend left its messages beside the spill file
+	if data, err := os.ReadFile(s.replayPath()); err == nil {
+		if err := s.appendSpill(data); err == nil {
+			os.Remove(s.replayPath())
+		}
+	}
+	if info, err := os.Stat(spillPath); err == nil && info.Size() > 0 {
+		s.spilled = true
+		fmt.Fprintf(status, "📤 Resending messages spilled to %s by an earlier run\n", spillPath)
+	}
+	go s.run()
+	return s
+}
+
+func (s *busSink) Name() string { return s.name }
+
+func (s *busSink) replayPath() string { return s.spillPath + ".replay" }
+
+// Write queues a thread's messages without waiting for the broker
+func (s *busSink) Write(ctx context.Context, thread *ForumThread) error {
+	messages, err := s.messages(thread)
+	if err != nil {
+		return err
+	}
+	defer s.signal()
+	for _, message := range messages {
+		select {
+		case s.queue <- message:
+		default:
+			if err := s.spill([]busMessage{message}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// signal wakes run without waiting for it
+func (s *busSink) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// messages encodes a thread as it goes on the bus
+func (s *busSink) messages(thread *ForumThread) ([]busMessage, error) {
+	key := threadKey(thread)
+	if s.perThread {
+		value, err := json.Marshal(thread)
+		if err != nil {
+			return nil, err
+		}
+		return []busMessage{{Key: key, Value: value}}, nil
+	}
+	messages := make([]busMessage, 0, len(thread.Posts))
+	for _, post := range thread.Posts {
+		value, err := json.Marshal(post)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, busMessage{Key: key, Value: value})
+	}
+	return messages, nil
+}
+
+// run publishes queued messages in batches until the queue is closed
+func (s *busSink) run() {
+	defer close(s.done)
+	for range s.wake {
+		for {
+			batch, closed := s.take()
+			if len(batch) > 0 {
+				s.send(batch)
+				s.sendingMu.Lock()
+				s.sending = nil
+				s.sendingMu.Unlock()
+			}
+			if closed {
+				return
+			}
+			if len(batch) < busBatchSize {
+				break
+			}
+		}
+	}
+}
+
+// take moves up to a batch of queued messages to sending, reporting whether the
+// queue is closed. Taking them under sendingMu means spillPending finds every
+// message either queued or sending.
+func (s *busSink) take() ([]busMessage, bool) {
+	s.sendingMu.Lock()
+	defer s.sendingMu.Unlock()
+	var batch []busMessage
+	for len(batch) < busBatchSize {
+		select {
+		case message, ok := <-s.queue:
+			if !ok {
+				s.sending = batch
+				return batch, true
+			}
+			batch = append(batch, message)
+		default:
+			s.sending = batch
+			return batch, false
+		}
+	}
+	s.sending = batch
+	return batch, false
+}
+
+// spillPending writes the batch being published and every queued message to the
+// spill file, for when the run is interrupted before Close. The batch may reach
+// the broker as well, which at-least-once delivery allows.
+func (s *busSink) spillPending() {
+	s.sendingMu.Lock()
+	pending := append([]busMessage(nil), s.sending...)
+	s.sendingMu.Unlock()
+drain:
+	for {
+		select {
+		case message, ok := <-s.queue:
+			if !ok {
+				break drain
+			}
+			pending = append(pending, message)
+		default:
+			break drain
+		}
+	}
+	if len(pending) > 0 {
+		s.spillOrReport(pending)
+		fmt.Fprintf(s.status, "⚠️ %d %s message(s) spilled to %s; they are resent on the next run\n", len(pending), s.name, s.spillPath)
+	}
+}
+
+// send publishes a batch, spilling it instead while the broker is failing. Spilled
+// messages are resent first, so a recovered broker sees the backlog before new ones.
+func (s *busSink) send(batch []busMessage) {
+	if time.Now().Before(s.offlineUntil) || !s.resend() {
+		s.spillOrReport(batch)
+		return
+	}
+	if err := s.publisher.Publish(context.Background(), batch); err != nil {
+		s.goOffline(err)
+		s.spillOrReport(batch)
+	}
+}
+
+// goOffline stops trying the broker for busRetryInterval
+func (s *busSink) goOffline(err error) {
+	if !s.offline {
+		s.offline = true
+		fmt.Fprintf(s.status, "⏸️ %s unavailable, spilling messages to %s: %v\n", s.name, s.spillPath, err)
+	}
+	s.offlineUntil = time.Now().Add(busRetryInterval)
+}
+
+func (s *busSink) spillOrReport(batch []busMessage) {
+	if err := s.spill(batch); err != nil {
+		fmt.Fprintf(s.status, "⚠️ Lost %d %s message(s): %v\n", len(batch), s.name, err)
+	}
+}
+
+// spill appends messages to the spill file
+func (s *busSink) spill(messages []busMessage) error {
+	var buf strings.Builder
+	for _, message := range messages {
+		line, err := json.Marshal(message)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return s.appendSpill([]byte(buf.String()))
+}
+
+func (s *busSink) appendSpill(data []byte) error {
+	s.spillMu.Lock()
+	defer s.spillMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.spillPath), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.spillPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	s.spilled = true
+	return f.Close()
+}
+
+// resend publishes whatever is in the spill file, reporting whether it is now
+// empty. The file is moved aside while sending so Write can keep spilling to it;
+// messages the broker doesn't take are put back.
+func (s *busSink) resend() bool {
+	s.spillMu.Lock()
+	if !s.spilled {
+		s.spillMu.Unlock()
+		return true
+	}
+	err := os.Rename(s.spillPath, s.replayPath())
+	s.spilled = err != nil && !os.IsNotExist(err)
+	s.spillMu.Unlock()
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+
+	f, err := os.Open(s.replayPath())
+	if err != nil {
+		return false
+	}
+	var pending []busMessage
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var message busMessage
+		if err := json.Unmarshal(scanner.Bytes(), &message); err == nil {
+			pending = append(pending, message)
+		}
+	}
+	f.Close()
+
+	sent := 0
+	for sent < len(pending) {
+		end := sent + busBatchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		if err := s.publisher.Publish(context.Background(), pending[sent:end]); err != nil {
+			s.goOffline(err)
+			break
+		}
+		sent = end
+	}
+	if sent > 0 {
+		fmt.Fprintf(s.status, "📤 Resent %d spilled %s message(s)\n", sent, s.name)
+	}
+	if sent < len(pending) {
+		if err := s.spill(pending[sent:]); err != nil {
+			// The unsent messages stay in the replay file for the next run
+			fmt.Fprintf(s.status, "⚠️ Failed to put back spilled %s messages: %v\n", s.name, err)
+			return false
+		}
+		os.Remove(s.replayPath())
+		return false
+	}
+	os.Remove(s.replayPath())
+	s.offline = false
+	s.offlineUntil = time.Time{}
+	return true
+}
+
+// Close sends what is queued, makes a last attempt at the spill file and reports
+// any messages left there for the next run
+func (s *busSink) Close() error {
+	close(s.queue)
+	s.signal()
+	<-s.done
+	s.offlineUntil = time.Time{}
+	s.resend()
+	if info, err := os.Stat(s.spillPath); err == nil && info.Size() > 0 {
+		fmt.Fprintf(s.status, "⚠️ %s messages remain in %s; they are resent on the next run\n", s.name, s.spillPath)
+	}
+	return s.publisher.Close()
+}
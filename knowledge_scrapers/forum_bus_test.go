@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// blockingPublisher holds every Publish until release is closed, then fails or
+// records the messages
+type blockingPublisher struct {
+	release chan struct{}
+	fail    bool
+
+	mu        sync.Mutex
+	published []busMessage
+}
+
+func (p *blockingPublisher) Publish(ctx context.Context, messages []busMessage) error {
+	<-p.release
+	if p.fail {
+		return errors.New("broker unreachable")
+	}
+	p.mu.Lock()
+	p.published = append(p.published, messages...)
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *blockingPublisher) Close() error { return nil }
+
+// busTestThread is a thread of n posts
+func busTestThread(n int) *ForumThread {
+	thread := &ForumThread{URL: "https://forum.example.com/viewtopic.php?t=7", ThreadID: "7"}
+	for i := 1; i <= n; i++ {
+		thread.Posts = append(thread.Posts, ForumPost{PostNumber: i, Content: fmt.Sprintf("post %d", i)})
+	}
+	return thread
+}
+
+func spillLines(t *testing.T, path string) int {
+	t.Helper()
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	lines := 0
+	for scanner := bufio.NewScanner(f); scanner.Scan(); {
+		lines++
+	}
+	return lines
+}
+
+func TestBusSinkSpillsPendingOnInterrupt(t *testing.T) {
+	spillPath := filepath.Join(t.TempDir(), "bus.spill")
+	publisher := &blockingPublisher{release: make(chan struct{})}
+	sink := newBusSink("kafka", publisher, false, spillPath, io.Discard)
+	if err := sink.Write(context.Background(), busTestThread(5)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Whether the run goroutine has taken a batch yet or not, all five are saved
+	sink.spillPending()
+	if lines := spillLines(t, spillPath); lines != 5 {
+		t.Errorf("spill file holds %d messages after an interrupt, want 5", lines)
+	}
+	close(publisher.release)
+}
+
+func TestBusSinkResendsSpillOnNextRun(t *testing.T) {
+	spillPath := filepath.Join(t.TempDir(), "bus.spill")
+	down := &blockingPublisher{release: make(chan struct{}), fail: true}
+	close(down.release)
+	sink := newBusSink("kafka", down, false, spillPath, io.Discard)
+	if err := sink.Write(context.Background(), busTestThread(3)); err != nil {
+		t.Fatal(err)
+	}
+	sink.Close()
+	if lines := spillLines(t, spillPath); lines != 3 {
+		t.Fatalf("spill file holds %d messages with the broker down, want 3", lines)
+	}
+
+	up := &blockingPublisher{release: make(chan struct{})}
+	close(up.release)
+	next := newBusSink("kafka", up, false, spillPath, io.Discard)
+	next.Close()
+	if len(up.published) != 3 {
+		t.Errorf("next run published %d spilled messages, want 3", len(up.published))
+	}
+	for _, message := range up.published {
+		if message.Key != "forum.example.com#7" {
+			t.Errorf("message key %q, want the thread key", message.Key)
+		}
+	}
+	if lines := spillLines(t, spillPath); lines != 0 {
+		t.Errorf("spill file still holds %d messages after a resend", lines)
+	}
+}
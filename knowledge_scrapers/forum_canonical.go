@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// extractCanonicalURL returns the absolute URL a page names as its canonical
+// address through link rel="canonical", or og:url when it has no canonical link.
+// Values that aren't http(s) URLs are ignored.
+func extractCanonicalURL(doc *goquery.Document, pageURL string) string {
+	candidates := []string{
+		doc.Find(`link[rel~="canonical"]`).First().AttrOr("href", ""),
+		doc.Find(`meta[property="og:url"]`).First().AttrOr("content", ""),
+	}
+	for _, candidate := range candidates {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "" {
+			continue
+		}
+		absolute, err := resolveURL(pageURL, candidate)
+		if err != nil {
+			continue
+		}
+		if u, err := url.Parse(absolute); err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+			return absolute
+		}
+	}
+	return ""
+}
+
+// threadCanonicalURL returns the canonical URL a thread page names, or "" when
+// it names none or, on a platform that identifies threads by ID, one that isn't
+// a thread URL: boards that point every page at their home page would otherwise
+// make all their threads duplicates of the first
+func (fs *ForumScraperGo) threadCanonicalURL(doc *goquery.Document, pageURL string) string {
+	canonicalURL := extractCanonicalURL(doc, pageURL)
+	if canonicalURL == "" || fs.extractThreadID(pageURL) == "" {
+		return canonicalURL
+	}
+	if fs.extractThreadID(canonicalURL) == "" {
+		fs.debugf("Ignoring canonical URL %s of %s: not a thread URL", canonicalURL, pageURL)
+		return ""
+	}
+	return canonicalURL
+}
+
+// markCanonical records a thread's canonical URL as scraped, so mobile, AMP and
+// ?page=1 aliases of one thread are only scraped once, reporting false if the
+// thread was already scraped under another alias
+func (fs *ForumScraperGo) markCanonical(canonicalURL, fetchedURL string) bool {
+	if normalizeURL(canonicalURL) == normalizeURL(fetchedURL) {
+		return true
+	}
+	if !sameSite(hostOf(canonicalURL), hostOf(fetchedURL)) {
+		fs.statusf("ℹ️ %s names a canonical URL on another host: %s\n", fetchedURL, canonicalURL)
+	}
+	return fs.markVisited(canonicalURL)
+}
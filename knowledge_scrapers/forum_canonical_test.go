@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const canonicalTopicPath = "/t/building-a-static-binary-with-cgo-disabled/4412"
+
+// canonicalScraper returns a Discourse scraper whose requests for any host reach
+// a server answering every path with the fixture topic, naming canonical as its
+// canonical URL
+func canonicalScraper(t *testing.T, canonical string) *ForumScraperGo {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(fixturesDir, "discourse/topic.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := strings.Replace(string(data), `href="`+canonicalTopicPath+`"`, `href="`+canonical+`"`, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, page)
+	}))
+	t.Cleanup(server.Close)
+
+	scraper := NewForumScraper("discourse", 0)
+	scraper.statusOut = io.Discard
+	scraper.client.Transport.(*http.Transport).DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+	}
+	return scraper
+}
+
+// isDeduplicated reports whether err is one reportThreadError counts as deduplicated
+func isDeduplicated(err error) bool {
+	return errors.Is(err, ErrAlreadyVisited) || errors.Is(err, ErrDuplicateThread)
+}
+
+func TestCanonicalMobileAlias(t *testing.T) {
+	scraper := canonicalScraper(t, "http://forum.test"+canonicalTopicPath)
+	if _, err := scraper.scrapeThread("http://forum.test"+canonicalTopicPath, fixtureMaxPosts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scraper.scrapeThread("http://m.forum.test"+canonicalTopicPath, fixtureMaxPosts); !isDeduplicated(err) {
+		t.Errorf("m. alias of a scraped thread: %v, want it deduplicated", err)
+	}
+}
+
+func TestCanonicalPageOneAlias(t *testing.T) {
+	scraper := canonicalScraper(t, "http://forum.test"+canonicalTopicPath)
+	if _, err := scraper.scrapeThread("http://forum.test"+canonicalTopicPath+"?page=1", fixtureMaxPosts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scraper.scrapeThread("http://forum.test"+canonicalTopicPath, fixtureMaxPosts); !isDeduplicated(err) {
+		t.Errorf("thread scraped first as ?page=1: %v, want it deduplicated", err)
+	}
+}
+
+func TestCanonicalIgnoredWhenNotAThread(t *testing.T) {
+	// A board naming its home page as every page's canonical
+	scraper := canonicalScraper(t, "http://forum.test/")
+	for _, path := range []string{"/t/first-topic/4412", "/t/second-topic/4413"} {
+		thread, err := scraper.scrapeThread("http://forum.test"+path, fixtureMaxPosts)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if thread.CanonicalURL != "" {
+			t.Errorf("%s: CanonicalURL = %q, want it ignored", path, thread.CanonicalURL)
+		}
+	}
+}
+
+func TestSameSite(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"forum.example.com", "forum.example.com", true},
+		{"www.example.com", "example.com", true},
+		{"m.example.com", "www.example.com", true},
+		{"m.example.com", "example.com", true},
+		{"forum.example.com", "example.com", false},
+		{"mobile.example.com", "example.com", false},
+	}
+	for _, tt := range tests {
+		if got := sameSite(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameSite(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
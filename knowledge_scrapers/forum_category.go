@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// discourseCategory is one category from Discourse's /categories.json
+type discourseCategory struct {
+	ID              int                 `json:"id"`
+	Name            string              `json:"name"`
+	Slug            string              `json:"slug"`
+	SubcategoryList []discourseCategory `json:"subcategory_list"`
+}
+
+// discourseCategoryList is the body of /categories.json
+type discourseCategoryList struct {
+	CategoryList struct {
+		Categories []discourseCategory `json:"categories"`
+	} `json:"category_list"`
+}
+
+// discourseTopicList is the body of one /c/{slug}/{id}.json page
+type discourseTopicList struct {
+	TopicList struct {
+		MoreTopicsURL string `json:"more_topics_url"`
+		Topics        []struct {
+			ID           int    `json:"id"`
+			Slug         string `json:"slug"`
+			Title        string `json:"title"`
+			CreatedAt    string `json:"created_at"`
+			LastPostedAt string `json:"last_posted_at"`
+			PostsCount   int    `json:"posts_count"`
+			CategoryID   int    `json:"category_id"`
+		} `json:"topics"`
+	} `json:"topic_list"`
+}
+
+// flattenCategories lists categories with their subcategories after each parent
+func flattenCategories(categories []discourseCategory) []discourseCategory {
+	var flat []discourseCategory
+	for _, category := range categories {
+		flat = append(flat, category)
+		flat = append(flat, flattenCategories(category.SubcategoryList)...)
+	}
+	return flat
+}
+
+// resolveCategory finds a Discourse category, subcategories included, by
+// case-insensitive name, and returns it with every category on the board by ID.
+// Unknown names fail with the available categories listed.
+func (fs *ForumScraperGo) resolveCategory(forumURL, name string) (discourseCategory, map[int]discourseCategory, error) {
+	listURL, err := resolveURL(forumURL, "/categories.json?include_subcategories=true")
+	if err != nil {
+		return discourseCategory{}, nil, err
+	}
+	var list discourseCategoryList
+	if err := fs.fetchJSON(fs.runContext(), listURL, &list); err != nil {
+		return discourseCategory{}, nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	atomic.AddInt64(&fs.stats.IndexPagesFetched, 1)
+
+	categories := flattenCategories(list.CategoryList.Categories)
+	byID := make(map[int]discourseCategory, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+	names := make([]string, 0, len(categories))
+	for _, category := range categories {
+		if strings.EqualFold(strings.TrimSpace(category.Name), strings.TrimSpace(name)) {
+			return category, byID, nil
+		}
+		names = append(names, category.Name)
+	}
+	sort.Strings(names)
+	return discourseCategory{}, nil, fmt.Errorf("%w: %q (available: %s)", ErrUnknownCategory, name, strings.Join(names, ", "))
+}
+
+// discoverFromCategory collects topic URLs from a Discourse category's JSON
+// listing, resolving --category by name and paging until maxThreads or the
+// index page cap. Threads scraped from the listing take the name and ID of the
+// category their topic names, which is a subcategory for topics the listing
+// includes from one, rather than whatever their breadcrumb says.
+func (fs *ForumScraperGo) discoverFromCategory(forumURL string, maxThreads int) ([]ThreadRef, error) {
+	category, categories, err := fs.resolveCategory(forumURL, fs.categoryName)
+	if err != nil {
+		return nil, err
+	}
+	fs.statusf("🗂️ Discovering threads from category %s (#%d)\n", category.Name, category.ID)
+
+	var refs []ThreadRef
+	seen := make(map[int]bool)
+	for page := 0; len(refs) < maxThreads && page < fs.maxIndexPages; page++ {
+		pageURL, err := resolveURL(forumURL, fmt.Sprintf("/c/%s/%d.json?page=%d", url.PathEscape(category.Slug), category.ID, page))
+		if err != nil {
+			return refs, err
+		}
+		// Rate limiting
+		fs.politeWait(pageURL)
+
+		var list discourseTopicList
+		if err := fs.fetchJSON(fs.runContext(), pageURL, &list); err != nil {
+			if page == 0 {
+				return nil, err
+			}
+			fs.statusf("⚠️ Stopped category pagination at %s: %v\n", pageURL, err)
+			break
+		}
+		atomic.AddInt64(&fs.stats.IndexPagesFetched, 1)
+
+		added := 0
+		for _, topic := range list.TopicList.Topics {
+			if len(refs) >= maxThreads {
+				break
+			}
+			if seen[topic.ID] {
+				continue
+			}
+			seen[topic.ID] = true
+			added++
+
+			topicURL, err := resolveURL(forumURL, fmt.Sprintf("/t/%s/%d", url.PathEscape(topic.Slug), topic.ID))
+			if err != nil {
+				continue
+			}
+			topicCategory, exists := categories[topic.CategoryID]
+			if !exists {
+				topicCategory = category
+			}
+			ref := ThreadRef{URL: topicURL, Title: topic.Title, SourceURL: forumURL, LastActivity: parseLastMod(topic.LastPostedAt), Category: topicCategory.Name}
+			if topic.PostsCount > 0 {
+				replies := topic.PostsCount - 1
+				ref.Replies = &replies
+			}
+			if fs.tooOld(ref) || !fs.allowThreadURL(topicURL, forumURL) {
+				continue
+			}
+			fs.assignCategory(topicURL, topicCategory)
+			refs = append(refs, ref)
+		}
+		if added == 0 || list.TopicList.MoreTopicsURL == "" {
+			break
+		}
+	}
+
+	fs.statusf("📊 Discovered %d thread URLs in category %s\n", len(refs), category.Name)
+	return refs, nil
+}
+
+// assignCategory records the category a thread was discovered under
+func (fs *ForumScraperGo) assignCategory(threadURL string, category discourseCategory) {
+	fs.visitedMutex.Lock()
+	defer fs.visitedMutex.Unlock()
+	fs.threadCategories[normalizeURL(threadURL)] = category
+}
+
+// assignedCategory returns the category a thread was discovered under, if any
+func (fs *ForumScraperGo) assignedCategory(threadURL string) (discourseCategory, bool) {
+	fs.visitedMutex.RLock()
+	defer fs.visitedMutex.RUnlock()
+	category, exists := fs.threadCategories[normalizeURL(threadURL)]
+	return category, exists
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const categoriesJSON = `{"category_list": {"categories": [
+	{"id": 6, "name": "Help", "slug": "help", "subcategory_list": [
+		{"id": 9, "name": "Networking", "slug": "networking"}
+	]},
+	{"id": 2, "name": "Lounge", "slug": "lounge"}
+]}}`
+
+// helpTopicsJSON lists Help's topics, one of them from its Networking subcategory
+const helpTopicsJSON = `{"topic_list": {"topics": [
+	{"id": 4412, "slug": "building-a-static-binary-with-cgo-disabled", "title": "Building a static binary with CGO disabled", "posts_count": 3, "category_id": 6},
+	{"id": 4420, "slug": "dns-fails-inside-containers", "title": "DNS fails inside containers", "posts_count": 5, "category_id": 9}
+]}}`
+
+func TestDiscoverFromCategorySubcategoryTopics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/categories.json":
+			io.WriteString(w, categoriesJSON)
+		case "/c/help/6.json":
+			io.WriteString(w, helpTopicsJSON)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	scraper := NewForumScraper("discourse", 0)
+	scraper.statusOut = io.Discard
+	scraper.categoryName = "help"
+
+	refs, err := scraper.discoverFromCategory(server.URL, fixtureMaxThreads)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]struct {
+		name string
+		id   int
+	}{
+		server.URL + "/t/building-a-static-binary-with-cgo-disabled/4412": {"Help", 6},
+		server.URL + "/t/dns-fails-inside-containers/4420":                {"Networking", 9},
+	}
+	if len(refs) != len(want) {
+		t.Fatalf("got %d refs, want %d", len(refs), len(want))
+	}
+	for _, ref := range refs {
+		w := want[ref.URL]
+		if ref.Category != w.name {
+			t.Errorf("%s: Category = %q, want %q", ref.URL, ref.Category, w.name)
+		}
+		category, exists := scraper.assignedCategory(ref.URL)
+		if !exists || category.ID != w.id || category.Name != w.name {
+			t.Errorf("%s: assigned %+v, want %s (#%d)", ref.URL, category, w.name, w.id)
+		}
+	}
+}
+
+// checkSkipped scrapes threadURL, which the scraper is set up to skip as skipType,
+// and checks it is counted as a skip, not logged as a failure, and not fetched again
+func checkSkipped(t *testing.T, scraper *ForumScraperGo, threadURL, skipType string) {
+	t.Helper()
+	var status bytes.Buffer
+	scraper.statusOut = &status
+	err := scraper.scrapeRefsEach([]ThreadRef{{URL: threadURL}}, fixtureMaxPosts, func(*ForumThread) error {
+		t.Error("skipped thread was emitted")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(status.String(), "❌") {
+		t.Errorf("skipped thread logged as a failure:\n%s", status.String())
+	}
+	if failures := scraper.failures.snapshot(); len(failures) != 1 || failures[0].Type != skipType {
+		t.Errorf("failures %+v, want one %s", failures, skipType)
+	}
+	var summary bytes.Buffer
+	scraper.failures.printSummary(&summary)
+	if want := "⏭️ Skipped (" + skipType + "): 1"; !strings.Contains(summary.String(), want) {
+		t.Errorf("summary %q, want %q", summary.String(), want)
+	}
+	// The thread keeps its visited claim, so another source doesn't fetch it again
+	if _, err := scraper.scrapeThread(threadURL, fixtureMaxPosts); !errors.Is(err, ErrAlreadyVisited) {
+		t.Errorf("second scrape: %v, want ErrAlreadyVisited", err)
+	}
+}
+
+func TestCategoryFilteredThreadIsSkipped(t *testing.T) {
+	server := topicHost(t, 0)
+	// The fixture topic has no category, so it is outside any --include-category
+	scraper := NewForumScraper("phpbb", 0, WithCategoryFilter([]string{"networking"}, nil))
+	checkSkipped(t, scraper, server.URL+"/viewtopic.php?f=2&t=101", "category_filtered")
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// --include-category and --exclude-category keep threads by the category
+// discovery saw them under: an index row's own category label, the section
+// path of link texts a subforum crawl followed, or a subforum index page's
+// breadcrumb. Threads filtered then are never fetched, and excluded sections
+// are pruned from the crawl before their index pages are requested. Threads
+// discovery had no category for are filtered by their scraped category instead.
+
+// categorySectionSeparator joins the link texts of a crawled section path
+const categorySectionSeparator = " › "
+
+// pageCategorySelectors find the category a page's breadcrumb or header names
+var pageCategorySelectors = []string{".breadcrumb a", ".forum-name", ".category-name"}
+
+// pageCategory returns the category a page's breadcrumb or header names, or ""
+func pageCategory(doc *goquery.Document) string {
+	for _, selector := range pageCategorySelectors {
+		if category := strings.TrimSpace(doc.Find(selector).First().Text()); category != "" {
+			return category
+		}
+	}
+	return ""
+}
+
+// indexPageCategory returns the category a subforum index page is for: what its
+// breadcrumb names, as on its threads' pages, or else its forum title heading
+func indexPageCategory(doc *goquery.Document) string {
+	if category := pageCategory(doc); category != "" {
+		return category
+	}
+	return strings.TrimSpace(doc.Find(".forum-title").First().Text())
+}
+
+// categoryPattern matches a category name case-insensitively, as a substring
+// or, when it compiles, as a regex
+type categoryPattern struct {
+	text string
+	re   *regexp.Regexp
+}
+
+func newCategoryPattern(pattern string) categoryPattern {
+	re, _ := regexp.Compile("(?i)" + pattern)
+	return categoryPattern{text: strings.ToLower(pattern), re: re}
+}
+
+func (p categoryPattern) match(name string) bool {
+	return strings.Contains(strings.ToLower(name), p.text) || (p.re != nil && p.re.MatchString(name))
+}
+
+// categoryCount is how many threads of one category a run scraped and
+// filtered, and how many sections of that name its crawl pruned
+type categoryCount struct {
+	Scraped  int `json:"scraped"`
+	Filtered int `json:"filtered"`
+	Pruned   int `json:"sections_pruned,omitempty"`
+}
+
+// categoryFilter holds the category patterns and tallies what they kept
+type categoryFilter struct {
+	include []categoryPattern
+	exclude []categoryPattern
+
+	mu     sync.Mutex
+	counts map[string]*categoryCount
+	// decided maps threads kept during discovery to the category they were kept by
+	decided map[string]string
+}
+
+// active reports whether any category pattern is set
+func (f *categoryFilter) active() bool {
+	return len(f.include) > 0 || len(f.exclude) > 0
+}
+
+// excluded reports whether an exclude pattern matches name
+func (f *categoryFilter) excluded(name string) bool {
+	for _, pattern := range f.exclude {
+		if pattern.match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// allows reports whether threads of category name are kept. With include
+// patterns, a thread whose category is unknown can't be shown to match and isn't.
+func (f *categoryFilter) allows(name string) bool {
+	if f.excluded(name) {
+		return false
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, pattern := range f.include {
+		if pattern.match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// count returns name's tally, creating it; f.mu must be held
+func (f *categoryFilter) count(name string) *categoryCount {
+	if name == "" {
+		name = uncategorized
+	}
+	if f.counts == nil {
+		f.counts = make(map[string]*categoryCount)
+	}
+	c, exists := f.counts[name]
+	if !exists {
+		c = &categoryCount{}
+		f.counts[name] = c
+	}
+	return c
+}
+
+// keepRef decides a discovered thread by its category, if discovery found
+// one. Refs without a category are kept for keepScraped to decide.
+func (f *categoryFilter) keepRef(ref ThreadRef) bool {
+	if !f.active() || ref.Category == "" {
+		return true
+	}
+	keep := f.allows(ref.Category)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !keep {
+		f.count(ref.Category).Filtered++
+		return false
+	}
+	if f.decided == nil {
+		f.decided = make(map[string]string)
+	}
+	f.decided[normalizeURL(ref.URL)] = ref.Category
+	return true
+}
+
+// keepScraped decides a scraped thread discovery couldn't, by its own category
+func (f *categoryFilter) keepScraped(thread *ForumThread) bool {
+	if !f.active() {
+		return true
+	}
+	f.mu.Lock()
+	_, decided := f.decided[normalizeURL(thread.URL)]
+	f.mu.Unlock()
+	if decided || f.allows(thread.Category) {
+		return true
+	}
+	f.mu.Lock()
+	f.count(thread.Category).Filtered++
+	f.mu.Unlock()
+	return false
+}
+
+// recordScraped counts a kept thread under the category it was kept by
+func (f *categoryFilter) recordScraped(thread *ForumThread) {
+	if !f.active() {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	category, decided := f.decided[normalizeURL(thread.URL)]
+	if !decided {
+		category = thread.Category
+	}
+	f.count(category).Scraped++
+}
+
+// recordPruned counts a crawl section skipped for its name
+func (f *categoryFilter) recordPruned(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.count(name).Pruned++
+}
+
+// snapshot returns a copy of the tallies, nil when there are none
+func (f *categoryFilter) snapshot() map[string]categoryCount {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.counts) == 0 {
+		return nil
+	}
+	counts := make(map[string]categoryCount, len(f.counts))
+	for name, c := range f.counts {
+		counts[name] = *c
+	}
+	return counts
+}
+
+// filterRefsByCategory drops the discovered threads whose category is filtered
+func (fs *ForumScraperGo) filterRefsByCategory(refs []ThreadRef) []ThreadRef {
+	if !fs.categories.active() {
+		return refs
+	}
+	kept := refs[:0]
+	for _, ref := range refs {
+		if fs.categories.keepRef(ref) {
+			kept = append(kept, ref)
+		} else {
+			fs.debugf("Category %q filtered: %s", ref.Category, ref.URL)
+		}
+	}
+	return kept
+}
+
+// printSummary writes the tallies, one category per line in name order
+func (f *categoryFilter) printSummary(w io.Writer) {
+	counts := f.snapshot()
+	if len(counts) == 0 {
+		return
+	}
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return strings.ToLower(names[i]) < strings.ToLower(names[j]) })
+	fmt.Fprintf(w, "📂 Categories (scraped / filtered):\n")
+	for _, name := range names {
+		c := counts[name]
+		line := fmt.Sprintf("   %-30s %d / %d", truncateRunes(name, 30), c.Scraped, c.Filtered)
+		if c.Pruned > 0 {
+			line += fmt.Sprintf(" (%d sections pruned)", c.Pruned)
+		}
+		fmt.Fprintln(w, line)
+	}
+}
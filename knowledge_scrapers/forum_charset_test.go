@@ -0,0 +1,80 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf8"
+)
+
+// charsetDir holds thread pages saved in legacy encodings rather than UTF-8
+const charsetDir = "testdata/charset"
+
+func TestLegacyCharsetsDecodeToUTF8(t *testing.T) {
+	tests := []struct {
+		file        string
+		contentType string // as served; windows-1251 is only declared in a <meta> tag
+		title       string
+		authors     []string
+		contents    []string
+	}{
+		{
+			file:        "windows-1251.html",
+			contentType: "text/html",
+			title:       "Не монтируется корневой раздел",
+			authors:     []string{"Иван", "Ольга"},
+			contents: []string{
+				"После обновления ядра система не загружается: «VFS: Unable to mount root fs». Образ initramfs пересобран.",
+				"Проверьте, что модуль nvme попал в initramfs, и пересоберите его с --add-drivers nvme.",
+			},
+		},
+		{
+			file:        "iso-8859-1.html",
+			contentType: "text/html; charset=ISO-8859-1",
+			title:       "Problème de démarrage après mise à jour",
+			authors:     []string{"Hélène", "François"},
+			contents: []string{
+				"Depuis la mise à jour, le système s'arrête au démarrage. Même après régénération de l'initramfs, rien à faire.",
+				"Vérifiez que le pilote nvme est présent ; sinon ajoutez-le à la configuration de dracut et régénérez l'image. Ça a réglé le problème chez moi.",
+			},
+		},
+	}
+	for _, tt := range tests {
+		page, err := os.ReadFile(filepath.Join(charsetDir, tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if utf8.Valid(page) {
+			t.Fatalf("%s is valid UTF-8, so it doesn't exercise transcoding", tt.file)
+		}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", tt.contentType)
+			w.Write(page)
+		}))
+		t.Cleanup(server.Close)
+
+		scraper := NewForumScraper("phpbb", 0)
+		scraper.statusOut = io.Discard
+		thread, err := scraper.scrapeThread(server.URL+"/viewtopic.php?f=3&t=7", fixtureMaxPosts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.file, err)
+		}
+		if thread.Title != tt.title {
+			t.Errorf("%s: title %q, want %q", tt.file, thread.Title, tt.title)
+		}
+		if len(thread.Posts) != len(tt.contents) {
+			t.Fatalf("%s: got %d posts, want %d", tt.file, len(thread.Posts), len(tt.contents))
+		}
+		for i, post := range thread.Posts {
+			if post.Author != tt.authors[i] {
+				t.Errorf("%s post %d: author %q, want %q", tt.file, i+1, post.Author, tt.authors[i])
+			}
+			if post.Content != tt.contents[i] {
+				t.Errorf("%s post %d: content %q, want %q", tt.file, i+1, post.Content, tt.contents[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// requiredFields are the config fields check-config fails without: a page with
+// no posts or no post content yields nothing
+var requiredFields = []string{"PostSelector", "ContentSelector"}
+
+// checkConfig runs every selector and regex rule in config against doc, the page
+// at pageURL. Fields the scraper extracts are run through the extraction itself,
+// posts and thread metadata alike; the rest are evaluated on the whole page.
+func (fs *ForumScraperGo) checkConfig(doc *goquery.Document, config PlatformConfig, pageURL string) []*fieldOutcome {
+	fs.fields = newFieldRecorder()
+	defer func() { fs.fields = nil }()
+
+	posts, selector := config.PostSelector.find(doc.Selection)
+	fs.fieldMatched("PostSelector", config.PostSelector, doc.Selection, selector, strings.Join(strings.Fields(posts.First().Text()), " "))
+	metadata := fs.extractThreadMetadata(doc, pageURL)
+	title, _ := metadata["title"].(string)
+	posts.Each(func(i int, s *goquery.Selection) {
+		fs.scrapePost(s, config, title, pageURL, i+1)
+	})
+
+	for _, field := range config.selectorFields() {
+		if fs.fields.reached(field.name) || strings.Join(field.chain, "") == "" {
+			continue
+		}
+		matches, selector := field.chain.find(doc.Selection)
+		fs.fields.record(field.name, selector, matches.Length(), strings.Join(strings.Fields(matches.First().Text()), " "))
+	}
+
+	var page string
+	for _, rule := range config.regexRules() {
+		if rule.pattern == "" {
+			continue
+		}
+		re, err := rule.compile()
+		if err != nil {
+			fs.fields.record(rule.field, "", 0, err.Error())
+			continue
+		}
+		if page == "" {
+			page, _ = doc.Html()
+		}
+		found := re.FindAllStringSubmatch(page, -1)
+		value := ""
+		if len(found) > 0 {
+			value = strings.TrimSpace(found[0][1])
+		}
+		fs.fields.record(rule.field, rule.pattern, len(found), value)
+	}
+	return fs.fields.outcomes
+}
+
+// truncateRunes shortens s to at most n runes, marking the cut with an ellipsis
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if n <= 0 || len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// runCheckConfig implements the check-config subcommand: it tables what each
+// field of a platform config finds on one live or saved page, and exits non-zero
+// when a required field finds nothing
+func runCheckConfig(args []string) {
+	fset := flag.NewFlagSet("forum_scraper check-config", flag.ExitOnError)
+	fset.Usage = func() {
+		fmt.Println("Usage: forum_scraper check-config [flags] <config_file> <platform> <url_or_html_file>")
+		fmt.Println("Example: forum_scraper check-config boards.json myboard https://forum.example.com/showthread.php?t=42")
+		fmt.Println("Example: forum_scraper check-config boards.json myboard saved/thread.html")
+		fset.PrintDefaults()
+	}
+	width := fset.Int("width", 60, "truncate selectors and values to this many characters")
+
+	positional, err := parseInterleaved(fset, args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(positional) != 3 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	configPath, platform, target := positional[0], positional[1], positional[2]
+
+	scraper := NewForumScraper(platform, 0)
+	if err := scraper.loadPlatformConfigs(configPath); err != nil {
+		log.Fatalf("❌ Failed to load %s: %v", configPath, err)
+	}
+	config, exists := scraper.configs[platform]
+	if !exists {
+		log.Fatalf("❌ Unknown platform %q: not built in or in %s", platform, configPath)
+	}
+
+	var doc *goquery.Document
+	pageURL := target
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		if doc, err = scraper.fetchDocument(target); err != nil {
+			log.Fatalf("❌ Failed to fetch %s: %v", target, err)
+		}
+		pageURL = doc.Url.String()
+	} else {
+		file, err := os.Open(target)
+		if err != nil {
+			log.Fatalf("❌ Failed to open %s: %v", target, err)
+		}
+		defer file.Close()
+		if absolute, err := filepath.Abs(target); err == nil {
+			pageURL = "file://" + filepath.ToSlash(absolute)
+		}
+		if doc, err = parseHTML(file, pageURL); err != nil {
+			log.Fatalf("❌ Failed to parse %s: %v", target, err)
+		}
+	}
+
+	outcomes := scraper.checkConfig(doc, config, pageURL)
+	table := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "\tFIELD\tMATCHES\tSELECTOR\tFIRST VALUE")
+	for _, outcome := range outcomes {
+		mark := "✅"
+		if outcome.Matches == 0 {
+			mark = "❌"
+		}
+		value := strconv.Quote(truncateRunes(outcome.Value, *width))
+		if outcome.Value == "" {
+			value = "-"
+		}
+		selector := truncateRunes(outcome.Selector, *width)
+		if selector == "" {
+			selector = "-"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%d\t%s\t%s\n", mark, outcome.Field, outcome.Matches, selector, value)
+	}
+	table.Flush()
+
+	var missing []string
+	for _, field := range requiredFields {
+		found := false
+		for _, outcome := range outcomes {
+			found = found || (outcome.Field == field && outcome.Matches > 0)
+		}
+		if !found {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		fmt.Fprintf(os.Stderr, "\n❌ Required field(s) found nothing: %s\n", strings.Join(missing, ", "))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const checkConfigPage = `<html><body>
+<h1>Seed potatoes over winter</h1>
+<div class="post"><span class="author">user1</span><div class="body">Keep them cool and dark.</div></div>
+</body></html>`
+
+// checkConfigOutcome runs check-config with a generic config whose ThreadSelector
+// is threadSelector and returns the ThreadSelector outcome
+func checkConfigOutcome(t *testing.T, threadSelector selectorChain) *fieldOutcome {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(checkConfigPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scraper := NewForumScraper("generic", 0)
+	scraper.statusOut = io.Discard
+	config := PlatformConfig{
+		PostSelector:    selectorChain{".post"},
+		AuthorSelector:  selectorChain{".author"},
+		ContentSelector: selectorChain{".body"},
+		ThreadSelector:  threadSelector,
+	}
+	scraper.configs["generic"] = config
+	for _, outcome := range scraper.checkConfig(doc, config, "http://forum.test/thread/1") {
+		if outcome.Field == "ThreadSelector" {
+			return outcome
+		}
+	}
+	return nil
+}
+
+func TestCheckConfigThreadSelectorIgnoresDefaults(t *testing.T) {
+	// The built-in h1 fallback finds the title, but the config's own selector doesn't
+	outcome := checkConfigOutcome(t, selectorChain{".thread-heading"})
+	if outcome == nil {
+		t.Fatal("no ThreadSelector outcome")
+	}
+	if outcome.Matches != 0 || outcome.Selector != "" || outcome.Value != "" {
+		t.Errorf("outcome = %+v, want no match", outcome)
+	}
+}
+
+func TestCheckConfigThreadSelectorMatch(t *testing.T) {
+	outcome := checkConfigOutcome(t, selectorChain{".thread-heading", "h1"})
+	if outcome == nil {
+		t.Fatal("no ThreadSelector outcome")
+	}
+	if outcome.Selector != "h1" || outcome.Matches != 1 || outcome.Value != "Seed potatoes over winter" {
+		t.Errorf("outcome = %+v, want h1 matching the title", outcome)
+	}
+}
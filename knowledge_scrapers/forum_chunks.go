@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// --format chunks flattens each thread into "Author: content" blocks, one per
+// post, and packs consecutive blocks into chunks of at most --chunk-tokens
+// tokens for embedding. A chunk only ends between posts; a post that alone is
+// over the budget is cut at word boundaries into pieces, each a chunk of its
+// own. With --chunk-overlap, a chunk of whole posts starts by repeating the last
+// whole posts of the chunk before, as many as fit in the overlap.
+
+// Defaults for --chunk-tokens and --chunk-overlap
+const (
+	defaultChunkTokens  = 512
+	defaultChunkOverlap = 0
+)
+
+// chunkSeparator joins post blocks within a chunk
+const chunkSeparator = "\n\n"
+
+// TokenCounter counts the tokens text costs against the --format chunks budget.
+// Counts must not shrink as text grows. Plug in a tiktoken-compatible tokenizer
+// with WithTokenCounter for exact model token counts.
+type TokenCounter interface {
+	CountTokens(text string) int
+}
+
+// TokenCounterFunc adapts a function to the TokenCounter interface
+type TokenCounterFunc func(string) int
+
+// CountTokens calls f(text)
+func (f TokenCounterFunc) CountTokens(text string) int { return f(text) }
+
+// approxTokens estimates tokens as one per four characters, rounded up, which is
+// close to what BPE tokenizers give for English prose
+func approxTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// Chunk is one record of --format chunks. PostRange holds the post numbers of
+// the chunk's first and last posts.
+type Chunk struct {
+	ThreadURL  string `json:"thread_url"`
+	ChunkIndex int    `json:"chunk_index"`
+	Text       string `json:"text"`
+	PostRange  [2]int `json:"post_range"`
+	Tokens     int    `json:"tokens"`
+}
+
+// chunkUnit is a post block, or a piece of one too long for a chunk
+type chunkUnit struct {
+	text  string
+	post  int
+	piece bool
+}
+
+// chunkWords splits text into words, each keeping the whitespace after it
+var chunkWords = regexp.MustCompile(`\s*\S+\s*`)
+
+// postBlock renders a post as it appears in a chunk
+func postBlock(post ForumPost) string {
+	content := strings.TrimSpace(post.Content)
+	if post.Author == "" {
+		return content
+	}
+	return post.Author + ": " + content
+}
+
+// chunkThread packs thread's posts into chunks of at most budget tokens, with
+// each chunk of whole posts repeating up to overlap tokens of whole posts from
+// the end of the chunk before
+func chunkThread(thread *ForumThread, counter TokenCounter, budget, overlap int) []Chunk {
+	var units []chunkUnit
+	for _, post := range thread.Posts {
+		block := postBlock(post)
+		if block == "" {
+			continue
+		}
+		if counter.CountTokens(block) <= budget {
+			units = append(units, chunkUnit{text: block, post: post.PostNumber})
+			continue
+		}
+		for _, piece := range splitOversized(block, counter, budget) {
+			units = append(units, chunkUnit{text: piece, post: post.PostNumber, piece: true})
+		}
+	}
+
+	var chunks []Chunk
+	var current []chunkUnit
+	fresh := 0 // units in current not already in an earlier chunk
+	emit := func() {
+		texts := make([]string, len(current))
+		for i, unit := range current {
+			texts[i] = unit.text
+		}
+		text := strings.Join(texts, chunkSeparator)
+		chunks = append(chunks, Chunk{
+			ThreadURL:  thread.URL,
+			ChunkIndex: len(chunks),
+			Text:       text,
+			PostRange:  [2]int{current[0].post, current[len(current)-1].post},
+			Tokens:     counter.CountTokens(text),
+		})
+	}
+	for _, unit := range units {
+		full := len(current) > 0 && (unit.piece || current[len(current)-1].piece ||
+			counter.CountTokens(joinUnits(current, unit)) > budget)
+		if full {
+			emit()
+			if unit.piece {
+				current = nil
+			} else {
+				current = overlapTail(current, counter, overlap)
+			}
+			for len(current) > 0 && counter.CountTokens(joinUnits(current, unit)) > budget {
+				current = current[1:]
+			}
+			fresh = 0
+		}
+		current = append(current, unit)
+		fresh++
+	}
+	if fresh > 0 {
+		emit()
+	}
+	return chunks
+}
+
+// joinUnits is the text of units with next appended
+func joinUnits(units []chunkUnit, next chunkUnit) string {
+	var b strings.Builder
+	for _, unit := range units {
+		b.WriteString(unit.text)
+		b.WriteString(chunkSeparator)
+	}
+	b.WriteString(next.text)
+	return b.String()
+}
+
+// overlapTail returns the longest run of trailing whole-post units whose text
+// fits in overlap tokens
+func overlapTail(units []chunkUnit, counter TokenCounter, overlap int) []chunkUnit {
+	if overlap <= 0 {
+		return nil
+	}
+	start := len(units)
+	for start > 0 && !units[start-1].piece {
+		tail := units[start-1:]
+		if counter.CountTokens(joinUnits(tail[:len(tail)-1], tail[len(tail)-1])) > overlap {
+			break
+		}
+		start--
+	}
+	return append([]chunkUnit(nil), units[start:]...)
+}
+
+// splitOversized cuts a block over budget into pieces within it, at word
+// boundaries where it can; a single word over budget is cut between characters
+func splitOversized(block string, counter TokenCounter, budget int) []string {
+	var pieces []string
+	var current strings.Builder
+	flush := func() {
+		if piece := strings.TrimSpace(current.String()); piece != "" {
+			pieces = append(pieces, piece)
+		}
+		current.Reset()
+	}
+	for _, word := range chunkWords.FindAllString(block, -1) {
+		if counter.CountTokens(strings.TrimSpace(current.String()+word)) <= budget {
+			current.WriteString(word)
+			continue
+		}
+		flush()
+		// Keep the whitespace after the word as the text has it
+		word = strings.TrimLeftFunc(word, unicode.IsSpace)
+		core := strings.TrimRightFunc(word, unicode.IsSpace)
+		for counter.CountTokens(core) > budget {
+			cut := longestPrefixWithin(core, counter, budget)
+			pieces = append(pieces, core[:cut])
+			core = core[cut:]
+		}
+		current.WriteString(core + word[len(strings.TrimRightFunc(word, unicode.IsSpace)):])
+	}
+	flush()
+	return pieces
+}
+
+// longestPrefixWithin returns the byte length of the longest prefix of word,
+// at least one character, that costs at most budget tokens
+func longestPrefixWithin(word string, counter TokenCounter, budget int) int {
+	var offsets []int
+	for i := range word {
+		if i > 0 {
+			offsets = append(offsets, i)
+		}
+	}
+	offsets = append(offsets, len(word))
+	// Counts don't shrink as the prefix grows, so the fitting prefixes come first
+	n := sort.Search(len(offsets), func(i int) bool {
+		return counter.CountTokens(word[:offsets[i]]) > budget
+	})
+	if n == 0 {
+		return offsets[0]
+	}
+	return offsets[n-1]
+}
+
+// threadChunks chunks every thread with the run's counter and budget
+func (fs *ForumScraperGo) threadChunks(threads []*ForumThread) []Chunk {
+	var chunks []Chunk
+	for _, thread := range threads {
+		chunks = append(chunks, chunkThread(thread, fs.tokenCounter, fs.chunkTokens, fs.chunkOverlap)...)
+	}
+	return chunks
+}
+
+// writeChunks writes chunks to w, one JSON object per line
+func writeChunks(w io.Writer, chunks []Chunk) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, chunk := range chunks {
+		if err := encoder.Encode(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveChunks writes the --format chunks export named like the results file,
+// with a .jsonl extension, or to stdout for -
+func (fs *ForumScraperGo) saveChunks(threads []*ForumThread, name string) ([]string, error) {
+	threads, path := fs.jsonlExportTarget(threads, name)
+	chunks := fs.threadChunks(threads)
+	err := writeJSONLExport(path, func(w io.Writer) error {
+		return writeChunks(w, chunks)
+	})
+	if err != nil || path == "-" {
+		return nil, err
+	}
+	fs.statusf("💾 Chunks saved to: %s (%d chunks of up to %d tokens)\n", path, len(chunks), fs.chunkTokens)
+	return []string{path}, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// The chunks fixture is a results file with short posts, a post over the budget
+// and a word over the budget, checked against the --format chunks records it
+// should produce at chunksFixtureTokens with chunksFixtureOverlap. Every budget
+// in chunksFixtureBudgets is also checked for oversized chunks and split posts.
+const (
+	chunksFixtureResults = "chunks/results.jsonl"
+	chunksFixtureGolden  = "chunks/golden.jsonl"
+	chunksFixtureTokens  = 40
+	chunksFixtureOverlap = 12
+)
+
+var chunksFixtureBudgets = [][2]int{{8, 0}, {8, 4}, {16, 0}, {25, 10}, {40, 0}, {40, 39}, {512, 100}}
+
+func TestChunksFixture(t *testing.T) {
+	threads := readFixtureThreads(t, chunksFixtureResults)
+	counter := TokenCounterFunc(approxTokens)
+	for _, budget := range chunksFixtureBudgets {
+		var chunks []Chunk
+		for _, thread := range threads {
+			chunks = append(chunks, chunkThread(thread, counter, budget[0], budget[1])...)
+		}
+		if err := checkChunks(threads, chunks, counter, budget[0]); err != nil {
+			t.Errorf("--chunk-tokens %d --chunk-overlap %d: %v", budget[0], budget[1], err)
+		}
+	}
+
+	var chunks []Chunk
+	for _, thread := range threads {
+		chunks = append(chunks, chunkThread(thread, counter, chunksFixtureTokens, chunksFixtureOverlap)...)
+	}
+	var encoded bytes.Buffer
+	if err := writeChunks(&encoded, chunks); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, chunksFixtureGolden, encoded.Bytes())
+}
+
+// checkChunks verifies chunks of threads against the --format chunks rules: no
+// chunk is over budget, and each is either whole consecutive posts or a piece of
+// one post
+func checkChunks(threads []*ForumThread, chunks []Chunk, counter TokenCounter, budget int) error {
+	blocks := make(map[string]map[int]string)
+	for _, thread := range threads {
+		blocks[thread.URL] = make(map[int]string)
+		for _, post := range thread.Posts {
+			blocks[thread.URL][post.PostNumber] = postBlock(post)
+		}
+	}
+	for _, chunk := range chunks {
+		name := fmt.Sprintf("%s chunk %d", chunk.ThreadURL, chunk.ChunkIndex)
+		if tokens := counter.CountTokens(chunk.Text); tokens > budget {
+			return fmt.Errorf("%s has %d tokens, over the budget of %d", name, tokens, budget)
+		}
+		first, last := chunk.PostRange[0], chunk.PostRange[1]
+		posts := blocks[chunk.ThreadURL]
+		if first == last && chunk.Text != posts[first] {
+			if !strings.Contains(posts[first], chunk.Text) {
+				return fmt.Errorf("%s is not a piece of post %d", name, first)
+			}
+			if counter.CountTokens(posts[first]) <= budget {
+				return fmt.Errorf("%s splits post %d, which fits the budget", name, first)
+			}
+			continue
+		}
+		var texts []string
+		for _, post := range threadPostNumbers(posts, first, last) {
+			if block := posts[post]; block != "" {
+				texts = append(texts, block)
+			}
+		}
+		if chunk.Text != strings.Join(texts, chunkSeparator) {
+			return fmt.Errorf("%s is not whole posts %d-%d", name, first, last)
+		}
+	}
+	return nil
+}
+
+// threadPostNumbers returns the post numbers of posts from first to last, in order
+func threadPostNumbers(posts map[int]string, first, last int) []int {
+	var numbers []int
+	for number := range posts {
+		if number >= first && number <= last {
+			numbers = append(numbers, number)
+		}
+	}
+	sort.Ints(numbers)
+	return numbers
+}
@@ -0,0 +1,814 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// stringList is a flag.Value collecting every occurrence of a repeatable flag
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// parseInterleaved parses flags that may appear before, between or after positional args
+func parseInterleaved(fset *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fset.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fset.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func usage() {
+	fmt.Println("Usage: forum_scraper [flags] <platform> <forum_url>... [max_threads] [max_posts_per_thread]")
+	fmt.Println("Example: forum_scraper phpbb https://forum.example.com/ 10 25")
+	fmt.Println("Example: forum_scraper --platform phpbb --urls-file boards.txt --max-threads 50")
+	fmt.Println("Example: forum_scraper phpbb ./mirror/forum.example.com/ 1000")
+	fmt.Println("Example: other-tool | forum_scraper --platform phpbb --stdin > threads.jsonl")
+	fmt.Println("Example: forum_scraper --quiet --summary-json - phpbb https://forum.example.com/ --output - | jq .total_posts")
+	fmt.Println("Example: forum_scraper --format qa-jsonl --qa-min-answer-likes 1 discourse https://forum.example.com/ 200 --output qa.jsonl")
+	fmt.Println("Example: forum_scraper --format chunks --chunk-tokens 256 --chunk-overlap 32 phpbb https://forum.example.com/ 50 --output - > chunks.jsonl")
+	fmt.Println("Example: forum_scraper --window 2019-01-01..2019-12-31 discourse https://forum.example.com/ 500")
+	fmt.Println("Example: forum_scraper --max-depth 2 --exclude-category lounge --exclude-category 'off[- ]?topic' phpbb https://forum.example.com/ 100")
+	fmt.Println("Example: forum_scraper discover --format json phpbb https://forum.example.com/ 50 > threads.json")
+	fmt.Println("Example: MARINA_TOKEN=... forum_scraper --run-config nightly.yaml --max-threads 5")
+	fmt.Println("Example: forum_scraper --notify-match '(?i)\\bmarina\\b' --notify-webhook https://hooks.example.com/forum phpbb https://forum.example.com/ 50")
+	fmt.Println("Example: forum_scraper merge --output corpus.json scraping_results/*.json")
+	fmt.Println("Example: forum_scraper diff yesterday.json today.json > changes.jsonl")
+	fmt.Println("Example: forum_scraper stats --format json scraping_results/forum_scrape_phpbb.json")
+	fmt.Println("Example: forum_scraper validate scraping_results/*.json")
+	fmt.Println("Example: forum_scraper check-config boards.json myboard saved/thread.html")
+	fmt.Println("Example: forum_scraper serve --addr :8080 --workers 4")
+	fmt.Println("Example: forum_scraper worker --redis redis://queue:6379/0 (build with -tags redis)")
+	fmt.Println("Example: forum_scraper grpc-serve --addr :50051 (build with -tags grpc)")
+	fmt.Println("Exit status is 3 when --max-total-posts, --max-requests or --deadline stopped the run early.")
+}
+
+// runGRPCServe and runGRPCClient run the grpc-serve and grpc-client commands.
+// They are only set in builds with the grpc tag, so the default binary carries
+// no gRPC stack.
+var runGRPCServe, runGRPCClient func(args []string)
+
+// subcommands are the commands main hands the rest of its arguments to, in place
+// of a scrape
+var subcommands = map[string]func(args []string){
+	"merge":        runMerge,
+	"stats":        runResultStats,
+	"diff":         runDiff,
+	"worker":       runWorker,
+	"enqueue":      runEnqueue,
+	"serve":        runServe,
+	"check-config": runCheckConfig,
+	"validate":     runValidate,
+	"grpc-serve":   func(args []string) { runGRPC("grpc-serve", runGRPCServe, args) },
+	"grpc-client":  func(args []string) { runGRPC("grpc-client", runGRPCClient, args) },
+}
+
+// runGRPC runs a gRPC command, which only builds with the grpc tag set
+func runGRPC(name string, run func(args []string), args []string) {
+	if run == nil {
+		log.Fatalf("❌ %s needs a build with gRPC support: go build -tags grpc", name)
+	}
+	run(args)
+}
+
+// CLI interface
+func main() {
+	args := os.Args[1:]
+	if len(args) > 0 {
+		if run, ok := subcommands[args[0]]; ok {
+			run(args[1:])
+			return
+		}
+		// discover is a scrape that stops at the thread list
+		if args[0] == "discover" {
+			args = append([]string{"--dry-run"}, args[1:]...)
+		}
+	}
+	runScrape(args)
+}
+
+// runScrape scrapes the sources named by args, or lists them with --dry-run
+func runScrape(args []string) {
+	fset := flag.NewFlagSet("forum_scraper", flag.ExitOnError)
+	fset.Usage = func() {
+		usage()
+		fset.PrintDefaults()
+	}
+
+	platformFlag := fset.String("platform", "", "forum platform (phpbb, vbulletin, discourse, reddit, xenforo, stackexchange, hackernews, generic)")
+	maxThreads := fset.Int("max-threads", 10, "maximum threads to discover per index page")
+	maxPostsPerThread := fset.Int("max-posts", 25, "maximum posts to scrape per thread")
+	postsMode := fset.String("posts-mode", postsModeAll, "which posts to scrape: all, first (opening post only), none (thread metadata only) or solved (question and accepted answer; unsolved threads are skipped)")
+	delay := fset.Float64("delay", 1.5, "delay in seconds before each request")
+	urlsFile := fset.String("urls-file", "", "file with one forum or thread URL per line (# comments ignored)")
+	threadPattern := fset.String("thread-pattern", "", "regex identifying thread URLs among the inputs")
+	fileGlob := fset.String("file-glob", "", "glob selecting the thread files of a local directory source (default: files matching the thread URL pattern)")
+	maxIndexPages := fset.Int("max-index-pages", 10, "maximum pages of each index to walk during discovery")
+	maxPagesPerThread := fset.Int("max-pages-per-thread", 1, "maximum pages of each thread to fetch, following its pagination from the first page")
+	lastPage := fset.Bool("last-page", false, "also fetch the last page of threads cut short by --max-pages-per-thread")
+	maxDepth := fset.Int("max-depth", 0, "levels of subforums to crawl below each index page")
+	maxForumsPerLevel := fset.Int("max-forums-per-level", 20, "maximum subforums to queue at each crawl level")
+	useSitemap := fset.Bool("sitemap", false, "discover threads from the forum's sitemap.xml instead of index pages")
+	maxSitemaps := fset.Int("max-sitemaps", 20, "maximum sitemap files to fetch per source")
+	searchQuery := fset.String("search", "", "discover threads from the forum's search results for this query")
+	user := fset.String("user", "", "discover the threads this member posted in: a name, a member ID, or name.ID (phpbb, discourse, xenforo)")
+	userPostsOnly := fset.Bool("user-posts-only", false, "with --user, emit only the member's posts, without fetching full threads where the history shows whole posts")
+	category := fset.String("category", "", "discover threads from this Discourse category (by name, subcategories included)")
+	feedURL := fset.String("feed", "", "discover threads from this RSS/Atom feed URL, or \"auto\" to use the feed each page advertises")
+	since := fset.String("since", "", "skip threads with no activity since this date or duration (e.g. 2024-01-01, 7d)")
+	window := fset.String("window", "", "keep only posts dated in start..end (e.g. 2019-01-01..2019-12-31, either side may be open), listing indexes by date where the platform allows; threads cut by it are marked partial_window")
+	render := fset.Bool("render", false, "load thread pages in headless Chrome (requires a build with -tags chromedp)")
+	renderTabs := fset.Int("render-tabs", 2, "maximum concurrent browser tabs when rendering")
+	renderTimeout := fset.Duration("render-timeout", 20*time.Second, "how long to wait for posts to appear in a rendered page")
+	maxBandwidth := fset.String("max-bandwidth", "", "cap download throughput across all requests in bytes per second (e.g. 500k, 2m)")
+	connectTimeout := fset.Duration("connect-timeout", defaultConnectTimeout, "how long to wait for a connection (0 for no limit)")
+	responseHeaderTimeout := fset.Duration("response-header-timeout", 0, "how long to wait for response headers once a request is sent (0 for no limit)")
+	requestTimeout := fset.Duration("request-timeout", defaultRequestTimeout, "how long one request may take, body included (0 for no limit)")
+	threadTimeout := fset.Duration("thread-timeout", defaultThreadTimeout, "how long one thread may take, pagination included, before its partial posts are kept (0 for no limit)")
+	dnsTTL := fset.Duration("dns-ttl", defaultDNSTTL, "how long to reuse a host's resolved addresses (0 to resolve on every connection)")
+	ipVersion := fset.String("ip-version", ipVersionAuto, "address family to connect over: 4, 6, or auto to race the other family when the first is slow")
+	precheck := fset.Bool("precheck", false, "check each thread's headers with a HEAD request before fetching it, skipping threads that are gone, not HTML or over --max-response-size")
+	maxResponseSize := fset.Int64("max-response-size", defaultMaxResponseSize, "maximum response body size in bytes (0 for no limit)")
+	var skipFrom stringList
+	fset.Var(&skipFrom, "skip-from", "results file (JSON or JSONL) from an earlier run whose threads are skipped as previously exported (repeatable)")
+	var allowHosts stringList
+	fset.Var(&allowHosts, "allow-host", "host discovered links may point at (repeatable, default: each source's host)")
+	urlPattern := fset.String("url-pattern", "", "regex discovered thread URLs must match")
+	urlExclude := fset.String("url-exclude", "", "regex rejecting discovered thread and pagination URLs")
+	var includeCategories, excludeCategories stringList
+	fset.Var(&includeCategories, "include-category", "only scrape threads in categories matching this case-insensitive substring or regex (repeatable)")
+	fset.Var(&excludeCategories, "exclude-category", "skip threads in, and subforum sections named by, categories matching this case-insensitive substring or regex (repeatable)")
+	stdinMode := fset.Bool("stdin", false, "read thread URLs from stdin and write JSONL threads to stdout")
+	dryRun := fset.Bool("dry-run", false, "list the threads discovery would scrape without fetching them")
+	format := fset.String("format", "", "output format: json, html (a browsable archive directory), markdown (a report per category), qa-jsonl (one question/answer pair per thread) or chunks (thread text in token-budgeted pieces) for scrapes, text or json for --dry-run")
+	singleFile := fset.Bool("single-file", false, "with --format markdown, write one report file instead of one per category")
+	excerptChars := fset.Int("excerpt-chars", defaultExcerptChars, "with --format markdown, cut quoted posts after this many characters (0 for no limit)")
+	qaMinAnswerChars := fset.Int("qa-min-answer-chars", defaultQAMinAnswerChars, "with --format qa-jsonl, skip answers shorter than this many characters")
+	qaMinAnswerLikes := fset.Int("qa-min-answer-likes", defaultQAMinAnswerLikes, "with --format qa-jsonl, skip answers with fewer likes than this unless accepted")
+	chunkTokens := fset.Int("chunk-tokens", defaultChunkTokens, "with --format chunks, the most tokens (about 4 characters each) in one chunk")
+	chunkOverlap := fset.Int("chunk-overlap", defaultChunkOverlap, "with --format chunks, repeat up to this many tokens of whole posts from the previous chunk")
+	outputDir := fset.String("output-dir", defaultOutputDir, "directory for result files (created if missing)")
+	output := fset.String("output", "", "result file name, a path with a directory to bypass --output-dir, or - for stdout")
+	quiet := fset.Bool("quiet", false, "print no status lines (errors are still reported on stderr)")
+	emitAuthors := fset.String("emit-authors", "", "also write one profile per author (posts, threads, first/last seen, likes, categories) to this file; .jsonl for JSON lines")
+	summaryJSON := fset.String("summary-json", "", "write a machine-readable run summary to this file, or - for one JSON line on stderr")
+	stackExchangeKey := fset.String("stackexchange-key", "", "Stack Exchange API key, for a higher daily quota")
+	downloadAttachments := fset.String("download-attachments", "", "save post attachments under this directory, one subdirectory per thread")
+	maxAttachmentSize := fset.String("max-attachment-size", "10m", "largest attachment --download-attachments saves (also bounded by --max-response-size)")
+	followReferences := fset.Int("follow-references", 0, "also scrape threads that posts link to, up to this many hops away")
+	oldReddit := fset.Bool("old-reddit", true, "rewrite reddit URLs to old.reddit.com, whose markup the reddit selectors target")
+	lightweight := fset.Bool("lightweight", false, "fetch whole threads from the platform's print view in one request (vbulletin printthread.php)")
+	selectiveParse := fset.Bool("selective-parse", true, "prune thread pages over 1 MB to their header and posts before extraction")
+	sortBy := fset.String("sort", "", "order saved threads by views, replies, recent or posts, highest first (default: by URL)")
+	top := fset.Int("top", 0, "keep only the N highest-ranked threads with --sort (0 for all)")
+	prioritize := fset.String("prioritize", "", "before --max-threads cuts each index page's discovered threads, order them by recent (last activity), active (replies) or title-match (default: discovery order)")
+	prioritizePattern := fset.String("prioritize-pattern", "", "regex scoring titles for --prioritize title-match, one point per match")
+	dedupePosts := fset.Bool("dedupe-posts", false, "drop posts whose normalized content already appeared earlier in the run")
+	anonymizeAuthors := fset.Bool("anonymize-authors", false, "replace author names with salted HMAC tokens")
+	anonymizeSalt := fset.String("anonymize-salt", "", "salt for --anonymize-authors (default: random per run, recorded in the output)")
+	redactPII := fset.Bool("redact-pii", false, "replace email addresses and phone numbers in post content with placeholders")
+	languages := fset.String("languages", "", "comma-separated language codes to keep (e.g. en,de); other posts and threads are dropped")
+	minPostLength := fset.Int("min-post-length", 10, "skip posts shorter than this many characters")
+	maxPostLength := fset.Int("max-post-length", 0, "skip posts longer than this many characters (0 for no limit)")
+	truncateLongPosts := fset.Bool("truncate-long-posts", false, "cut posts over --max-post-length with an ellipsis instead of skipping them")
+	platformConfig := fset.String("platform-config", "", "JSON file overriding or extending platform selectors and ignore patterns")
+	runConfigPath := fset.String("run-config", "", "TOML or YAML file of flag settings and \"sources\" for the run; flags override it, and it overrides "+envPrefix+"* environment variables")
+	noNormalize := fset.Bool("no-normalize", false, "keep extracted text as-is instead of collapsing whitespace and stripping BBCode")
+	scoreQuery := fset.String("score-query", "", "score each post's relevance to these terms")
+	minScore := fset.Float64("min-score", 0, "drop posts scoring below this with --score-query")
+	concurrency := fset.Int("concurrency", 5, "maximum threads scraped at once across all hosts")
+	perHostConcurrency := fset.Int("per-host-concurrency", defaultPerHostConcurrency, "maximum threads scraped at once from one host")
+	maxRedirects := fset.Int("max-redirects", defaultMaxRedirects, "maximum redirects to follow per request")
+	allowExternal := fset.Bool("allow-external", false, "follow redirects to other hosts")
+	caCert := fset.String("ca-cert", "", "PEM file with an extra CA to trust (e.g. a corporate CA)")
+	clientCert := fset.String("client-cert", "", "PEM client certificate for mutual TLS (with --client-key)")
+	clientKey := fset.String("client-key", "", "PEM private key for --client-cert")
+	basicAuth := fset.String("basic-auth", "", "HTTP Basic credentials as user:pass (or set "+envBasicAuth+")")
+	bearerToken := fset.String("bearer-token", "", "API token sent with every request (or set "+envToken+")")
+	tokenUser := fset.String("token-user", "", "account --bearer-token acts as, required on platforms whose tokens name one (discourse: sent as Api-Username)")
+	insecureSkipVerify := fset.Bool("insecure-skip-verify", false, "do not verify server certificates (unsafe)")
+	jitter := fset.Float64("jitter", 0, "randomize each delay by ± this fraction (e.g. 0.3 for ±30%)")
+	adaptiveDelay := fset.Bool("adaptive-delay", false, "back off per host when responses are slow or rate-limited (429/503)")
+	retries := fset.Int("retries", 0, "retry a request up to this many times after a timeout, a dropped connection or a 429/502/503/504")
+	userAgentFile := fset.String("user-agent-file", "", "file with one User-Agent per line to rotate through")
+	uaRotate := fset.String("ua-rotate", uaRotateSticky, "User-Agent rotation with --user-agent-file: sticky (one per host) or per-request")
+	debug := fset.Bool("debug", false, "print debug lines")
+	accessLogPath := fset.String("access-log", "", "append one line per HTTP request to this file (time, method, URL, status, bytes, duration, retry, worker)")
+	accessLogMaxSize := fset.String("access-log-max-size", "", "rotate --access-log once it passes this size (e.g. 100m; default: never)")
+	sinkOptions := registerSinkFlags(fset)
+	s3Target := fset.String("s3", "", "also upload results to s3://bucket/prefix (needs a build with -tags s3)")
+	s3Endpoint := fset.String("s3-endpoint", "", "endpoint URL of an S3-compatible server for --s3 (default: AWS)")
+	seed := fset.Int64("seed", 0, "seed for jitter and User-Agent choices, so runs repeat exactly (0 for random)")
+	fixedTimestamps := fset.Bool("fixed-timestamps", false, "record every scraped_at as "+fixedTimestamp.Format(time.RFC3339)+" (for golden tests)")
+	maxTotalPosts := fset.Int("max-total-posts", 0, "stop starting new requests once this many posts are scraped (0 for no limit)")
+	maxRequests := fset.Int("max-requests", 0, "stop after this many HTTP requests in total (0 for no limit)")
+	deadline := fset.Duration("deadline", 0, "stop starting new requests after this long (e.g. 30m; 0 for no limit)")
+	stripQuotes := fset.Bool("strip-quotes", false, "remove quoted replies from post content")
+	splitSize := fset.Int64("split-size", 0, "start a new -partNNN results file after this many bytes of threads (0 for no limit)")
+	splitThreads := fset.Int("split-threads", 0, "start a new -partNNN results file after this many threads (0 for no limit)")
+	filenameTemplate := fset.String("filename-template", defaultFilenameTemplate, "result file name template; placeholders {platform}, {host}, {date}, {time}, {threads}")
+
+	positional, err := parseInterleaved(fset, args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var runConfigFile *runConfigFile
+	if *runConfigPath != "" {
+		if runConfigFile, err = loadRunConfigFile(*runConfigPath, fset); err != nil {
+			log.Fatalf("❌ Invalid --run-config: %v", err)
+		}
+	}
+	origins, err := resolveRunSettings(fset, runConfigFile)
+	if err != nil {
+		log.Fatalf("❌ Invalid run configuration: %v", err)
+	}
+	if sinkOptions.runNotifyTest(*outputDir, os.Stderr) {
+		return
+	}
+
+	platform := *platformFlag
+	if platform == "" {
+		if len(positional) == 0 {
+			fset.Usage()
+			os.Exit(1)
+		}
+		platform = positional[0]
+		positional = positional[1:]
+	}
+
+	// Trailing integers keep the original "<max_threads> [max_posts_per_thread]" form working
+	var sources, limits []string
+	for _, arg := range positional {
+		if _, err := strconv.Atoi(arg); err == nil {
+			limits = append(limits, arg)
+		} else {
+			sources = append(sources, arg)
+		}
+	}
+	if len(limits) > 0 {
+		if *maxThreads, err = strconv.Atoi(limits[0]); err != nil {
+			log.Fatal("Invalid max_threads value")
+		}
+		origins["max-threads"] = originFlag
+	}
+	if len(limits) > 1 {
+		if val, err := strconv.Atoi(limits[1]); err == nil {
+			*maxPostsPerThread = val
+			origins["max-posts"] = originFlag
+		}
+	}
+	// Sources on the command line replace the run config file's
+	if len(sources) == 0 && runConfigFile != nil {
+		sources = append(sources, runConfigFile.sources...)
+	}
+
+	if *urlsFile != "" {
+		fileURLs, err := readURLList(*urlsFile)
+		if err != nil {
+			log.Fatalf("❌ Failed to read URL list: %v", err)
+		}
+		sources = append(sources, fileURLs...)
+	}
+	if len(sources) == 0 && !*stdinMode {
+		fset.Usage()
+		os.Exit(1)
+	}
+	// Local directories and file:// URLs are read from disk
+	localInput := false
+	for i, source := range sources {
+		if local, ok := localSourceURL(source); ok {
+			sources[i] = local
+			localInput = true
+		}
+	}
+	if *fileGlob != "" && !localInput {
+		log.Fatalf("❌ --file-glob needs a local directory source")
+	}
+	if err := validateFilenameTemplate(*filenameTemplate); err != nil {
+		log.Fatalf("❌ Invalid --filename-template: %v", err)
+	}
+
+	switch *postsMode {
+	case postsModeAll, postsModeFirst, postsModeNone, postsModeSolved:
+	default:
+		log.Fatalf("❌ Invalid --posts-mode: %s (want all, first, none or solved)", *postsMode)
+	}
+	if *sortBy != "" && !validRankKey(*sortBy) {
+		log.Fatalf("❌ Invalid --sort: %s (want views, replies, recent or posts)", *sortBy)
+	}
+	if *prioritize != "" && !validPriority(*prioritize) {
+		log.Fatalf("❌ Invalid --prioritize: %s (want recent, active or title-match)", *prioritize)
+	}
+	if (*prioritize == prioritizeTitleMatch) != (*prioritizePattern != "") {
+		log.Fatalf("❌ --prioritize title-match and --prioritize-pattern go together")
+	}
+	if !validIPVersion(*ipVersion) {
+		log.Fatalf("❌ Invalid --ip-version: %s (want 4, 6 or auto)", *ipVersion)
+	}
+	var priorityPattern *regexp.Regexp
+	if *prioritizePattern != "" {
+		if priorityPattern, err = regexp.Compile(*prioritizePattern); err != nil {
+			log.Fatalf("❌ Invalid --prioritize-pattern: %v", err)
+		}
+	}
+	if *renderTabs < 1 {
+		log.Fatalf("❌ Invalid --render-tabs: %d (must be at least 1)", *renderTabs)
+	}
+	if !(*jitter >= 0 && *jitter <= maxJitter) {
+		log.Fatalf("❌ Invalid --jitter: %g (want a fraction from 0 to %g)", *jitter, maxJitter)
+	}
+	if *retries < 0 {
+		log.Fatalf("❌ Invalid --retries: %d (must not be negative)", *retries)
+	}
+	if *followReferences < 0 {
+		log.Fatalf("❌ Invalid --follow-references: %d (must not be negative)", *followReferences)
+	}
+	if *top < 0 || (*top > 0 && *sortBy == "") {
+		log.Fatalf("❌ Invalid --top: %d (needs --sort and a positive count)", *top)
+	}
+	if *qaMinAnswerChars < 0 || *qaMinAnswerLikes < 0 {
+		log.Fatal("❌ Invalid --qa-min-answer-chars or --qa-min-answer-likes: must not be negative")
+	}
+	if *chunkTokens < 1 || *chunkOverlap < 0 || *chunkOverlap >= *chunkTokens {
+		log.Fatalf("❌ Invalid --chunk-tokens %d / --chunk-overlap %d: need a positive budget and an overlap below it", *chunkTokens, *chunkOverlap)
+	}
+
+	if *maxPagesPerThread < 1 {
+		log.Fatalf("❌ Invalid --max-pages-per-thread: must be at least 1")
+	}
+	if *category != "" && strings.ToLower(platform) != "discourse" {
+		log.Fatalf("❌ --category needs --platform discourse")
+	}
+	if *userPostsOnly && *user == "" {
+		log.Fatalf("❌ --user-posts-only needs --user")
+	}
+	if *excerptChars < 0 {
+		log.Fatalf("❌ Invalid --excerpt-chars: must not be negative")
+	}
+	if !*dryRun {
+		switch *format {
+		case "", "json":
+		case "html", "markdown", "qa-jsonl", "chunks":
+			if *stdinMode {
+				log.Fatalf("❌ --format %s can't be streamed; --stdin writes JSONL", *format)
+			}
+		default:
+			log.Fatalf("❌ Unsupported --format: %s (use json, html, markdown, qa-jsonl or chunks)", *format)
+		}
+	}
+	if *output == "-" {
+		switch {
+		case *stdinMode || *dryRun:
+			log.Fatal("❌ --output - is for scrapes; --stdin and --dry-run already write to stdout")
+		case *format == "html" || *format == "markdown":
+			log.Fatalf("❌ --format %s can't be written to stdout", *format)
+		case *splitSize > 0 || *splitThreads > 0:
+			log.Fatal("❌ --output - can't be split into parts")
+		case *s3Target != "":
+			log.Fatal("❌ --output - leaves no results file for --s3 to upload")
+		}
+	}
+
+	// Create scraper
+	opts := []Option{
+		WithOutputDir(*outputDir),
+		WithFilenameTemplate(*filenameTemplate),
+		WithSplit(*splitSize, *splitThreads),
+		WithPostsMode(*postsMode),
+		WithRanking(*sortBy, *top),
+		WithPrioritization(*prioritize, priorityPattern),
+		WithLightweight(*lightweight),
+		WithSelectiveParse(*selectiveParse),
+		WithOldReddit(*oldReddit),
+		WithFollowReferences(*followReferences),
+		WithStackExchangeKey(*stackExchangeKey),
+		WithDedupePosts(*dedupePosts),
+		WithPIIRedaction(*redactPII),
+		WithPostLength(*minPostLength, *maxPostLength, *truncateLongPosts),
+		WithNormalization(!*noNormalize),
+		WithScoreQuery(*scoreQuery, *minScore),
+		WithBudget(*maxTotalPosts, *maxRequests, *deadline),
+		WithConcurrency(*concurrency, *perHostConcurrency),
+		WithRedirectPolicy(*maxRedirects, *allowExternal),
+		WithTimeouts(*connectTimeout, *responseHeaderTimeout, *requestTimeout),
+		WithDNSCache(*dnsTTL),
+		WithIPVersion(*ipVersion),
+		WithPrecheck(*precheck),
+		WithAuthorProfiles(*emitAuthors),
+		WithQAMinAnswer(*qaMinAnswerChars, *qaMinAnswerLikes),
+		WithChunking(*chunkTokens, *chunkOverlap),
+		WithCategoryFilter(includeCategories, excludeCategories),
+		WithThreadTimeout(*threadTimeout),
+		WithJitter(*jitter),
+		WithAdaptiveDelay(*adaptiveDelay),
+		WithRetries(*retries),
+		WithDebug(*debug),
+		WithFixedTimestamps(*fixedTimestamps),
+		WithIndexPages(*maxIndexPages),
+		WithThreadPages(*maxPagesPerThread, *lastPage),
+		WithMaxResponseSize(*maxResponseSize),
+		WithSubforumCrawl(*maxDepth, *maxForumsPerLevel),
+		WithSitemap(*useSitemap, *maxSitemaps),
+		WithFeed(*feedURL),
+		WithSearch(*searchQuery),
+		WithDiscourseCategory(*category),
+		WithMarkdownReport(*singleFile, *excerptChars),
+		WithRunConfig(effectiveRunConfig(fset, *runConfigPath, platform, sources, origins)),
+	}
+	if *seed != 0 {
+		opts = append(opts, WithSeed(*seed))
+	}
+	if *anonymizeAuthors {
+		opts = append(opts, WithAnonymizedAuthors(*anonymizeSalt))
+	}
+	if *stripQuotes {
+		opts = append(opts, WithQuoteStripping(true))
+	}
+	if *userAgentFile != "" {
+		if *uaRotate != uaRotateSticky && *uaRotate != uaRotatePerRequest {
+			log.Fatalf("❌ Invalid --ua-rotate: %s (want sticky or per-request)", *uaRotate)
+		}
+		agents, err := readUserAgents(*userAgentFile)
+		if err != nil {
+			log.Fatalf("❌ Failed to read --user-agent-file: %v", err)
+		}
+		opts = append(opts, WithUserAgents(agents, *uaRotate))
+	}
+	if *caCert != "" || *clientCert != "" || *clientKey != "" || *insecureSkipVerify {
+		tlsConfig, err := loadTLSConfig(*caCert, *clientCert, *clientKey, *insecureSkipVerify)
+		if err != nil {
+			log.Fatalf("❌ Invalid TLS options: %v", err)
+		}
+		if *insecureSkipVerify {
+			fmt.Fprintln(os.Stderr, "⚠️ WARNING: --insecure-skip-verify is set; server certificates will NOT be verified")
+		}
+		opts = append(opts, WithTLSConfig(tlsConfig))
+	}
+	// resolveRunSettings read any credentials from the environment, which keeps
+	// them out of shell history
+	if *basicAuth != "" {
+		user, pass, err := parseBasicAuth(*basicAuth)
+		if err != nil {
+			log.Fatalf("❌ Invalid --basic-auth: %v", err)
+		}
+		opts = append(opts, WithBasicAuth(user, pass))
+	}
+	if *bearerToken != "" {
+		opts = append(opts, WithBearerToken(*bearerToken))
+	}
+	if *tokenUser != "" {
+		opts = append(opts, WithTokenUser(*tokenUser))
+	}
+	if *maxBandwidth != "" {
+		bytesPerSecond, err := parseBandwidth(*maxBandwidth)
+		if err != nil {
+			log.Fatalf("❌ Invalid --max-bandwidth: %v", err)
+		}
+		opts = append(opts, WithMaxBandwidth(bytesPerSecond))
+	}
+	if *downloadAttachments != "" {
+		maxSize, err := parseByteSize(*maxAttachmentSize)
+		if err != nil {
+			log.Fatalf("❌ Invalid --max-attachment-size: %v", err)
+		}
+		opts = append(opts, WithAttachmentDownloads(*downloadAttachments, maxSize))
+	}
+	if *languages != "" {
+		opts = append(opts, WithLanguages(strings.Split(*languages, ",")...))
+	}
+	if localInput {
+		opts = append(opts, WithLocalInput(*fileGlob))
+	}
+	if *user != "" {
+		name, id := parseUserSpec(*user)
+		opts = append(opts, WithUserHistory(name, id, *userPostsOnly))
+	}
+	if *since != "" {
+		sinceTime, err := parseSince(*since)
+		if err != nil {
+			log.Fatalf("❌ Invalid --since: %v", err)
+		}
+		opts = append(opts, WithSince(sinceTime))
+	}
+	if *window != "" {
+		postWindow, err := parseWindow(*window)
+		if err != nil {
+			log.Fatalf("❌ Invalid --window: %v", err)
+		}
+		opts = append(opts, WithWindow(postWindow))
+	}
+	if *threadPattern != "" {
+		re, err := regexp.Compile(*threadPattern)
+		if err != nil {
+			log.Fatalf("❌ Invalid --thread-pattern: %v", err)
+		}
+		opts = append(opts, WithThreadPattern(re))
+	}
+	filter := URLFilter{AllowHosts: allowHosts}
+	if *urlPattern != "" {
+		if filter.Include, err = regexp.Compile(*urlPattern); err != nil {
+			log.Fatalf("❌ Invalid --url-pattern: %v", err)
+		}
+	}
+	if *urlExclude != "" {
+		if filter.Exclude, err = regexp.Compile(*urlExclude); err != nil {
+			log.Fatalf("❌ Invalid --url-exclude: %v", err)
+		}
+	}
+	opts = append(opts, WithURLFilter(filter))
+
+	if *accessLogPath != "" {
+		var maxSize int64
+		if *accessLogMaxSize != "" {
+			if maxSize, err = parseByteSize(*accessLogMaxSize); err != nil {
+				log.Fatalf("❌ Invalid --access-log-max-size: %v", err)
+			}
+		}
+		accessLog, err := openAccessLog(*accessLogPath, maxSize)
+		if err != nil {
+			log.Fatalf("❌ Failed to open --access-log: %v", err)
+		}
+		defer accessLog.Close()
+		accessLog.closeOnInterrupt()
+		opts = append(opts, WithAccessLog(accessLog))
+	}
+
+	if *s3Target != "" {
+		if newObjectStore == nil {
+			log.Fatal("❌ --s3 needs a build with S3 support: go build -tags s3")
+		}
+		bucket, prefix, err := parseS3URL(*s3Target)
+		if err != nil {
+			log.Fatalf("❌ Invalid --s3: %v", err)
+		}
+		store, err := newObjectStore(bucket, *s3Endpoint)
+		if err != nil {
+			log.Fatalf("❌ Failed to set up --s3: %v", err)
+		}
+		opts = append(opts, WithObjectStore(store, prefix))
+	} else if *s3Endpoint != "" {
+		log.Fatal("❌ --s3-endpoint needs --s3")
+	}
+
+	if *render {
+		if newRenderer == nil {
+			log.Fatal("❌ --render needs a build with browser support: go build -tags chromedp")
+		}
+		renderer, err := newRenderer()
+		if err != nil {
+			log.Fatalf("❌ Failed to start headless browser: %v", err)
+		}
+		defer renderer.Close()
+		opts = append(opts, WithRenderer(renderer, *renderTabs, *renderTimeout))
+	}
+
+	var statusOut io.Writer = os.Stderr
+	if *quiet {
+		statusOut = io.Discard
+	}
+	// Sinks open last, so a bad flag above can't exit with messages queued; from
+	// here on failures exit through fatalf, which closes them
+	sinks := sinkOptions.open(*outputDir, statusOut)
+	opts = append(opts, WithStatusOutput(statusOut), WithSinks(sinks...))
+	scraper := NewForumScraper(platform, *delay, opts...)
+	defer scraper.closeSinks()
+	scraper.spillSinksOnInterrupt()
+
+	scraper.runConfig.print(scraper)
+	scraper.statusf("🆔 Run ID: %s\n", scraper.run.RunID)
+	if *platformConfig != "" {
+		if err := scraper.loadPlatformConfigs(*platformConfig); err != nil {
+			fatalf(scraper, "❌ Failed to load --platform-config: %v", err)
+		}
+	}
+	if err := scraper.checkTokenUser(); err != nil {
+		fatalf(scraper, "❌ Invalid --bearer-token: %v", err)
+	}
+	if err := scraper.checkUserHistory(); err != nil {
+		fatalf(scraper, "❌ Invalid --user: %v", err)
+	}
+	if len(skipFrom) > 0 {
+		count, err := scraper.loadSkipFrom(skipFrom)
+		if err != nil {
+			fatalf(scraper, "❌ Failed to read --skip-from: %v", err)
+		}
+		scraper.statusf("⏭️ Skipping %d previously exported thread(s) from %d file(s)\n", count, len(skipFrom))
+	}
+
+	if *stdinMode {
+		runStdin(scraper, *maxPostsPerThread, *output, *summaryJSON)
+		return
+	}
+	if *dryRun {
+		runDryRun(scraper, sources, *maxThreads, *format)
+		return
+	}
+
+	// Scrape forum. JSON results stream through a spill file, so the run's threads
+	// are never in memory together; the other formats and --sort need them all at once.
+	var saved []string
+	threadCount, totalPosts := 0, 0
+	if (*format == "" || *format == "json") && scraper.sortBy == "" {
+		saved, threadCount, totalPosts = scrapeToSpill(scraper, sources, *maxThreads, *maxPostsPerThread, *output)
+	} else {
+		threads, err := scraper.scrapeSources(sources, *maxThreads, *maxPostsPerThread)
+		if err != nil {
+			fatalf(scraper, "❌ Scraping failed: %v", err)
+		}
+		saved = saveThreads(scraper, threads, *format, *output)
+		threadCount = len(threads)
+		for _, thread := range threads {
+			totalPosts += len(thread.Posts)
+		}
+	}
+	if scraper.objectStore != nil {
+		if err := scraper.uploadResults(saved); err != nil {
+			fatalf(scraper, "❌ Failed to upload results: %v", err)
+		}
+	}
+	scraper.finishRun(threadCount, totalPosts, saved, *summaryJSON)
+}
+
+// scrapeToSpill scrapes sources, spilling each thread to disk as it completes, and
+// saves the JSON results from the spill. It returns the files written and the
+// run's thread and post counts.
+func scrapeToSpill(scraper *ForumScraperGo, sources []string, maxThreads, maxPostsPerThread int, output string) ([]string, int, int) {
+	spill, err := newResultsSpill(scraper.outputDir)
+	if err != nil {
+		fatalf(scraper, "❌ Failed to create results spill: %v", err)
+	}
+	threadCount, totalPosts := 0, 0
+	err = scraper.scrapeSourcesEach(sources, maxThreads, maxPostsPerThread, func(thread *ForumThread) error {
+		threadCount++
+		totalPosts += len(thread.Posts)
+		return spill.add(thread)
+	})
+	if err != nil {
+		spill.Close()
+		fatalf(scraper, "❌ Scraping failed: %v", err)
+	}
+	saved, err := scraper.saveSpilledResults(spill, output)
+	spill.Close()
+	if err != nil {
+		fatalf(scraper, "❌ Failed to save results: %v", err)
+	}
+	return saved, threadCount, totalPosts
+}
+
+// saveThreads saves a scrape's threads in format, returning the files written
+func saveThreads(scraper *ForumScraperGo, threads []*ForumThread, format, output string) []string {
+	var saved []string
+	var err error
+	switch format {
+	case "html":
+		indexPath, err := scraper.saveHTMLArchive(threads, output)
+		if err != nil {
+			fatalf(scraper, "❌ Failed to save HTML archive: %v", err)
+		}
+		saved = []string{filepath.Dir(indexPath)}
+	case "markdown":
+		if saved, err = scraper.saveMarkdownReport(threads, output); err != nil {
+			fatalf(scraper, "❌ Failed to save Markdown report: %v", err)
+		}
+	case "qa-jsonl":
+		if saved, err = scraper.saveQAPairs(threads, output); err != nil {
+			fatalf(scraper, "❌ Failed to save Q&A pairs: %v", err)
+		}
+	case "chunks":
+		if saved, err = scraper.saveChunks(threads, output); err != nil {
+			fatalf(scraper, "❌ Failed to save chunks: %v", err)
+		}
+	default:
+		if saved, err = scraper.saveResults(threads, output); err != nil {
+			fatalf(scraper, "❌ Failed to save results: %v", err)
+		}
+	}
+	return saved
+}
+
+// exitIfBudgetStopped reports a budget stop and exits with exitBudgetStopped, so
+// callers can tell a truncated run from a completed one
+func exitIfBudgetStopped(scraper *ForumScraperGo) {
+	if reason := scraper.budgetStopReason(); reason != "" {
+		scraper.statusf("🛑 Run stopped early by budget: %s\n", reason)
+		scraper.closeSinks()
+		scraper.accessLog.Close()
+		os.Exit(exitBudgetStopped)
+	}
+}
+
+// fatalf closes the sinks and the access log, which log.Fatalf would skip, so
+// messages still queued for a broker are delivered or spilled, then exits like it
+func fatalf(scraper *ForumScraperGo, format string, args ...interface{}) {
+	scraper.closeSinks()
+	scraper.accessLog.Close()
+	log.Fatalf(format, args...)
+}
+
+// runDryRun prints the threads a scrape would fetch, without fetching any thread pages
+func runDryRun(scraper *ForumScraperGo, sources []string, maxThreads int, format string) {
+	refs, err := scraper.discoverSources(sources, maxThreads)
+	if err != nil {
+		log.Fatalf("❌ Discovery failed: %v", err)
+	}
+
+	indexPages := int(atomic.LoadInt64(&scraper.stats.IndexPagesFetched))
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if refs == nil {
+			refs = []ThreadRef{}
+		}
+		if err := encoder.Encode(refs); err != nil {
+			log.Fatalf("❌ Failed to write thread list: %v", err)
+		}
+	case "", "text":
+		for _, ref := range refs {
+			if ref.Title != "" {
+				fmt.Printf("%s\t%s\n", ref.URL, ref.Title)
+			} else {
+				fmt.Println(ref.URL)
+			}
+		}
+	default:
+		log.Fatalf("❌ Unsupported dry-run format: %s", format)
+	}
+
+	scraper.statusf("\n📊 Threads discovered: %d\n", len(refs))
+	scraper.statusf("📊 Estimated requests: %d (%d index, %d thread)\n", indexPages+len(refs), indexPages, len(refs))
+	scraper.statusf("📊 URLs excluded by filters: %d\n", atomic.LoadInt64(&scraper.stats.ExcludedURLs))
+	scraper.printPrioritization()
+}
+
+// runStdin scrapes thread URLs piped on stdin, streaming JSONL threads to stdout.
+// Streamed threads can't be reordered, so --sort writes a rank index file instead.
+func runStdin(scraper *ForumScraperGo, maxPostsPerThread int, indexFile, summaryPath string) {
+	var out io.Writer = os.Stdout
+	var stream objectStream
+	if scraper.objectStore != nil {
+		var err error
+		if stream, err = scraper.streamResults(); err != nil {
+			fatalf(scraper, "❌ Failed to start upload: %v", err)
+		}
+		out = io.MultiWriter(os.Stdout, stream)
+	}
+
+	encoder := json.NewEncoder(out)
+	threadCount, totalPosts := 0, 0
+	var ranked []rankEntry
+	err := scraper.scrapeStream(os.Stdin, maxPostsPerThread, func(thread *ForumThread) error {
+		threadCount++
+		totalPosts += len(thread.Posts)
+		if scraper.sortBy != "" {
+			ranked = append(ranked, newRankEntry(thread))
+		}
+		return encoder.Encode(threadRecord{SchemaVersion: resultsSchemaVersion, ForumThread: thread})
+	})
+	if err != nil {
+		fatalf(scraper, "❌ Scraping failed: %v", err)
+	}
+	var results []string
+	if scraper.sortBy != "" {
+		indexPath, err := scraper.saveRankIndex(ranked, indexFile)
+		if err != nil {
+			fatalf(scraper, "❌ Failed to save rank index: %v", err)
+		}
+		results = append(results, indexPath)
+	}
+	if stream != nil {
+		objectURL, err := stream.Complete(context.Background())
+		if err != nil {
+			fatalf(scraper, "❌ Failed to complete upload: %v", err)
+		}
+		scraper.statusf("☁️ Results uploaded to: %s\n", objectURL)
+		scraper.uploads = append(scraper.uploads, objectURL)
+	}
+
+	scraper.finishRun(threadCount, totalPosts, results, summaryPath)
+}
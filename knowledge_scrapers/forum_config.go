@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// loadPlatformConfigs merges a JSON file of platform configs into the built-in ones.
+// Keys are platform names and values use PlatformConfig's field names. Fields set in
+// the file override an existing platform's; ignore-pattern and URLRewrites lists
+// extend it instead. Selector fields take a single selector or an array tried in
+// order, each CSS or "xpath:" followed by an XPath expression. Unknown platforms
+// start from the generic config.
+func (fs *ForumScraperGo) loadPlatformConfigs(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("invalid platform config %s: %w", path, err)
+	}
+
+	for name, override := range raw {
+		base, exists := fs.configs[name]
+		if !exists {
+			base = fs.configs["generic"]
+		}
+
+		config := base
+		config.IgnoreAuthorPatterns, config.IgnoreContentPatterns, config.URLRewrites = nil, nil, nil
+		if err := json.Unmarshal(override, &config); err != nil {
+			return fmt.Errorf("invalid platform config %s for %q: %w", path, name, err)
+		}
+		config.IgnoreAuthorPatterns = append(append([]string{}, base.IgnoreAuthorPatterns...), config.IgnoreAuthorPatterns...)
+		config.IgnoreContentPatterns = append(append([]string{}, base.IgnoreContentPatterns...), config.IgnoreContentPatterns...)
+		config.URLRewrites = append(append([]URLRewrite{}, base.URLRewrites...), config.URLRewrites...)
+
+		for _, pattern := range append(config.IgnoreAuthorPatterns, config.IgnoreContentPatterns...) {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("invalid ignore pattern for %q: %w", name, err)
+			}
+		}
+		for _, rule := range config.URLRewrites {
+			if _, err := rule.compile(); err != nil {
+				return fmt.Errorf("invalid URLRewrites pattern for %q: %w", name, err)
+			}
+		}
+		if _, err := regexp.Compile(config.ThreadIDPattern); err != nil {
+			return fmt.Errorf("invalid ThreadIDPattern for %q: %w", name, err)
+		}
+		for _, rule := range config.regexRules() {
+			if err := rule.validate(); err != nil {
+				return fmt.Errorf("invalid %s for %q: %w", rule.field, name, err)
+			}
+		}
+		for _, field := range config.selectorFields() {
+			if err := field.chain.validate(); err != nil {
+				return fmt.Errorf("invalid %s for %q: %w", field.name, name, err)
+			}
+		}
+		fs.configs[name] = config
+	}
+	return nil
+}
+
+// selectorField is one named selector chain of a PlatformConfig
+type selectorField struct {
+	name  string
+	chain selectorChain
+}
+
+// selectorFields lists every selector in config by field name, including those of
+// its print view, user history, consent form and attachment markup
+func (config PlatformConfig) selectorFields() []selectorField {
+	fields := []selectorField{
+		{"ThreadSelector", config.ThreadSelector},
+		{"PostSelector", config.PostSelector},
+		{"ContentSelector", config.ContentSelector},
+		{"AuthorSelector", config.AuthorSelector},
+		{"TimestampSelector", config.TimestampSelector},
+		{"ThreadLinkSelector", config.ThreadLinkSelector},
+		{"IndexPaginationSelector", config.IndexPaginationSelector},
+		{"MissingSelector", config.MissingSelector},
+		{"ScoreSelector", config.ScoreSelector},
+		{"AwardsSelector", config.AwardsSelector},
+		{"EditedSelector", config.EditedSelector},
+		{"QuoteSelector", config.QuoteSelector},
+		{"AcceptedAnswerSelector", config.AcceptedAnswerSelector},
+		{"IndexRepliesSelector", config.IndexRepliesSelector},
+		{"IndexViewsSelector", config.IndexViewsSelector},
+		{"IndexCategorySelector", config.IndexCategorySelector},
+	}
+	if view := config.PrintView; view != nil {
+		fields = append(fields,
+			selectorField{"PrintView.TitleSelector", view.TitleSelector},
+			selectorField{"PrintView.PostSelector", view.PostSelector},
+			selectorField{"PrintView.ContentSelector", view.ContentSelector},
+			selectorField{"PrintView.AuthorSelector", view.AuthorSelector},
+			selectorField{"PrintView.TimestampSelector", view.TimestampSelector},
+		)
+	}
+	if history := config.UserHistory; history != nil {
+		fields = append(fields,
+			selectorField{"UserHistory.PostSelector", history.PostSelector},
+			selectorField{"UserHistory.ThreadLinkSelector", history.ThreadLinkSelector},
+			selectorField{"UserHistory.AuthorSelector", history.AuthorSelector},
+			selectorField{"UserHistory.ContentSelector", history.ContentSelector},
+			selectorField{"UserHistory.TimestampSelector", history.TimestampSelector},
+		)
+	}
+	if form := config.ConsentForm; form != nil {
+		fields = append(fields, selectorField{"ConsentForm.Selector", selectorChain{form.Selector}})
+	}
+	if markup := config.Attachments; markup != nil {
+		fields = append(fields,
+			selectorField{"Attachments.ItemSelector", selectorChain{markup.ItemSelector}},
+			selectorField{"Attachments.LinkSelector", selectorChain{markup.LinkSelector}},
+			selectorField{"Attachments.NameSelector", selectorChain{markup.NameSelector}},
+		)
+	}
+	return fields
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync/atomic"
+)
+
+// quoteChars are stripped before hashing so straight and curly quoting hash alike
+var quoteChars = strings.NewReplacer(`"`, "", "'", "", "“", "", "”", "", "‘", "", "’", "", "„", "", "«", "", "»", "")
+
+// contentHash fingerprints post content after lowercasing, stripping quote marks and
+// collapsing whitespace. It depends only on the text, so it is stable across runs.
+func contentHash(content string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(quoteChars.Replace(content))), " ")
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// isDuplicatePost reports whether a post with the same content hash has already been
+// kept in this run, remembering the hash otherwise
+func (fs *ForumScraperGo) isDuplicatePost(post *ForumPost) bool {
+	fs.seenPostsMutex.Lock()
+	defer fs.seenPostsMutex.Unlock()
+	if fs.seenPosts[post.ContentHash] {
+		atomic.AddInt64(&fs.stats.DuplicatePosts, 1)
+		return true
+	}
+	fs.seenPosts[post.ContentHash] = true
+	return false
+}
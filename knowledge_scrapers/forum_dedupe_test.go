@@ -0,0 +1,37 @@
+package main
+
+import (
+	"io"
+	"testing"
+)
+
+// A thread's author and dates come from the posts it kept, not from posts
+// --dedupe-posts dropped
+func TestFinishThreadDedupedPosts(t *testing.T) {
+	scraper := NewForumScraper("generic", 0, WithDedupePosts(true))
+	scraper.statusOut = io.Discard
+	post := func(author, timestamp, content string) *ForumPost {
+		return &ForumPost{Author: author, Timestamp: timestamp, Content: content, ContentHash: contentHash(content)}
+	}
+
+	first := &ForumThread{URL: "https://forum.example.com/t/1"}
+	if _, err := scraper.finishThread(first, []*ForumPost{post("alice", "2024-01-01", "Original question")}, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	second := &ForumThread{URL: "https://forum.example.com/t/2"}
+	thread, err := scraper.finishThread(second, []*ForumPost{
+		post("bob", "2024-02-01", "Original question"),
+		post("carol", "2024-02-02", "A fresh reply"),
+		post("dave", "2024-02-03", "Original question"),
+	}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(thread.Posts) != 1 || thread.DuplicatePostsDropped != 2 {
+		t.Fatalf("kept %d posts, dropped %d, want 1 and 2", len(thread.Posts), thread.DuplicatePostsDropped)
+	}
+	if thread.Author != "carol" || thread.CreatedAt != "2024-02-02" || thread.LastPostAt != "2024-02-02" {
+		t.Errorf("author %q, created %q, last post %q, want carol's post throughout", thread.Author, thread.CreatedAt, thread.LastPostAt)
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// boardIndexPaths are final URL paths that mean a thread redirect landed on the board index
+var boardIndexPaths = map[string]bool{
+	"":            true,
+	"/":           true,
+	"/index.php":  true,
+	"/index.html": true,
+	"/forum":      true,
+	"/forums":     true,
+	"/latest":     true,
+	"/categories": true,
+}
+
+// ConsentForm describes an interstitial form that can be submitted automatically
+type ConsentForm struct {
+	// Selector matches the consent or age-gate form
+	Selector string
+	// Fields are set on top of the form's own inputs before it is posted
+	Fields map[string]string
+}
+
+// botChallengeSelector matches the markup of common anti-bot interstitials
+const botChallengeSelector = "#challenge-form, #cf-challenge-running, #challenge-running, .cf-browser-verification, script[src*=\"/cdn-cgi/challenge-platform/\"]"
+
+// botChallengeMarkers are phrases shown on anti-bot interstitials. A forum thread
+// can quote them, so they only count on a page with little visible text.
+var botChallengeMarkers = []string{
+	"checking your browser before accessing",
+	"ddos protection by",
+	"enable javascript and cookies to continue",
+}
+
+// botChallengeMaxText is the most visible text an interstitial carries; pages
+// with more are real content even if they mention a marker
+const botChallengeMaxText = 1024
+
+// consentSelector matches age-gate and consent forms
+const consentSelector = "form[action*=\"age\"], form[id*=\"age-gate\"], form[class*=\"ageGate\"], input[name=\"dob\"], input[name=\"birthday\"]"
+
+// consentMarkers are phrases shown on age gates and consent walls
+var consentMarkers = []string{
+	"confirm your age",
+	"you must be 18",
+	"age verification",
+	"are you over 18",
+}
+
+// loginSelector matches login forms
+const loginSelector = "form[action*=\"login\"], form[action*=\"signin\"], form#login"
+
+// loginMarkers are phrases shown when content needs a member session
+var loginMarkers = []string{
+	"you must be logged in",
+	"you are not logged in",
+	"log in to view",
+	"login to view",
+	"must be registered",
+	"do not have permission to view",
+}
+
+// containsAny reports whether the start of the page text contains one of the
+// lowercase phrases
+func containsAny(doc *goquery.Document, phrases []string) bool {
+	text := strings.ToLower(leadingText(doc.Selection, maxMarkerText))
+	for _, phrase := range phrases {
+		if strings.Contains(text, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBotChallenge reports whether the page is an anti-bot interstitial: it has
+// the challenge markup or title, or a challenge phrase and almost nothing else
+func isBotChallenge(doc *goquery.Document) bool {
+	if doc.Find(botChallengeSelector).Length() > 0 {
+		return true
+	}
+	if strings.TrimSpace(doc.Find("title").First().Text()) == "Just a moment..." {
+		return true
+	}
+	return !visibleTextExceeds(doc, botChallengeMaxText) && containsAny(doc, botChallengeMarkers)
+}
+
+// visibleTextExceeds reports whether the page's text outside scripts and styles
+// runs past limit bytes, whitespace aside, stopping as soon as it does
+func visibleTextExceeds(doc *goquery.Document, limit int) bool {
+	total := 0
+	var walk func(*html.Node) bool
+	walk = func(n *html.Node) bool {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style", "noscript", "template":
+				return false
+			}
+		}
+		if n.Type == html.TextNode {
+			if total += len(strings.TrimSpace(n.Data)); total > limit {
+				return true
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if walk(child) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, n := range doc.Nodes {
+		if walk(n) {
+			return true
+		}
+	}
+	return false
+}
+
+// isConsentWall reports whether the page is an age gate or consent interstitial
+func isConsentWall(doc *goquery.Document) bool {
+	return doc.Find(consentSelector).Length() > 0 || containsAny(doc, consentMarkers)
+}
+
+// isLoginWall reports whether the page asks the visitor to log in. It is only
+// consulted when no posts were found, since most boards show a login form everywhere.
+func isLoginWall(doc *goquery.Document) bool {
+	return doc.Find(loginSelector).Length() > 0 && containsAny(doc, loginMarkers)
+}
+
+// passConsentWall returns doc unchanged unless it is a consent interstitial. When the
+// platform configures a ConsentForm the form is posted and the thread refetched;
+// otherwise ErrConsentWall is returned.
+func (fs *ForumScraperGo) passConsentWall(ctx context.Context, doc *goquery.Document, threadURL string) (*goquery.Document, error) {
+	config, exists := fs.configs[fs.platform]
+	if !exists {
+		config = fs.configs["generic"]
+	}
+
+	var form *goquery.Selection
+	if config.ConsentForm != nil {
+		form = findSelector(doc.Selection, config.ConsentForm.Selector).First()
+	}
+	if form == nil || form.Length() == 0 {
+		if isConsentWall(doc) && config.PostSelector.findAll(doc.Selection).Length() == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrConsentWall, threadURL)
+		}
+		return doc, nil
+	}
+
+	fs.statusf("🔓 Submitting consent form on %s\n", threadURL)
+	if err := fs.submitForm(doc, form, config.ConsentForm.Fields); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrConsentWall, threadURL, err)
+	}
+
+	doc, err := fs.fetchDocumentContext(ctx, threadURL)
+	if err != nil {
+		return nil, err
+	}
+	if findSelector(doc.Selection, config.ConsentForm.Selector).Length() > 0 {
+		return nil, fmt.Errorf("%w: %s (still shown after submitting)", ErrConsentWall, threadURL)
+	}
+	return doc, nil
+}
+
+// checkThreadMissing detects soft-404s: pages matching the platform's missing-thread
+// selector, or thread URLs that redirected to the board index or a forum listing
+func (fs *ForumScraperGo) checkThreadMissing(doc *goquery.Document, threadURL string) error {
+	config, exists := fs.configs[fs.platform]
+	if !exists {
+		config = fs.configs["generic"]
+	}
+
+	if len(config.MissingSelector) > 0 && config.MissingSelector.findAll(doc.Selection).Length() > 0 {
+		return fmt.Errorf("%w: %s", ErrThreadMissing, threadURL)
+	}
+
+	if doc.Url == nil {
+		return nil
+	}
+	finalURL := doc.Url.String()
+	if normalizeURL(finalURL) == normalizeURL(threadURL) || fs.isThreadURL(finalURL) {
+		return nil
+	}
+
+	landedOnIndex := boardIndexPaths[strings.TrimRight(doc.Url.Path, "/")]
+	if forumPattern := fs.forumURLRegexp(); !landedOnIndex && forumPattern != nil {
+		landedOnIndex = forumPattern.MatchString(finalURL)
+	}
+	if landedOnIndex {
+		return fmt.Errorf("%w: %s redirected to %s", ErrThreadMissing, threadURL, finalURL)
+	}
+	return nil
+}
+
+// hasMissingMarker reports whether the page contains one of the platform's
+// "thread not found" phrases
+func (fs *ForumScraperGo) hasMissingMarker(doc *goquery.Document) bool {
+	config, exists := fs.configs[fs.platform]
+	if !exists {
+		config = fs.configs["generic"]
+	}
+	if len(config.MissingMarkers) == 0 {
+		return false
+	}
+
+	text := strings.ToLower(leadingText(doc.Selection, maxMarkerText))
+	for _, marker := range config.MissingMarkers {
+		if strings.Contains(text, strings.ToLower(marker)) {
+			return true
+		}
+	}
+	return false
+}
+
+// submitForm posts an HTML form with its own inputs plus the given overrides,
+// leaving any cookies it sets in the client's jar
+func (fs *ForumScraperGo) submitForm(doc *goquery.Document, form *goquery.Selection, fields map[string]string) error {
+	action, _ := form.Attr("action")
+	target, err := resolveURL(doc.Url.String(), action)
+	if err != nil {
+		return err
+	}
+
+	values := url.Values{}
+	form.Find("input[name]").Each(func(i int, s *goquery.Selection) {
+		name, _ := s.Attr("name")
+		value, _ := s.Attr("value")
+		values.Set(name, value)
+	})
+	for name, value := range fields {
+		values.Set(name, value)
+	}
+
+	if err := fs.spendRequest(); err != nil {
+		return err
+	}
+	req, err := fs.newRequest("POST", target, strings.NewReader(values.Encode()))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := fs.requestContext(fs.runContext())
+	defer cancel()
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if err := fs.runRequestHooks(req); err != nil {
+		return err
+	}
+
+	access := fs.startAccess(ctx, req)
+	resp, err := fs.client.Do(req)
+	if err != nil {
+		fs.finishAccess(access, "-")
+		return fs.classifyTimeout(err, target)
+	}
+	fs.runResponseHooks(resp)
+	resp.Body.Close()
+	fs.finishAccess(access, strconv.Itoa(resp.StatusCode))
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestIsBotChallenge(t *testing.T) {
+	longPost := strings.Repeat("The router kept dropping the connection every few minutes. ", 40)
+	tests := []struct {
+		name string
+		page string
+		want bool
+	}{
+		{"challenge form", `<html><body><form id="challenge-form"></form></body></html>`, true},
+		{"challenge script", `<html><body><script src="/cdn-cgi/challenge-platform/h/b/orchestrate/jsch/v1"></script></body></html>`, true},
+		{"challenge title", `<html><head><title>Just a moment...</title></head><body></body></html>`, true},
+		{"interstitial text", `<html><body><h1>Checking your browser before accessing forum.example.com</h1>
+			<p>This process is automatic.</p><script>` + strings.Repeat("var a=1;", 500) + `</script></body></html>`, true},
+		{"thread quoting the phrase", `<html><body><div class="post"><blockquote>Checking your browser before accessing
+			forum.example.com</blockquote><p>` + longPost + `</p></div></body></html>`, false},
+		{"plain thread", `<html><body><div class="post"><p>` + longPost + `</p></div></body></html>`, false},
+	}
+	for _, tt := range tests {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.page))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := isBotChallenge(doc); got != tt.want {
+			t.Errorf("%s: isBotChallenge = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFixtureThreadsAreNotChallenges(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join(fixturesDir, "*", "*.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc, err := goquery.NewDocumentFromReader(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isBotChallenge(doc) {
+			t.Errorf("%s reads as a bot challenge", path)
+		}
+	}
+}
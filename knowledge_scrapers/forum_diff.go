@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+// Change types emitted by the diff subcommand
+const (
+	changeNewThread  = "new_thread"
+	changeNewPosts   = "new_posts"
+	changeEditedPost = "edited_post"
+)
+
+// changeEvent is one line of diff output
+type changeEvent struct {
+	ChangeType   string       `json:"change_type"`
+	ThreadURL    string       `json:"thread_url"`
+	ThreadTitle  string       `json:"thread_title"`
+	Thread       *ForumThread `json:"thread,omitempty"`
+	Posts        []ForumPost  `json:"posts,omitempty"`
+	PreviousHash string       `json:"previous_hash,omitempty"`
+}
+
+// postHash returns a post's stored content hash, computing it for files written before the field existed
+func postHash(post ForumPost) string {
+	if post.ContentHash != "" {
+		return post.ContentHash
+	}
+	return contentHash(post.Content)
+}
+
+// postRef is where postMatcher indexed a post, and the platform ID it had
+type postRef struct {
+	index int
+	id    string
+}
+
+// postMatcher finds the other copy of a post across two scrapes of a thread: by
+// PostID when both copies have one, as deleting a post renumbers every later one,
+// and by post number when either lacks it (older files, platforms without IDs)
+type postMatcher struct {
+	byID     map[string]int
+	byNumber map[int]postRef
+}
+
+func newPostMatcher() *postMatcher {
+	return &postMatcher{byID: make(map[string]int), byNumber: make(map[int]postRef)}
+}
+
+// add indexes post as the one at index
+func (m *postMatcher) add(index int, post ForumPost) {
+	if post.PostID != "" {
+		m.byID[post.PostID] = index
+	}
+	m.byNumber[post.PostNumber] = postRef{index: index, id: post.PostID}
+}
+
+// find returns the index of post's other copy
+func (m *postMatcher) find(post ForumPost) (int, bool) {
+	if post.PostID != "" {
+		if index, ok := m.byID[post.PostID]; ok {
+			return index, true
+		}
+	}
+	if ref, ok := m.byNumber[post.PostNumber]; ok && (post.PostID == "" || ref.id == "") {
+		return ref.index, true
+	}
+	return 0, false
+}
+
+// diffThread compares the old and new copies of one thread, returning the change events
+func diffThread(old, current *ForumThread) []changeEvent {
+	if old == nil {
+		return []changeEvent{{ChangeType: changeNewThread, ThreadURL: current.URL, ThreadTitle: current.Title, Thread: current}}
+	}
+
+	oldPosts := newPostMatcher()
+	for i, post := range old.Posts {
+		oldPosts.add(i, post)
+	}
+
+	var events []changeEvent
+	var added []ForumPost
+	for _, post := range current.Posts {
+		index, ok := oldPosts.find(post)
+		if !ok {
+			added = append(added, post)
+			continue
+		}
+		previous := old.Posts[index]
+		if previousHash := postHash(previous); previousHash != postHash(post) {
+			events = append(events, changeEvent{
+				ChangeType:   changeEditedPost,
+				ThreadURL:    current.URL,
+				ThreadTitle:  current.Title,
+				Posts:        []ForumPost{post},
+				PreviousHash: previousHash,
+			})
+		}
+	}
+	if len(added) > 0 {
+		events = append([]changeEvent{{ChangeType: changeNewPosts, ThreadURL: current.URL, ThreadTitle: current.Title, Posts: added}}, events...)
+	}
+	return events
+}
+
+// runDiff implements the diff subcommand: JSONL change events between an old and a new results file
+func runDiff(args []string) {
+	fset := flag.NewFlagSet("forum_scraper diff", flag.ExitOnError)
+	fset.Usage = func() {
+		fmt.Println("Usage: forum_scraper diff [flags] <old_results> <new_results>")
+		fmt.Println("Example: forum_scraper diff yesterday.json today.json > changes.jsonl")
+		fset.PrintDefaults()
+	}
+	output := fset.String("output", "", "write change events to this file instead of stdout")
+
+	files, err := parseInterleaved(fset, args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(files) != 2 {
+		fset.Usage()
+		os.Exit(1)
+	}
+
+	// The old scrape is indexed in memory; the new one is streamed against it
+	oldThreads := make(map[string]*ForumThread)
+	if _, err := readResultsFile(files[0], func(thread *ForumThread) error {
+		oldThreads[threadKey(thread)] = thread
+		return nil
+	}); err != nil {
+		log.Fatalf("❌ Diff failed: %v", err)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		if out, err = os.Create(*output); err != nil {
+			log.Fatalf("❌ Failed to create %s: %v", *output, err)
+		}
+		defer out.Close()
+	}
+
+	encoder := json.NewEncoder(out)
+	counts := make(map[string]int)
+	if _, err := readResultsFile(files[1], func(thread *ForumThread) error {
+		for _, event := range diffThread(oldThreads[threadKey(thread)], thread) {
+			counts[event.ChangeType]++
+			if err := encoder.Encode(event); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		log.Fatalf("❌ Diff failed: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "📊 New threads: %d\n", counts[changeNewThread])
+	fmt.Fprintf(os.Stderr, "📊 Threads with new posts: %d\n", counts[changeNewPosts])
+	fmt.Fprintf(os.Stderr, "📊 Edited posts: %d\n", counts[changeEditedPost])
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// diffTestThread returns a thread whose posts are id/content pairs, numbered in order
+func diffTestThread(posts ...[2]string) *ForumThread {
+	thread := &ForumThread{URL: "https://forum.example.com/viewtopic.php?t=7", Title: "Thread 7"}
+	for i, p := range posts {
+		thread.Posts = append(thread.Posts, ForumPost{PostNumber: i + 1, PostID: p[0], Content: p[1]})
+	}
+	return thread
+}
+
+// eventSummary lists each event as its type and the post IDs it carries
+func eventSummary(events []changeEvent) []string {
+	var summary []string
+	for _, event := range events {
+		line := event.ChangeType
+		for _, post := range event.Posts {
+			line += " " + post.PostID
+		}
+		summary = append(summary, line)
+	}
+	return summary
+}
+
+func TestDiffThreadMatchesPostIDs(t *testing.T) {
+	old := diffTestThread([2]string{"p1", "first"}, [2]string{"p2", "second"}, [2]string{"p3", "third"}, [2]string{"p4", "fourth"})
+	// p2 was deleted, shifting p3 and p4 up a number; p4 was edited and p5 is new
+	current := diffTestThread([2]string{"p1", "first"}, [2]string{"p3", "third"}, [2]string{"p4", "fourth, edited"}, [2]string{"p5", "fifth"})
+
+	got := eventSummary(diffThread(old, current))
+	want := []string{changeNewPosts + " p5", changeEditedPost + " p4"}
+	if !slices.Equal(got, want) {
+		t.Errorf("events %v, want %v", got, want)
+	}
+}
+
+func TestDiffThreadFallsBackToPostNumber(t *testing.T) {
+	// Written before posts carried IDs
+	old := diffTestThread([2]string{"", "first"}, [2]string{"", "second"})
+	current := diffTestThread([2]string{"p1", "first"}, [2]string{"p2", "second, edited"}, [2]string{"p3", "third"})
+
+	got := eventSummary(diffThread(old, current))
+	want := []string{changeNewPosts + " p3", changeEditedPost + " p2"}
+	if !slices.Equal(got, want) {
+		t.Errorf("events %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// defaultThreadLinkSelectors are evaluated on every index page after the platform's own selector
+var defaultThreadLinkSelectors = []string{
+	"a[href*=\"/thread/\"]",
+	"a[href*=\"/topic/\"]",
+	"a[href*=\"/t/\"]",
+	"a[href*=\"/viewtopic.php\"]",
+	".threadtitle a",
+	".topictitle",
+}
+
+// defaultPaginationSelector finds next-page links on boards without a platform-specific selector
+const defaultPaginationSelector = "link[rel=\"next\"], a[rel=\"next\"], .pagination .next a, a.next"
+
+var (
+	startParamPattern = regexp.MustCompile(`[?&]start=(\d+)`)
+	pageNumberPattern = regexp.MustCompile(`(?:[?&]page=|/page-?)(\d+)`)
+)
+
+// extractThreadLinks collects thread links from an index page. The platform's
+// chain stops at its first selector that finds links, then every default selector
+// is evaluated; only links that look like threads are kept, so navigation chrome
+// matched by a broad selector can't crowd out real topics.
+func (fs *ForumScraperGo) extractThreadLinks(doc *goquery.Document, pageURL, sourceURL string) []ThreadRef {
+	config, exists := fs.configs[fs.platform]
+	if !exists {
+		config = fs.configs["generic"]
+	}
+
+	threadPattern := fs.threadURLRegexp()
+	collect := func(refs []ThreadRef, selector string) []ThreadRef {
+		findSelector(doc.Selection, selector).Each(func(i int, s *goquery.Selection) {
+			href, exists := s.Attr("href")
+			if !exists {
+				return
+			}
+			absolute, ok := fs.resolveLink(pageURL, href)
+			if !ok {
+				return
+			}
+			absolute = fs.canonicalThreadURL(absolute)
+			if threadPattern != nil && !threadPattern.MatchString(urlPatternTarget(absolute)) {
+				return
+			}
+			ref := ThreadRef{
+				URL:       absolute,
+				Title:     strings.TrimSpace(s.Text()),
+				SourceURL: sourceURL,
+			}
+			if len(config.IndexRepliesSelector) > 0 || len(config.IndexViewsSelector) > 0 || len(config.IndexCategorySelector) > 0 {
+				row := indexRow(s, selector)
+				ref.Replies = indexCount(row, config.IndexRepliesSelector)
+				ref.Views = indexCount(row, config.IndexViewsSelector)
+				ref.Category, _ = config.IndexCategorySelector.text(row)
+			}
+			refs = append(refs, ref)
+		})
+		return refs
+	}
+
+	var refs []ThreadRef
+	for _, selector := range config.ThreadLinkSelector {
+		if refs = collect(refs, selector); len(refs) > 0 {
+			fs.selectorMatched("ThreadLinkSelector", config.ThreadLinkSelector, selector)
+			break
+		}
+	}
+	for _, selector := range defaultThreadLinkSelectors {
+		refs = collect(refs, selector)
+	}
+	return refs
+}
+
+// forumLink is a subforum or category link and its text
+type forumLink struct {
+	URL  string
+	Text string
+}
+
+// forumURLRegexp returns the platform's ForumURLPattern compiled, nil if it has none
+func (fs *ForumScraperGo) forumURLRegexp() *regexp.Regexp {
+	config, exists := fs.configs[fs.platform]
+	if !exists {
+		config = fs.configs["generic"]
+	}
+	return compiledConfigPattern(config.ForumURLPattern)
+}
+
+// isForumURL reports whether rawURL is a subforum or category page
+func (fs *ForumScraperGo) isForumURL(rawURL string) bool {
+	re := fs.forumURLRegexp()
+	return re != nil && re.MatchString(urlPatternTarget(rawURL)) && !fs.isThreadURL(rawURL)
+}
+
+// extractForumLinks collects subforum and category links from an index page
+func (fs *ForumScraperGo) extractForumLinks(doc *goquery.Document, pageURL string) []forumLink {
+	forumPattern := fs.forumURLRegexp()
+	if forumPattern == nil {
+		return nil
+	}
+
+	var links []forumLink
+	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		absolute, ok := fs.resolveLink(pageURL, href)
+		if !ok || !forumPattern.MatchString(urlPatternTarget(absolute)) || fs.isThreadURL(absolute) {
+			return
+		}
+		links = append(links, forumLink{URL: absolute, Text: strings.Join(strings.Fields(s.Text()), " ")})
+	})
+	return links
+}
+
+// crawlSection is a forum queued by crawlForums, with the path of link texts
+// that led to it
+type crawlSection struct {
+	url  string
+	path string
+}
+
+// crawlForums discovers threads from rootURL and the subforums beneath it,
+// descending breadth-first up to maxDepth levels. Each level queues at most
+// maxForumsPerLevel new forums, and forums already crawled are never revisited.
+// Subforums whose link text --exclude-category matches are pruned unvisited.
+func (fs *ForumScraperGo) crawlForums(rootURL string, maxThreads int) ([]ThreadRef, error) {
+	visited := map[string]bool{normalizeURL(rootURL): true}
+	seen := make(map[string]bool)
+	level := []crawlSection{{url: rootURL}}
+	var refs []ThreadRef
+	forumsCrawled := 0
+
+	for depth := 0; len(level) > 0 && len(refs) < maxThreads; depth++ {
+		var nextLevel []crawlSection
+		for _, section := range level {
+			forumURL := section.url
+			if len(refs) >= maxThreads {
+				break
+			}
+			if forumsCrawled > 0 {
+				// Rate limiting
+				fs.politeWait(forumURL)
+			}
+
+			threads, forums, err := fs.walkIndex(forumURL, section.path, maxThreads-len(refs))
+			if err != nil {
+				if depth == 0 {
+					return nil, err
+				}
+				fs.statusf("⚠️ Skipping forum %s: %v\n", forumURL, err)
+				continue
+			}
+			forumsCrawled++
+
+			for _, ref := range threads {
+				if key := normalizeURL(ref.URL); !seen[key] {
+					seen[key] = true
+					ref.SourceURL = rootURL
+					refs = append(refs, ref)
+				}
+			}
+
+			if depth >= fs.maxDepth {
+				continue
+			}
+			for _, forum := range forums {
+				if len(nextLevel) >= fs.maxForumsPerLevel {
+					break
+				}
+				key := normalizeURL(forum.URL)
+				if visited[key] || !fs.allowPageURL(forum.URL, rootURL) {
+					continue
+				}
+				visited[key] = true
+				if forum.Text != "" && fs.categories.excluded(forum.Text) {
+					fs.statusf("🚫 Skipping section %q (--exclude-category)\n", forum.Text)
+					fs.categories.recordPruned(forum.Text)
+					continue
+				}
+				path := section.path
+				if forum.Text != "" && path != "" {
+					path += categorySectionSeparator
+				}
+				path += forum.Text
+				nextLevel = append(nextLevel, crawlSection{url: forum.URL, path: path})
+			}
+		}
+		level = nextLevel
+	}
+
+	if len(refs) > maxThreads {
+		refs = refs[:maxThreads]
+	}
+
+	fs.statusf("📊 Crawled %d forum(s), discovered %d thread URLs\n", forumsCrawled, len(refs))
+	return refs, nil
+}
+
+// nextIndexPage returns the URL of the page following pageURL, or "" on the last page.
+// The platform's IndexPaginationSelector is tried first, then rel=next style links,
+// then &start=N and page-N links pointing past the current page.
+func (fs *ForumScraperGo) nextIndexPage(doc *goquery.Document, pageURL string) string {
+	config, exists := fs.configs[fs.platform]
+	if !exists {
+		config = fs.configs["generic"]
+	}
+
+	for _, selector := range append(append(selectorChain{}, config.IndexPaginationSelector...), defaultPaginationSelector) {
+		if href, exists := findSelector(doc.Selection, selector).First().Attr("href"); exists {
+			if next, ok := fs.resolveLink(pageURL, href); ok && next != pageURL {
+				fs.selectorMatched("IndexPaginationSelector", config.IndexPaginationSelector, selector)
+				return next
+			}
+		}
+	}
+
+	return fs.nextPageByPattern(doc, pageURL)
+}
+
+// nextPageByPattern picks the link with the smallest start= offset or page number
+// greater than the current page's
+func (fs *ForumScraperGo) nextPageByPattern(doc *goquery.Document, pageURL string) string {
+	for _, pattern := range []*regexp.Regexp{startParamPattern, pageNumberPattern} {
+		// Page numbers are 1-based, so an unnumbered page is page 1
+		current := 0
+		if pattern == pageNumberPattern {
+			current = 1
+		}
+		if matches := pattern.FindStringSubmatch(pageURL); len(matches) > 1 {
+			current, _ = strconv.Atoi(matches[1])
+		}
+
+		best, bestValue := "", -1
+		doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+			href, _ := s.Attr("href")
+			matches := pattern.FindStringSubmatch(href)
+			if len(matches) < 2 {
+				return
+			}
+			value, err := strconv.Atoi(matches[1])
+			if err != nil || value <= current || (bestValue >= 0 && value >= bestValue) {
+				return
+			}
+			if next, ok := fs.resolveLink(pageURL, href); ok {
+				best, bestValue = next, value
+			}
+		})
+		if best != "" {
+			return best
+		}
+	}
+	return ""
+}
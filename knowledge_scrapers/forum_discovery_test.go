@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// threadLinkURLs returns the thread URLs extracted from an index page
+func threadLinkURLs(t *testing.T, platform, page string) []string {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scraper := NewForumScraper(platform, 0)
+	scraper.statusOut = io.Discard
+	const pageURL = "https://forum.example.com/latest"
+	var urls []string
+	for _, ref := range scraper.extractThreadLinks(doc, pageURL, pageURL) {
+		urls = append(urls, strings.TrimPrefix(ref.URL, "https://forum.example.com"))
+	}
+	return urls
+}
+
+// The first generic selector, a[href*="/t/"], matches only navigation here; the
+// topics are found by the platform's own selector and nav links are filtered out
+func TestThreadLinksSkipNavigation(t *testing.T) {
+	page := `<html><body>
+		<nav>
+			<a href="/t/faq">FAQ</a>
+			<a href="/t/guidelines">Guidelines</a>
+			<a href="/tos/t/">Terms</a>
+		</nav>
+		<table class="topic-list">
+			<tr><td><a class="raw-topic-link" href="/t/building-a-static-binary/4412">Building a static binary</a></td></tr>
+			<tr><td><a class="raw-topic-link" href="/t/cross-compiling-for-arm/4417?u=alice">Cross-compiling for ARM</a></td></tr>
+		</table>
+	</body></html>`
+	got := threadLinkURLs(t, "discourse", page)
+	want := []string{"/t/building-a-static-binary/4412", "/t/cross-compiling-for-arm/4417"}
+	for _, url := range want {
+		if !slices.ContainsFunc(got, func(u string) bool { return strings.HasPrefix(u, url) }) {
+			t.Errorf("missing topic %s in %v", url, got)
+		}
+	}
+	for _, url := range got {
+		if !strings.HasPrefix(url, want[0]) && !strings.HasPrefix(url, want[1]) {
+			t.Errorf("kept navigation link %s", url)
+		}
+	}
+}
+
+// Every selector is evaluated and the results combined, rather than stopping at
+// the first selector that matched anything
+func TestThreadLinksUnionSelectors(t *testing.T) {
+	page := `<html><body>
+		<div class="nav"><a href="/thread/">All threads</a></div>
+		<ul>
+			<li><a href="/thread/12/potato-blight">Potato blight</a></li>
+			<li class="threadtitle"><a href="/showthread.php?t=34">Seed saving</a></li>
+			<li><a class="topictitle" href="/viewtopic.php?t=56">Raised beds</a></li>
+		</ul>
+	</body></html>`
+	got := threadLinkURLs(t, "generic", page)
+	for _, url := range []string{"/thread/12/potato-blight", "/showthread.php?t=34", "/viewtopic.php?t=56"} {
+		if !slices.Contains(got, url) {
+			t.Errorf("missing %s in %v", url, got)
+		}
+	}
+}
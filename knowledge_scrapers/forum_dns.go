@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Address families for --ip-version
+const (
+	ipVersionAuto = "auto"
+	ipVersion4    = "4"
+	ipVersion6    = "6"
+)
+
+// defaultDNSTTL is how long a resolved host's addresses are reused. Go's resolver
+// doesn't expose record TTLs, so every host gets the same lifetime.
+const defaultDNSTTL = 5 * time.Minute
+
+// dnsFallbackDelay is how long a dial waits on the preferred address family before
+// racing the other one, as net.Dialer does for hostnames
+const dnsFallbackDelay = 300 * time.Millisecond
+
+// dnsEntry is one host's cached addresses and what resolving them cost
+type dnsEntry struct {
+	addrs   []net.IPAddr
+	lookup  time.Duration
+	expires time.Time
+}
+
+// dnsCache resolves and caches the hosts the transport dials, and dials their
+// addresses in the --ip-version family
+type dnsCache struct {
+	ttl       time.Duration
+	ipVersion string
+	dialer    *net.Dialer
+	// resolver is Go's in-process resolver; system is retried once when it fails
+	resolver *net.Resolver
+	system   *net.Resolver
+
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+	stats   *runStats
+}
+
+// newDNSCache returns a cache dialing with a connectTimeout dialer and counting
+// into stats
+func newDNSCache(connectTimeout time.Duration, stats *runStats) *dnsCache {
+	return &dnsCache{
+		ttl:       defaultDNSTTL,
+		ipVersion: ipVersionAuto,
+		dialer:    &net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second},
+		resolver:  &net.Resolver{PreferGo: true},
+		system:    &net.Resolver{},
+		entries:   make(map[string]dnsEntry),
+		stats:     stats,
+	}
+}
+
+// validIPVersion reports whether version is an --ip-version value
+func validIPVersion(version string) bool {
+	switch version {
+	case ipVersionAuto, ipVersion4, ipVersion6:
+		return true
+	}
+	return false
+}
+
+// lookup returns host's addresses, from the cache while they are fresh. A failed
+// lookup is retried once against the system resolver before it fails the dial.
+func (c *dnsCache) lookup(ctx context.Context, host string) ([]net.IPAddr, error) {
+	c.mu.Lock()
+	entry, cached := c.entries[host]
+	c.mu.Unlock()
+	if cached && time.Now().Before(entry.expires) {
+		atomic.AddInt64(&c.stats.DNSCacheHits, 1)
+		atomic.AddInt64(&c.stats.DNSTimeSavedUS, entry.lookup.Microseconds())
+		return entry.addrs, nil
+	}
+
+	started := time.Now()
+	addrs, err := c.resolver.LookupIPAddr(ctx, host)
+	if err != nil && ctx.Err() == nil {
+		atomic.AddInt64(&c.stats.DNSFallbacks, 1)
+		addrs, err = c.system.LookupIPAddr(ctx, host)
+	}
+	atomic.AddInt64(&c.stats.DNSLookups, 1)
+	if err != nil {
+		return nil, err
+	}
+	if c.ttl > 0 {
+		c.mu.Lock()
+		c.entries[host] = dnsEntry{addrs: addrs, lookup: time.Since(started), expires: time.Now().Add(c.ttl)}
+		c.mu.Unlock()
+	}
+	return addrs, nil
+}
+
+// family reports whether ip belongs to the --ip-version family
+func (c *dnsCache) family(ip net.IP) bool {
+	switch c.ipVersion {
+	case ipVersion4:
+		return ip.To4() != nil
+	case ipVersion6:
+		return ip.To4() == nil
+	}
+	return true
+}
+
+// DialContext is the transport's dialer: it resolves through the cache, keeps the
+// --ip-version addresses, and with auto races the other family after
+// dnsFallbackDelay so a host with a broken AAAA record doesn't stall every request
+func (c *dnsCache) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		addrs, err := c.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range addrs {
+			ips = append(ips, a.IP)
+		}
+	}
+
+	// Addresses of the family the resolver listed first are dialed first
+	var primary, fallback []string
+	var primaryIPv4 bool
+	for _, ip := range ips {
+		if !c.family(ip) {
+			continue
+		}
+		target := net.JoinHostPort(ip.String(), port)
+		if len(primary) == 0 {
+			primaryIPv4 = ip.To4() != nil
+		}
+		if (ip.To4() != nil) == primaryIPv4 {
+			primary = append(primary, target)
+		} else {
+			fallback = append(fallback, target)
+		}
+	}
+	if len(primary) == 0 {
+		return nil, fmt.Errorf("dial %s: no IPv%s address for %s", network, c.ipVersion, host)
+	}
+	if len(fallback) == 0 {
+		return c.dialSerial(ctx, network, primary)
+	}
+	return c.dialRace(ctx, network, primary, fallback)
+}
+
+// dialSerial tries targets in order, returning the first connection made
+func (c *dnsCache) dialSerial(ctx context.Context, network string, targets []string) (net.Conn, error) {
+	var firstErr error
+	for _, target := range targets {
+		conn, err := c.dialer.DialContext(ctx, network, target)
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, firstErr
+}
+
+// dialRace dials primary, and fallback once dnsFallbackDelay passes or primary
+// fails, keeping whichever connects first
+func (c *dnsCache) dialRace(ctx context.Context, network string, primary, fallback []string) (net.Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type dialResult struct {
+		conn    net.Conn
+		err     error
+		primary bool
+	}
+	results := make(chan dialResult, 2)
+	dial := func(targets []string, isPrimary bool) {
+		conn, err := c.dialSerial(ctx, network, targets)
+		results <- dialResult{conn: conn, err: err, primary: isPrimary}
+	}
+	go dial(primary, true)
+
+	timer := time.NewTimer(dnsFallbackDelay)
+	defer timer.Stop()
+	started, pending := false, 1
+	startFallback := func() {
+		if !started {
+			started = true
+			pending++
+			go dial(fallback, false)
+		}
+	}
+
+	var firstErr error
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			startFallback()
+		case result := <-results:
+			pending--
+			if result.err == nil {
+				// The loser's connection, if it still arrives, is closed unused
+				go func(pending int) {
+					for ; pending > 0; pending-- {
+						if late := <-results; late.conn != nil {
+							late.conn.Close()
+						}
+					}
+				}(pending)
+				return result.conn, nil
+			}
+			if firstErr == nil || result.primary {
+				firstErr = result.err
+			}
+			startFallback()
+		}
+	}
+	return nil, firstErr
+}
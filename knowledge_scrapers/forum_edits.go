@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// editFooter matches "last edited" notices: phpBB's "Last edited by alice on
+// Mon Jan 05, 2026 10:00 am, edited 2 times in total." and XenForo's "Last edited: Jan 5, 2026"
+var editFooter = regexp.MustCompile(`(?is)\s*last edited(?:\s+by\s+(.+?))?(?:\s+on\s+|:\s*)(.+?)(?:[,;]\s*edited \d+ times? in total)?\.?\s*$`)
+
+// editFooterLine finds a "Last edited" notice on the last line of a post body, for
+// platforms without a separate notice element; a sentence mentioning editing mid-post doesn't count
+var editFooterLine = regexp.MustCompile(`(?i)(?:^|\n)[ \t]*last edited\b[^\n]*$`)
+
+// editInfo is what a post's edit notice says
+type editInfo struct {
+	edited bool
+	at     string
+	by     string
+}
+
+// parseEditNotice reads who edited a post and when from the text of its edit
+// notice; the time element's datetime attribute wins over the text when present
+func parseEditNotice(notice *goquery.Selection) editInfo {
+	info := editInfo{edited: true}
+	if matches := editFooter.FindStringSubmatch(validUTF8(strings.TrimSpace(notice.Text()))); matches != nil {
+		info.by = strings.TrimSpace(matches[1])
+		info.at = strings.TrimSpace(matches[2])
+	}
+	timeElem := notice.Filter("time").AddSelection(notice.Find("time")).First()
+	if datetime, exists := timeElem.Attr("datetime"); exists {
+		info.at = datetime
+	}
+	return info
+}
+
+// extractEdit finds a post's edit notice through the platform's EditedSelector and
+// returns the post body without it, so the notice doesn't end up in the content.
+// Bodies without a notice element are checked for a trailing "Last edited by" line
+// that reads as a notice.
+func extractEdit(selection, body *goquery.Selection, config PlatformConfig) (editInfo, *goquery.Selection, string) {
+	if notice, selector := config.EditedSelector.find(selection); notice.Length() > 0 {
+		info := parseEditNotice(notice.First())
+		if findSelector(body, selector).Length() > 0 {
+			body = body.Clone()
+			findSelector(body, selector).Remove()
+		}
+		return info, body, validUTF8(strings.TrimSpace(body.Text()))
+	}
+
+	// A last line that starts "Last edited" but doesn't read as a notice, such as
+	// "Last edited the config and it works now", is the poster's own text
+	content := validUTF8(strings.TrimSpace(body.Text()))
+	loc := editFooterLine.FindStringIndex(content)
+	if loc == nil {
+		return editInfo{}, body, content
+	}
+	matches := editFooter.FindStringSubmatch(content[loc[0]:])
+	if matches == nil {
+		return editInfo{}, body, content
+	}
+	info := editInfo{edited: true, by: strings.TrimSpace(matches[1]), at: strings.TrimSpace(matches[2])}
+	return info, body, strings.TrimSpace(content[:loc[0]])
+}
+
+// discourseRevision is the body of Discourse's /posts/{id}/revisions/{version}.json
+type discourseRevision struct {
+	CreatedAt string `json:"created_at"`
+	Username  string `json:"username"`
+}
+
+// discourseEdit fills in who last edited a Discourse post and when from its
+// latest revision. The revisions endpoint needs an API key on most boards, so
+// without --bearer-token the post is only marked edited.
+func (fs *ForumScraperGo) discourseEdit(ctx context.Context, postURL string, postID, version int) editInfo {
+	if version <= 1 {
+		return editInfo{}
+	}
+	info := editInfo{edited: true}
+	if fs.auth.token == "" {
+		return info
+	}
+	revisionURL, err := resolveURL(postURL, fmt.Sprintf("/posts/%d/revisions/%d.json", postID, version))
+	if err != nil {
+		return info
+	}
+	// Rate limiting
+	fs.politeWaitContext(ctx, revisionURL)
+
+	var revision discourseRevision
+	if err := fs.fetchJSON(ctx, revisionURL, &revision); err != nil {
+		fs.statusf("⚠️ No revision details for post %d: %v\n", postID, err)
+		return info
+	}
+	info.at = revision.CreatedAt
+	info.by = revision.Username
+	return info
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestExtractEditFooterLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		edited  bool
+		by, at  string
+		content string
+	}{
+		{
+			name:    "phpBB notice",
+			body:    "Rebuilt the initramfs and it boots.\nLast edited by user1 on Mon Mar 11, 2024 9:40 am, edited 2 times in total.",
+			edited:  true,
+			by:      "user1",
+			at:      "Mon Mar 11, 2024 9:40 am",
+			content: "Rebuilt the initramfs and it boots.",
+		},
+		{
+			name:    "XenForo notice",
+			body:    "Feed it twice a day.\nLast edited: Jan 5, 2026",
+			edited:  true,
+			at:      "Jan 5, 2026",
+			content: "Feed it twice a day.",
+		},
+		{
+			name:    "poster's own sentence",
+			body:    "Thanks, that was it.\nLast edited the fstab entry and it mounts now",
+			content: "Thanks, that was it.\nLast edited the fstab entry and it mounts now",
+		},
+		{
+			name:    "mid-post mention",
+			body:    "Last edited by user2 on Monday, per the log.\nStill broken though.",
+			content: "Last edited by user2 on Monday, per the log.\nStill broken though.",
+		},
+	}
+	for _, tt := range tests {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader("<div class=\"body\">" + tt.body + "</div>"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := doc.Find(".body")
+		info, _, content := extractEdit(body, body, PlatformConfig{})
+		if info.edited != tt.edited || info.by != tt.by || info.at != tt.at {
+			t.Errorf("%s: edit = %+v, want edited=%v by=%q at=%q", tt.name, info, tt.edited, tt.by, tt.at)
+		}
+		if content != tt.content {
+			t.Errorf("%s: content = %q, want %q", tt.name, content, tt.content)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrThreadMissing means the server answered but the thread no longer exists
+	// (a soft-404 page, or a redirect back to the board index)
+	ErrThreadMissing = errors.New("thread not found")
+	// ErrBotChallenge means an anti-bot interstitial (e.g. Cloudflare) was served instead of the page
+	ErrBotChallenge = errors.New("bot challenge page")
+	// ErrConsentWall means an age gate or consent interstitial was served instead of the page
+	ErrConsentWall = errors.New("consent or age gate")
+	// ErrLoginRequired means the thread is only visible to logged-in members
+	ErrLoginRequired = errors.New("login required")
+	// ErrNotHTML means the response was an attachment or other non-HTML resource
+	ErrNotHTML = errors.New("response is not HTML")
+	// ErrResponseTooLarge means the response exceeded the configured size cap
+	ErrResponseTooLarge = errors.New("response too large")
+	// ErrMalformedHTML means the HTML parser refused the page, e.g. because unclosed
+	// tags nest elements deeper than it allows; the page is rejected rather than half-parsed
+	ErrMalformedHTML = errors.New("malformed HTML")
+	// ErrNoPosts means the thread page parsed but no posts matched the selectors
+	ErrNoPosts = errors.New("no posts found in thread")
+	// ErrLanguageFiltered means the thread's majority language is outside --languages
+	ErrLanguageFiltered = errors.New("thread language not allowed")
+	// ErrThreadFiltered means a registered ThreadFilter rejected the thread
+	ErrThreadFiltered = errors.New("thread rejected by filter")
+	// ErrCategoryFiltered means the thread's category is outside --include-category
+	// or matches --exclude-category
+	ErrCategoryFiltered = errors.New("thread category filtered")
+	// ErrOutsideWindow means none of the thread's dated posts fall inside --window
+	ErrOutsideWindow = errors.New("thread has no posts in the window")
+	// ErrUnsolved means --posts-mode solved found no accepted answer in the thread
+	ErrUnsolved = errors.New("thread has no accepted answer")
+	// ErrRedirectLoop means a redirect chain came back to a URL it had already visited
+	ErrRedirectLoop = errors.New("redirect loop")
+	// ErrTooManyRedirects means a redirect chain exceeded --max-redirects
+	ErrTooManyRedirects = errors.New("too many redirects")
+	// ErrExternalRedirect means a redirect pointed at another host without --allow-external
+	ErrExternalRedirect = errors.New("redirect to another host")
+	// ErrDuplicateThread means the thread redirected to, or names as its canonical
+	// URL, a URL that was already scraped
+	ErrDuplicateThread = errors.New("thread already scraped under another URL")
+	// ErrAlreadyVisited means the thread URL was already scraped in this run, e.g. because
+	// two index pages link to it; it is a silent skip rather than a failure
+	ErrAlreadyVisited = errors.New("thread already visited")
+	// ErrThreadTimeout means --thread-timeout ran out before the thread's first page arrived
+	ErrThreadTimeout = errors.New("thread timed out")
+	// ErrPreviouslyExported means a --skip-from results file already holds the thread;
+	// it is a silent skip rather than a failure
+	ErrPreviouslyExported = errors.New("thread previously exported")
+	// ErrTimeout means connecting, waiting for headers or reading the response took too long
+	ErrTimeout = errors.New("request timed out")
+	// ErrBudgetExhausted means a run-wide budget tripped before the request was made
+	ErrBudgetExhausted = errors.New("run budget exhausted")
+	// ErrUnknownCategory means --category named no category on the board; discovery stops at once
+	ErrUnknownCategory = errors.New("unknown category")
+	// ErrPanic means scraping the thread panicked; the panic was recovered so the run could continue
+	ErrPanic = errors.New("panic while scraping")
+	// ErrRequestHook means a hook registered with OnRequest refused to let a request go out
+	ErrRequestHook = errors.New("request rejected by hook")
+)
+
+// httpStatusError is a non-200 response, keeping the status so callers can act on it
+type httpStatusError struct {
+	StatusCode int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("HTTP %d", e.StatusCode)
+}
+
+// Failure records one thread that could not be scraped
+type Failure struct {
+	URL   string `json:"url"`
+	Type  string `json:"type"`
+	Error string `json:"error"`
+}
+
+// failureReport collects failures from every worker in a run
+type failureReport struct {
+	mutex    sync.Mutex
+	failures []Failure
+	counts   map[string]int
+}
+
+// failureType classifies an error for the failure report
+func failureType(err error) string {
+	switch {
+	case errors.Is(err, ErrThreadMissing):
+		return "thread_missing"
+	case errors.Is(err, ErrBotChallenge):
+		return "bot_challenge"
+	case errors.Is(err, ErrConsentWall):
+		return "consent_wall"
+	case errors.Is(err, ErrLoginRequired):
+		return "login_required"
+	case errors.Is(err, ErrNotHTML):
+		return "not_html"
+	case errors.Is(err, ErrResponseTooLarge):
+		return "too_large"
+	case errors.Is(err, ErrMalformedHTML):
+		return "malformed_html"
+	case errors.Is(err, ErrNoPosts):
+		return "no_posts"
+	case errors.Is(err, ErrLanguageFiltered):
+		return "language_filtered"
+	case errors.Is(err, ErrThreadFiltered):
+		return "thread_filtered"
+	case errors.Is(err, ErrCategoryFiltered):
+		return "category_filtered"
+	case errors.Is(err, ErrOutsideWindow):
+		return "outside_window"
+	case errors.Is(err, ErrUnsolved):
+		return "unsolved"
+	case errors.Is(err, ErrRedirectLoop):
+		return "redirect_loop"
+	case errors.Is(err, ErrTooManyRedirects):
+		return "too_many_redirects"
+	case errors.Is(err, ErrExternalRedirect):
+		return "external_redirect"
+	case errors.Is(err, ErrDuplicateThread):
+		return "duplicate_thread"
+	case errors.Is(err, ErrPanic):
+		return "panic"
+	case errors.Is(err, ErrRequestHook):
+		return "request_hook"
+	case errors.Is(err, ErrThreadTimeout):
+		return "thread_timeout"
+	case errors.Is(err, ErrTimeout):
+		return "timeout"
+	default:
+		return "fetch_error"
+	}
+}
+
+// skipTypes are failure types reported as deliberate skips rather than failures
+var skipTypes = map[string]bool{
+	"not_html":          true,
+	"too_large":         true,
+	"language_filtered": true,
+	"thread_filtered":   true,
+	"unsolved":          true,
+	"category_filtered": true,
+	"outside_window":    true,
+}
+
+// isSkip reports whether err skips a thread deliberately, as a filter or size cap
+// does, rather than failing it
+func isSkip(err error) bool {
+	return skipTypes[failureType(err)]
+}
+
+// failureHints suggest a way past failure types that selector tweaks won't fix
+var failureHints = map[string]string{
+	"bot_challenge":     "the board sits behind an anti-bot challenge; use cookies from a real browser session or the browser fallback",
+	"consent_wall":      "the board shows a consent or age gate; configure ConsentForm for the platform or use cookies from a browser session",
+	"login_required":    "the threads need a logged-in session; use cookies from a browser session",
+	"external_redirect": "threads redirect to another host; pass --allow-external to follow them",
+	"timeout":           "the board is slow to respond; raise --request-timeout or --response-header-timeout",
+	"thread_timeout":    "threads take longer than --thread-timeout to fetch; raise it, or lower --delay",
+	"panic":             "the scraper hit a bug on these pages; the stack traces above show where",
+}
+
+// record adds a failed URL to the report
+func (r *failureReport) record(url string, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.counts == nil {
+		r.counts = make(map[string]int)
+	}
+	failure := Failure{URL: url, Type: failureType(err), Error: err.Error()}
+	r.failures = append(r.failures, failure)
+	r.counts[failure.Type]++
+}
+
+// snapshot returns a copy of the recorded failures, ordered by URL
+func (r *failureReport) snapshot() []Failure {
+	r.mutex.Lock()
+	failures := append([]Failure(nil), r.failures...)
+	r.mutex.Unlock()
+	sort.SliceStable(failures, func(i, j int) bool { return failures[i].URL < failures[j].URL })
+	return failures
+}
+
+// countSnapshot returns a copy of the failure and skip counts by type
+func (r *failureReport) countSnapshot() map[string]int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	counts := make(map[string]int, len(r.counts))
+	for failureType, n := range r.counts {
+		counts[failureType] = n
+	}
+	return counts
+}
+
+// printSummary writes failure and skip counts by type, most frequent first
+func (r *failureReport) printSummary(w io.Writer) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	types := make([]string, 0, len(r.counts))
+	for failureType := range r.counts {
+		types = append(types, failureType)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if r.counts[types[i]] != r.counts[types[j]] {
+			return r.counts[types[i]] > r.counts[types[j]]
+		}
+		return types[i] < types[j]
+	})
+
+	for _, failureType := range types {
+		if skipTypes[failureType] {
+			fmt.Fprintf(w, "⏭️ Skipped (%s): %d\n", failureType, r.counts[failureType])
+			continue
+		}
+		fmt.Fprintf(w, "❌ Failed (%s): %d\n", failureType, r.counts[failureType])
+		if hint, exists := failureHints[failureType]; exists {
+			fmt.Fprintf(w, "   💡 %s\n", hint)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// feedSelector finds the feed a forum page advertises
+const feedSelector = "link[rel=\"alternate\"][type=\"application/rss+xml\"], link[rel=\"alternate\"][type=\"application/atom+xml\"]"
+
+// feedLink is an RSS <link> (URL as text) or Atom <link> (URL in href) element
+type feedLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Text string `xml:",chardata"`
+}
+
+// feedItem is an RSS <item> or Atom <entry>
+type feedItem struct {
+	Title     string     `xml:"title"`
+	Links     []feedLink `xml:"link"`
+	PubDate   string     `xml:"pubDate"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+}
+
+// feedDoc decodes both RSS 2.0 and Atom documents
+type feedDoc struct {
+	Items   []feedItem `xml:"channel>item"`
+	Entries []feedItem `xml:"entry"`
+}
+
+// url returns the item's link, preferring Atom's rel="alternate"
+func (item feedItem) url() string {
+	for _, link := range item.Links {
+		if link.Href != "" && (link.Rel == "" || link.Rel == "alternate") {
+			return strings.TrimSpace(link.Href)
+		}
+		if text := strings.TrimSpace(link.Text); text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+// date returns the item's raw publication or update date
+func (item feedItem) date() string {
+	for _, value := range []string{item.PubDate, item.Updated, item.Published} {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// findFeed returns the feed URL advertised by a forum page
+func (fs *ForumScraperGo) findFeed(forumURL string) (string, error) {
+	doc, err := fs.fetchDocument(forumURL)
+	if err != nil {
+		return "", err
+	}
+	atomic.AddInt64(&fs.stats.IndexPagesFetched, 1)
+
+	href, exists := doc.Find(feedSelector).First().Attr("href")
+	if !exists {
+		return "", fmt.Errorf("no feed advertised on %s", forumURL)
+	}
+	return resolveURL(forumURL, href)
+}
+
+// discoverFromFeed collects thread URLs from the forum's RSS or Atom feed.
+// With feedURL "auto" the feed is located via the page's <link rel="alternate">.
+func (fs *ForumScraperGo) discoverFromFeed(forumURL, feedURL string, maxThreads int) ([]ThreadRef, error) {
+	if feedURL == "auto" {
+		found, err := fs.findFeed(forumURL)
+		if err != nil {
+			return nil, err
+		}
+		feedURL = found
+	}
+	fs.statusf("📰 Discovering threads from feed: %s\n", feedURL)
+
+	// Rate limiting
+	fs.politeWait(feedURL)
+	resp, err := fs.doRequest(feedURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	atomic.AddInt64(&fs.stats.IndexPagesFetched, 1)
+
+	var feed feedDoc
+	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return nil, fmt.Errorf("malformed feed %s: %w", feedURL, err)
+	}
+
+	threadPattern := fs.threadURLRegexp()
+	seen := make(map[string]bool)
+	var refs []ThreadRef
+	for _, item := range append(feed.Items, feed.Entries...) {
+		if len(refs) >= maxThreads {
+			break
+		}
+		link := item.url()
+		if link == "" {
+			continue
+		}
+		absolute, ok := fs.resolveLink(feedURL, link)
+		if !ok {
+			continue
+		}
+		if threadPattern != nil && !threadPattern.MatchString(absolute) {
+			continue
+		}
+		key := normalizeURL(absolute)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		ref := ThreadRef{
+			URL:          absolute,
+			Title:        strings.TrimSpace(item.Title),
+			SourceURL:    forumURL,
+			LastActivity: parseLastMod(item.date()),
+		}
+		if fs.tooOld(ref) || !fs.allowThreadURL(absolute, forumURL) {
+			continue
+		}
+		refs = append(refs, ref)
+	}
+
+	fs.statusf("📊 Discovered %d thread URLs from feed\n", len(refs))
+	return refs, nil
+}
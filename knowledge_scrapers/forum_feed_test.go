@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseFeedDates(t *testing.T) {
+	want := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+	for _, value := range []string{
+		"Mon, 02 Jan 2006 15:04:05 GMT",
+		"Mon, 2 Jan 2006 15:04:05 GMT",
+		"Mon, 2 Jan 2006 15:04:05 +0000",
+		"2006-01-02T15:04:05Z",
+	} {
+		got := parseLastMod(value)
+		if got == nil || !got.Equal(want) {
+			t.Errorf("parseLastMod(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestFeedFetchIsPaced(t *testing.T) {
+	var mu sync.Mutex
+	requested := make(map[string]time.Time)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested[r.URL.Path] = time.Now()
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			io.WriteString(w, `<html><head><link rel="alternate" type="application/rss+xml" href="/feed.xml"></head><body></body></html>`)
+		case "/feed.xml":
+			w.Header().Set("Content-Type", "application/rss+xml")
+			fmt.Fprintf(w, `<rss><channel><item><title>Router drops</title><link>http://%s/viewtopic.php?f=2&amp;t=101</link><pubDate>Mon, 2 Jan 2006 15:04:05 GMT</pubDate></item></channel></rss>`, r.Host)
+		}
+	}))
+	defer server.Close()
+
+	delay := 200 * time.Millisecond
+	scraper := NewForumScraper("phpbb", delay.Seconds())
+	scraper.statusOut = io.Discard
+	refs, err := scraper.discoverFromFeed(server.URL+"/", "auto", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 1 || refs[0].LastActivity == nil {
+		t.Fatalf("discovered %+v, want one dated thread", refs)
+	}
+	if gap := requested["/feed.xml"].Sub(requested["/"]); gap < delay {
+		t.Errorf("feed fetched %v after the page, want at least the %v delay", gap, delay)
+	}
+}
@@ -0,0 +1,430 @@
+package main
+
+//go:generate go test -run TestRefreshFixtures -refresh
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Golden fixtures are saved, anonymized pages for each HTML platform under
+// testdata/fixtures, with the expected results in golden.json. TestGoldenFixtures
+// serves them from a local server and scrapes them, so selector changes are
+// checked against every platform without network access. Run the tests with
+// -update to rewrite the golden files from the current results.
+
+var (
+	updateGolden    = flag.Bool("update", false, "rewrite the golden files with the current results instead of comparing")
+	refreshFixtures = flag.Bool("refresh", false, "re-anonymize the fixture pages in place (TestRefreshFixtures)")
+)
+
+const (
+	fixturesDir = "testdata/fixtures"
+	goldenFile  = "golden.json"
+	// fixtureMaxThreads and fixtureMaxPosts are well above what any fixture holds
+	fixtureMaxThreads = 100
+	fixtureMaxPosts   = 1000
+	// anonymousEmail replaces every email address in a fixture
+	anonymousEmail = "user@example.com"
+)
+
+// fixtureCase is one platform's entry in golden.json
+type fixtureCase struct {
+	// Name tells apart cases for the same platform; it defaults to the platform
+	Name     string `json:"name,omitempty"`
+	Platform string `json:"platform"`
+	// Config is a platform config file in the fixtures directory, loaded as with
+	// --platform-config, for cases covering a custom config rather than a built-in one
+	Config string `json:"config,omitempty"`
+	// ExcludeCategories are --exclude-category patterns applied while scraping
+	ExcludeCategories []string `json:"exclude_categories,omitempty"`
+	// Routes maps request URIs (path and query) to files in the fixtures directory
+	Routes map[string]string `json:"routes"`
+	Thread string            `json:"thread"`
+	Index  string            `json:"index"`
+	Want   fixtureResult     `json:"want"`
+}
+
+// fixtureResult is what scraping a case's thread and index yields. Content is a
+// substring of one of the thread's posts; LongestPost, in runes, catches posts
+// swallowing their neighbours; ThreadURLs are relative to the server. IndexCounts
+// are the replies/views the index listed for each of ThreadURLs, "-" for a count
+// it didn't list, and are left out when it listed none. InvalidLinks counts the
+// index's hrefs dropped as unfetchable or malformed. IndexCategories are the
+// distinct categories discovery gave the index's threads.
+type fixtureResult struct {
+	Posts           int      `json:"posts"`
+	LongestPost     int      `json:"longest_post"`
+	FirstAuthor     string   `json:"first_author"`
+	LastAuthor      string   `json:"last_author"`
+	Title           string   `json:"title"`
+	Category        string   `json:"category"`
+	Content         string   `json:"content"`
+	ThreadURLs      []string `json:"thread_urls"`
+	IndexCounts     []string `json:"index_counts,omitempty"`
+	InvalidLinks    int      `json:"invalid_links,omitempty"`
+	IndexCategories []string `json:"index_categories,omitempty"`
+}
+
+func (c fixtureCase) name() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Platform
+}
+
+// loadFixtureCases reads golden.json from the fixtures directory
+func loadFixtureCases(t testing.TB) []fixtureCase {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(fixturesDir, goldenFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cases []fixtureCase
+	if err := json.Unmarshal(data, &cases); err != nil {
+		t.Fatalf("%s: %v", goldenFile, err)
+	}
+	return cases
+}
+
+// fixtureHandler serves a case's routes from the fixtures directory and 404s
+// everything else, so links off the fixture set fail like a dead page would
+func fixtureHandler(dir string, routes map[string]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, ok := routes[r.URL.RequestURI()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(data)
+	})
+}
+
+// newFixtureScraper returns a quiet, reproducible scraper for a case's platform
+// with the case's config loaded
+func newFixtureScraper(dir string, c fixtureCase) (*ForumScraperGo, error) {
+	scraper := NewForumScraper(c.Platform, 0, WithSeed(1), WithFixedTimestamps(true), WithCategoryFilter(nil, c.ExcludeCategories))
+	scraper.statusOut = io.Discard
+	if c.Config != "" {
+		if err := scraper.loadPlatformConfigs(filepath.Join(dir, c.Config)); err != nil {
+			return nil, err
+		}
+	}
+	return scraper, nil
+}
+
+// runFixtureCase scrapes a case's thread and index from a local server
+func runFixtureCase(dir string, c fixtureCase) (fixtureResult, error) {
+	server := httptest.NewServer(fixtureHandler(dir, c.Routes))
+	defer server.Close()
+
+	var result fixtureResult
+	scraper, err := newFixtureScraper(dir, c)
+	if err != nil {
+		return result, err
+	}
+
+	thread, err := scraper.scrapeThread(server.URL+c.Thread, fixtureMaxPosts)
+	if err != nil {
+		return result, fmt.Errorf("thread: %w", err)
+	}
+	result.Posts = len(thread.Posts)
+	result.Title = thread.Title
+	result.Category = thread.Category
+	if len(thread.Posts) > 0 {
+		result.FirstAuthor = thread.Posts[0].Author
+		result.LastAuthor = thread.Posts[len(thread.Posts)-1].Author
+	}
+	for _, post := range thread.Posts {
+		if length := utf8.RuneCountInString(post.Content); length > result.LongestPost {
+			result.LongestPost = length
+		}
+	}
+	for _, post := range thread.Posts {
+		if c.Want.Content != "" && strings.Contains(post.Content, c.Want.Content) {
+			result.Content = c.Want.Content
+			break
+		}
+	}
+
+	invalidBefore := atomic.LoadInt64(&scraper.stats.InvalidLinks)
+	refs, err := scraper.discoverThreads(server.URL+c.Index, fixtureMaxThreads)
+	if err != nil {
+		return result, fmt.Errorf("index: %w", err)
+	}
+	result.InvalidLinks = int(atomic.LoadInt64(&scraper.stats.InvalidLinks) - invalidBefore)
+	result.ThreadURLs = []string{}
+	listed := false
+	categories := make(map[string]bool)
+	for _, ref := range refs {
+		if ref.Category != "" && !categories[ref.Category] {
+			categories[ref.Category] = true
+			result.IndexCategories = append(result.IndexCategories, ref.Category)
+		}
+		result.ThreadURLs = append(result.ThreadURLs, strings.TrimPrefix(ref.URL, server.URL))
+		result.IndexCounts = append(result.IndexCounts, formatCount(ref.Replies)+"/"+formatCount(ref.Views))
+		listed = listed || ref.Replies != nil || ref.Views != nil
+	}
+	if !listed {
+		result.IndexCounts = nil
+	}
+	return result, nil
+}
+
+// formatCount renders an optional count for IndexCounts
+func formatCount(count *int) string {
+	if count == nil {
+		return "-"
+	}
+	return strconv.Itoa(*count)
+}
+
+// diff lists the fields where got differs from want
+func (want fixtureResult) diff(got fixtureResult) []string {
+	var problems []string
+	check := func(field string, got, want interface{}) {
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			problems = append(problems, fmt.Sprintf("%s = %q, want %q", field, fmt.Sprint(got), fmt.Sprint(want)))
+		}
+	}
+	check("posts", got.Posts, want.Posts)
+	check("longest post", got.LongestPost, want.LongestPost)
+	check("first author", got.FirstAuthor, want.FirstAuthor)
+	check("last author", got.LastAuthor, want.LastAuthor)
+	check("title", got.Title, want.Title)
+	check("category", got.Category, want.Category)
+	if got.Content != want.Content {
+		problems = append(problems, fmt.Sprintf("no post contains %q", want.Content))
+	}
+	check("thread urls", strings.Join(got.ThreadURLs, " "), strings.Join(want.ThreadURLs, " "))
+	check("index counts", strings.Join(got.IndexCounts, " "), strings.Join(want.IndexCounts, " "))
+	check("invalid links", got.InvalidLinks, want.InvalidLinks)
+	check("index categories", strings.Join(got.IndexCategories, " | "), strings.Join(want.IndexCategories, " | "))
+	return problems
+}
+
+// TestGoldenFixtures scrapes every case's thread and index and compares the
+// results with golden.json
+func TestGoldenFixtures(t *testing.T) {
+	cases := loadFixtureCases(t)
+	for i, c := range cases {
+		i, c := i, c
+		t.Run(c.name(), func(t *testing.T) {
+			got, err := runFixtureCase(fixturesDir, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *updateGolden {
+				if got.Content == "" {
+					got.Content = c.Want.Content
+				}
+				cases[i].Want = got
+				return
+			}
+			for _, problem := range c.Want.diff(got) {
+				t.Error(problem)
+			}
+		})
+	}
+
+	if *updateGolden && !t.Failed() {
+		// Routes and URLs keep their & unescaped
+		err := writeFileAtomicFunc(filepath.Join(fixturesDir, goldenFile), 0644, func(w io.Writer) error {
+			encoder := json.NewEncoder(w)
+			encoder.SetEscapeHTML(false)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(cases)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readFixtureThreads reads a results file in the fixtures directory
+func readFixtureThreads(t testing.TB, name string) []*ForumThread {
+	t.Helper()
+	var threads []*ForumThread
+	_, err := readResultsFile(filepath.Join(fixturesDir, name), func(thread *ForumThread) error {
+		threads = append(threads, thread)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return threads
+}
+
+// checkGolden compares got with a golden file in the fixtures directory, or
+// rewrites the file with -update
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join(fixturesDir, name)
+	if *updateGolden {
+		if err := writeFileAtomic(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(want) != string(got) {
+		t.Errorf("output differs from %s:\n%s", name, lineDiff(string(want), string(got)))
+	}
+}
+
+// lineDiff lists the lines of got that aren't in want, and the other way round
+func lineDiff(want, got string) string {
+	wantLines := make(map[string]int)
+	for _, line := range strings.Split(want, "\n") {
+		wantLines[line]++
+	}
+	gotLines := make(map[string]int)
+	for _, line := range strings.Split(got, "\n") {
+		gotLines[line]++
+	}
+	var diff []string
+	for _, line := range strings.Split(want, "\n") {
+		if gotLines[line] > 0 {
+			gotLines[line]--
+			continue
+		}
+		diff = append(diff, "   - "+strings.TrimSpace(line))
+	}
+	for _, line := range strings.Split(got, "\n") {
+		if wantLines[line] > 0 {
+			wantLines[line]--
+			continue
+		}
+		diff = append(diff, "   + "+strings.TrimSpace(line))
+	}
+	return strings.Join(diff, "\n")
+}
+
+// pseudonymPattern matches the names refresh gives authors
+var pseudonymPattern = regexp.MustCompile(`^user(\d+)$`)
+
+// TestRefreshFixtures re-anonymizes each case's pages in place when run with
+// -refresh (see the go:generate line above): author names found with the
+// platform's author selector become user1, user2, ... in order of first
+// appearance across the case's files, and email addresses become anonymousEmail.
+// Names the platform ignores (bots, [deleted]) are kept so the ignore rules stay
+// covered. Existing pseudonyms keep their numbers, so refresh is idempotent.
+func TestRefreshFixtures(t *testing.T) {
+	if !*refreshFixtures {
+		t.Skip("rewrites testdata; run with -refresh")
+	}
+	for _, c := range loadFixtureCases(t) {
+		scraper, err := newFixtureScraper(fixturesDir, c)
+		if err != nil {
+			t.Fatalf("%s config: %v", c.name(), err)
+		}
+		config, exists := scraper.configs[c.Platform]
+		if !exists {
+			config = scraper.configs["generic"]
+		}
+		var ignored []*regexp.Regexp
+		for _, pattern := range config.IgnoreAuthorPatterns {
+			ignored = append(ignored, regexp.MustCompile(pattern))
+		}
+
+		files := make([]string, 0, len(c.Routes))
+		for _, file := range c.Routes {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+
+		pages := make(map[string]string, len(files))
+		var names []string
+		used := make(map[string]bool)
+		for _, file := range files {
+			data, err := os.ReadFile(filepath.Join(fixturesDir, file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			pages[file] = string(data)
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(data)))
+			if err != nil {
+				t.Fatalf("%s: %v", file, err)
+			}
+			// Relative XPath author selectors only match within a post
+			authors := config.AuthorSelector.findAll(doc.Selection)
+			authors = authors.AddSelection(config.AuthorSelector.findAll(config.PostSelector.findAll(doc.Selection)))
+			authors.Each(func(i int, s *goquery.Selection) {
+				name := strings.TrimSpace(s.Text())
+				if name == "" || used[name] {
+					return
+				}
+				for _, re := range ignored {
+					if re.MatchString(name) {
+						return
+					}
+				}
+				used[name] = true
+				names = append(names, name)
+			})
+		}
+
+		// Pseudonyms already in use keep their numbers; other names take the next free one
+		pseudonyms := make(map[string]string)
+		next := 1
+		for _, name := range names {
+			if pseudonymPattern.MatchString(name) {
+				pseudonyms[name] = name
+			}
+		}
+		for _, name := range names {
+			if _, done := pseudonyms[name]; done {
+				continue
+			}
+			for used[fmt.Sprintf("user%d", next)] {
+				next++
+			}
+			pseudonyms[name] = fmt.Sprintf("user%d", next)
+			used[pseudonyms[name]] = true
+		}
+
+		changed := 0
+		for _, file := range files {
+			page := pages[file]
+			for name, pseudonym := range pseudonyms {
+				if name == pseudonym {
+					continue
+				}
+				page = regexp.MustCompile(`\b`+regexp.QuoteMeta(name)+`\b`).ReplaceAllString(page, pseudonym)
+			}
+			page = emailPattern.ReplaceAllString(page, anonymousEmail)
+			if page == pages[file] {
+				continue
+			}
+			if err := writeFileAtomic(filepath.Join(fixturesDir, file), []byte(page), 0644); err != nil {
+				t.Fatal(err)
+			}
+			changed++
+		}
+		t.Logf("%s: %d author(s), %d file(s) rewritten", c.name(), len(pseudonyms), changed)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// addFixturePages seeds a fuzz corpus with every fixture page, under the
+// platform its directory is named for
+func addFixturePages(f *testing.F) {
+	paths, err := filepath.Glob(filepath.Join(fixturesDir, "*", "*.html"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, path := range paths {
+		page, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(filepath.Base(filepath.Dir(path)), string(page))
+	}
+}
+
+// fuzzScraper returns a quiet scraper for platform, generic when it isn't one
+func fuzzScraper(platform string) (*ForumScraperGo, PlatformConfig) {
+	scraper := NewForumScraper(platform, 0, WithSeed(1), WithFixedTimestamps(true))
+	scraper.statusOut = io.Discard
+	config, exists := scraper.configs[scraper.platform]
+	if !exists {
+		config = scraper.configs["generic"]
+	}
+	return scraper, config
+}
+
+// Any page's posts either are skipped or come out with an author and valid UTF-8
+func FuzzScrapePost(f *testing.F) {
+	addFixturePages(f)
+	f.Fuzz(func(t *testing.T, platform, page string) {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+		if err != nil {
+			return
+		}
+		scraper, config := fuzzScraper(platform)
+		config.PostSelector.findAll(doc.Selection).Each(func(i int, s *goquery.Selection) {
+			post, reason := scraper.scrapePost(s, config, "Fuzzed thread", "https://forum.example.com/t/1", i+1)
+			if post == nil {
+				return
+			}
+			if reason != "" {
+				t.Errorf("post %d kept with skip reason %q", i+1, reason)
+			}
+			if post.Author == "" || !utf8.ValidString(post.Author) {
+				t.Errorf("post %d has author %q", i+1, post.Author)
+			}
+			if post.Content == "" {
+				t.Errorf("post %d kept without content", i+1)
+			}
+		})
+	})
+}
+
+// Any page's metadata has a valid UTF-8 title, when it has one, and a source for
+// every field
+func FuzzExtractThreadMetadata(f *testing.F) {
+	addFixturePages(f)
+	f.Fuzz(func(t *testing.T, platform, page string) {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+		if err != nil {
+			return
+		}
+		scraper, _ := fuzzScraper(platform)
+		metadata := scraper.extractThreadMetadata(doc, "https://forum.example.com/t/1")
+		if title, ok := metadata["title"].(string); ok && !utf8.ValidString(title) {
+			t.Errorf("title %q isn't valid UTF-8", title)
+		}
+		sources, ok := metadata["sources"].(map[string]string)
+		if !ok {
+			t.Fatal("metadata has no sources")
+		}
+		for key := range metadata {
+			if key != "sources" && sources[key] == "" {
+				t.Errorf("metadata %q has no source", key)
+			}
+		}
+	})
+}
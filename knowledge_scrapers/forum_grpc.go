@@ -0,0 +1,335 @@
+//go:build grpc
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func init() {
+	runGRPCServe = serveGRPC
+	runGRPCClient = callGRPC
+}
+
+// grpcServer serves forum_rpc.proto. Each RPC gets its own scraper bound to the
+// RPC's context, so a client deadline or cancellation aborts requests in flight.
+// Like serve's jobs, every RPC's scraper shares the server's thread slots,
+// per-host slots, pacing and budget, so concurrent calls against one host are
+// held to one host's limits.
+type grpcServer struct {
+	UnimplementedForumScraperServer
+	shared         *ForumScraperGo
+	minDelay       float64
+	maxConcurrency int
+}
+
+// newGRPCServer returns a server whose calls share maxConcurrency thread slots
+// and perHost slots on each host
+func newGRPCServer(minDelay float64, maxConcurrency, perHost int) *grpcServer {
+	return &grpcServer{
+		shared:         NewForumScraper("generic", minDelay, WithConcurrency(maxConcurrency, perHost)),
+		minDelay:       minDelay,
+		maxConcurrency: maxConcurrency,
+	}
+}
+
+// scraper builds the scraper for one RPC, holding the request's politeness to the
+// server's floor on delay and ceiling on concurrency. The request's concurrency
+// caps its own workers and per-host slots within the server's.
+func (s *grpcServer) scraper(ctx context.Context, platform string, politeness *Politeness) *ForumScraperGo {
+	if platform == "" {
+		platform = "generic"
+	}
+	delay := politeness.GetDelaySeconds()
+	if delay < s.minDelay {
+		delay = s.minDelay
+	}
+	jitter := politeness.GetJitter()
+	if !(jitter >= 0 && jitter <= maxJitter) {
+		jitter = 0
+	}
+	concurrency := int(politeness.GetConcurrency())
+	if concurrency <= 0 || concurrency > s.maxConcurrency {
+		concurrency = s.maxConcurrency
+	}
+	perHost := int(politeness.GetPerHostConcurrency())
+	if perHost <= 0 {
+		perHost = defaultPerHostConcurrency
+	}
+	if perHost > concurrency {
+		perHost = concurrency
+	}
+
+	scraper := NewForumScraper(platform, delay,
+		WithJitter(jitter),
+		WithAdaptiveDelay(politeness.GetAdaptiveDelay()),
+		withSharedLimits(s.shared),
+		WithStatusOutput(io.Discard))
+	scraper.workers = concurrency
+	scraper.hostSlots = &hostLimiter{limit: perHost, parent: s.shared.hostSlots}
+	scraper.ctx = ctx
+	return scraper
+}
+
+// rpcError maps a scrape error to a gRPC status. A context error wins, since the
+// scrape error is then only a symptom of the deadline or cancellation.
+func rpcError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	code := codes.Unknown
+	var statusErr *httpStatusError
+	switch {
+	case errors.As(err, &statusErr):
+		switch {
+		case statusErr.StatusCode == 404 || statusErr.StatusCode == 410:
+			code = codes.NotFound
+		case statusErr.StatusCode == 401 || statusErr.StatusCode == 403:
+			code = codes.PermissionDenied
+		case statusErr.StatusCode == 429 || statusErr.StatusCode >= 500:
+			code = codes.Unavailable
+		}
+	case errors.Is(err, ErrThreadMissing):
+		code = codes.NotFound
+	case errors.Is(err, ErrLoginRequired), errors.Is(err, ErrConsentWall):
+		code = codes.PermissionDenied
+	case errors.Is(err, ErrBotChallenge), errors.Is(err, ErrTimeout), errors.Is(err, ErrThreadTimeout):
+		code = codes.Unavailable
+	case errors.Is(err, ErrUnknownCategory):
+		code = codes.InvalidArgument
+	case errors.Is(err, ErrNotHTML), errors.Is(err, ErrResponseTooLarge), errors.Is(err, ErrMalformedHTML), errors.Is(err, ErrNoPosts),
+		errors.Is(err, ErrLanguageFiltered), errors.Is(err, ErrThreadFiltered), errors.Is(err, ErrCategoryFiltered), errors.Is(err, ErrOutsideWindow),
+		errors.Is(err, ErrUnsolved), errors.Is(err, ErrRedirectLoop), errors.Is(err, ErrTooManyRedirects), errors.Is(err, ErrExternalRedirect):
+		code = codes.FailedPrecondition
+	case errors.Is(err, ErrPanic):
+		code = codes.Internal
+	}
+	return status.Error(code, err.Error())
+}
+
+var errBadURL = status.Error(codes.InvalidArgument, "url must be an http(s) URL")
+
+func (s *grpcServer) ScrapeThread(ctx context.Context, req *ScrapeThreadRequest) (*Thread, error) {
+	if !isHTTPURL(req.GetUrl()) {
+		return nil, errBadURL
+	}
+	maxPosts := int(req.GetMaxPosts())
+	if maxPosts <= 0 {
+		maxPosts = 25
+	}
+	scraper := s.scraper(ctx, req.GetPlatform(), req.GetPoliteness())
+	thread, err := scraper.scrapeThreadSafe(req.GetUrl(), maxPosts)
+	if err != nil {
+		return nil, rpcError(ctx, err)
+	}
+	return threadToProto(thread), nil
+}
+
+func (s *grpcServer) ScrapeForum(req *ScrapeForumRequest, stream ForumScraper_ScrapeForumServer) error {
+	if !isHTTPURL(req.GetUrl()) {
+		return errBadURL
+	}
+	maxThreads, maxPosts := int(req.GetMaxThreads()), int(req.GetMaxPosts())
+	if maxThreads <= 0 {
+		maxThreads = 10
+	}
+	if maxPosts <= 0 {
+		maxPosts = 25
+	}
+	ctx := stream.Context()
+	scraper := s.scraper(ctx, req.GetPlatform(), req.GetPoliteness())
+
+	// Each thread goes down the stream as it completes and is not kept
+	sent := 0
+	err := scraper.scrapeSourcesEach([]string{req.GetUrl()}, maxThreads, maxPosts, func(thread *ForumThread) error {
+		if err := stream.Send(threadToProto(thread)); err != nil {
+			return err
+		}
+		sent++
+		return nil
+	})
+	log.Printf("⏹️ ScrapeForum %s: %d threads sent", req.GetUrl(), sent)
+	if err != nil || ctx.Err() != nil {
+		return rpcError(ctx, err)
+	}
+	return nil
+}
+
+func (s *grpcServer) DiscoverThreads(ctx context.Context, req *DiscoverThreadsRequest) (*DiscoverThreadsResponse, error) {
+	if !isHTTPURL(req.GetUrl()) {
+		return nil, errBadURL
+	}
+	maxThreads := int(req.GetMaxThreads())
+	if maxThreads <= 0 {
+		maxThreads = 10
+	}
+	scraper := s.scraper(ctx, req.GetPlatform(), req.GetPoliteness())
+	refs, err := scraper.discoverSources([]string{req.GetUrl()}, maxThreads)
+	if err != nil || ctx.Err() != nil {
+		return nil, rpcError(ctx, err)
+	}
+	response := &DiscoverThreadsResponse{}
+	for _, ref := range refs {
+		response.Threads = append(response.Threads, threadRefToProto(ref))
+	}
+	return response, nil
+}
+
+// serveGRPC starts the gRPC API described by forum_rpc.proto
+func serveGRPC(args []string) {
+	fset := flag.NewFlagSet("forum_scraper grpc-serve", flag.ExitOnError)
+	fset.Usage = func() {
+		fmt.Println("Usage: forum_scraper grpc-serve [flags]")
+		fmt.Println("Example: forum_scraper grpc-serve --addr :50051 --min-delay 2")
+		fmt.Println("  Serves ScrapeThread, ScrapeForum and DiscoverThreads (see forum_rpc.proto)")
+		fset.PrintDefaults()
+	}
+	addr := fset.String("addr", "127.0.0.1:50051", "address to listen on")
+	minDelay := fset.Float64("min-delay", 1.5, "smallest delay in seconds a request may ask for")
+	maxConcurrency := fset.Int("max-concurrency", 5, "concurrent thread scrapes across all calls, and the most one request may ask for")
+	perHostConcurrency := fset.Int("per-host-concurrency", defaultPerHostConcurrency, "concurrent thread scrapes against one host, across all calls")
+
+	if _, err := parseInterleaved(fset, args); err != nil {
+		log.Fatal(err)
+	}
+	if *minDelay < 0 {
+		log.Fatalf("❌ Invalid --min-delay: %g (must not be negative)", *minDelay)
+	}
+	if *maxConcurrency < 1 {
+		log.Fatalf("❌ Invalid --max-concurrency: %d (must be at least 1)", *maxConcurrency)
+	}
+	if *perHostConcurrency < 1 {
+		log.Fatalf("❌ Invalid --per-host-concurrency: %d (must be at least 1)", *perHostConcurrency)
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("❌ Failed to listen on %s: %v", *addr, err)
+	}
+	server := grpc.NewServer()
+	RegisterForumScraperServer(server, newGRPCServer(*minDelay, *maxConcurrency, *perHostConcurrency))
+
+	fmt.Fprintf(os.Stderr, "🌐 Serving gRPC on %s\n", listener.Addr())
+	if err := server.Serve(listener); err != nil {
+		log.Fatalf("❌ Server failed: %v", err)
+	}
+}
+
+// callGRPC calls a grpc-serve server, writing threads as JSONL in the results
+// schema and discovered threads as in discover's text output. It doubles as an
+// example of using the generated client.
+func callGRPC(args []string) {
+	fset := flag.NewFlagSet("forum_scraper grpc-client", flag.ExitOnError)
+	fset.Usage = func() {
+		fmt.Println("Usage: forum_scraper grpc-client [flags] <thread|forum|discover> <url>")
+		fmt.Println("Example: forum_scraper grpc-client --addr scraper:50051 --platform phpbb forum https://forum.example.com/ > threads.jsonl")
+		fset.PrintDefaults()
+	}
+	addr := fset.String("addr", "127.0.0.1:50051", "address of a grpc-serve server")
+	platform := fset.String("platform", "generic", "forum platform of the URL")
+	maxThreads := fset.Int("max-threads", 10, "maximum threads to discover or scrape")
+	maxPosts := fset.Int("max-posts", 25, "maximum posts per thread")
+	delay := fset.Float64("delay", 0, "delay in seconds before each request (0 for the server's minimum)")
+	jitter := fset.Float64("jitter", 0, "randomize each delay by ± this fraction")
+	adaptiveDelay := fset.Bool("adaptive-delay", false, "back off per host when responses are slow or rate-limited")
+	concurrency := fset.Int("concurrency", 0, "concurrent thread scrapes (0 for the server's maximum)")
+	perHostConcurrency := fset.Int("per-host-concurrency", 0, "concurrent thread scrapes against one host (0 for the default)")
+	timeout := fset.Duration("timeout", 0, "deadline for the call (e.g. 5m; 0 for none)")
+
+	positional, err := parseInterleaved(fset, args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(positional) != 2 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	method, target := positional[0], positional[1]
+	if !(*jitter >= 0 && *jitter <= maxJitter) {
+		log.Fatalf("❌ Invalid --jitter: %g (want a fraction from 0 to %g)", *jitter, maxJitter)
+	}
+
+	conn, err := grpc.NewClient(*addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("❌ Invalid --addr: %v", err)
+	}
+	defer conn.Close()
+	client := NewForumScraperClient(conn)
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	politeness := &Politeness{
+		DelaySeconds:       *delay,
+		Jitter:             *jitter,
+		AdaptiveDelay:      *adaptiveDelay,
+		Concurrency:        int32(*concurrency),
+		PerHostConcurrency: int32(*perHostConcurrency),
+	}
+	encoder := json.NewEncoder(os.Stdout)
+
+	switch method {
+	case "thread":
+		thread, err := client.ScrapeThread(ctx, &ScrapeThreadRequest{
+			Platform: *platform, Url: target, MaxPosts: int32(*maxPosts), Politeness: politeness,
+		})
+		if err != nil {
+			log.Fatalf("❌ ScrapeThread failed: %v", err)
+		}
+		encoder.Encode(threadRecord{SchemaVersion: resultsSchemaVersion, ForumThread: threadFromProto(thread)})
+	case "forum":
+		stream, err := client.ScrapeForum(ctx, &ScrapeForumRequest{
+			Platform: *platform, Url: target, MaxThreads: int32(*maxThreads), MaxPosts: int32(*maxPosts), Politeness: politeness,
+		})
+		if err != nil {
+			log.Fatalf("❌ ScrapeForum failed: %v", err)
+		}
+		received := 0
+		for {
+			thread, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				log.Fatalf("❌ ScrapeForum failed after %d threads: %v", received, err)
+			}
+			encoder.Encode(threadRecord{SchemaVersion: resultsSchemaVersion, ForumThread: threadFromProto(thread)})
+			received++
+		}
+		fmt.Fprintf(os.Stderr, "✅ Received %d threads\n", received)
+	case "discover":
+		response, err := client.DiscoverThreads(ctx, &DiscoverThreadsRequest{
+			Platform: *platform, Url: target, MaxThreads: int32(*maxThreads), Politeness: politeness,
+		})
+		if err != nil {
+			log.Fatalf("❌ DiscoverThreads failed: %v", err)
+		}
+		for _, message := range response.GetThreads() {
+			ref := threadRefFromProto(message)
+			if ref.Title != "" {
+				fmt.Printf("%s\t%s\n", ref.URL, ref.Title)
+			} else {
+				fmt.Println(ref.URL)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "📊 Threads discovered: %d\n", len(response.GetThreads()))
+	default:
+		log.Fatalf("❌ Unknown grpc-client call: %s (use thread, forum or discover)", method)
+	}
+}
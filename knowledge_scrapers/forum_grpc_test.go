@@ -0,0 +1,186 @@
+//go:build grpc
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// startGRPC serves server over an in-memory connection and returns a client for it
+func startGRPC(t *testing.T, server *grpcServer) ForumScraperClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	RegisterForumScraperServer(grpcServer, server)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewForumScraperClient(conn)
+}
+
+// phpbbForumServer serves a phpBB forum index listing topics t=1 to t=n, each the
+// fixture topic
+func phpbbForumServer(t *testing.T, n int) *httptest.Server {
+	t.Helper()
+	page, err := os.ReadFile(filepath.Join(fixturesDir, "phpbb/viewtopic.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if r.URL.Path == "/viewtopic.php" {
+			io.WriteString(w, strings.ReplaceAll(string(page), "t=101", "t="+r.URL.Query().Get("t")))
+			return
+		}
+		io.WriteString(w, "<html><body>")
+		for i := 1; i <= n; i++ {
+			fmt.Fprintf(w, `<a class="topictitle" href="./viewtopic.php?f=2&amp;t=%d">Topic %d</a>`, i, i)
+		}
+		io.WriteString(w, "</body></html>")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGRPCScrapeThread(t *testing.T) {
+	forum, _ := phpbbTopicServer(t)
+	client := startGRPC(t, newGRPCServer(0, 2, 2))
+
+	thread, err := client.ScrapeThread(context.Background(), &ScrapeThreadRequest{
+		Platform: "phpbb", Url: forum.URL + "/viewtopic.php?f=2&t=101", MaxPosts: fixtureMaxPosts,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	direct := NewForumScraper("phpbb", 0)
+	direct.statusOut = io.Discard
+	want, err := direct.scrapeThread(forum.URL+"/viewtopic.php?f=2&t=101", fixtureMaxPosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := threadFromProto(thread)
+	if got.ThreadID != "101" || got.Title != want.Title || len(got.Posts) != len(want.Posts) {
+		t.Errorf("ScrapeThread: thread %q %q with %d posts, want 101 %q with %d", got.ThreadID, got.Title, len(got.Posts), want.Title, len(want.Posts))
+	}
+}
+
+func TestGRPCScrapeForumStreams(t *testing.T) {
+	forum := phpbbForumServer(t, 3)
+	client := startGRPC(t, newGRPCServer(0, 2, 2))
+
+	stream, err := client.ScrapeForum(context.Background(), &ScrapeForumRequest{
+		Platform: "phpbb", Url: forum.URL + "/viewforum.php?f=2", MaxThreads: 3, MaxPosts: fixtureMaxPosts,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]bool{}
+	for {
+		thread, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[thread.GetThreadId()] = true
+	}
+	if len(ids) != 3 || !ids["1"] || !ids["2"] || !ids["3"] {
+		t.Errorf("ScrapeForum streamed threads %v, want 1, 2 and 3", ids)
+	}
+}
+
+func TestGRPCDiscoverThreads(t *testing.T) {
+	forum := phpbbForumServer(t, 4)
+	client := startGRPC(t, newGRPCServer(0, 2, 2))
+
+	response, err := client.DiscoverThreads(context.Background(), &DiscoverThreadsRequest{
+		Platform: "phpbb", Url: forum.URL + "/viewforum.php?f=2", MaxThreads: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(response.GetThreads()); n != 4 {
+		t.Errorf("DiscoverThreads found %d threads, want 4", n)
+	}
+}
+
+func TestGRPCErrors(t *testing.T) {
+	stalled := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { stall(r) }))
+	defer stalled.Close()
+	client := startGRPC(t, newGRPCServer(0, 2, 2))
+
+	_, err := client.ScrapeThread(context.Background(), &ScrapeThreadRequest{Url: "ftp://forum.example.com/t/1"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("non-HTTP URL: %v, want InvalidArgument", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err = client.ScrapeThread(ctx, &ScrapeThreadRequest{Platform: "phpbb", Url: stalled.URL + "/viewtopic.php?t=1"})
+	if status.Code(err) != codes.DeadlineExceeded && !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("stalled server past the deadline: %v, want DeadlineExceeded", err)
+	}
+}
+
+// Calls share the server's slots and pacing, each within its own politeness
+func TestGRPCCallsShareScheduler(t *testing.T) {
+	server := newGRPCServer(0, 4, 2)
+	a := server.scraper(context.Background(), "phpbb", &Politeness{Concurrency: 2, PerHostConcurrency: 1})
+	b := server.scraper(context.Background(), "discourse", nil)
+
+	if a.threadSem != server.shared.threadSem || b.threadSem != server.shared.threadSem {
+		t.Error("calls don't share the server's thread slots")
+	}
+	if a.hostSlots.parent != server.shared.hostSlots || b.hostSlots.parent != server.shared.hostSlots {
+		t.Error("calls don't share the server's per-host slots")
+	}
+	if a.pacing != server.shared.pacing || a.budget.parent != server.shared.budget {
+		t.Error("calls don't share the server's pacing and budget")
+	}
+	if a.workers != 2 || b.workers != 4 {
+		t.Errorf("call workers %d and %d, want the requested 2 and the server's 4", a.workers, b.workers)
+	}
+
+	// Two calls on one host hold no more than the server's per-host slots
+	host := "https://forum.example.com/t/1"
+	var releases []func()
+	for _, scraper := range []*ForumScraperGo{a, b, b} {
+		if release, ok := scraper.tryHostSlot(host); ok {
+			releases = append(releases, release)
+		}
+	}
+	if len(releases) != 2 {
+		t.Errorf("calls took %d slots on one host, want the server's 2", len(releases))
+	}
+	for _, release := range releases {
+		release()
+	}
+}
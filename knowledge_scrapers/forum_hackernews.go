@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// hackerNewsAPI is the Hacker News Firebase API root the hackernews platform reads from
+var hackerNewsAPI = "https://hacker-news.firebaseio.com/v0"
+
+// hackerNewsItemURL is the public page of an item, used as the thread URL
+const hackerNewsItemURL = "https://news.ycombinator.com/item?id="
+
+// hackerNewsMaxDepth bounds how deep comment replies are followed below the story
+const hackerNewsMaxDepth = 10
+
+// hackerNewsLists are the story lists discovery accepts, keyed by source name
+var hackerNewsLists = map[string]string{
+	"topstories": "topstories",
+	"newstories": "newstories",
+	"/":          "topstories",
+	"/news":      "topstories",
+	"/newest":    "newstories",
+}
+
+// hackerNewsItem is one item from /v0/item/{id}.json: a story, comment, job or poll
+type hackerNewsItem struct {
+	ID          int    `json:"id"`
+	Type        string `json:"type"`
+	By          string `json:"by"`
+	Time        int64  `json:"time"`
+	Text        string `json:"text"`
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Score       int    `json:"score"`
+	Descendants int    `json:"descendants"`
+	Kids        []int  `json:"kids"`
+	Deleted     bool   `json:"deleted"`
+	Dead        bool   `json:"dead"`
+}
+
+// hackerNewsBareID matches a source given as an item ID alone
+var hackerNewsBareID = regexp.MustCompile(`^\d+$`)
+
+// hackerNewsURL turns a bare item ID into its item URL when the platform is hackernews
+func (fs *ForumScraperGo) hackerNewsURL(source string) string {
+	if fs.platform != "hackernews" || !hackerNewsBareID.MatchString(strings.TrimSpace(source)) {
+		return source
+	}
+	return hackerNewsItemURL + strings.TrimSpace(source)
+}
+
+// fetchHackerNewsItem fetches one item; it is nil when the API has no such item
+func (fs *ForumScraperGo) fetchHackerNewsItem(ctx context.Context, id int) (*hackerNewsItem, error) {
+	itemURL := fmt.Sprintf("%s/item/%d.json", hackerNewsAPI, id)
+
+	// Rate limiting
+	fs.politeWaitContext(ctx, itemURL)
+
+	var item *hackerNewsItem
+	if err := fs.fetchJSON(ctx, itemURL, &item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// hackerNewsThread collects one story's posts while walking its comment tree
+type hackerNewsThread struct {
+	fs      *ForumScraperGo
+	ctx     context.Context
+	url     string
+	title   string
+	limit   int
+	posts   []*ForumPost
+	skipped map[string]int
+	stopped bool
+}
+
+// skip counts a comment left out of the thread
+func (t *hackerNewsThread) skip(reason string) {
+	t.skipped[reason]++
+	atomic.AddInt64(&t.fs.stats.PostsSkipped, 1)
+}
+
+// add runs an item through the post pipeline and returns its post number, or
+// parentNumber when the item was skipped so its replies attach to the nearest kept post
+func (t *hackerNewsThread) add(item *hackerNewsItem, content string, parentNumber int) int {
+	if content == "" {
+		return parentNumber
+	}
+	author := item.By
+	if author == "" {
+		author = "Anonymous"
+	}
+
+	postNumber := len(t.posts) + 1
+	post := &ForumPost{
+		URL:              fmt.Sprintf("%s%d", hackerNewsItemURL, item.ID),
+		ThreadTitle:      t.title,
+		Author:           author,
+		Content:          content,
+		PostNumber:       postNumber,
+		PostID:           strconv.Itoa(item.ID),
+		ParentPostNumber: parentNumber,
+		Timestamp:        time.Unix(item.Time, 0).UTC().Format(time.RFC3339),
+		ScrapedAt:        t.fs.now(),
+	}
+	if item.Type != "comment" {
+		score := item.Score
+		post.LikesCount = &score
+	}
+	post, reason := t.fs.processPost(post)
+	if post == nil {
+		if reason != "" {
+			t.skip(reason)
+		}
+		return parentNumber
+	}
+	post.ContentHash = contentHash(post.Content)
+	post.Language = detectLanguage(post.Content)
+	t.posts = append(t.posts, post)
+	return postNumber
+}
+
+// walk adds the comments under kids depth-first, in the order Hacker News shows them,
+// until the post limit, the depth limit or the run budget is reached
+func (t *hackerNewsThread) walk(kids []int, parentNumber, depth int) {
+	if depth > hackerNewsMaxDepth {
+		return
+	}
+	for _, id := range kids {
+		if t.stopped || len(t.posts) >= t.limit {
+			return
+		}
+		item, err := t.fs.fetchHackerNewsItem(t.ctx, id)
+		if errors.Is(err, ErrBudgetExhausted) || t.fs.threadTimedOut(t.ctx) {
+			t.stopped = true
+			return
+		}
+		if err != nil {
+			t.fs.statusf("⚠️ Skipping comment %d of %s: %v\n", id, t.url, err)
+			t.skip("unavailable")
+			continue
+		}
+		if item == nil {
+			t.skip("unavailable")
+			continue
+		}
+
+		// Replies to a deleted or dead comment survive it and attach to its parent
+		number := parentNumber
+		switch {
+		case item.Deleted:
+			t.skip("deleted")
+		case item.Dead:
+			t.skip("dead")
+		default:
+			number = t.add(item, t.fs.apiPostText(item.Text), parentNumber)
+		}
+		t.walk(item.Kids, number, depth+1)
+	}
+}
+
+// scrapeHackerNews builds a thread from a Hacker News story through the Firebase
+// API: the story's text or link is post 1 and its comments follow depth-first,
+// each with the post number of the comment it replies to
+func (fs *ForumScraperGo) scrapeHackerNews(threadURL string, maxPosts int) (*ForumThread, error) {
+	threadID := fs.extractThreadID(threadURL)
+	id, err := strconv.Atoi(threadID)
+	if err != nil {
+		return nil, fmt.Errorf("no item ID in %s", threadURL)
+	}
+	if !fs.markThreadID(threadURL, threadID) {
+		return nil, fmt.Errorf("%w: %s is item %s", ErrDuplicateThread, threadURL, threadID)
+	}
+	// --thread-timeout covers the story and every comment
+	threadCtx, cancel := fs.threadContext()
+	defer cancel()
+	ctx, provenance := fs.withProvenance(threadCtx, threadURL)
+
+	story, err := fs.fetchHackerNewsItem(ctx, id)
+	if err != nil {
+		return nil, fs.threadTimeoutError(threadCtx, err, threadURL)
+	}
+	if story == nil || story.Deleted || story.Dead {
+		return nil, fmt.Errorf("%w: %s", ErrThreadMissing, threadURL)
+	}
+	title := validUTF8(html.UnescapeString(story.Title))
+
+	t := &hackerNewsThread{
+		fs:      fs,
+		ctx:     ctx,
+		url:     threadURL,
+		title:   title,
+		limit:   fs.postLimit(maxPosts, story.Descendants+1),
+		skipped: make(map[string]int),
+	}
+	if t.limit > 0 {
+		content := fs.apiPostText(story.Text)
+		if story.URL != "" {
+			content = strings.TrimSpace(content + "\n\n" + story.URL)
+		}
+		t.add(story, content, 0)
+		t.walk(story.Kids, 1, 1)
+	}
+	if len(t.posts) == 0 && fs.postsMode != postsModeNone {
+		return nil, ErrNoPosts
+	}
+
+	thread := &ForumThread{
+		URL:          threadURL,
+		ThreadID:     threadID,
+		Title:        title,
+		Category:     story.Type,
+		RepliesCount: story.Descendants,
+		Provenance:   provenance,
+		ScrapedAt:    fs.now(),
+	}
+	if len(t.skipped) > 0 {
+		thread.SkippedPosts = t.skipped
+	}
+	if fs.threadTimedOut(threadCtx) {
+		thread.Truncated = true
+		fs.noteThreadTimeout(threadURL, provenance, fmt.Sprintf("%d of %d posts", len(t.posts), story.Descendants+1))
+	}
+	return fs.finishThread(thread, t.posts, maxPosts)
+}
+
+// discoverFromHackerNews queues the stories of a Hacker News list: topstories or
+// newstories, or the news.ycombinator.com front page and /newest that show them
+func (fs *ForumScraperGo) discoverFromHackerNews(source string, maxThreads int) ([]ThreadRef, error) {
+	name := strings.TrimSpace(source)
+	if u, err := url.Parse(source); err == nil && u.Host != "" {
+		name = u.Path
+		if name == "" {
+			name = "/"
+		}
+	}
+	list, exists := hackerNewsLists[name]
+	if !exists {
+		return nil, fmt.Errorf("%s is neither a Hacker News item nor a story list (topstories, newstories)", source)
+	}
+	fs.statusf("🔍 Discovering Hacker News %s\n", list)
+
+	listURL := fmt.Sprintf("%s/%s.json", hackerNewsAPI, list)
+	// Rate limiting
+	fs.politeWait(listURL)
+
+	var ids []int
+	if err := fs.fetchJSON(fs.runContext(), listURL, &ids); err != nil {
+		return nil, err
+	}
+	atomic.AddInt64(&fs.stats.IndexPagesFetched, 1)
+
+	var refs []ThreadRef
+	for _, id := range ids {
+		if len(refs) >= maxThreads {
+			break
+		}
+		itemURL := hackerNewsItemURL + strconv.Itoa(id)
+		// A list name has no host, so items are checked against news.ycombinator.com
+		if !fs.allowThreadURL(itemURL, hackerNewsItemURL) {
+			continue
+		}
+		refs = append(refs, ThreadRef{URL: itemURL, SourceURL: source})
+	}
+
+	fs.statusf("📊 Discovered %d stories\n", len(refs))
+	return refs, nil
+}
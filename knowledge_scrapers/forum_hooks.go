@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// RequestHook is called with every request just before it is sent. It may add
+// headers (for example a signature computed from the path); an error aborts the
+// fetch with ErrRequestHook.
+type RequestHook func(*http.Request) error
+
+// ResponseHook is called with every response as it arrives, before the scraper
+// checks its status or reads its body. It is for observation (metrics, logging):
+// it must not read or close the body, and an error it returns is only reported.
+type ResponseHook func(*http.Response) error
+
+// OnRequest registers a hook run on every outgoing request, including thread
+// pagination, sitemap, feed and API calls and each redirect followed. Hooks run
+// in registration order, concurrently from the scraper's worker goroutines, so
+// they must be safe for concurrent use. Register hooks before scraping starts.
+// Pages loaded through --render bypass the hooks.
+func (fs *ForumScraperGo) OnRequest(hook RequestHook) {
+	fs.requestHooks = append(fs.requestHooks, hook)
+}
+
+// OnResponse registers a hook run on every response received, under the same
+// ordering and concurrency rules as OnRequest
+func (fs *ForumScraperGo) OnResponse(hook ResponseHook) {
+	fs.responseHooks = append(fs.responseHooks, hook)
+}
+
+// runRequestHooks passes req through the registered request hooks, stopping at the first error
+func (fs *ForumScraperGo) runRequestHooks(req *http.Request) error {
+	for _, hook := range fs.requestHooks {
+		if err := hook(req); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrRequestHook, req.URL, err)
+		}
+	}
+	return nil
+}
+
+// runResponseHooks passes resp to every registered response hook, reporting their errors
+func (fs *ForumScraperGo) runResponseHooks(resp *http.Response) {
+	for _, hook := range fs.responseHooks {
+		if err := hook(resp); err != nil {
+			fs.statusf("⚠️ Response hook failed for %s: %v\n", resp.Request.URL, err)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/brotli"
+	"golang.org/x/net/html/charset"
+)
+
+// userAgent is sent on every request unless --user-agent-file supplies a pool
+const userAgent = "Marina-ForumScraper/2.0 (Educational Research)"
+
+// defaultMaxResponseSize caps response bodies unless --max-response-size says otherwise
+const defaultMaxResponseSize = 10 << 20
+
+// countingReader adds the bytes read through it to a shared counter
+type countingReader struct {
+	r     io.Reader
+	count *int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	atomic.AddInt64(c.count, int64(n))
+	return n, err
+}
+
+// decodedBody pairs a decoding reader with the underlying body it must close
+type decodedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// decodeContent wraps a raw body in the decoder for its Content-Encoding. Bodies
+// whose encoding header doesn't match their bytes are passed through as plain.
+func decodeContent(raw *bufio.Reader, encoding string) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "gzip", "x-gzip":
+		if magic, err := raw.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+			if gz, err := gzip.NewReader(raw); err == nil {
+				return gz
+			}
+		}
+	case "br":
+		// Brotli has no magic number, so trial-decode the start of the body
+		head, _ := raw.Peek(4096)
+		_, err := io.Copy(io.Discard, brotli.NewReader(bytes.NewReader(head)))
+		if err == nil || err == io.ErrUnexpectedEOF {
+			return brotli.NewReader(raw)
+		}
+	}
+	return raw
+}
+
+// limitedBody fails reads once more than limit bytes have been received, so an
+// oversized response aborts cleanly instead of being parsed in full
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	url       string
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n, fmt.Errorf("%w: %s", ErrResponseTooLarge, b.url)
+	}
+	return n, err
+}
+
+// readErrRecorder remembers the first read error other than io.EOF
+type readErrRecorder struct {
+	r   io.Reader
+	err error
+}
+
+func (r *readErrRecorder) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF && r.err == nil {
+		r.err = err
+	}
+	return n, err
+}
+
+// parseHTML parses a page, telling a body that failed to read apart from markup
+// the parser refuses, which is ErrMalformedHTML
+func parseHTML(body io.Reader, rawURL string) (*goquery.Document, error) {
+	reader := &readErrRecorder{r: body}
+	doc, err := goquery.NewDocumentFromReader(reader)
+	if err != nil && reader.err == nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrMalformedHTML, rawURL, err)
+	}
+	return doc, err
+}
+
+// newRequest builds a request carrying the scraper's User-Agent and credentials.
+// Every request is built here so headers stay consistent across fetch paths.
+func (fs *ForumScraperGo) newRequest(method, rawURL string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", fs.userAgentFor(rawURL))
+	fs.setAuth(req)
+	return req, nil
+}
+
+// runContext is the context requests derive from: the job's for serve, otherwise
+// one that is never cancelled
+func (fs *ForumScraperGo) runContext() context.Context {
+	if fs.ctx != nil {
+		return fs.ctx
+	}
+	return context.Background()
+}
+
+// doRequest issues a GET for rawURL with the scraper's headers and rejects non-200 responses
+func (fs *ForumScraperGo) doRequest(rawURL string) (*http.Response, error) {
+	return fs.doRequestContext(fs.runContext(), rawURL)
+}
+
+// doRequestContext is doRequest recording into any provenance carried by ctx.
+// Every fetch goes through here so request-level behavior stays in one place.
+// Transient failures are retried up to --retries times, each retry waiting its
+// turn with politeWait after a backoff that doubles per attempt.
+func (fs *ForumScraperGo) doRequestContext(ctx context.Context, rawURL string) (*http.Response, error) {
+	backoff := retryBackoffBase
+	for attempt := 0; ; attempt++ {
+		resp, err := fs.doRequestAttempt(context.WithValue(ctx, attemptKey{}, attempt), rawURL)
+		if err == nil || attempt >= fs.retries || !retryableRequest(err) || ctx.Err() != nil {
+			return resp, err
+		}
+		fs.debugf("Retrying %s in %v after: %v", rawURL, backoff, err)
+		atomic.AddInt64(&fs.stats.RequestRetries, 1)
+		fs.recordRetry(ctx)
+		sleepContext(ctx, backoff)
+		if backoff *= 2; backoff > retryBackoffMax {
+			backoff = retryBackoffMax
+		}
+		fs.politeWaitContext(ctx, rawURL)
+	}
+}
+
+// retryBackoffBase and retryBackoffMax pace retries of one request: 2s, 4s, 8s …
+// capped at retryBackoffMax, on top of the host's usual delay
+var (
+	retryBackoffBase = 2 * time.Second
+	retryBackoffMax  = time.Minute
+)
+
+// attemptKey carries the retry number of the request being made in a context, 0
+// for the first attempt
+type attemptKey struct{}
+
+// retryableRequest reports whether a failed request may succeed if made again:
+// timeouts, dropped connections and 429, 502, 503 and 504 responses. Refusals
+// such as budgets, hooks, redirect policy and bot challenges are final.
+func retryableRequest(err error) bool {
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case 429, 502, 503, 504:
+			return true
+		}
+		return false
+	}
+	if errors.Is(err, ErrTimeout) {
+		return true
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	// *url.Error is a net.Error itself, whatever it wraps, redirect refusals included
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// doRequestAttempt makes one attempt at a request for doRequestContext
+func (fs *ForumScraperGo) doRequestAttempt(ctx context.Context, rawURL string) (*http.Response, error) {
+	if err := fs.spendRequest(); err != nil {
+		return nil, err
+	}
+	req, err := fs.newRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := fs.requestContext(ctx)
+	req = req.WithContext(ctx)
+	// Asking explicitly turns off the transport's transparent gzip, so decoding
+	// (and byte accounting) happens below for both encodings
+	req.Header.Set("Accept-Encoding", "gzip, br")
+	if err := fs.runRequestHooks(req); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	started := time.Now()
+	access := fs.startAccess(ctx, req)
+	resp, err := fs.client.Do(req)
+	if err != nil {
+		cancel()
+		fs.observeResponse(rawURL, time.Since(started), 0)
+		fs.finishAccess(access, "-")
+		return nil, fs.classifyTimeout(err, rawURL)
+	}
+	fs.observeResponse(rawURL, time.Since(started), resp.StatusCode)
+	fs.runResponseHooks(resp)
+	if access != nil {
+		access.url = resp.Request.URL.String()
+	}
+	resp.Body = decodedBody{Reader: resp.Body, Closer: cancelOnClose{Closer: resp.Body, cancel: cancel}}
+
+	if resp.StatusCode != 200 {
+		resp.Body.Close()
+		fs.finishAccess(access, strconv.Itoa(resp.StatusCode))
+		if resp.Header.Get("Cf-Mitigated") == "challenge" {
+			return nil, fmt.Errorf("%w: %s (HTTP %d)", ErrBotChallenge, rawURL, resp.StatusCode)
+		}
+		return nil, &httpStatusError{StatusCode: resp.StatusCode}
+	}
+
+	if fs.maxResponseSize > 0 && resp.ContentLength > fs.maxResponseSize {
+		resp.Body.Close()
+		fs.finishAccess(access, strconv.Itoa(resp.StatusCode))
+		return nil, fmt.Errorf("%w: %s (%d bytes)", ErrResponseTooLarge, rawURL, resp.ContentLength)
+	}
+
+	// Throttling applies to bytes on the wire, before decompression
+	raw := fs.throttle(resp.Body)
+	if access != nil {
+		raw = &countingReader{r: raw, count: &access.bytes}
+	}
+	wire := bufio.NewReader(&countingReader{r: raw, count: &fs.stats.BytesTransferred})
+	decoded := decodeContent(wire, resp.Header.Get("Content-Encoding"))
+	resp.Body = decodedBody{
+		Reader: &countingReader{r: decoded, count: &fs.stats.BytesDecoded},
+		Closer: resp.Body,
+	}
+	resp.Header.Del("Content-Encoding")
+	resp.ContentLength = -1
+	fs.recordFetch(ctx, resp)
+
+	// The cap applies to decoded bytes so compression bombs are caught too
+	if fs.maxResponseSize > 0 {
+		resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: fs.maxResponseSize, url: rawURL}
+	}
+	// The access log line is written once the body is read and closed
+	if access != nil {
+		resp.Body = &accessBody{ReadCloser: resp.Body, fs: fs, entry: access, status: strconv.Itoa(resp.StatusCode)}
+	}
+	return resp, nil
+}
+
+// htmlBody checks that a response is HTML before it is parsed. Declared content
+// types must be HTML; undeclared bodies are sniffed and rejected if they look binary.
+func htmlBody(resp *http.Response, rawURL string) (io.Reader, error) {
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != "text/html" && mediaType != "application/xhtml+xml") {
+			return nil, fmt.Errorf("%w: %s is %s", ErrNotHTML, rawURL, contentType)
+		}
+		return resp.Body, nil
+	}
+
+	body := bufio.NewReader(resp.Body)
+	head, err := body.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	sniffed := http.DetectContentType(head)
+	if !strings.HasPrefix(sniffed, "text/") || bytes.IndexByte(head, 0) >= 0 {
+		return nil, fmt.Errorf("%w: %s looks like %s", ErrNotHTML, rawURL, sniffed)
+	}
+	return body, nil
+}
+
+// fetchDocument fetches rawURL and parses the response as HTML.
+// The document's Url is the final URL after any redirects.
+func (fs *ForumScraperGo) fetchDocument(rawURL string) (*goquery.Document, error) {
+	return fs.fetchDocumentContext(fs.runContext(), rawURL)
+}
+
+// fetchDocumentContext is fetchDocument recording into any provenance carried by ctx
+func (fs *ForumScraperGo) fetchDocumentContext(ctx context.Context, rawURL string) (*goquery.Document, error) {
+	resp, err := fs.doRequestContext(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := htmlBody(resp, rawURL)
+	if err != nil {
+		return nil, fs.classifyTimeout(err, rawURL)
+	}
+	// Transcode legacy charsets (windows-1251, ISO-8859-1, Shift-JIS...) declared in
+	// the Content-Type header or a <meta> tag, so goquery always sees UTF-8
+	body, err = charset.NewReader(body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fs.classifyTimeout(err, rawURL)
+	}
+	doc, err := parseHTML(body, rawURL)
+	if err != nil {
+		return nil, fs.classifyTimeout(err, rawURL)
+	}
+	doc.Url = resp.Request.URL
+	if isBotChallenge(doc) {
+		return nil, fmt.Errorf("%w: %s", ErrBotChallenge, rawURL)
+	}
+	return doc, nil
+}
+
+// fetchJSON fetches rawURL and decodes its JSON body into v, recording into any
+// provenance carried by ctx
+func (fs *ForumScraperGo) fetchJSON(ctx context.Context, rawURL string, v interface{}) error {
+	resp, err := fs.doRequestContext(ctx, rawURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fs.classifyTimeout(fmt.Errorf("invalid JSON from %s: %w", rawURL, err), rawURL)
+	}
+	return nil
+}
+
+// htmlText turns an HTML fragment from a JSON API, such as a post body, into plain text
+func htmlText(fragment string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(fragment))
+	if err != nil {
+		return validUTF8(strings.TrimSpace(html.UnescapeString(fragment)))
+	}
+	return validUTF8(strings.TrimSpace(doc.Text()))
+}
+
+// validUTF8 replaces any invalid UTF-8 left after transcoding with U+FFFD
+func validUTF8(text string) string {
+	return strings.ToValidUTF8(text, "\uFFFD")
+}
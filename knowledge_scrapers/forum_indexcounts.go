@@ -0,0 +1,105 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// threadListing holds the reply and view counts an index listed beside a thread
+type threadListing struct {
+	replies *int
+	views   *int
+}
+
+// indexCountPattern reads a listed count such as "1,520", "7 Replies" or "1.5K"
+var indexCountPattern = regexp.MustCompile(`(\d+(?:[.,]\d+)*)(?:\s*([kKmM])\b)?`)
+
+// parseIndexCount reads the count an index cell shows; false when it has none
+func parseIndexCount(text string) (int, bool) {
+	matches := indexCountPattern.FindStringSubmatch(text)
+	if matches == nil {
+		return 0, false
+	}
+	var multiplier float64
+	switch strings.ToLower(matches[2]) {
+	case "":
+		count, err := strconv.Atoi(strings.NewReplacer(",", "", ".", "").Replace(matches[1]))
+		return count, err == nil
+	case "k":
+		multiplier = 1e3
+	case "m":
+		multiplier = 1e6
+	}
+	// Abbreviated counts carry a decimal point, or a comma in some locales
+	value, err := strconv.ParseFloat(strings.ReplaceAll(matches[1], ",", "."), 64)
+	if err != nil {
+		return 0, false
+	}
+	return int(value * multiplier), true
+}
+
+// indexRow returns the row of an index listing that holds a thread link: its
+// outermost ancestor holding no other match of the selector that found the link
+func indexRow(link *goquery.Selection, selector string) *goquery.Selection {
+	row := link
+	for parent := link.Parent(); parent.Length() > 0; parent = parent.Parent() {
+		if findSelector(parent, selector).Length() > 1 {
+			break
+		}
+		row = parent
+	}
+	return row
+}
+
+// indexCount reads the count chain finds within an index row, nil when it finds none
+func indexCount(row *goquery.Selection, chain selectorChain) *int {
+	if len(chain) == 0 {
+		return nil
+	}
+	text, _ := chain.text(row)
+	count, ok := parseIndexCount(text)
+	if !ok {
+		return nil
+	}
+	return &count
+}
+
+// recordListedCounts keeps the counts discovery found for each thread, for
+// applyIndexCounts to fall back on once the thread is scraped
+func (fs *ForumScraperGo) recordListedCounts(refs []ThreadRef) {
+	fs.visitedMutex.Lock()
+	defer fs.visitedMutex.Unlock()
+	for _, ref := range refs {
+		if ref.Replies != nil || ref.Views != nil {
+			fs.listedCounts[normalizeURL(ref.URL)] = threadListing{replies: ref.Replies, views: ref.Views}
+		}
+	}
+}
+
+// applyIndexCounts fills the view and reply counts the thread page didn't state
+// from the index the thread was discovered on, recording "index" as their source
+func (fs *ForumScraperGo) applyIndexCounts(threadURL string, metadata map[string]interface{}) {
+	fs.visitedMutex.RLock()
+	counts, exists := fs.listedCounts[normalizeURL(threadURL)]
+	fs.visitedMutex.RUnlock()
+	if !exists {
+		return
+	}
+
+	sources, _ := metadata["sources"].(map[string]string)
+	if sources == nil {
+		sources = make(map[string]string)
+		metadata["sources"] = sources
+	}
+	if _, stated := metadata["views_count"]; !stated && counts.views != nil {
+		metadata["views_count"] = *counts.views
+		sources["views_count"] = metadataSourceIndex
+	}
+	if _, stated := metadata["replies_count"]; !stated && counts.replies != nil {
+		metadata["replies_count"] = *counts.replies
+		sources["replies_count"] = metadataSourceIndex
+	}
+}
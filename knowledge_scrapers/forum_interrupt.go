@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+var (
+	interruptMu       sync.Mutex
+	interruptHandlers []func()
+	interruptOnce     sync.Once
+)
+
+// onInterrupt registers fn to run when the run is interrupted by SIGINT or
+// SIGTERM. Handlers run in the order they were registered, then the process
+// exits with 130.
+func onInterrupt(fn func()) {
+	interruptMu.Lock()
+	interruptHandlers = append(interruptHandlers, fn)
+	interruptMu.Unlock()
+
+	interruptOnce.Do(func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			sig := <-signals
+			interruptMu.Lock()
+			for _, handler := range interruptHandlers {
+				handler()
+			}
+			fmt.Fprintf(os.Stderr, "🛑 Interrupted by %v\n", sig)
+			os.Exit(130)
+		}()
+	})
+}
@@ -0,0 +1,62 @@
+//go:build kafka
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func init() {
+	newKafkaPublisher = openKafkaPublisher
+}
+
+// kafkaPublisher writes to one Kafka topic, hashing message keys to partitions
+type kafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func openKafkaPublisher(brokers []string, topic, acks string) (busPublisher, error) {
+	required := kafka.RequireAll
+	switch acks {
+	case busAcksAll:
+	case busAcksOne:
+		required = kafka.RequireOne
+	case busAcksNone:
+		required = kafka.RequireNone
+	default:
+		return nil, fmt.Errorf("unknown acks level %q", acks)
+	}
+
+	// Fail at startup when no broker answers, rather than spilling the whole run
+	conn, err := kafka.DialContext(context.Background(), "tcp", brokers[0])
+	if err != nil {
+		return nil, err
+	}
+	conn.Close()
+
+	return &kafkaPublisher{writer: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: required,
+		BatchTimeout: 10 * time.Millisecond,
+		WriteTimeout: 10 * time.Second,
+		MaxAttempts:  3,
+	}}, nil
+}
+
+func (p *kafkaPublisher) Publish(ctx context.Context, messages []busMessage) error {
+	records := make([]kafka.Message, len(messages))
+	for i, message := range messages {
+		records[i] = kafka.Message{Key: []byte(message.Key), Value: message.Value}
+	}
+	return p.writer.WriteMessages(ctx, records...)
+}
+
+func (p *kafkaPublisher) Close() error {
+	return p.writer.Close()
+}
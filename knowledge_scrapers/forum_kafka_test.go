@@ -0,0 +1,85 @@
+//go:build kafka
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// TestKafkaSinkDelivers publishes a thread through a real broker. It runs only
+// with FORUM_SCRAPER_TEST_KAFKA set to a broker address, for example against
+//
+//	docker run --rm -p 9092:9092 apache/kafka:3.9.0
+//
+// and FORUM_SCRAPER_TEST_KAFKA=127.0.0.1:9092 go test -tags kafka -run Kafka
+func TestKafkaSinkDelivers(t *testing.T) {
+	broker := os.Getenv("FORUM_SCRAPER_TEST_KAFKA")
+	if broker == "" {
+		t.Skip("FORUM_SCRAPER_TEST_KAFKA not set")
+	}
+	topic := fmt.Sprintf("forum-scraper-test-%d", time.Now().UnixNano())
+	conn, err := kafka.Dial("tcp", broker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	controller, err := conn.Controller()
+	conn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := kafka.Dial("tcp", fmt.Sprintf("%s:%d", controller.Host, controller.Port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = admin.CreateTopics(kafka.TopicConfig{Topic: topic, NumPartitions: 3, ReplicationFactor: 1})
+	admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publisher, err := openKafkaPublisher([]string{broker}, topic, busAcksAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thread := busTestThread(4)
+	sink := newBusSink("kafka", publisher, false, filepath.Join(t.TempDir(), "kafka.spill"), io.Discard)
+	if err := sink.Write(context.Background(), thread); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{Brokers: []string{broker}, Topic: topic, GroupID: topic})
+	defer reader.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	partitions := map[int]bool{}
+	for i := 0; i < len(thread.Posts); i++ {
+		message, err := reader.ReadMessage(ctx)
+		if err != nil {
+			t.Fatalf("reading message %d: %v", i+1, err)
+		}
+		if string(message.Key) != threadKey(thread) {
+			t.Errorf("message key %q, want %q", message.Key, threadKey(thread))
+		}
+		var post ForumPost
+		if err := json.Unmarshal(message.Value, &post); err != nil {
+			t.Errorf("message %d is not a post: %v", i+1, err)
+		}
+		partitions[message.Partition] = true
+	}
+	// Keyed by thread, every post lands on one partition
+	if len(partitions) != 1 {
+		t.Errorf("posts of one thread spread over partitions %v", partitions)
+	}
+}
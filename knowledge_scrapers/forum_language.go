@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// minLanguageChars is the shortest content detectLanguage will classify; shorter posts are left unlabeled
+const minLanguageChars = 20
+
+// languageStopwords are frequent function words that identify Latin-script languages
+var languageStopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "was", "of", "to", "in", "that", "it", "for", "with", "you", "this", "have", "not", "but", "on", "be", "what"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ich", "sie", "es", "ein", "eine", "mit", "auf", "für", "den", "dem", "auch", "wie", "aber", "bei"},
+	"fr": {"le", "la", "les", "et", "est", "un", "une", "des", "que", "pas", "je", "il", "pour", "dans", "sur", "avec", "ce", "qui", "mais", "vous"},
+	"es": {"el", "la", "los", "las", "y", "es", "un", "una", "que", "no", "de", "en", "por", "con", "para", "pero", "lo", "del", "como", "está"},
+	"it": {"il", "lo", "la", "gli", "e", "è", "un", "una", "che", "non", "di", "per", "con", "ma", "sono", "del", "della", "come", "anche", "questo"},
+	"nl": {"de", "het", "een", "en", "is", "niet", "ik", "je", "dat", "van", "op", "met", "voor", "maar", "zijn", "ook", "wat", "er", "als", "naar"},
+	"pt": {"o", "os", "as", "e", "é", "um", "uma", "que", "não", "do", "da", "em", "para", "com", "mas", "por", "se", "dos", "como", "você"},
+}
+
+// stopwordSets indexes languageStopwords for lookup
+var stopwordSets = func() map[string]map[string]bool {
+	sets := make(map[string]map[string]bool, len(languageStopwords))
+	for lang, words := range languageStopwords {
+		sets[lang] = make(map[string]bool, len(words))
+		for _, word := range words {
+			sets[lang][word] = true
+		}
+	}
+	return sets
+}()
+
+// scriptLanguage labels text by its dominant non-Latin script, or returns ""
+func scriptLanguage(text string) string {
+	counts := make(map[string]int)
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case strings.ContainsRune("іїєґІЇЄҐ", r):
+			counts["uk"]++
+			counts["ru"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			counts["ru"]++
+		case unicode.Is(unicode.Greek, r):
+			counts["el"]++
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			counts["ja"]++
+		case unicode.Is(unicode.Hangul, r):
+			counts["ko"]++
+		case unicode.Is(unicode.Han, r):
+			counts["zh"]++
+		case unicode.Is(unicode.Arabic, r):
+			counts["ar"]++
+		case unicode.Is(unicode.Hebrew, r):
+			counts["he"]++
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+	// Any kana means Japanese even though most characters may be Han
+	if counts["ja"] > 0 && counts["ja"]+counts["zh"] > letters/2 {
+		return "ja"
+	}
+	if counts["uk"] > 0 && counts["ru"] > letters/2 {
+		return "uk"
+	}
+	for _, lang := range []string{"ru", "el", "ko", "zh", "ar", "he"} {
+		if counts[lang] > letters/2 {
+			return lang
+		}
+	}
+	return ""
+}
+
+// detectLanguage returns an ISO 639-1 code for text, or "" when the text is too
+// short or no language wins clearly
+func detectLanguage(text string) string {
+	if len([]rune(strings.TrimSpace(text))) < minLanguageChars {
+		return ""
+	}
+	if lang := scriptLanguage(text); lang != "" {
+		return lang
+	}
+
+	scores := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		for lang, set := range stopwordSets {
+			if set[word] {
+				scores[lang]++
+			}
+		}
+	}
+
+	best, bestScore, runnerUp := "", 0, 0
+	for lang, score := range scores {
+		if score > bestScore || (score == bestScore && lang < best) {
+			best, bestScore, runnerUp = lang, score, bestScore
+		} else if score > runnerUp {
+			runnerUp = score
+		}
+	}
+	if bestScore < 2 || bestScore == runnerUp {
+		return ""
+	}
+	return best
+}
+
+// majorityLanguage returns the most common non-empty language among posts
+func majorityLanguage(posts []*ForumPost) string {
+	counts := make(map[string]int)
+	for _, post := range posts {
+		if post.Language != "" {
+			counts[post.Language]++
+		}
+	}
+	best := ""
+	for lang, count := range counts {
+		if count > counts[best] || (count == counts[best] && lang < best) {
+			best = lang
+		}
+	}
+	return best
+}
+
+// languageAllowed reports whether a detected language passes --languages;
+// unlabeled content always passes since nothing is known about it
+func (fs *ForumScraperGo) languageAllowed(lang string) bool {
+	return len(fs.languages) == 0 || lang == "" || fs.languages[lang]
+}
+
+// languageHistogram counts kept posts per detected language
+type languageHistogram struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// record counts one post's language; "" is reported as "unknown"
+func (h *languageHistogram) record(lang string) {
+	if lang == "" {
+		lang = "unknown"
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.counts == nil {
+		h.counts = make(map[string]int)
+	}
+	h.counts[lang]++
+}
+
+// snapshot returns a copy of the counts
+func (h *languageHistogram) snapshot() map[string]int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	counts := make(map[string]int, len(h.counts))
+	for lang, count := range h.counts {
+		counts[lang] = count
+	}
+	return counts
+}
+
+// printSummary writes the histogram, most common language first
+func (h *languageHistogram) printSummary(w io.Writer) {
+	counts := h.snapshot()
+	if len(counts) == 0 {
+		return
+	}
+	langs := make([]string, 0, len(counts))
+	for lang := range counts {
+		langs = append(langs, lang)
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if counts[langs[i]] != counts[langs[j]] {
+			return counts[langs[i]] > counts[langs[j]]
+		}
+		return langs[i] < langs[j]
+	})
+	parts := make([]string, len(langs))
+	for i, lang := range langs {
+		parts[i] = fmt.Sprintf("%s=%d", lang, counts[lang])
+	}
+	fmt.Fprintf(w, "📊 Post languages: %s\n", strings.Join(parts, ", "))
+}
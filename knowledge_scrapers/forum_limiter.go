@@ -0,0 +1,123 @@
+package main
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// defaultPerHostConcurrency caps concurrent thread scrapes against one host
+const defaultPerHostConcurrency = 2
+
+// hostLimiter hands out per-host slots so concurrent scrapes spread across hosts
+type hostLimiter struct {
+	mu    sync.Mutex
+	limit int
+	slots map[string]chan struct{}
+	// parent is a limiter shared with other scrapes, as gRPC calls share the
+	// server's; a slot is only free when it is free in both
+	parent *hostLimiter
+}
+
+// slot returns the semaphore for host, creating it on first use
+func (l *hostLimiter) slot(host string) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.slots == nil {
+		l.slots = make(map[string]chan struct{})
+	}
+	sem, exists := l.slots[host]
+	if !exists {
+		limit := l.limit
+		if limit <= 0 {
+			limit = defaultPerHostConcurrency
+		}
+		sem = make(chan struct{}, limit)
+		l.slots[host] = sem
+	}
+	return sem
+}
+
+// hostPollInterval is how often refs waiting on busy hosts are retried, for
+// slots freed by another scrape sharing the limiter
+const hostPollInterval = 100 * time.Millisecond
+
+// tryHostSlot takes a slot for the thread URL's host if one is free, returning
+// the function that releases it. A board's www. and m. hosts share its slots, as
+// they are one server. Local files need none.
+func (fs *ForumScraperGo) tryHostSlot(threadURL string) (func(), bool) {
+	if isFileURL(threadURL) {
+		return func() {}, true
+	}
+	host := siteHost(hostOf(threadURL))
+	var held []chan struct{}
+	release := func() {
+		for _, hostSem := range held {
+			<-hostSem
+		}
+	}
+	for limiter := fs.hostSlots; limiter != nil; limiter = limiter.parent {
+		hostSem := limiter.slot(host)
+		select {
+		case hostSem <- struct{}{}:
+			held = append(held, hostSem)
+		default:
+			release()
+			return nil, false
+		}
+	}
+	return release, true
+}
+
+// hostDispatch is a ref whose host slot is held, with the function releasing it
+type hostDispatch struct {
+	ref     ThreadRef
+	release func()
+}
+
+// dispatchByHost hands refs on to workers only once their host has a free slot,
+// so a worker never sits waiting on one busy host while threads on others queue
+// behind it. Refs for busy hosts wait, up to pipelineBuffer of them, in the order
+// they came; workers signal freed when they release a slot.
+func (fs *ForumScraperGo) dispatchByHost(refs <-chan ThreadRef, freed <-chan struct{}) <-chan hostDispatch {
+	ready := make(chan hostDispatch)
+	go func() {
+		defer close(ready)
+		var pending []ThreadRef
+		in := refs
+		for {
+			for i := 0; i < len(pending); {
+				release, ok := fs.tryHostSlot(pending[i].URL)
+				if !ok {
+					i++
+					continue
+				}
+				ready <- hostDispatch{ref: pending[i], release: release}
+				pending = slices.Delete(pending, i, i+1)
+			}
+			if in == nil && len(pending) == 0 {
+				return
+			}
+
+			accept := in
+			if len(pending) >= pipelineBuffer {
+				accept = nil
+			}
+			var poll <-chan time.Time
+			if len(pending) > 0 {
+				poll = time.After(hostPollInterval)
+			}
+			select {
+			case ref, ok := <-accept:
+				if !ok {
+					in = nil
+					continue
+				}
+				pending = append(pending, ref)
+			case <-freed:
+			case <-poll:
+			}
+		}
+	}()
+	return ready
+}
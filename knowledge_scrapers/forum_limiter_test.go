@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// topicHost serves the phpBB fixture topic as topic t=<n> for any n, after delay
+func topicHost(t *testing.T, delay time.Duration) *httptest.Server {
+	t.Helper()
+	page, err := os.ReadFile(filepath.Join(fixturesDir, "phpbb/viewtopic.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, strings.ReplaceAll(string(page), "t=101", "t="+r.URL.Query().Get("t")))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestBusyHostDoesNotBlockOthers(t *testing.T) {
+	slow := topicHost(t, 200*time.Millisecond)
+	fast := topicHost(t, 0)
+	// The hosts differ by name, not port, as host slots ignore ports
+	fastURL := strings.Replace(fast.URL, "127.0.0.1", "localhost", 1)
+
+	refs := []ThreadRef{
+		{URL: slow.URL + "/viewtopic.php?f=2&t=1"},
+		{URL: slow.URL + "/viewtopic.php?f=2&t=2"},
+		{URL: slow.URL + "/viewtopic.php?f=2&t=3"},
+		{URL: fastURL + "/viewtopic.php?f=2&t=4"},
+	}
+	scraper := NewForumScraper("phpbb", 0, WithConcurrency(2, 1))
+	scraper.statusOut = io.Discard
+
+	var order []string
+	err := scraper.scrapeRefsEach(refs, fixtureMaxPosts, func(thread *ForumThread) error {
+		order = append(order, thread.ThreadID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 4 {
+		t.Fatalf("scraped threads %v, want all 4", order)
+	}
+	// The fast host's thread runs beside the first slow one, not once a worker
+	// is done waiting on the slow host
+	if slices.Index(order, "4") > 1 {
+		t.Errorf("thread order %v: the thread on the free host waited for the busy one", order)
+	}
+}
+
+// inFlightRecorder serves the phpBB fixture topic after delay, recording the most
+// requests it had in flight at once for each board, www. and m. hosts included
+type inFlightRecorder struct {
+	page  string
+	delay time.Duration
+
+	mu       sync.Mutex
+	inFlight map[string]int
+	max      map[string]int
+}
+
+func (rec *inFlightRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/viewtopic.php" {
+		http.NotFound(w, r)
+		return
+	}
+	board := siteHost(hostOf("http://" + r.Host))
+	rec.mu.Lock()
+	rec.inFlight[board]++
+	rec.max[board] = max(rec.max[board], rec.inFlight[board])
+	rec.mu.Unlock()
+	defer func() {
+		rec.mu.Lock()
+		rec.inFlight[board]--
+		rec.mu.Unlock()
+	}()
+
+	time.Sleep(rec.delay)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	io.WriteString(w, strings.ReplaceAll(rec.page, "t=101", "t="+r.URL.Query().Get("t")))
+}
+
+func TestPerHostLimitHolds(t *testing.T) {
+	page, err := os.ReadFile(filepath.Join(fixturesDir, "phpbb/viewtopic.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := &inFlightRecorder{page: string(page), delay: 50 * time.Millisecond, inFlight: make(map[string]int), max: make(map[string]int)}
+	server := httptest.NewServer(rec)
+	t.Cleanup(server.Close)
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	const perHost = 2
+	scraper := NewForumScraper("phpbb", 0, WithConcurrency(10, perHost))
+	scraper.statusOut = io.Discard
+	// Every host name resolves to the test server
+	hosts := []string{"board.test", "www.board.test", "m.board.test", "other.test"}
+	for _, host := range hosts {
+		scraper.dns.entries[host] = dnsEntry{addrs: []net.IPAddr{{IP: net.IPv4(127, 0, 0, 1)}}, expires: time.Now().Add(time.Hour)}
+	}
+
+	var refs []ThreadRef
+	for i := 0; i < 24; i++ {
+		refs = append(refs, ThreadRef{URL: fmt.Sprintf("http://%s:%s/viewtopic.php?f=2&t=%d", hosts[i%len(hosts)], port, i+1)})
+	}
+	scraped := 0
+	err = scraper.scrapeRefsEach(refs, fixtureMaxPosts, func(*ForumThread) error {
+		scraped++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scraped != len(refs) {
+		t.Fatalf("scraped %d threads, want %d", scraped, len(refs))
+	}
+	for _, board := range []string{"board.test", "other.test"} {
+		if got := rec.max[board]; got > perHost {
+			t.Errorf("%s had %d requests in flight at once, over the per-host limit of %d", board, got, perHost)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Local input reads an already-downloaded copy of a forum, such as a wget mirror,
+// through the same fetch and parse path as a live one. A directory or file:// URL
+// given as a source is served by localTransport under file:// URLs, so relative
+// links resolve against the mirror's own layout; directories are walked for
+// thread files instead of being parsed as an index page.
+
+// fileURL returns the file:// URL of an absolute path
+func fileURL(absPath string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(absPath)}).String()
+}
+
+// isFileURL reports whether rawURL is a file:// URL
+func isFileURL(rawURL string) bool {
+	return len(rawURL) >= 7 && strings.EqualFold(rawURL[:7], "file://")
+}
+
+// localSourceURL returns source as a file:// URL when it is one or names an
+// existing file or directory, and whether it did
+func localSourceURL(source string) (string, bool) {
+	if isFileURL(source) {
+		u, err := url.Parse(source)
+		if err != nil {
+			return source, false
+		}
+		return fileURL(u.Path), true
+	}
+	if strings.Contains(source, "://") {
+		return source, false
+	}
+	if _, err := os.Stat(source); err != nil {
+		return source, false
+	}
+	abs, err := filepath.Abs(source)
+	if err != nil {
+		return source, false
+	}
+	return fileURL(abs), true
+}
+
+// urlPatternTarget is what thread URL and ID patterns are matched against: the
+// URL itself, or for a file:// URL its unescaped path, where a mirrored
+// "viewtopic.php?t=1.html" keeps its question mark
+func urlPatternTarget(rawURL string) string {
+	if !isFileURL(rawURL) {
+		return rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	if u.RawQuery != "" {
+		return u.Path + "?" + u.RawQuery
+	}
+	return u.Path
+}
+
+// localFilePath returns the file a file:// URL names. Mirrors save pages with a
+// query string under file names that include it, often with .html appended, so
+// those are tried first; a URL with a query never falls back to the bare path,
+// which would be some other page. Directories are served by their index.html.
+func localFilePath(u *url.URL) (string, os.FileInfo, error) {
+	base := filepath.FromSlash(u.Path)
+	var candidates []string
+	if u.RawQuery != "" {
+		query := u.RawQuery
+		if unescaped, err := url.QueryUnescape(query); err == nil && unescaped != query {
+			candidates = append(candidates, base+"?"+unescaped, base+"?"+unescaped+".html")
+		}
+		candidates = append(candidates, base+"?"+query, base+"?"+query+".html")
+	} else {
+		candidates = append(candidates, base, base+".html", filepath.Join(base, "index.html"))
+	}
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if err == nil && !info.IsDir() {
+			return candidate, info, nil
+		}
+	}
+	return "", nil, os.ErrNotExist
+}
+
+// localTransport answers GET and HEAD requests for file:// URLs from disk
+type localTransport struct{}
+
+func (localTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp := &http.Response{
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Body:       http.NoBody,
+		Request:    req,
+	}
+	status := func(code int) (*http.Response, error) {
+		resp.StatusCode = code
+		resp.Status = fmt.Sprintf("%d %s", code, http.StatusText(code))
+		return resp, nil
+	}
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return status(http.StatusMethodNotAllowed)
+	}
+
+	name, info, err := localFilePath(req.URL)
+	if err != nil {
+		return status(http.StatusNotFound)
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		if os.IsPermission(err) {
+			return status(http.StatusForbidden)
+		}
+		return status(http.StatusNotFound)
+	}
+	contentType, err := localContentType(file, name)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	resp.Header.Set("Content-Type", contentType)
+	resp.Header.Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	resp.Header.Set("Last-Modified", info.ModTime().UTC().Format(http.TimeFormat))
+	resp.ContentLength = info.Size()
+	if req.Method == http.MethodHead {
+		file.Close()
+	} else {
+		resp.Body = file
+	}
+	return status(http.StatusOK)
+}
+
+// localContentType declares .html files as HTML and sniffs the rest, since
+// mirrored pages are often saved under their original .php name or none at all
+func localContentType(file *os.File, name string) (string, error) {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".html", ".htm", ".xhtml":
+		return "text/html; charset=utf-8", nil
+	}
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return http.DetectContentType(head[:n]), nil
+}
+
+// localDirectory returns the directory a file:// URL names, if it names one
+func localDirectory(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery != "" {
+		return "", false
+	}
+	dir := filepath.FromSlash(u.Path)
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return "", false
+	}
+	return dir, true
+}
+
+// discoverLocal walks a mirror directory for thread files: those matching
+// --file-glob when set, otherwise the platform's thread URL pattern. Where
+// several files hold pages of one thread, the one with the shortest name (the
+// first page, as mirrors save it) stands for the thread.
+func (fs *ForumScraperGo) discoverLocal(dir string, maxThreads int) ([]ThreadRef, error) {
+	fs.statusf("📂 Discovering thread files under: %s\n", dir)
+	pattern := fs.threadURLRegexp()
+	if fs.fileGlob == "" && pattern == nil {
+		return nil, fmt.Errorf("platform %s has no thread URL pattern to match files against; use --file-glob", fs.platform)
+	}
+	sourceURL := fileURL(dir)
+
+	var refs []ThreadRef
+	byID := make(map[string]int)
+	err := filepath.WalkDir(dir, func(name string, entry os.DirEntry, err error) error {
+		if err != nil {
+			fs.statusf("⚠️ Skipping %s: %v\n", name, err)
+			if entry != nil && entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return nil
+		}
+		rel = "/" + filepath.ToSlash(rel)
+
+		if fs.fileGlob != "" {
+			matched, _ := path.Match(fs.fileGlob, rel[1:])
+			if !matched {
+				matched, _ = path.Match(fs.fileGlob, path.Base(rel))
+			}
+			if !matched {
+				return nil
+			}
+		} else if !pattern.MatchString(rel) {
+			return nil
+		}
+
+		threadURL := fileURL(name)
+		if !fs.allowThreadURL(threadURL, sourceURL) {
+			return nil
+		}
+		if id := fs.extractThreadID(threadURL); id != "" {
+			if i, seen := byID[id]; seen {
+				if len(threadURL) < len(refs[i].URL) {
+					refs[i].URL = threadURL
+				}
+				return nil
+			}
+			byID[id] = len(refs)
+		}
+		refs = append(refs, ThreadRef{URL: threadURL, SourceURL: sourceURL})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(refs, func(i, j int) bool { return refs[i].URL < refs[j].URL })
+	if len(refs) > maxThreads {
+		refs = refs[:maxThreads]
+	}
+	fs.statusf("📊 Found %d thread files\n", len(refs))
+	return refs, nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// defaultExcerptChars is where --format markdown cuts a post excerpt short
+const defaultExcerptChars = 500
+
+// markdownSpecial are the characters escaped anywhere in scraped text
+var markdownSpecial = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`,
+	`<`, `\<`, `>`, `\>`, `|`, `\|`, `#`, `\#`, `~`, `\~`, `!`, `\!`, `&`, `\&`,
+)
+
+// markdownLineStart matches line openings that would start a list, heading rule or
+// numbered list once the rest of the line is escaped
+var markdownLineStart = regexp.MustCompile(`^(\s*)([-+=]|\d+\.)`)
+
+// escapeMarkdown makes scraped text render as the same text in Markdown
+func escapeMarkdown(text string) string {
+	lines := strings.Split(markdownSpecial.Replace(text), "\n")
+	for i, line := range lines {
+		lines[i] = markdownLineStart.ReplaceAllStringFunc(line, func(opening string) string {
+			trimmed := strings.TrimLeftFunc(opening, unicode.IsSpace)
+			indent := opening[:len(opening)-len(trimmed)]
+			if strings.HasSuffix(trimmed, ".") {
+				return indent + strings.TrimSuffix(trimmed, ".") + `\.`
+			}
+			return indent + `\` + trimmed
+		})
+	}
+	return strings.Join(lines, "\n")
+}
+
+// markdownURL makes a URL safe inside a Markdown link target
+func markdownURL(rawURL string) string {
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E").Replace(rawURL)
+}
+
+// headingSlug is the anchor GitHub-style renderers give a heading: lowercased,
+// punctuation dropped and spaces turned into hyphens, numbered when repeated
+func headingSlug(heading string, used map[string]int) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+	slug := b.String()
+	if n := used[slug]; n > 0 {
+		used[slug] = n + 1
+		return fmt.Sprintf("%s-%d", slug, n)
+	}
+	used[slug] = 1
+	return slug
+}
+
+// excerptPost picks the post a thread's report section quotes: its accepted answer
+// when it has one, the opening post otherwise
+func excerptPost(thread *ForumThread) (*ForumPost, bool) {
+	for i := range thread.Posts {
+		if thread.Posts[i].IsAcceptedAnswer {
+			return &thread.Posts[i], true
+		}
+	}
+	if len(thread.Posts) > 0 {
+		return &thread.Posts[0], false
+	}
+	return nil, false
+}
+
+// markdownThread writes one thread's section under a heading of the given level
+func (fs *ForumScraperGo) markdownThread(b *strings.Builder, thread *ForumThread, level int) {
+	link := markdownURL(thread.URL)
+	fmt.Fprintf(b, "%s %s\n\n", strings.Repeat("#", level), escapeMarkdown(thread.Title))
+
+	stats := []string{fmt.Sprintf("%d posts", len(thread.Posts))}
+	if thread.ViewsCount != nil {
+		stats = append(stats, fmt.Sprintf("%d views", *thread.ViewsCount))
+	}
+	if thread.CreatedAt != "" || thread.LastPostAt != "" {
+		stats = append(stats, escapeMarkdown(strings.Trim(thread.CreatedAt+" – "+thread.LastPostAt, " –")))
+	}
+	fmt.Fprintf(b, "[Original thread](%s) · %s\n\n", link, strings.Join(stats, " · "))
+
+	post, accepted := excerptPost(thread)
+	if post == nil {
+		return
+	}
+	if accepted {
+		fmt.Fprintf(b, "Accepted answer by **%s**:\n\n", escapeMarkdown(post.Author))
+	} else {
+		fmt.Fprintf(b, "**%s** wrote:\n\n", escapeMarkdown(post.Author))
+	}
+	content := []rune(post.Content)
+	excerpt := escapeMarkdown(string(content))
+	if fs.excerptChars > 0 && len(content) > fs.excerptChars {
+		excerpt = escapeMarkdown(strings.TrimSpace(string(content[:fs.excerptChars]))) + "… [read more](" + link + ")"
+	}
+	for _, line := range strings.Split(excerpt, "\n") {
+		fmt.Fprintf(b, "> %s\n", line)
+	}
+	b.WriteString("\n")
+}
+
+// markdownReport renders threads as one Markdown document under title, with a
+// table of contents. Thread headings sit one level below the category headings
+// when byCategory is set.
+func (fs *ForumScraperGo) markdownReport(title string, groups []*categoryGroup, byCategory bool) string {
+	var b strings.Builder
+	used := make(map[string]int)
+	headingSlug(title, used)
+	headingSlug("Contents", used)
+	fmt.Fprintf(&b, "# %s\n\n", escapeMarkdown(title))
+
+	// Slugs are worked out in document order so repeated titles number as the renderer does
+	threadLevel := 2
+	if byCategory {
+		threadLevel = 3
+	}
+	categorySlugs := make(map[*categoryGroup]string)
+	threadSlugs := make(map[*ForumThread]string)
+	for _, group := range groups {
+		if byCategory {
+			categorySlugs[group] = headingSlug(group.name, used)
+		}
+		for _, thread := range group.threads {
+			threadSlugs[thread] = headingSlug(thread.Title, used)
+		}
+	}
+
+	b.WriteString("## Contents\n\n")
+	for _, group := range groups {
+		indent := ""
+		if byCategory {
+			fmt.Fprintf(&b, "- [%s](#%s)\n", escapeMarkdown(group.name), categorySlugs[group])
+			indent = "  "
+		}
+		for _, thread := range group.threads {
+			fmt.Fprintf(&b, "%s- [%s](#%s)\n", indent, escapeMarkdown(thread.Title), threadSlugs[thread])
+		}
+	}
+	b.WriteString("\n")
+
+	for _, group := range groups {
+		if byCategory {
+			fmt.Fprintf(&b, "## %s\n\n", escapeMarkdown(group.name))
+		}
+		for _, thread := range group.threads {
+			fs.markdownThread(&b, thread, threadLevel)
+		}
+	}
+	return b.String()
+}
+
+// saveMarkdownReport writes threads as Markdown for --format markdown: one file per
+// category in a directory named like the results file, or everything in one .md
+// file with --single-file. It returns the paths written.
+func (fs *ForumScraperGo) saveMarkdownReport(threads []*ForumThread, name string) ([]string, error) {
+	threads, base := fs.exportTarget(threads, name)
+	groups := groupByCategory(threads)
+
+	if fs.singleFile {
+		path := base + ".md"
+		report := fs.markdownReport(fmt.Sprintf("%s threads", fs.platform), groups, true)
+		if err := writeFileAtomic(path, []byte(report), 0644); err != nil {
+			return nil, err
+		}
+		fs.statusf("💾 Markdown report saved to: %s\n", path)
+		return []string{path}, nil
+	}
+
+	var paths []string
+	used := make(map[string]bool)
+	for _, group := range groups {
+		// Categories whose names sanitize alike get a numeric suffix
+		filename := sanitizeFilename(group.name)
+		for n := 2; used[strings.ToLower(filename)]; n++ {
+			filename = fmt.Sprintf("%s-%d", sanitizeFilename(group.name), n)
+		}
+		used[strings.ToLower(filename)] = true
+
+		path := filepath.Join(base, filename+".md")
+		report := fs.markdownReport(group.name, []*categoryGroup{group}, false)
+		if err := writeFileAtomic(path, []byte(report), 0644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	fs.statusf("💾 Markdown report saved to: %s (%d categories)\n", base, len(paths))
+	return paths, nil
+}
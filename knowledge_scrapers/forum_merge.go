@@ -0,0 +1,385 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// openResultsFile opens a results file, transparently decompressing gzip
+func openResultsFile(path string) (io.Reader, io.Closer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	body := bufio.NewReader(file)
+	if magic, err := body.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			file.Close()
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return gz, file, nil
+	}
+	return body, file, nil
+}
+
+// resultsHeader is what a results envelope says about its threads
+type resultsHeader struct {
+	forumType string
+	// runs are the runs that produced the threads: the envelope's run, or a merged
+	// file's source runs
+	runs []*RunMetadata
+}
+
+// readResultsFile streams the threads of a results file to fn one at a time.
+// .jsonl files hold one thread record per line; anything else is a results envelope,
+// whose header is returned. Files with an incompatible schema version are rejected.
+func readResultsFile(path string, fn func(*ForumThread) error) (resultsHeader, error) {
+	var header resultsHeader
+	body, closer, err := openResultsFile(path)
+	if err != nil {
+		return header, err
+	}
+	defer closer.Close()
+
+	decoder := json.NewDecoder(body)
+	if strings.HasSuffix(strings.TrimSuffix(path, ".gz"), ".jsonl") {
+		for line := 1; ; line++ {
+			record := threadRecord{ForumThread: &ForumThread{}}
+			if err := decoder.Decode(&record); err == io.EOF {
+				return header, nil
+			} else if err != nil {
+				return header, fmt.Errorf("%s: %w", path, err)
+			}
+			if record.SchemaVersion != "" {
+				if err := checkSchemaVersion(record.SchemaVersion); err != nil {
+					return header, fmt.Errorf("%s: record %d: %w", path, line, err)
+				}
+			}
+			backfillThreadID(record.ForumThread, "")
+			if err := fn(record.ForumThread); err != nil {
+				return header, err
+			}
+		}
+	}
+
+	if err := expectDelim(decoder, '{'); err != nil {
+		return header, fmt.Errorf("%s: not a results file: %w", path, err)
+	}
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return header, fmt.Errorf("%s: %w", path, err)
+		}
+		switch key {
+		case "schema_version":
+			var raw json.RawMessage
+			if err := decoder.Decode(&raw); err != nil {
+				return header, fmt.Errorf("%s: %w", path, err)
+			}
+			version, err := parseSchemaVersion(raw)
+			if err == nil {
+				err = checkSchemaVersion(version)
+			}
+			if err != nil {
+				return header, fmt.Errorf("%s: %w", path, err)
+			}
+		case "forum_type":
+			if err := decoder.Decode(&header.forumType); err != nil {
+				return header, fmt.Errorf("%s: %w", path, err)
+			}
+		case "run":
+			var run RunMetadata
+			if err := decoder.Decode(&run); err != nil {
+				return header, fmt.Errorf("%s: %w", path, err)
+			}
+			header.runs = append(header.runs, &run)
+		case "source_runs":
+			var runs []*RunMetadata
+			if err := decoder.Decode(&runs); err != nil {
+				return header, fmt.Errorf("%s: %w", path, err)
+			}
+			header.runs = append(header.runs, runs...)
+		case "threads":
+			if err := expectDelim(decoder, '['); err != nil {
+				return header, fmt.Errorf("%s: %w", path, err)
+			}
+			for decoder.More() {
+				var thread ForumThread
+				if err := decoder.Decode(&thread); err != nil {
+					return header, fmt.Errorf("%s: %w", path, err)
+				}
+				backfillThreadID(&thread, header.forumType)
+				if err := fn(&thread); err != nil {
+					return header, err
+				}
+			}
+			if err := expectDelim(decoder, ']'); err != nil {
+				return header, fmt.Errorf("%s: %w", path, err)
+			}
+		default:
+			var skip json.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return header, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	}
+	return header, nil
+}
+
+// expectDelim reads the next JSON token and checks it is the given delimiter
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, got %v", delim, token)
+	}
+	return nil
+}
+
+// threadMerger dedupes threads by threadKey (host and thread ID, or normalized URL),
+// keeping the most recently scraped copy and folding in posts only an older copy
+// has. An index pass counts each thread's copies first, so the merge pass can hand
+// a thread on as soon as its last copy is read and only holds threads whose
+// remaining copies are still to come.
+type threadMerger struct {
+	copies     map[string]int
+	pending    map[string]*ForumThread
+	threads    int
+	duplicates int
+}
+
+func newThreadMerger() *threadMerger {
+	return &threadMerger{copies: make(map[string]int), pending: make(map[string]*ForumThread)}
+}
+
+// index counts one copy of a thread, ahead of the merge pass
+func (m *threadMerger) index(thread *ForumThread) {
+	key := threadKey(thread)
+	if m.copies[key] == 0 {
+		m.threads++
+	} else {
+		m.duplicates++
+	}
+	m.copies[key]++
+}
+
+// add merges one copy of a thread with the copies read before it, returning the
+// merged thread once its last copy has been read and nil until then
+func (m *threadMerger) add(thread *ForumThread) *ForumThread {
+	key := threadKey(thread)
+	if existing, ok := m.pending[key]; ok {
+		newer, older := thread, existing
+		if existing.ScrapedAt.After(thread.ScrapedAt) {
+			newer, older = existing, thread
+		}
+		newer.Posts = mergePosts(newer.Posts, older.Posts)
+		thread = newer
+	}
+	m.copies[key]--
+	if m.copies[key] > 0 {
+		m.pending[key] = thread
+		return nil
+	}
+	delete(m.pending, key)
+	return thread
+}
+
+// mergePosts combines two copies of a thread's posts, matched as diff matches them,
+// preferring whichever copy of each post was scraped more recently
+func mergePosts(a, b []ForumPost) []ForumPost {
+	var merged []ForumPost
+	matcher := newPostMatcher()
+	for _, posts := range [][]ForumPost{a, b} {
+		for _, post := range posts {
+			if index, ok := matcher.find(post); ok {
+				if post.ScrapedAt.After(merged[index].ScrapedAt) {
+					merged[index] = post
+					matcher.add(index, post)
+				}
+				continue
+			}
+			matcher.add(len(merged), post)
+			merged = append(merged, post)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].PostNumber < merged[j].PostNumber })
+	return merged
+}
+
+// runMerge implements the merge subcommand: combine result files into one deduplicated corpus
+func runMerge(args []string) {
+	fset := flag.NewFlagSet("forum_scraper merge", flag.ExitOnError)
+	fset.Usage = func() {
+		fmt.Println("Usage: forum_scraper merge [flags] <results_file>...")
+		fmt.Println("Example: forum_scraper merge --output corpus.json scraping_results/forum_scrape_*.json")
+		fset.PrintDefaults()
+	}
+	format := fset.String("format", "json", "output format (json or jsonl)")
+	output := fset.String("output", "", "output file name, or a path with a directory to bypass --output-dir (jsonl default: stdout)")
+	outputDir := fset.String("output-dir", defaultOutputDir, "directory for the merged results file")
+
+	files, err := parseInterleaved(fset, args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(files) == 0 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	if *format != "json" && *format != "jsonl" {
+		log.Fatalf("❌ Unsupported merge format: %s", *format)
+	}
+
+	merger := newThreadMerger()
+	platform := ""
+	read := 0
+	var first *ForumThread
+	var runs []*RunMetadata
+	seenRuns := make(map[string]bool)
+	for _, path := range files {
+		header, err := readResultsFile(path, func(thread *ForumThread) error {
+			read++
+			if first == nil {
+				first = &ForumThread{URL: thread.URL, SourceURL: thread.SourceURL}
+			}
+			merger.index(thread)
+			return nil
+		})
+		if err != nil {
+			log.Fatalf("❌ Merge failed: %v", err)
+		}
+		switch {
+		case platform == "":
+			platform = header.forumType
+		case header.forumType != "" && header.forumType != platform:
+			platform = "mixed"
+		}
+		for _, run := range header.runs {
+			if !seenRuns[run.RunID] {
+				seenRuns[run.RunID] = true
+				runs = append(runs, run)
+			}
+		}
+	}
+	if platform == "" {
+		platform = "merged"
+	}
+
+	// Merged threads keep the run IDs they were scraped with; the file lists those runs
+	scraper := NewForumScraper(platform, 0, WithOutputDir(*outputDir))
+	scraper.run, scraper.sourceRuns = nil, runs
+
+	// mergeThreads reads the files again, handing each thread to emit once merged
+	mergeThreads := func(emit func(*ForumThread) error) error {
+		for _, path := range files {
+			_, err := readResultsFile(path, func(thread *ForumThread) error {
+				if merged := merger.add(thread); merged != nil {
+					scraper.summary.add(merged)
+					return emit(merged)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	path := *output
+	switch {
+	case path == "" && *format == "json":
+		path = filepath.Join(*outputDir, scraper.resultsFilenameFor(first, merger.threads, scraper.now()))
+	case path != "" && path != "-" && filepath.Base(path) == path:
+		path = filepath.Join(*outputDir, path)
+	}
+	write := func(w io.Writer) error {
+		if *format == "json" {
+			return writeMergedEnvelope(w, scraper, mergeThreads)
+		}
+		encoder := json.NewEncoder(w)
+		return mergeThreads(func(thread *ForumThread) error {
+			return encoder.Encode(threadRecord{SchemaVersion: resultsSchemaVersion, ForumThread: thread})
+		})
+	}
+	if path == "" || path == "-" {
+		out := bufio.NewWriter(os.Stdout)
+		err = write(out)
+		if err == nil && *format == "json" {
+			io.WriteString(out, "\n")
+		}
+		if flushErr := out.Flush(); err == nil {
+			err = flushErr
+		}
+	} else if err = writeFileAtomicFunc(path, 0644, write); err == nil {
+		fmt.Fprintf(os.Stderr, "💾 Results saved to: %s\n", path)
+	}
+	if err != nil {
+		log.Fatalf("❌ Failed to save merged results: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "\n📊 Files merged: %d\n", len(files))
+	fmt.Fprintf(os.Stderr, "📊 Threads read: %d\n", read)
+	fmt.Fprintf(os.Stderr, "📊 Duplicates dropped: %d\n", merger.duplicates)
+	fmt.Fprintf(os.Stderr, "📊 Threads written: %d\n", merger.threads)
+	scraper.summary.printTable(os.Stderr)
+}
+
+// writeMergedEnvelope writes the merged threads as a results envelope. The
+// envelope's totals and stats come before its threads, so the threads are spooled
+// to a temporary file as they are merged, then streamed from it by writeEnvelopeFunc.
+func writeMergedEnvelope(w io.Writer, scraper *ForumScraperGo, mergeThreads func(emit func(*ForumThread) error) error) error {
+	spool, err := os.CreateTemp("", "forum_merge-*.jsonl")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	buffered := bufio.NewWriter(spool)
+	encoder := json.NewEncoder(buffered)
+	threads, posts := 0, 0
+	err = mergeThreads(func(thread *ForumThread) error {
+		threads++
+		posts += len(thread.Posts)
+		return encoder.Encode(thread)
+	})
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	results := scraper.resultsEnvelope(nil)
+	results.TotalThreads, results.TotalPosts = threads, posts
+	decoder := json.NewDecoder(bufio.NewReader(spool))
+	return writeEnvelopeFunc(w, results, func(emit func(*ForumThread) error) error {
+		for {
+			var thread ForumThread
+			if err := decoder.Decode(&thread); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if err := emit(&thread); err != nil {
+				return err
+			}
+		}
+	})
+}
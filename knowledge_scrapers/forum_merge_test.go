@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// writeMergeInput saves threads as a results file for merge to read
+func writeMergeInput(t *testing.T, path string, threads ...*ForumThread) {
+	t.Helper()
+	scraper := NewForumScraper("phpbb", 0)
+	var out bytes.Buffer
+	if err := writeEnvelope(&out, scraper.resultsEnvelope(threads), threads); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mergeTestThread(id string, scrapedAt time.Time, posts ...int) *ForumThread {
+	thread := &ForumThread{
+		URL:       "https://forum.example.com/viewtopic.php?t=" + id,
+		ThreadID:  id,
+		Title:     "Thread " + id,
+		ScrapedAt: scrapedAt,
+	}
+	for _, number := range posts {
+		thread.Posts = append(thread.Posts, ForumPost{PostNumber: number, Content: "post", ScrapedAt: scrapedAt})
+	}
+	return thread
+}
+
+func TestMergeWritesDeduplicatedEnvelope(t *testing.T) {
+	dir := t.TempDir()
+	older, newer := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	writeMergeInput(t, filepath.Join(dir, "a.json"), mergeTestThread("1", older, 1, 2), mergeTestThread("2", older, 1))
+	writeMergeInput(t, filepath.Join(dir, "b.json"), mergeTestThread("3", newer, 1), mergeTestThread("1", newer, 2, 3))
+
+	output := filepath.Join(dir, "corpus.json")
+	runMerge([]string{"--output", output, filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")})
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results ResultsEnvelope
+	if err := json.Unmarshal(data, &results); err != nil {
+		t.Fatalf("merged file is not a results envelope: %v", err)
+	}
+	if results.TotalThreads != 3 || len(results.Threads) != 3 {
+		t.Fatalf("merged %d threads (total_threads %d), want 3", len(results.Threads), results.TotalThreads)
+	}
+	if results.TotalPosts != 5 || results.Stats.Posts != 5 {
+		t.Errorf("total_posts %d, stats posts %d, want 5", results.TotalPosts, results.Stats.Posts)
+	}
+	for _, thread := range results.Threads {
+		if thread.ThreadID != "1" {
+			continue
+		}
+		if !thread.ScrapedAt.Equal(newer) || len(thread.Posts) != 3 {
+			t.Errorf("thread 1 scraped %v with %d posts, want the newer copy with 3", thread.ScrapedAt, len(thread.Posts))
+		}
+	}
+
+	// The document is laid out exactly as json.MarshalIndent would write it
+	want, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Error("merged envelope is not laid out as json.MarshalIndent would")
+	}
+}
+
+func TestMergeWritesJSONL(t *testing.T) {
+	dir := t.TempDir()
+	scrapedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeMergeInput(t, filepath.Join(dir, "a.json"), mergeTestThread("1", scrapedAt, 1))
+	writeMergeInput(t, filepath.Join(dir, "b.json"), mergeTestThread("1", scrapedAt, 2), mergeTestThread("2", scrapedAt, 1))
+
+	output := filepath.Join(dir, "corpus.jsonl")
+	runMerge([]string{"--format", "jsonl", "--output", output, filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")})
+
+	var threads []*ForumThread
+	if _, err := readResultsFile(output, func(thread *ForumThread) error {
+		threads = append(threads, thread)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(threads) != 2 {
+		t.Fatalf("merged %d threads, want 2", len(threads))
+	}
+}
+
+// Only threads with copies still to come are held while merging
+func TestThreadMergerHoldsOnlyPendingDuplicates(t *testing.T) {
+	scrapedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	inputs := []*ForumThread{
+		mergeTestThread("1", scrapedAt, 1),
+		mergeTestThread("2", scrapedAt, 1),
+		mergeTestThread("1", scrapedAt, 2),
+		mergeTestThread("3", scrapedAt, 1),
+	}
+	merger := newThreadMerger()
+	for _, thread := range inputs {
+		merger.index(thread)
+	}
+
+	var emitted []string
+	for _, thread := range inputs {
+		if merged := merger.add(thread); merged != nil {
+			emitted = append(emitted, merged.ThreadID)
+		}
+		if len(merger.pending) > 1 {
+			t.Fatalf("holding %d threads, want at most 1", len(merger.pending))
+		}
+	}
+	if want := []string{"2", "1", "3"}; !slices.Equal(emitted, want) {
+		t.Errorf("emitted %v, want %v", emitted, want)
+	}
+}
+
+func TestMergePostsMatchesPostIDs(t *testing.T) {
+	older := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(24 * time.Hour)
+	post := func(number int, id, content string, scrapedAt time.Time) ForumPost {
+		return ForumPost{PostNumber: number, PostID: id, Content: content, ScrapedAt: scrapedAt}
+	}
+	// p2 was deleted between the scrapes, so p3 moved up to number 2
+	a := []ForumPost{post(1, "p1", "first", older), post(2, "p2", "second", older), post(3, "p3", "third", older)}
+	b := []ForumPost{post(1, "p1", "first", newer), post(2, "p3", "third, edited", newer)}
+
+	var got []string
+	for _, p := range mergePosts(a, b) {
+		got = append(got, p.PostID+": "+p.Content)
+	}
+	// The deleted post is kept from the older copy, not overwritten by its successor
+	want := []string{"p1: first", "p2: second", "p3: third, edited"}
+	if !slices.Equal(got, want) {
+		t.Errorf("merged posts %v, want %v", got, want)
+	}
+}
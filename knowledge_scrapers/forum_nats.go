@@ -0,0 +1,73 @@
+//go:build nats
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func init() {
+	newNATSPublisher = openNATSPublisher
+}
+
+// natsKeyHeader carries the thread key, as NATS messages have no key of their own
+const natsKeyHeader = "Thread-Key"
+
+// natsPublisher publishes to one subject. With acks it publishes through
+// JetStream, which confirms each message once a stream has stored it; without,
+// it uses core NATS and only waits for the server to have received the batch.
+type natsPublisher struct {
+	conn    *nats.Conn
+	js      nats.JetStreamContext
+	subject string
+}
+
+func openNATSPublisher(url, subject, acks string) (busPublisher, error) {
+	switch acks {
+	case busAcksAll, busAcksOne, busAcksNone:
+	default:
+		return nil, fmt.Errorf("unknown acks level %q", acks)
+	}
+	conn, err := nats.Connect(url, nats.Name("forum-scraper"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	p := &natsPublisher{conn: conn, subject: subject}
+	if acks != busAcksNone {
+		if p.js, err = conn.JetStream(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (p *natsPublisher) Publish(ctx context.Context, messages []busMessage) error {
+	for _, message := range messages {
+		msg := nats.NewMsg(p.subject)
+		msg.Header.Set(natsKeyHeader, message.Key)
+		msg.Data = message.Value
+		if p.js != nil {
+			ackCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			_, err := p.js.PublishMsg(msg, nats.Context(ackCtx))
+			cancel()
+			if err != nil {
+				return err
+			}
+		} else if err := p.conn.PublishMsg(msg); err != nil {
+			return err
+		}
+	}
+	if p.js == nil {
+		return p.conn.FlushTimeout(10 * time.Second)
+	}
+	return nil
+}
+
+func (p *natsPublisher) Close() error {
+	return p.conn.Drain()
+}
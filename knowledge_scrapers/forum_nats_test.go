@@ -0,0 +1,76 @@
+//go:build nats
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// TestNATSSinkDelivers publishes a thread through a real JetStream server. It
+// runs only with FORUM_SCRAPER_TEST_NATS set to a server URL, for example against
+//
+//	docker run --rm -p 4222:4222 nats:2.10 -js
+//
+// and FORUM_SCRAPER_TEST_NATS=nats://127.0.0.1:4222 go test -tags nats -run NATS
+func TestNATSSinkDelivers(t *testing.T) {
+	serverURL := os.Getenv("FORUM_SCRAPER_TEST_NATS")
+	if serverURL == "" {
+		t.Skip("FORUM_SCRAPER_TEST_NATS not set")
+	}
+	subject := fmt.Sprintf("forum-scraper-test.%d", time.Now().UnixNano())
+	conn, err := nats.Connect(serverURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	js, err := conn.JetStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := fmt.Sprintf("FORUM_SCRAPER_TEST_%d", time.Now().UnixNano())
+	if _, err := js.AddStream(&nats.StreamConfig{Name: stream, Subjects: []string{subject}}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { js.DeleteStream(stream) })
+
+	publisher, err := openNATSPublisher(serverURL, subject, busAcksAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thread := busTestThread(4)
+	sink := newBusSink("nats", publisher, true, filepath.Join(t.TempDir(), "nats.spill"), io.Discard)
+	if err := sink.Write(context.Background(), thread); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := js.SubscribeSync(subject, nats.DeliverAll())
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := sub.NextMsg(10 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key := msg.Header.Get(natsKeyHeader); key != threadKey(thread) {
+		t.Errorf("%s header %q, want %q", natsKeyHeader, key, threadKey(thread))
+	}
+	var got ForumThread
+	if err := json.Unmarshal(msg.Data, &got); err != nil || len(got.Posts) != len(thread.Posts) {
+		t.Errorf("per-thread message: %d posts, %v; want %d", len(got.Posts), err, len(thread.Posts))
+	}
+	if _, err := sub.NextMsg(time.Second); err == nil {
+		t.Error("--per-thread published more than one message for the thread")
+	}
+}
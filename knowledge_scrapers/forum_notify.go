@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// --notify-match sends one notification per new post matching a pattern, to a
+// webhook or a command. Posts already notified are recorded by thread and content
+// hash in a state file, so scheduled runs over the same threads only report what
+// is new, and an edit that leaves the text alone (a bumped timestamp) stays quiet.
+
+// Notification event types
+const (
+	notifyEventMatch = "match"
+	notifyEventTest  = "test"
+)
+
+// notifySnippetContext is how many characters around a match the snippet keeps
+const notifySnippetContext = 80
+
+// notifyTimeout bounds one webhook call or command run
+const notifyTimeout = 30 * time.Second
+
+// notifyEvent is the JSON payload a notification carries
+type notifyEvent struct {
+	Event       string    `json:"event"`
+	Pattern     string    `json:"pattern"`
+	ThreadURL   string    `json:"thread_url"`
+	ThreadTitle string    `json:"thread_title"`
+	Snippet     string    `json:"snippet"`
+	Post        ForumPost `json:"post"`
+	NotifiedAt  string    `json:"notified_at"`
+}
+
+// notifyState is the state file: when each notified post was first reported,
+// keyed by notifyKey
+type notifyState struct {
+	Notified map[string]string `json:"notified"`
+}
+
+// notifyKey identifies a post across runs by its thread and content
+func notifyKey(threadURL string, post ForumPost) string {
+	return normalizeURL(threadURL) + " " + postHash(post)
+}
+
+// notifySink is a threadSink that notifies about new matching posts
+type notifySink struct {
+	match     *regexp.Regexp
+	webhook   string
+	command   string
+	statePath string
+	client    *http.Client
+	status    io.Writer
+
+	state  notifyState
+	sent   int
+	failed int
+}
+
+// newNotifySink loads statePath, if it exists, and returns a sink notifying the
+// webhook or command about posts matching match
+func newNotifySink(match *regexp.Regexp, webhook, command, statePath string, status io.Writer) (*notifySink, error) {
+	s := &notifySink{
+		match:     match,
+		webhook:   webhook,
+		command:   command,
+		statePath: statePath,
+		client:    &http.Client{Timeout: notifyTimeout},
+		status:    status,
+		state:     notifyState{Notified: make(map[string]string)},
+	}
+	data, err := os.ReadFile(statePath)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.state); err != nil {
+		return nil, fmt.Errorf("%s: %w", statePath, err)
+	}
+	if s.state.Notified == nil {
+		s.state.Notified = make(map[string]string)
+	}
+	return s, nil
+}
+
+func (s *notifySink) Name() string { return "notify" }
+
+// Write notifies about each matching post of thread not notified before. A post
+// whose notification fails is left out of the state, so the next run retries it.
+func (s *notifySink) Write(ctx context.Context, thread *ForumThread) error {
+	var firstErr error
+	changed := false
+	for _, post := range thread.Posts {
+		key := notifyKey(thread.URL, post)
+		if _, done := s.state.Notified[key]; done {
+			continue
+		}
+		snippet, ok := matchSnippet(s.match, post.Content)
+		if !ok {
+			continue
+		}
+		now := time.Now().UTC().Format(time.RFC3339)
+		event := notifyEvent{
+			Event:       notifyEventMatch,
+			Pattern:     s.match.String(),
+			ThreadURL:   thread.URL,
+			ThreadTitle: thread.Title,
+			Snippet:     snippet,
+			Post:        post,
+			NotifiedAt:  now,
+		}
+		if err := s.send(ctx, event); err != nil {
+			s.failed++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("post %d: %w", post.PostNumber, err)
+			}
+			continue
+		}
+		s.sent++
+		s.state.Notified[key] = now
+		changed = true
+	}
+	if changed {
+		if err := s.saveState(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("saving %s: %w", s.statePath, err)
+		}
+	}
+	return firstErr
+}
+
+// Close reports how many notifications the run sent
+func (s *notifySink) Close() error {
+	if s.sent > 0 || s.failed > 0 {
+		fmt.Fprintf(s.status, "🔔 Sent %d notifications (%d failed)\n", s.sent, s.failed)
+	}
+	return nil
+}
+
+// saveState writes the notified posts; their keys come out sorted, so the file diffs cleanly
+func (s *notifySink) saveState() error {
+	return writeFileAtomicFunc(s.statePath, 0644, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(s.state)
+	})
+}
+
+// send delivers one event to the webhook or the command
+func (s *notifySink) send(ctx context.Context, event notifyEvent) error {
+	var payload bytes.Buffer
+	encoder := json.NewEncoder(&payload)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(event); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+
+	if s.webhook != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.webhook, bytes.NewReader(payload.Bytes()))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := s.client.Do(req)
+		if err != nil {
+			// The error quotes the URL, whose path is the webhook's secret
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				urlErr.URL = maskURLPath(urlErr.URL)
+			}
+			return err
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("webhook answered HTTP %d", resp.StatusCode)
+		}
+		return nil
+	}
+
+	// The command gets the payload on stdin
+	cmd := exec.CommandContext(ctx, "sh", "-c", s.command)
+	cmd.Stdin = bytes.NewReader(payload.Bytes())
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, truncateRunes(msg, 200))
+		}
+		return err
+	}
+	return nil
+}
+
+// sendTest delivers a synthetic event so the wiring can be checked without a scrape
+func (s *notifySink) sendTest() error {
+	now := time.Now().UTC()
+	var pattern string
+	if s.match != nil {
+		pattern = s.match.String()
+	}
+	event := notifyEvent{
+		Event:       notifyEventTest,
+		Pattern:     pattern,
+		ThreadURL:   "https://forum.example.com/t/notification-test/1",
+		ThreadTitle: "Notification test",
+		Snippet:     "This is a test notification from forum_scraper",
+		Post: ForumPost{
+			URL:         "https://forum.example.com/t/notification-test/1#post1",
+			ThreadTitle: "Notification test",
+			Author:      "forum_scraper",
+			Content:     "This is a test notification from forum_scraper.",
+			PostNumber:  1,
+			ContentHash: contentHash("This is a test notification from forum_scraper."),
+			ScrapedAt:   now,
+		},
+		NotifiedAt: now.Format(time.RFC3339),
+	}
+	return s.send(context.Background(), event)
+}
+
+// matchSnippet returns the first match of re in content with up to
+// notifySnippetContext characters either side, and whether there was a match
+func matchSnippet(re *regexp.Regexp, content string) (string, bool) {
+	loc := re.FindStringIndex(content)
+	if loc == nil {
+		return "", false
+	}
+	before := []rune(content[:loc[0]])
+	after := []rune(content[loc[1]:])
+	prefix, suffix := "", ""
+	if len(before) > notifySnippetContext {
+		before = before[len(before)-notifySnippetContext:]
+		prefix = "…"
+	}
+	if len(after) > notifySnippetContext {
+		after = after[:notifySnippetContext]
+		suffix = "…"
+	}
+	return prefix + string(before) + content[loc[0]:loc[1]] + string(after) + suffix, true
+}
@@ -0,0 +1,589 @@
+package main
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Option configures a ForumScraperGo at construction time
+type Option func(*ForumScraperGo)
+
+// WithOutputDir sets the directory saveResults writes into (default ./scraping_results)
+func WithOutputDir(dir string) Option {
+	return func(fs *ForumScraperGo) {
+		fs.outputDir = dir
+	}
+}
+
+// WithFilenameTemplate sets the template saveResults uses to name result files
+func WithFilenameTemplate(template string) Option {
+	return func(fs *ForumScraperGo) {
+		fs.filenameTemplate = template
+	}
+}
+
+// WithSplit starts a new results part every maxBytes of thread records or maxThreads
+// threads, whichever comes first; 0 disables a limit
+func WithSplit(maxBytes int64, maxThreads int) Option {
+	return func(fs *ForumScraperGo) {
+		fs.splitSize = maxBytes
+		fs.splitThreads = maxThreads
+	}
+}
+
+// WithStackExchangeKey sends an API key with Stack Exchange API requests, raising the quota
+func WithStackExchangeKey(key string) Option {
+	return func(fs *ForumScraperGo) {
+		fs.stackExchangeKey = key
+	}
+}
+
+// WithAttachmentDownloads saves post attachments of up to maxSize bytes under dir
+func WithAttachmentDownloads(dir string, maxSize int64) Option {
+	return func(fs *ForumScraperGo) {
+		fs.downloadDir = dir
+		fs.maxAttachmentSize = maxSize
+	}
+}
+
+// WithFollowReferences scrapes the threads that scraped posts link to, up to hops
+// links away from the discovered threads
+func WithFollowReferences(hops int) Option {
+	return func(fs *ForumScraperGo) {
+		fs.followHops = hops
+	}
+}
+
+// WithOldReddit controls rewriting reddit URLs to old.reddit.com (on by default)
+func WithOldReddit(enabled bool) Option {
+	return func(fs *ForumScraperGo) {
+		fs.oldReddit = enabled
+	}
+}
+
+// WithLightweight fetches each thread through its platform's print view, such as
+// vBulletin's printthread.php, falling back to the thread page when it's disabled
+func WithLightweight(enabled bool) Option {
+	return func(fs *ForumScraperGo) {
+		fs.lightweight = enabled
+	}
+}
+
+// WithSelectiveParse prunes thread pages over 1 MB to their header area and posts
+// before extraction; disabling it extracts from every page whole
+func WithSelectiveParse(enabled bool) Option {
+	return func(fs *ForumScraperGo) {
+		fs.selectiveParse = enabled
+	}
+}
+
+// WithPostsMode limits post extraction: postsModeAll, postsModeFirst for the
+// opening post only, postsModeNone for thread metadata alone, or postsModeSolved
+// for the question and its accepted answer
+func WithPostsMode(mode string) Option {
+	return func(fs *ForumScraperGo) {
+		fs.postsMode = mode
+	}
+}
+
+// WithRanking orders saved threads by an engagement key (rankByViews, rankByReplies,
+// rankByRecent or rankByPosts) and keeps the top highest-ranked; top 0 keeps all
+func WithRanking(key string, top int) Option {
+	return func(fs *ForumScraperGo) {
+		fs.sortBy = key
+		fs.top = top
+	}
+}
+
+// WithPrioritization orders discovered threads before --max-threads cuts them;
+// pattern scores titles for title-match
+func WithPrioritization(order string, pattern *regexp.Regexp) Option {
+	return func(fs *ForumScraperGo) {
+		fs.prioritize = order
+		fs.priorityPattern = pattern
+	}
+}
+
+// WithDedupePosts drops posts whose normalized content was already seen in the run
+func WithDedupePosts(enabled bool) Option {
+	return func(fs *ForumScraperGo) {
+		fs.dedupePosts = enabled
+	}
+}
+
+// WithAnonymizedAuthors replaces author names with HMAC tokens keyed by salt;
+// an empty salt generates a random one, recorded in the results envelope
+func WithAnonymizedAuthors(salt string) Option {
+	return func(fs *ForumScraperGo) {
+		if salt == "" {
+			salt = newAnonymizationSalt()
+		}
+		fs.anonymizeSalt = salt
+	}
+}
+
+// WithQuoteStripping removes quoted replies from post content: quote elements
+// before a page's text is read, and quote lines from Hacker News posts
+func WithQuoteStripping(enabled bool) Option {
+	return func(fs *ForumScraperGo) {
+		fs.stripQuotes = enabled
+	}
+}
+
+// WithPIIRedaction replaces email addresses and phone numbers in post content with placeholders
+func WithPIIRedaction(enabled bool) Option {
+	return func(fs *ForumScraperGo) {
+		fs.redactPIIEnabled = enabled
+	}
+}
+
+// WithLanguages keeps only posts and threads detected as one of the given ISO 639-1 codes
+func WithLanguages(codes ...string) Option {
+	return func(fs *ForumScraperGo) {
+		fs.languages = make(map[string]bool, len(codes))
+		for _, code := range codes {
+			fs.languages[strings.ToLower(strings.TrimSpace(code))] = true
+		}
+	}
+}
+
+// WithPostProcessors appends processors to the post chain, after the built-in length check
+func WithPostProcessors(processors ...PostProcessor) Option {
+	return func(fs *ForumScraperGo) {
+		fs.postProcessors = append(fs.postProcessors, processors...)
+	}
+}
+
+// WithThreadFilters appends filters every scraped thread must pass
+func WithThreadFilters(filters ...ThreadFilter) Option {
+	return func(fs *ForumScraperGo) {
+		fs.threadFilters = append(fs.threadFilters, filters...)
+	}
+}
+
+// WithPostLength bounds post content in runes; maxRunes 0 means no upper bound.
+// With truncate, posts over maxRunes are cut with an ellipsis instead of dropped.
+func WithPostLength(minRunes, maxRunes int, truncate bool) Option {
+	return func(fs *ForumScraperGo) {
+		fs.minPostLength = minRunes
+		fs.maxPostLength = maxRunes
+		fs.truncateLongPosts = truncate
+	}
+}
+
+// WithNormalization turns the built-in whitespace, BBCode and control-character cleanup on or off (default on)
+func WithNormalization(enabled bool) Option {
+	return func(fs *ForumScraperGo) {
+		fs.skipNormalize = !enabled
+	}
+}
+
+// WithScoreQuery scores posts against query's terms and drops those scoring below minScore (0 keeps all)
+func WithScoreQuery(query string, minScore float64) Option {
+	return func(fs *ForumScraperGo) {
+		fs.scoreQuery = query
+		fs.minScore = minScore
+	}
+}
+
+// WithBudget caps the run's total posts and requests and sets a deadline measured from
+// construction; zero values leave a limit off
+func WithBudget(maxPosts, maxRequests int, deadline time.Duration) Option {
+	return func(fs *ForumScraperGo) {
+		fs.budget.maxPosts = int64(maxPosts)
+		fs.budget.maxRequests = int64(maxRequests)
+		if deadline > 0 {
+			fs.budget.deadline = time.Now().Add(deadline)
+		}
+	}
+}
+
+// WithThreadTimeout bounds how long one thread's fetches may take, pagination
+// included; a thread that runs out keeps the posts of the pages it fetched. Zero
+// leaves it unbounded.
+func WithThreadTimeout(d time.Duration) Option {
+	return func(fs *ForumScraperGo) {
+		fs.threadTimeout = d
+	}
+}
+
+// WithJitter varies each delay randomly by ± fraction of the configured delay
+func WithJitter(fraction float64) Option {
+	return func(fs *ForumScraperGo) {
+		fs.jitter = fraction
+	}
+}
+
+// WithRetries makes each request up to n more times after a timeout, a dropped
+// connection or a 429, 502, 503 or 504 response
+func WithRetries(n int) Option {
+	return func(fs *ForumScraperGo) {
+		fs.retries = n
+	}
+}
+
+// WithAdaptiveDelay doubles a host's delay while its responses are slow or
+// rate-limited, up to 16×, and decays it back as they recover
+func WithAdaptiveDelay(enabled bool) Option {
+	return func(fs *ForumScraperGo) {
+		fs.adaptiveDelay = enabled
+	}
+}
+
+// WithDebug turns on debug lines in the status output
+func WithDebug(enabled bool) Option {
+	return func(fs *ForumScraperGo) {
+		fs.debug = enabled
+	}
+}
+
+// WithUserAgents rotates requests through a pool of User-Agent strings, either sticky
+// per host ("sticky") or picked afresh for every request ("per-request")
+func WithUserAgents(agents []string, rotate string) Option {
+	return func(fs *ForumScraperGo) {
+		fs.userAgents = agents
+		fs.uaRotate = rotate
+	}
+}
+
+// WithConcurrency sets how many threads are scraped at once in total and per host
+func WithConcurrency(global, perHost int) Option {
+	return func(fs *ForumScraperGo) {
+		if global > 0 {
+			fs.threadSem = make(chan struct{}, global)
+		}
+		fs.hostSlots.limit = perHost
+	}
+}
+
+// WithRedirectPolicy caps redirects per request and sets whether they may leave the original host
+func WithRedirectPolicy(maxRedirects int, allowExternal bool) Option {
+	return func(fs *ForumScraperGo) {
+		fs.maxRedirects = maxRedirects
+		fs.allowExternal = allowExternal
+	}
+}
+
+// WithTLSConfig sets the TLS settings every request is made with
+func WithTLSConfig(config *tls.Config) Option {
+	return func(fs *ForumScraperGo) {
+		if transport, ok := fs.client.Transport.(*http.Transport); ok {
+			transport.TLSClientConfig = config
+		}
+	}
+}
+
+// WithDNSCache reuses each host's resolved addresses for ttl; zero resolves on every dial
+func WithDNSCache(ttl time.Duration) Option {
+	return func(fs *ForumScraperGo) {
+		fs.dns.ttl = ttl
+	}
+}
+
+// WithIPVersion dials only IPv4 ("4") or IPv6 ("6") addresses, or either ("auto"),
+// preferring the family the resolver lists first
+func WithIPVersion(version string) Option {
+	return func(fs *ForumScraperGo) {
+		fs.dns.ipVersion = version
+	}
+}
+
+// WithPrecheck sends a HEAD request (or a ranged GET when HEAD is refused) before
+// each thread fetch, skipping threads that are gone, not HTML or too large
+func WithPrecheck(enabled bool) Option {
+	return func(fs *ForumScraperGo) {
+		fs.precheck = enabled
+	}
+}
+
+// WithAuthorProfiles aggregates every scraped post by host and author, written to
+// path as JSON (or JSONL for a .jsonl path) when the run finishes
+func WithAuthorProfiles(path string) Option {
+	return func(fs *ForumScraperGo) {
+		fs.authorsPath = path
+	}
+}
+
+// WithCategoryFilter keeps only threads whose category matches an include pattern,
+// when any are given, and no exclude pattern. Patterns match case-insensitively,
+// as substrings or regexes.
+func WithCategoryFilter(include, exclude []string) Option {
+	return func(fs *ForumScraperGo) {
+		for _, pattern := range include {
+			fs.categories.include = append(fs.categories.include, newCategoryPattern(pattern))
+		}
+		for _, pattern := range exclude {
+			fs.categories.exclude = append(fs.categories.exclude, newCategoryPattern(pattern))
+		}
+	}
+}
+
+// WithQAAnswerProcessors appends processors a reply must pass to be chosen as a
+// --format qa-jsonl answer. They see a copy, so changes reach only the pair.
+func WithQAAnswerProcessors(processors ...PostProcessor) Option {
+	return func(fs *ForumScraperGo) {
+		fs.qaAnswerProcessors = append(fs.qaAnswerProcessors, processors...)
+	}
+}
+
+// WithQAThreadFilters appends filters a thread must pass to yield a Q&A pair
+func WithQAThreadFilters(filters ...ThreadFilter) Option {
+	return func(fs *ForumScraperGo) {
+		fs.qaThreadFilters = append(fs.qaThreadFilters, filters...)
+	}
+}
+
+// WithQAMinAnswer is the built-in answer heuristic: answers must be at least
+// minRunes long and, unless accepted, have at least minLikes likes
+func WithQAMinAnswer(minRunes, minLikes int) Option {
+	return WithQAAnswerProcessors(LengthProcessor{MinRunes: minRunes}, MinLikesProcessor{MinLikes: minLikes})
+}
+
+// WithChunking sets the --format chunks budget: chunks of at most tokens tokens,
+// each repeating up to overlap tokens of whole posts from the chunk before
+func WithChunking(tokens, overlap int) Option {
+	return func(fs *ForumScraperGo) {
+		fs.chunkTokens = tokens
+		fs.chunkOverlap = overlap
+	}
+}
+
+// WithTokenCounter replaces the four-characters-per-token estimate chunk budgets
+// are counted with, e.g. by a tiktoken-compatible tokenizer
+func WithTokenCounter(counter TokenCounter) Option {
+	return func(fs *ForumScraperGo) {
+		fs.tokenCounter = counter
+	}
+}
+
+// WithLocalInput lets sources be file:// URLs or local paths, read from disk
+// without delays; fileGlob, when set, selects the thread files of a directory
+func WithLocalInput(fileGlob string) Option {
+	return func(fs *ForumScraperGo) {
+		fs.localInput = true
+		fs.fileGlob = fileGlob
+		fs.client.Transport.(*http.Transport).RegisterProtocol("file", localTransport{})
+	}
+}
+
+// WithBasicAuth sends HTTP Basic credentials with every request
+func WithBasicAuth(user, pass string) Option {
+	return func(fs *ForumScraperGo) {
+		fs.auth.basicUser = user
+		fs.auth.basicPass = pass
+	}
+}
+
+// WithBearerToken sends token with every request, in the platform's token header
+// or as "Authorization: Bearer"
+func WithBearerToken(token string) Option {
+	return func(fs *ForumScraperGo) {
+		fs.auth.token = token
+	}
+}
+
+// WithTokenUser names the account the bearer token acts as, on platforms whose
+// tokens name one (Discourse's Api-Username)
+func WithTokenUser(user string) Option {
+	return func(fs *ForumScraperGo) {
+		fs.auth.tokenUser = user
+	}
+}
+
+// WithMaxBandwidth caps the combined download rate of all responses in bytes per second
+func WithMaxBandwidth(bytesPerSecond int64) Option {
+	return func(fs *ForumScraperGo) {
+		if bytesPerSecond > 0 {
+			fs.bandwidth = newBandwidthLimiter(bytesPerSecond)
+		}
+	}
+}
+
+// WithSeed makes jitter and User-Agent choices repeat between runs with the same seed
+func WithSeed(seed int64) Option {
+	return func(fs *ForumScraperGo) {
+		fs.seed = seed
+	}
+}
+
+// WithFixedTimestamps freezes every recorded scraped_at time, for golden-file comparisons
+func WithFixedTimestamps(enabled bool) Option {
+	return func(fs *ForumScraperGo) {
+		fs.fixedTimestamps = enabled
+	}
+}
+
+// WithTimeouts sets how long to wait to connect, for response headers, and for a whole
+// request including its body; zero leaves that limit off
+func WithTimeouts(connect, responseHeader, request time.Duration) Option {
+	return func(fs *ForumScraperGo) {
+		fs.dns.dialer.Timeout = connect
+		if transport, ok := fs.client.Transport.(*http.Transport); ok {
+			transport.ResponseHeaderTimeout = responseHeader
+		}
+		fs.requestTimeout = request
+	}
+}
+
+// WithStatusOutput sends the human-facing progress lines to w (default stderr);
+// io.Discard silences them
+func WithStatusOutput(w io.Writer) Option {
+	return func(fs *ForumScraperGo) {
+		fs.statusOut = w
+	}
+}
+
+// WithRunConfig records the run's effective configuration in the results
+// envelope, and its hash in the run metadata
+func WithRunConfig(config *RunConfig) Option {
+	return func(fs *ForumScraperGo) {
+		fs.runConfig = config
+	}
+}
+
+// WithIndexPages caps how many pages of one index discovery walks (default 10)
+func WithIndexPages(maxPages int) Option {
+	return func(fs *ForumScraperGo) {
+		fs.maxIndexPages = maxPages
+	}
+}
+
+// WithThreadPages fetches up to maxPages pages of each thread from its first page
+// on (default 1); with lastPage, a thread cut short also gets its final page
+func WithThreadPages(maxPages int, lastPage bool) Option {
+	return func(fs *ForumScraperGo) {
+		fs.maxPagesPerThread = maxPages
+		fs.lastPage = lastPage
+	}
+}
+
+// WithMaxResponseSize caps response bodies at maxBytes; 0 leaves them unbounded
+func WithMaxResponseSize(maxBytes int64) Option {
+	return func(fs *ForumScraperGo) {
+		fs.maxResponseSize = maxBytes
+	}
+}
+
+// WithSubforumCrawl descends maxDepth levels of subforums below each index page,
+// queuing up to maxForumsPerLevel of them at each level
+func WithSubforumCrawl(maxDepth, maxForumsPerLevel int) Option {
+	return func(fs *ForumScraperGo) {
+		fs.maxDepth = maxDepth
+		fs.maxForumsPerLevel = maxForumsPerLevel
+	}
+}
+
+// WithSitemap discovers threads from each source's sitemap instead of its index
+// pages when enabled, fetching up to maxSitemaps files per source either way
+func WithSitemap(enabled bool, maxSitemaps int) Option {
+	return func(fs *ForumScraperGo) {
+		fs.useSitemap = enabled
+		fs.maxSitemaps = maxSitemaps
+	}
+}
+
+// WithFeed discovers threads from an RSS/Atom feed, or from the feed each page
+// advertises for "auto"
+func WithFeed(feedURL string) Option {
+	return func(fs *ForumScraperGo) {
+		fs.feedURL = feedURL
+	}
+}
+
+// WithSearch discovers threads from the forum's search results for query
+func WithSearch(query string) Option {
+	return func(fs *ForumScraperGo) {
+		fs.searchQuery = query
+	}
+}
+
+// WithDiscourseCategory discovers threads from a Discourse category's listing, by name
+func WithDiscourseCategory(name string) Option {
+	return func(fs *ForumScraperGo) {
+		fs.categoryName = name
+	}
+}
+
+// WithUserHistory discovers the threads one member posted in, by name, ID or
+// both; with postsOnly, only the member's posts are emitted
+func WithUserHistory(name, id string, postsOnly bool) Option {
+	return func(fs *ForumScraperGo) {
+		fs.userName = name
+		fs.userID = id
+		fs.userPostsOnly = postsOnly
+	}
+}
+
+// WithSince drops discovered threads with no activity since t
+func WithSince(t time.Time) Option {
+	return func(fs *ForumScraperGo) {
+		fs.since = t
+	}
+}
+
+// WithWindow keeps only posts dated inside window, listing indexes by date where
+// the platform allows
+func WithWindow(window timeWindow) Option {
+	return func(fs *ForumScraperGo) {
+		fs.window = window
+	}
+}
+
+// WithThreadPattern identifies thread URLs by pattern instead of the platform's ThreadURLPattern
+func WithThreadPattern(pattern *regexp.Regexp) Option {
+	return func(fs *ForumScraperGo) {
+		fs.threadPattern = pattern
+	}
+}
+
+// WithURLFilter limits which discovered links are queued or followed
+func WithURLFilter(filter URLFilter) Option {
+	return func(fs *ForumScraperGo) {
+		fs.urlFilter = filter
+	}
+}
+
+// WithAccessLog records every request in log; the caller closes it
+func WithAccessLog(log *accessLog) Option {
+	return func(fs *ForumScraperGo) {
+		fs.accessLog = log
+	}
+}
+
+// WithObjectStore uploads results to store under prefix
+func WithObjectStore(store objectStore, prefix string) Option {
+	return func(fs *ForumScraperGo) {
+		fs.objectStore = store
+		fs.objectPrefix = prefix
+	}
+}
+
+// WithRenderer loads thread pages through renderer, in up to tabs tabs at once,
+// waiting up to timeout for a page's posts to appear; the caller closes it
+func WithRenderer(renderer pageRenderer, tabs int, timeout time.Duration) Option {
+	return func(fs *ForumScraperGo) {
+		fs.renderer = renderer
+		fs.renderSem = make(chan struct{}, tabs)
+		fs.renderTimeout = timeout
+	}
+}
+
+// WithMarkdownReport shapes --format markdown reports: one file instead of one per
+// category with singleFile, and quoted posts cut after excerptChars (0 for no limit)
+func WithMarkdownReport(singleFile bool, excerptChars int) Option {
+	return func(fs *ForumScraperGo) {
+		fs.singleFile = singleFile
+		fs.excerptChars = excerptChars
+	}
+}
+
+// WithSinks hands each thread to sinks as it is scraped; closeSinks closes them
+func WithSinks(sinks ...threadSink) Option {
+	return func(fs *ForumScraperGo) {
+		fs.sinks = append(fs.sinks, sinks...)
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultOutputDir is where results go unless --output-dir or WithOutputDir says otherwise
+const defaultOutputDir = "scraping_results"
+
+// defaultFilenameTemplate reproduces the historical forum_scrape_<platform>_<timestamp>.json names
+const defaultFilenameTemplate = "forum_scrape_{platform}_{date}_{time}.json"
+
+// templatePlaceholder matches one {name} placeholder in a filename template
+var templatePlaceholder = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// unsafeFilenameChars are characters not allowed in file names on common filesystems
+var unsafeFilenameChars = regexp.MustCompile(`[<>:"/\\|?*\x00-\x1f]`)
+
+// templateFields are the placeholders a filename template may use
+var templateFields = map[string]bool{"platform": true, "host": true, "date": true, "time": true, "threads": true}
+
+// validateFilenameTemplate rejects unknown placeholders, stray braces and directory separators
+func validateFilenameTemplate(template string) error {
+	if template == "" {
+		return fmt.Errorf("filename template is empty")
+	}
+	if strings.ContainsAny(template, `/\`) {
+		return fmt.Errorf("filename template %q must not contain a directory; use --output-dir", template)
+	}
+	for _, match := range templatePlaceholder.FindAllStringSubmatch(template, -1) {
+		if !templateFields[match[1]] {
+			return fmt.Errorf("unknown placeholder {%s} in filename template %q", match[1], template)
+		}
+	}
+	if strings.ContainsAny(templatePlaceholder.ReplaceAllString(template, ""), "{}") {
+		return fmt.Errorf("unbalanced braces in filename template %q", template)
+	}
+	return nil
+}
+
+// sanitizeFilename replaces characters that are illegal in file names with underscores
+func sanitizeFilename(name string) string {
+	return unsafeFilenameChars.ReplaceAllString(name, "_")
+}
+
+// resultsFilename expands the scraper's filename template for a finished run.
+// {host} is the host of the first thread's source, or "unknown" with no threads.
+func (fs *ForumScraperGo) resultsFilename(threads []*ForumThread, now time.Time) string {
+	var first *ForumThread
+	if len(threads) > 0 {
+		first = threads[0]
+	}
+	return fs.resultsFilenameFor(first, len(threads), now)
+}
+
+// resultsFilenameFor expands the filename template for a file of count threads
+// starting with first, for callers that stream the threads rather than hold them
+func (fs *ForumScraperGo) resultsFilenameFor(first *ForumThread, count int, now time.Time) string {
+	host := "unknown"
+	if first != nil {
+		source := first.SourceURL
+		if source == "" {
+			source = first.URL
+		}
+		if h := hostOf(source); h != "" {
+			host = h
+		}
+	}
+
+	values := map[string]string{
+		"platform": fs.platform,
+		"host":     host,
+		"date":     now.Format("20060102"),
+		"time":     now.Format("150405"),
+		"threads":  strconv.Itoa(count),
+	}
+	return templatePlaceholder.ReplaceAllStringFunc(fs.filenameTemplate, func(placeholder string) string {
+		return sanitizeFilename(values[placeholder[1:len(placeholder)-1]])
+	})
+}
+
+// writeFileAtomic writes data to a temp file beside path, fsyncs it and renames it
+// into place, so readers never see a truncated file even if the process dies mid-write
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	return writeFileAtomicFunc(path, perm, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// writeFileAtomicFunc is writeFileAtomic for content produced by write as it goes
+func writeFileAtomicFunc(path string, perm os.FileMode, write func(w io.Writer) error) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // no-op once the rename has succeeded
+
+	buffered := bufio.NewWriterSize(tmp, 64<<10)
+	if err := write(buffered); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		return err
+	}
+
+	// Sync the directory so the rename itself survives a power loss
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// writeEnvelope writes a results document as json.MarshalIndent would lay it out,
+// but encodes the threads one at a time, so a big run is never held in memory twice.
+// results.Threads must be empty; threads is the array written in its place.
+func writeEnvelope(w io.Writer, results *ResultsEnvelope, threads []*ForumThread) error {
+	return writeEnvelopeFunc(w, results, func(emit func(*ForumThread) error) error {
+		for _, thread := range threads {
+			if err := emit(thread); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// writeEnvelopeFunc is writeEnvelope for threads that each hands to emit one at
+// a time, so they never need to be in memory together
+func writeEnvelopeFunc(w io.Writer, results *ResultsEnvelope, each func(emit func(*ForumThread) error) error) error {
+	return writeEnvelopeRecords(w, results, func(emit func([]byte) error) error {
+		return each(func(thread *ForumThread) error {
+			record, err := marshalThreadRecord(thread)
+			if err != nil {
+				return err
+			}
+			return emit(record)
+		})
+	})
+}
+
+// marshalThreadRecord lays a thread out as it sits inside the envelope's threads array
+func marshalThreadRecord(thread *ForumThread) ([]byte, error) {
+	return json.MarshalIndent(thread, "    ", "  ")
+}
+
+// writeEnvelopeRecords is writeEnvelopeFunc for threads already laid out by
+// marshalThreadRecord, such as those read back from a resultsSpill
+func writeEnvelopeRecords(w io.Writer, results *ResultsEnvelope, each func(emit func(record []byte) error) error) error {
+	head, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Threads is the envelope's last field, so its value closes the document
+	const emptyTail = "[]\n}"
+	if !bytes.HasSuffix(head, []byte(emptyTail)) {
+		return fmt.Errorf("results envelope does not end with its threads")
+	}
+	if _, err := w.Write(head[:len(head)-len(emptyTail)]); err != nil {
+		return err
+	}
+	io.WriteString(w, "[")
+	written := 0
+	err = each(func(record []byte) error {
+		if written > 0 {
+			io.WriteString(w, ",")
+		}
+		written++
+		io.WriteString(w, "\n    ")
+		_, err := w.Write(record)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if written == 0 {
+		_, err = io.WriteString(w, "]\n}")
+		return err
+	}
+	_, err = io.WriteString(w, "\n  ]\n}")
+	return err
+}
+
+// partFilename inserts a -partNNN suffix before the extension of a results path
+func partFilename(path string, part int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-part%03d%s", strings.TrimSuffix(path, ext), part, ext)
+}
+
+// splitParts divides threads into result parts under the split limits. Parts only
+// break between threads, and a single thread larger than --split-size gets a part to itself.
+func (fs *ForumScraperGo) splitParts(threads []*ForumThread) ([][]*ForumThread, error) {
+	bounds, err := fs.splitBounds(len(threads), func(i int) (int64, error) {
+		// Measured as the record is laid out inside the envelope's threads array
+		data, err := marshalThreadRecord(threads[i])
+		return int64(len(data)), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	parts := make([][]*ForumThread, len(bounds))
+	for i, b := range bounds {
+		parts[i] = threads[b[0]:b[1]]
+	}
+	return parts, nil
+}
+
+// splitBounds divides count threads into parts under the split limits, returning
+// each part's [start, end) indexes. size gives a thread's record size and is
+// only called under --split-size. There is always at least one part.
+func (fs *ForumScraperGo) splitBounds(count int, size func(i int) (int64, error)) ([][2]int, error) {
+	if fs.splitSize <= 0 && fs.splitThreads <= 0 {
+		return [][2]int{{0, count}}, nil
+	}
+
+	var bounds [][2]int
+	start := 0
+	var currentSize int64
+	for i := 0; i < count; i++ {
+		var recordSize int64
+		if fs.splitSize > 0 {
+			var err error
+			if recordSize, err = size(i); err != nil {
+				return nil, err
+			}
+		}
+
+		full := fs.splitThreads > 0 && i-start >= fs.splitThreads
+		if fs.splitSize > 0 && currentSize+recordSize > fs.splitSize {
+			full = true
+		}
+		if full && i > start {
+			bounds = append(bounds, [2]int{start, i})
+			start, currentSize = i, 0
+		}
+		currentSize += recordSize
+	}
+	if count > start || len(bounds) == 0 {
+		bounds = append(bounds, [2]int{start, count})
+	}
+	return bounds, nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// syntheticThreads returns threads threads of postsPerThread posts each
+func syntheticThreads(threads, postsPerThread int) []*ForumThread {
+	result := make([]*ForumThread, threads)
+	for i := range result {
+		likes := i % 7
+		thread := &ForumThread{
+			URL:          fmt.Sprintf("https://forum.example.com/viewtopic.php?t=%d", i+1),
+			Title:        fmt.Sprintf("Topic %d", i+1),
+			Category:     "Kernel & Hardware",
+			Author:       "user1",
+			RepliesCount: postsPerThread - 1,
+			ScrapedAt:    fixedTimestamp,
+		}
+		for j := 0; j < postsPerThread; j++ {
+			thread.Posts = append(thread.Posts, ForumPost{
+				URL:        fmt.Sprintf("%s#post%d", thread.URL, j+1),
+				Author:     fmt.Sprintf("user%d", j%50),
+				Content:    fmt.Sprintf("Post %d of topic %d. %s", j+1, i+1, syntheticWords),
+				PostNumber: j + 1,
+				LikesCount: &likes,
+				ScrapedAt:  fixedTimestamp,
+			})
+		}
+		result[i] = thread
+	}
+	return result
+}
+
+// marshalEnvelope lays out a results document the way saveResults did before it
+// streamed: every thread copied into the envelope and marshalled in one piece
+func marshalEnvelope(results ResultsEnvelope, threads []*ForumThread) ([]byte, error) {
+	results.Threads = make([]ForumThread, 0, len(threads))
+	for _, thread := range threads {
+		results.Threads = append(results.Threads, *thread)
+	}
+	return json.MarshalIndent(results, "", "  ")
+}
+
+func TestWriteEnvelopeMatchesMarshal(t *testing.T) {
+	for _, count := range []int{0, 1, 3} {
+		threads := syntheticThreads(count, 2)
+		results := ResultsEnvelope{
+			SchemaVersion: resultsSchemaVersion,
+			ForumType:     "phpbb",
+			TotalThreads:  count,
+			TotalPosts:    2 * count,
+			ScrapedAt:     fixedTimestamp.Format(time.RFC3339),
+			Failures:      []Failure{{URL: "https://forum.example.com/viewtopic.php?t=99", Type: "http_error", Error: "HTTP 404"}},
+			Threads:       []ForumThread{},
+		}
+		want, err := marshalEnvelope(results, threads)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got bytes.Buffer
+		if err := writeEnvelope(&got, &results, threads); err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != string(want) {
+			t.Errorf("%d thread(s): streamed envelope differs from the marshalled one:\n%s", count, lineDiff(string(want), got.String()))
+		}
+	}
+}
+
+// BenchmarkWriteEnvelope writes 100,000 synthetic posts as one results document,
+// marshalled whole or streamed a thread at a time
+func BenchmarkWriteEnvelope(b *testing.B) {
+	threads := syntheticThreads(1000, 100)
+	results := ResultsEnvelope{SchemaVersion: resultsSchemaVersion, ForumType: "phpbb", TotalThreads: 1000, TotalPosts: 100000, Threads: []ForumThread{}}
+
+	for _, mode := range []struct {
+		name  string
+		write func() error
+	}{
+		{"marshal", func() error {
+			data, err := marshalEnvelope(results, threads)
+			if err != nil {
+				return err
+			}
+			_, err = io.Discard.Write(data)
+			return err
+		}},
+		{"stream", func() error {
+			return writeEnvelope(io.Discard, &results, threads)
+		}},
+	} {
+		b.Run(mode.name, func(b *testing.B) {
+			b.ReportAllocs()
+			var peak uint64
+			for i := 0; i < b.N; i++ {
+				heap := peakHeap(func() {
+					if err := mode.write(); err != nil {
+						b.Fatal(err)
+					}
+				})
+				peak = max(peak, heap)
+			}
+			b.ReportMetric(float64(peak)/(1<<20), "peak-heap-MB")
+		})
+	}
+}
+
+func TestSpilledResultsMatchSavedResults(t *testing.T) {
+	// Completion order, reversed, so both paths have to sort
+	threads := syntheticThreads(10, 3)
+	slices.Reverse(threads)
+	record, err := marshalThreadRecord(threads[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		splitSize    int64
+		splitThreads int
+	}{
+		{"whole", 0, 0},
+		{"split by threads", 0, 3},
+		{"split by size", int64(len(record)) * 5 / 2, 0},
+	}
+	for _, tt := range tests {
+		scraper := NewForumScraper("phpbb", 0, WithFixedTimestamps(true), WithSplit(tt.splitSize, tt.splitThreads), WithStatusOutput(io.Discard))
+
+		scraper.outputDir = t.TempDir()
+		want, err := scraper.saveResults(slices.Clone(threads), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		scraper.outputDir = t.TempDir()
+		spill, err := newResultsSpill(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, thread := range threads {
+			if err := spill.add(thread); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got, err := scraper.saveSpilledResults(spill, "")
+		spill.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(got) != len(want) || (tt.name != "whole" && len(want) < 2) {
+			t.Fatalf("%s: spilled save wrote %d files, saveResults %d", tt.name, len(got), len(want))
+		}
+		for i := range want {
+			if filepath.Base(got[i]) != filepath.Base(want[i]) {
+				t.Errorf("%s: file %d named %s, want %s", tt.name, i+1, filepath.Base(got[i]), filepath.Base(want[i]))
+			}
+			gotData, err := os.ReadFile(got[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantData, err := os.ReadFile(want[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(gotData, wantData) {
+				t.Errorf("%s: %s differs from saveResults' output", tt.name, filepath.Base(got[i]))
+			}
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Adaptive backoff tuning. A response is "bad" when it is a 429/503, a transport
+// error, or slower than slowResponseThreshold; badRate is a moving average of that.
+const (
+	slowResponseThreshold = 5 * time.Second
+	backoffRaiseRate      = 0.3
+	backoffDecayRate      = 0.1
+	backoffSmoothing      = 0.3
+	maxBackoffMultiplier  = 16.0
+	backoffDecayFactor    = 0.75
+)
+
+// hostPacingTable holds every host's pacing state. It sits behind a pointer so
+// scrapers serving jobs side by side can share one.
+type hostPacingTable struct {
+	mu    sync.Mutex
+	hosts map[string]*hostPacing
+}
+
+// hostPacing is one host's adaptive delay state
+type hostPacing struct {
+	multiplier float64
+	badRate    float64
+	// holdUntil keeps requests off the host until then, as the server asked
+	holdUntil time.Time
+}
+
+// HostDelay is a host's effective delay as recorded in the results envelope
+type HostDelay struct {
+	DelaySeconds float64 `json:"delay_seconds"`
+	Multiplier   float64 `json:"multiplier"`
+}
+
+// pacingFor returns the host's pacing state, creating it on first use; callers hold pacing.mu
+func (fs *ForumScraperGo) pacingFor(host string) *hostPacing {
+	if fs.pacing.hosts == nil {
+		fs.pacing.hosts = make(map[string]*hostPacing)
+	}
+	state, exists := fs.pacing.hosts[host]
+	if !exists {
+		state = &hostPacing{multiplier: 1}
+		fs.pacing.hosts[host] = state
+	}
+	return state
+}
+
+// maxJitter is the largest --jitter: at ±100% every delay stays between zero and double
+const maxJitter = 1.0
+
+// delayFor returns the delay to wait before the next request to rawURL's host:
+// the configured delay, scaled by the host's backoff multiplier, with ± jitter
+func (fs *ForumScraperGo) delayFor(rawURL string) time.Duration {
+	delay := float64(fs.delay)
+	if fs.adaptiveDelay {
+		fs.pacing.mu.Lock()
+		delay *= fs.pacingFor(hostOf(rawURL)).multiplier
+		fs.pacing.mu.Unlock()
+	}
+	if fs.jitter > 0 {
+		delay *= 1 + fs.jitter*(2*fs.rng.Float64()-1)
+	}
+	return time.Duration(delay)
+}
+
+// politeWait sleeps before a request to rawURL, and on past the end of any hold
+// on its host. Local files are read without waiting.
+func (fs *ForumScraperGo) politeWait(rawURL string) {
+	fs.politeWaitContext(fs.runContext(), rawURL)
+}
+
+// politeWaitContext is politeWait cut short when ctx ends, so a thread's
+// --thread-timeout covers its waits as well as its fetches
+func (fs *ForumScraperGo) politeWaitContext(ctx context.Context, rawURL string) {
+	if isFileURL(rawURL) {
+		return
+	}
+	sleepContext(ctx, fs.delayFor(rawURL))
+
+	fs.pacing.mu.Lock()
+	var holdUntil time.Time
+	if state, exists := fs.pacing.hosts[hostOf(rawURL)]; exists {
+		holdUntil = state.holdUntil
+	}
+	fs.pacing.mu.Unlock()
+	sleepContext(ctx, time.Until(holdUntil))
+}
+
+// sleepContext sleeps for d or until ctx ends, whichever comes first
+func sleepContext(ctx context.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// holdHost keeps requests off rawURL's host for d, for servers that say how long to back off
+func (fs *ForumScraperGo) holdHost(rawURL string, d time.Duration) {
+	fs.pacing.mu.Lock()
+	defer fs.pacing.mu.Unlock()
+	state := fs.pacingFor(hostOf(rawURL))
+	if until := time.Now().Add(d); until.After(state.holdUntil) {
+		state.holdUntil = until
+	}
+}
+
+// observeResponse feeds one response into its host's adaptive backoff. The delay
+// doubles while bad responses stay above backoffRaiseRate and decays back once
+// they fall below backoffDecayRate. status is 0 for transport errors.
+func (fs *ForumScraperGo) observeResponse(rawURL string, latency time.Duration, status int) {
+	if !fs.adaptiveDelay {
+		return
+	}
+	bad := 0.0
+	if status == 0 || status == 429 || status == 503 || latency > slowResponseThreshold {
+		bad = 1
+	}
+
+	host := hostOf(rawURL)
+	fs.pacing.mu.Lock()
+	defer fs.pacing.mu.Unlock()
+	state := fs.pacingFor(host)
+	state.badRate = (1-backoffSmoothing)*state.badRate + backoffSmoothing*bad
+
+	switch {
+	case state.badRate > backoffRaiseRate && state.multiplier < maxBackoffMultiplier:
+		state.multiplier *= 2
+		if state.multiplier > maxBackoffMultiplier {
+			state.multiplier = maxBackoffMultiplier
+		}
+		atomic.AddInt64(&fs.stats.BackoffIncreases, 1)
+		fs.debugf("Backing off %s: delay ×%.2f (bad response rate %.2f, last HTTP %d in %v)", host, state.multiplier, state.badRate, status, latency.Round(time.Millisecond))
+	case state.badRate < backoffDecayRate && state.multiplier > 1:
+		state.multiplier *= backoffDecayFactor
+		if state.multiplier < 1 {
+			state.multiplier = 1
+		}
+		fs.debugf("Recovering %s: delay ×%.2f", host, state.multiplier)
+	}
+}
+
+// hostDelays returns each host's current effective delay
+func (fs *ForumScraperGo) hostDelays() map[string]HostDelay {
+	fs.pacing.mu.Lock()
+	defer fs.pacing.mu.Unlock()
+	delays := make(map[string]HostDelay, len(fs.pacing.hosts))
+	for host, state := range fs.pacing.hosts {
+		delays[host] = HostDelay{
+			DelaySeconds: (time.Duration(float64(fs.delay) * state.multiplier)).Seconds(),
+			Multiplier:   state.multiplier,
+		}
+	}
+	return delays
+}
+
+// printPacingSummary lists hosts whose delay ended above the configured one
+func (fs *ForumScraperGo) printPacingSummary(w io.Writer) {
+	delays := fs.hostDelays()
+	hosts := make([]string, 0, len(delays))
+	for host, delay := range delays {
+		if delay.Multiplier > 1 {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		fmt.Fprintf(w, "🐢 Adaptive delay for %s: %.1fs (×%.2f)\n", host, delays[host].DelaySeconds, delays[host].Multiplier)
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// At the largest --jitter every delay stays between zero and double the base
+func TestJitterStaysWithinBounds(t *testing.T) {
+	scraper := NewForumScraper("phpbb", 1, WithJitter(maxJitter), WithSeed(7))
+	for i := 0; i < 1000; i++ {
+		delay := scraper.delayFor("https://forum.example.com/viewtopic.php?t=1")
+		if delay < 0 || delay > 2*time.Second {
+			t.Fatalf("delay %v outside 0..2s", delay)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+//go:build postgres
+
+package main
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// postgresMigrations are applied in file name order, each once per database
+//
+//go:embed sql/postgres/*.sql
+var postgresMigrations embed.FS
+
+const (
+	// postgresMaxConns keeps the pool small; writes are one short transaction per thread
+	postgresMaxConns = 4
+	// postgresMaxRetries and postgresRetryBase pace retries while the database is
+	// unreachable: 1s, 2s, 4s … capped at postgresRetryMax, about six minutes in all
+	postgresMaxRetries = 12
+	postgresRetryBase  = time.Second
+	postgresRetryMax   = 30 * time.Second
+	// postgresMigrationLock is the advisory lock that keeps concurrent runs from
+	// migrating the same database at once
+	postgresMigrationLock = 7_245_061_511
+)
+
+func init() {
+	newPostgresSink = openPostgresSink
+}
+
+// postgresSink upserts threads and their posts into PostgreSQL
+type postgresSink struct {
+	pool   *pgxpool.Pool
+	status io.Writer
+}
+
+func openPostgresSink(dsn string, status io.Writer) (threadSink, error) {
+	config, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	config.MaxConns = postgresMaxConns
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	// Connect now so a bad DSN fails at startup, not after the first thread
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	if err := migratePostgres(ctx, pool); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("migrating schema: %w", err)
+	}
+	return &postgresSink{pool: pool, status: status}, nil
+}
+
+// migratePostgres applies the embedded migrations not yet recorded in
+// forum_schema_migrations, each in its own transaction
+func migratePostgres(ctx context.Context, pool *pgxpool.Pool) error {
+	if _, err := pool.Exec(ctx, `CREATE TABLE IF NOT EXISTS forum_schema_migrations (
+		version    TEXT PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return err
+	}
+
+	entries, err := postgresMigrations.ReadDir("sql/postgres")
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		script, err := postgresMigrations.ReadFile(path.Join("sql/postgres", name))
+		if err != nil {
+			return err
+		}
+		version := strings.TrimSuffix(name, ".sql")
+		err = pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", postgresMigrationLock); err != nil {
+				return err
+			}
+			var applied bool
+			if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM forum_schema_migrations WHERE version = $1)", version).Scan(&applied); err != nil || applied {
+				return err
+			}
+			if _, err := tx.Exec(ctx, string(script)); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, "INSERT INTO forum_schema_migrations (version) VALUES ($1)", version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (s *postgresSink) Name() string { return "postgres" }
+
+func (s *postgresSink) Close() error {
+	s.pool.Close()
+	return nil
+}
+
+// Write upserts a thread, retrying with backoff while the database is unreachable
+// so an outage pauses the sink instead of losing threads. Any other error, from
+// the database rejecting the data or the run being cancelled, is returned straight away.
+func (s *postgresSink) Write(ctx context.Context, thread *ForumThread) error {
+	wait := postgresRetryBase
+	for attempt := 0; ; attempt++ {
+		err := s.writeThread(ctx, thread)
+		if err == nil || !postgresUnavailable(err) || attempt >= postgresMaxRetries {
+			return err
+		}
+		fmt.Fprintf(s.status, "⏳ PostgreSQL unavailable, retrying %s in %v: %v\n", thread.URL, wait, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > postgresRetryMax {
+			wait = postgresRetryMax
+		}
+	}
+}
+
+// postgresUnavailable reports whether err means the database couldn't be reached
+// or went away (connection exceptions, shutdowns and network failures) rather
+// than rejecting the data
+func postgresUnavailable(err error) bool {
+	// context.DeadlineExceeded is a net.Error too
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return strings.HasPrefix(pgErr.Code, "08") || strings.HasPrefix(pgErr.Code, "57P")
+	}
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	return errors.As(err, &connectErr) || errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || pgconn.SafeToRetry(err)
+}
+
+const upsertThreadSQL = `
+INSERT INTO forum_threads (thread_key, url, thread_id, host, title, category, author, views_count,
+	replies_count, created_at, last_post_at, language, canonical_url, source_url, tags, provenance,
+	record, scraped_at, run_id, record_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+ON CONFLICT (thread_key) DO UPDATE SET
+	url = EXCLUDED.url, thread_id = EXCLUDED.thread_id, host = EXCLUDED.host, title = EXCLUDED.title,
+	category = EXCLUDED.category, author = EXCLUDED.author, views_count = EXCLUDED.views_count,
+	replies_count = EXCLUDED.replies_count, created_at = EXCLUDED.created_at,
+	last_post_at = EXCLUDED.last_post_at, language = EXCLUDED.language,
+	canonical_url = EXCLUDED.canonical_url, source_url = EXCLUDED.source_url, tags = EXCLUDED.tags,
+	provenance = EXCLUDED.provenance, record = EXCLUDED.record, scraped_at = EXCLUDED.scraped_at,
+	run_id = EXCLUDED.run_id, record_id = EXCLUDED.record_id, updated_at = now()`
+
+const upsertPostSQL = `
+INSERT INTO forum_posts (thread_key, post_key, post_id, post_number, url, author, content, posted_at,
+	likes_count, parent_post_number, content_hash, language, is_accepted_answer, edited, mentions,
+	internal_thread_links, attachments, record, scraped_at, run_id, record_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
+ON CONFLICT (thread_key, post_key) DO UPDATE SET
+	post_id = EXCLUDED.post_id, post_number = EXCLUDED.post_number, url = EXCLUDED.url, author = EXCLUDED.author, content = EXCLUDED.content,
+	posted_at = EXCLUDED.posted_at, likes_count = EXCLUDED.likes_count,
+	parent_post_number = EXCLUDED.parent_post_number, content_hash = EXCLUDED.content_hash,
+	language = EXCLUDED.language, is_accepted_answer = EXCLUDED.is_accepted_answer,
+	edited = EXCLUDED.edited, mentions = EXCLUDED.mentions,
+	internal_thread_links = EXCLUDED.internal_thread_links, attachments = EXCLUDED.attachments,
+	record = EXCLUDED.record, scraped_at = EXCLUDED.scraped_at, run_id = EXCLUDED.run_id,
+	record_id = EXCLUDED.record_id, updated_at = now()`
+
+// deleteStalePostsSQL drops the posts a thread no longer has: those numbered
+// within the range just scraped whose keys the scrape didn't see
+const deleteStalePostsSQL = `
+DELETE FROM forum_posts
+WHERE thread_key = $1 AND post_number <= $2 AND NOT (post_key = ANY($3))`
+
+const upsertRunSQL = `
+INSERT INTO forum_runs (run_id, started_at, ended_at, scraper_version, git_commit, config_hash, host)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (run_id) DO UPDATE SET ended_at = EXCLUDED.ended_at`
+
+// jsonArray encodes a list for a JSONB column, as [] rather than null when empty
+func jsonArray[T any](items []T) []byte {
+	if items == nil {
+		items = []T{}
+	}
+	data, _ := json.Marshal(items)
+	return data
+}
+
+// nullable turns an empty string into SQL NULL
+func nullable(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// writeThread upserts a thread and all its posts in one transaction, sent as one
+// batch, and deletes the posts the thread has lost since it was last written
+func (s *postgresSink) writeThread(ctx context.Context, thread *ForumThread) error {
+	key := threadKey(thread)
+	header := *thread
+	header.Posts = nil
+	record, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	var provenance []byte
+	if thread.Provenance != nil {
+		if provenance, err = json.Marshal(thread.Provenance); err != nil {
+			return err
+		}
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(upsertThreadSQL, key, thread.URL, nullable(thread.ThreadID), hostOf(thread.URL), thread.Title,
+		nullable(thread.Category), nullable(thread.Author), thread.ViewsCount, thread.RepliesCount,
+		nullable(thread.CreatedAt), nullable(thread.LastPostAt), nullable(thread.Language),
+		nullable(thread.CanonicalURL), nullable(thread.SourceURL), jsonArray(thread.Tags), provenance,
+		record, thread.ScrapedAt, nullable(thread.RunID), nullable(thread.RecordID))
+	var keys []string
+	lastNumber := 0
+	for _, post := range thread.Posts {
+		keys = append(keys, postKey(&post))
+		lastNumber = max(lastNumber, post.PostNumber)
+		postRecord, err := json.Marshal(post)
+		if err != nil {
+			return err
+		}
+		var parent *int
+		if post.ParentPostNumber != 0 {
+			parent = &post.ParentPostNumber
+		}
+		batch.Queue(upsertPostSQL, key, postKey(&post), nullable(post.PostID), post.PostNumber, post.URL, nullable(post.Author), post.Content,
+			nullable(post.Timestamp), post.LikesCount, parent, nullable(post.ContentHash),
+			nullable(post.Language), post.IsAcceptedAnswer, post.Edited, jsonArray(post.Mentions),
+			jsonArray(post.InternalThreadLinks), jsonArray(post.Attachments), postRecord, post.ScrapedAt,
+			nullable(post.RunID), nullable(post.RecordID))
+	}
+	// Posts past the last one scraped may only be beyond --max-posts, and a
+	// --window scrape leaves out posts the thread still has
+	if len(keys) > 0 && !thread.PartialWindow {
+		batch.Queue(deleteStalePostsSQL, key, lastNumber, keys)
+	}
+
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		return tx.SendBatch(ctx, batch).Close()
+	})
+}
+
+// WriteRun upserts the run's row in forum_runs
+func (s *postgresSink) WriteRun(ctx context.Context, run *RunMetadata) error {
+	_, err := s.pool.Exec(ctx, upsertRunSQL, run.RunID, run.StartedAt, run.EndedAt, run.ScraperVersion,
+		nullable(run.GitCommit), nullable(run.ConfigHash), nullable(run.Host))
+	return err
+}
@@ -0,0 +1,37 @@
+//go:build postgres
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestPostgresUnavailable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"admin shutdown", &pgconn.PgError{Code: "57P01"}, true},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, true},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true},
+		{"connection dropped", fmt.Errorf("reading reply: %w", io.ErrUnexpectedEOF), true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"invalid text", &pgconn.PgError{Code: "22P02"}, false},
+		{"encoding", errors.New("json: unsupported value"), false},
+		{"cancelled", context.Canceled, false},
+		{"deadline", context.DeadlineExceeded, false},
+	}
+	for _, tt := range tests {
+		if got := postgresUnavailable(tt.err); got != tt.want {
+			t.Errorf("%s: postgresUnavailable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
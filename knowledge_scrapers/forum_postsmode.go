@@ -0,0 +1,76 @@
+package main
+
+import "github.com/PuerkitoBio/goquery"
+
+// Post extraction modes for --posts-mode
+const (
+	postsModeAll    = "all"
+	postsModeFirst  = "first"
+	postsModeNone   = "none"
+	postsModeSolved = "solved"
+)
+
+// postLimit returns how many of a page's post elements scrapeThread extracts
+// under the posts mode. Solved mode reads them all, since the accepted answer can
+// sit anywhere in the thread; its output is trimmed to maxPosts afterwards.
+func (fs *ForumScraperGo) postLimit(maxPosts, available int) int {
+	switch fs.postsMode {
+	case postsModeFirst:
+		if maxPosts > 1 {
+			return 1
+		}
+	case postsModeNone:
+		return 0
+	case postsModeSolved:
+		return available
+	}
+	return maxPosts
+}
+
+// solvedPosts reduces a thread's posts to the question (its first post) and the
+// posts marked as accepted answers, capped at maxPosts but always keeping one
+// answer. It returns nil when no post is an accepted answer.
+func solvedPosts(posts []*ForumPost, maxPosts int) []*ForumPost {
+	if len(posts) == 0 {
+		return nil
+	}
+	kept := []*ForumPost{posts[0]}
+	for _, post := range posts[1:] {
+		if post.IsAcceptedAnswer && (len(kept) == 1 || len(kept) < maxPosts) {
+			kept = append(kept, post)
+		}
+	}
+	if len(kept) == 1 {
+		return nil
+	}
+	return kept
+}
+
+// isAcceptedAnswer reports whether a post element carries the platform's accepted-answer marker
+func isAcceptedAnswer(selection *goquery.Selection, config PlatformConfig) bool {
+	if len(config.AcceptedAnswerSelector) == 0 {
+		return false
+	}
+	return config.AcceptedAnswerSelector.matches(selection)
+}
+
+// repliesCount returns a thread's reply count. A count from structured data covers
+// the whole thread and wins; with every post scraped it's the posts after the
+// first; otherwise the count the page states, falling back to the post elements
+// on the page.
+func (fs *ForumScraperGo) repliesCount(metadata map[string]interface{}, postElements *goquery.Selection, posts []*ForumPost) int {
+	sources, _ := metadata["sources"].(map[string]string)
+	if replies, ok := metadata["replies_count"].(int); ok && sources["replies_count"] == metadataSourceJSONLD {
+		return replies
+	}
+	if fs.postsMode == postsModeAll {
+		return len(posts) - 1
+	}
+	if replies, ok := metadata["replies_count"].(int); ok {
+		return replies
+	}
+	if postElements.Length() > 0 {
+		return postElements.Length() - 1
+	}
+	return 0
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// parsePostTime parses a post timestamp as the standard date forms parseLastMod
+// takes, then as each of layouts, a platform's own display formats. Runs of
+// whitespace, including the non-breaking spaces forums put between date and
+// time, count as one space. It returns nil when nothing matches.
+func parsePostTime(value string, layouts []string) *time.Time {
+	if t := parseLastMod(value); t != nil {
+		return t
+	}
+	value = strings.Join(strings.Fields(value), " ")
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// postTime parses a post timestamp with the run platform's TimestampLayouts
+func (fs *ForumScraperGo) postTime(timestamp string) *time.Time {
+	return parsePostTime(timestamp, fs.configs[fs.platform].TimestampLayouts)
+}
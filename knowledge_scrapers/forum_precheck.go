@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// --precheck asks for a thread's headers before fetching it, so sitemap and index
+// candidates that are gone, not HTML or too large cost a HEAD request instead of a
+// full download. Only definitive answers skip a thread: servers that answer HEAD
+// with 200 for everything, or refuse it, still get the full fetch.
+
+// precheckRangeBytes is how much of the body a ranged GET asks for when HEAD is refused
+const precheckRangeBytes = 1023
+
+// precheckHeaders issues method for threadURL with the scraper's headers, hooks
+// and request budget, returning the response with its body unread
+func (fs *ForumScraperGo) precheckHeaders(ctx context.Context, method, threadURL string) (*http.Response, error) {
+	if err := fs.spendRequest(); err != nil {
+		return nil, err
+	}
+	atomic.AddInt64(&fs.stats.PrecheckRequests, 1)
+	req, err := fs.newRequest(method, threadURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if method == http.MethodGet {
+		req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", precheckRangeBytes))
+	}
+	// Lengths are only comparable to --max-response-size uncompressed
+	req.Header.Set("Accept-Encoding", "identity")
+	ctx, cancel := fs.requestContext(ctx)
+	defer cancel()
+	req = req.WithContext(ctx)
+	if err := fs.runRequestHooks(req); err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	access := fs.startAccess(ctx, req)
+	resp, err := fs.client.Do(req)
+	if err != nil {
+		fs.observeResponse(threadURL, time.Since(started), 0)
+		fs.finishAccess(access, "-")
+		return nil, fs.classifyTimeout(err, threadURL)
+	}
+	resp.Body.Close()
+	fs.observeResponse(threadURL, time.Since(started), resp.StatusCode)
+	fs.runResponseHooks(resp)
+	if access != nil {
+		access.url = resp.Request.URL.String()
+	}
+	fs.finishAccess(access, strconv.Itoa(resp.StatusCode))
+	return resp, nil
+}
+
+// precheckRefused reports whether a server turned down a HEAD request as a method
+// rather than answering for the resource
+func precheckRefused(status int) bool {
+	switch status {
+	case http.StatusBadRequest, http.StatusForbidden, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// precheckLength returns the full size of the resource a response describes: the
+// total of a ranged response's Content-Range, otherwise its Content-Length; -1 when
+// neither says
+func precheckLength(resp *http.Response) int64 {
+	if resp.StatusCode == http.StatusPartialContent {
+		_, total, found := strings.Cut(resp.Header.Get("Content-Range"), "/")
+		if !found {
+			return -1
+		}
+		length, err := strconv.ParseInt(strings.TrimSpace(total), 10, 64)
+		if err != nil {
+			return -1
+		}
+		return length
+	}
+	return resp.ContentLength
+}
+
+// precheckThread checks threadURL's headers before it is fetched, returning the
+// URL any redirects ended at. A thread is skipped, with the error its full fetch
+// would have failed with, only when the answer is definitive: 404 or 410, a
+// declared non-HTML content type, a length over --max-response-size, or a
+// redirect to a thread already scraped. Errors reaching the server and every
+// other answer let the full fetch go ahead and decide.
+func (fs *ForumScraperGo) precheckThread(ctx context.Context, threadURL string) (string, error) {
+	resp, err := fs.precheckHeaders(ctx, http.MethodHead, threadURL)
+	if err == nil && precheckRefused(resp.StatusCode) {
+		fs.debugf("HEAD refused with HTTP %d for %s, pre-checking with a ranged GET", resp.StatusCode, threadURL)
+		resp, err = fs.precheckHeaders(ctx, http.MethodGet, threadURL)
+	}
+	if errors.Is(err, ErrBudgetExhausted) || errors.Is(err, ErrRequestHook) {
+		return "", err
+	}
+	if err != nil {
+		// The full fetch would follow the same redirects and be refused the same way
+		if errors.Is(err, ErrRedirectLoop) || errors.Is(err, ErrTooManyRedirects) || errors.Is(err, ErrExternalRedirect) {
+			atomic.AddInt64(&fs.stats.PrecheckAvoided, 1)
+			return "", fmt.Errorf("pre-check: %w", err)
+		}
+		fs.debugf("Pre-check of %s failed, fetching anyway: %v", threadURL, err)
+		return "", nil
+	}
+
+	finalURL := resp.Request.URL.String()
+	var skip error
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		skip = &httpStatusError{StatusCode: resp.StatusCode}
+	case resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent:
+		// Rate limits, server errors and the like say nothing final about the thread
+		return finalURL, nil
+	case !precheckHTML(resp.Header.Get("Content-Type")):
+		skip = fmt.Errorf("%w: %s is %s", ErrNotHTML, threadURL, resp.Header.Get("Content-Type"))
+	case fs.maxResponseSize > 0 && precheckLength(resp) > fs.maxResponseSize:
+		skip = fmt.Errorf("%w: %s (%d bytes)", ErrResponseTooLarge, threadURL, precheckLength(resp))
+	case normalizeURL(finalURL) != normalizeURL(threadURL):
+		// A redirect target is recorded as visited here, as the full fetch would
+		finalURL = fs.canonicalThreadURL(finalURL)
+		if fs.previouslyExported(finalURL, "") {
+			skip = fmt.Errorf("%w: %s", ErrPreviouslyExported, threadURL)
+		} else if !fs.markVisited(finalURL) {
+			skip = fmt.Errorf("%w: %s redirected to %s", ErrDuplicateThread, threadURL, finalURL)
+		}
+	}
+	if skip == nil {
+		return finalURL, nil
+	}
+	atomic.AddInt64(&fs.stats.PrecheckAvoided, 1)
+	fs.debugf("Pre-check skipped %s: %v", threadURL, skip)
+	return "", fmt.Errorf("pre-check: %w", skip)
+}
+
+// precheckHTML reports whether a declared content type could be a thread page;
+// an undeclared one could
+func precheckHTML(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return true
+	}
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// discourseTopic is the part of a Discourse topic object the scraper reads: the
+// topic's own counts, as opposed to those of the suggested topics listed in it
+type discourseTopic struct {
+	ID         int  `json:"id"`
+	Views      *int `json:"views"`
+	ReplyCount *int `json:"reply_count"`
+}
+
+// preloadedDiscourseTopic reads the topic object out of a Discourse topic page's
+// data-preloaded attribute, a JSON object whose "topic_<id>" entry is the topic
+// JSON encoded as a string. It is nil when the page has no such entry.
+func preloadedDiscourseTopic(doc *goquery.Document, threadID string) *discourseTopic {
+	raw := doc.Find("#data-preloaded").First().AttrOr("data-preloaded", "")
+	if raw == "" || threadID == "" {
+		return nil
+	}
+	var preloaded map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &preloaded); err != nil {
+		return nil
+	}
+	var encoded string
+	if err := json.Unmarshal(preloaded["topic_"+threadID], &encoded); err != nil {
+		return nil
+	}
+	var topic discourseTopic
+	if err := json.Unmarshal([]byte(encoded), &topic); err != nil || strconv.Itoa(topic.ID) != threadID {
+		return nil
+	}
+	return &topic
+}
+
+// applyDiscoursePreloaded fills the view and reply counts nothing else found from
+// a Discourse page's preloaded topic JSON
+func (fs *ForumScraperGo) applyDiscoursePreloaded(doc *goquery.Document, pageURL string, metadata map[string]interface{}, sources map[string]string) {
+	topic := preloadedDiscourseTopic(doc, fs.extractThreadID(pageURL))
+	if topic == nil {
+		return
+	}
+	for key, value := range map[string]*int{"views_count": topic.Views, "replies_count": topic.ReplyCount} {
+		if _, found := metadata[key]; found || value == nil {
+			continue
+		}
+		metadata[key] = *value
+		sources[key] = metadataSourcePreloaded
+	}
+}
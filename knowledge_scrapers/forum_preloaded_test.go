@@ -0,0 +1,49 @@
+package main
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const preloadedTopicPath = "/t/building-a-static-binary-with-cgo-disabled/4412"
+
+// TestDiscoursePreloadedViews scrapes a topic page whose preloaded topic JSON
+// lists suggested topics, each with views of its own, ahead of the topic's
+func TestDiscoursePreloadedViews(t *testing.T) {
+	server := httptest.NewServer(fixtureHandler(fixturesDir, map[string]string{
+		preloadedTopicPath: "discourse/topic-preloaded.html",
+	}))
+	defer server.Close()
+	scraper := NewForumScraper("discourse", 0)
+	scraper.statusOut = io.Discard
+
+	thread, err := scraper.scrapeThread(server.URL+preloadedTopicPath, fixtureMaxPosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thread.ViewsCount == nil || *thread.ViewsCount != 318 {
+		t.Errorf("ViewsCount = %v, want 318", thread.ViewsCount)
+	}
+	if source := thread.Provenance.MetadataSources["views_count"]; source != metadataSourcePreloaded {
+		t.Errorf("views_count source = %q, want %q", source, metadataSourcePreloaded)
+	}
+}
+
+func TestPreloadedDiscourseTopic(t *testing.T) {
+	page := `<div id="data-preloaded" data-preloaded="{&quot;topic_7&quot;:&quot;{\&quot;suggested_topics\&quot;:[{\&quot;id\&quot;:8,\&quot;views\&quot;:900}],\&quot;id\&quot;:7,\&quot;views\&quot;:12}&quot;}"></div>`
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	topic := preloadedDiscourseTopic(doc, "7")
+	if topic == nil || topic.Views == nil || *topic.Views != 12 {
+		t.Fatalf("topic 7 = %+v, want views 12", topic)
+	}
+	if topic := preloadedDiscourseTopic(doc, "8"); topic != nil {
+		t.Errorf("topic 8 = %+v, want nil: only the page's own topic is preloaded", topic)
+	}
+}
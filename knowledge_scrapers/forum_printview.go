@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// PrintView describes a platform's printable thread view, which renders every post
+// of a thread in one minimal document. --lightweight fetches it instead of the thread page.
+type PrintView struct {
+	// URLTemplate is resolved against the thread URL, with {id} replaced by the thread ID
+	URLTemplate string
+	// The selectors replace the platform's own while the print view is parsed
+	TitleSelector     selectorChain
+	PostSelector      selectorChain
+	ContentSelector   selectorChain
+	AuthorSelector    selectorChain
+	TimestampSelector selectorChain
+}
+
+// apply returns config with its post selectors replaced by the print view's
+func (v *PrintView) apply(config PlatformConfig) PlatformConfig {
+	config.PostSelector = v.PostSelector
+	config.ContentSelector = v.ContentSelector
+	config.AuthorSelector = v.AuthorSelector
+	config.TimestampSelector = v.TimestampSelector
+	return config
+}
+
+// printViewURL builds the print view URL of a thread, or "" when its ID is unknown
+func (fs *ForumScraperGo) printViewURL(threadURL string, view *PrintView) string {
+	threadID := fs.extractThreadID(threadURL)
+	if threadID == "" {
+		return ""
+	}
+
+	// PATH_INFO-style URLs (showthread.php/123-title) resolve from the script's directory
+	base := threadURL
+	if u, err := url.Parse(threadURL); err == nil {
+		if i := strings.Index(u.Path, ".php/"); i >= 0 {
+			u.Path = u.Path[:i+len(".php")]
+			base = u.String()
+		}
+	}
+	printURL, err := resolveURL(base, strings.ReplaceAll(view.URLTemplate, "{id}", url.QueryEscape(threadID)))
+	if err != nil {
+		return ""
+	}
+	return printURL
+}
+
+// fetchPrintView fetches a thread's print view for --lightweight. The document
+// is nil when the platform has no print view or the board has it disabled (HTTP
+// 403/404, or a page without posts), and the caller falls back to the thread page.
+// The document's Url is the thread URL, so posts are attributed to the thread.
+func (fs *ForumScraperGo) fetchPrintView(ctx context.Context, threadURL string) (*goquery.Document, error) {
+	config, exists := fs.configs[fs.platform]
+	if !exists {
+		config = fs.configs["generic"]
+	}
+	if !fs.lightweight || config.PrintView == nil {
+		return nil, nil
+	}
+	printURL := fs.printViewURL(threadURL, config.PrintView)
+	if printURL == "" {
+		return nil, nil
+	}
+
+	doc, err := fs.fetchDocumentContext(ctx, printURL)
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) && (statusErr.StatusCode == 403 || statusErr.StatusCode == 404) {
+		fs.statusf("⚠️ Print view unavailable for %s (HTTP %d), using the thread page\n", threadURL, statusErr.StatusCode)
+		fs.politeWaitContext(ctx, threadURL)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if posts, _ := config.PrintView.PostSelector.find(doc.Selection); posts.Length() == 0 {
+		fs.statusf("⚠️ Print view for %s has no posts, using the thread page\n", threadURL)
+		fs.politeWaitContext(ctx, threadURL)
+		return nil, nil
+	}
+
+	if doc.Url, err = url.Parse(threadURL); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
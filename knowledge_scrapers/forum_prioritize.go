@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"sync/atomic"
+)
+
+// Orderings for --prioritize
+const (
+	prioritizeRecent     = "recent"
+	prioritizeActive     = "active"
+	prioritizeTitleMatch = "title-match"
+)
+
+// validPriority reports whether order is a --prioritize value
+func validPriority(order string) bool {
+	switch order {
+	case prioritizeRecent, prioritizeActive, prioritizeTitleMatch:
+		return true
+	}
+	return false
+}
+
+// discoveryLimit is how many threads to discover per source: all that the index
+// page and sitemap limits allow when prioritizing, since the best candidates may
+// be anywhere in discovery order, otherwise maxThreads
+func (fs *ForumScraperGo) discoveryLimit(maxThreads int) int {
+	if fs.prioritize == "" {
+		return maxThreads
+	}
+	return math.MaxInt32
+}
+
+// priorityValue returns a candidate's value for the --prioritize ordering; false
+// means it has none (no last activity or reply count on the index, no title)
+func (fs *ForumScraperGo) priorityValue(ref ThreadRef) (float64, bool) {
+	switch fs.prioritize {
+	case prioritizeRecent:
+		if ref.LastActivity == nil {
+			return 0, false
+		}
+		return float64(ref.LastActivity.Unix()), true
+	case prioritizeActive:
+		if ref.Replies == nil {
+			return 0, false
+		}
+		return float64(*ref.Replies), true
+	case prioritizeTitleMatch:
+		if ref.Title == "" || fs.priorityPattern == nil {
+			return 0, false
+		}
+		return float64(len(fs.priorityPattern.FindAllStringIndex(ref.Title, -1))), true
+	}
+	return 0, false
+}
+
+// prioritizeRefs orders one source's discovered candidates by --prioritize,
+// highest first and candidates without a value last, keeping discovery order
+// among equals, then keeps the first maxThreads. The rest are counted as
+// discarded for the summary. Without --prioritize refs are returned as they are.
+func (fs *ForumScraperGo) prioritizeRefs(refs []ThreadRef, maxThreads int) []ThreadRef {
+	if fs.prioritize == "" {
+		return refs
+	}
+	values := make([]float64, len(refs))
+	valued := make([]bool, len(refs))
+	for i, ref := range refs {
+		values[i], valued[i] = fs.priorityValue(ref)
+	}
+	sort.Stable(prioritizedRefs{refs: refs, values: values, valued: valued})
+
+	if len(refs) > maxThreads {
+		atomic.AddInt64(&fs.stats.CandidatesDiscarded, int64(len(refs)-maxThreads))
+		refs = refs[:maxThreads]
+	}
+	return refs
+}
+
+// prioritizedRefs sorts candidates and their priority values together
+type prioritizedRefs struct {
+	refs   []ThreadRef
+	values []float64
+	valued []bool
+}
+
+func (p prioritizedRefs) Len() int { return len(p.refs) }
+func (p prioritizedRefs) Less(i, j int) bool {
+	if p.valued[i] != p.valued[j] {
+		return p.valued[i]
+	}
+	return p.values[i] > p.values[j]
+}
+func (p prioritizedRefs) Swap(i, j int) {
+	p.refs[i], p.refs[j] = p.refs[j], p.refs[i]
+	p.values[i], p.values[j] = p.values[j], p.values[i]
+	p.valued[i], p.valued[j] = p.valued[j], p.valued[i]
+}
+
+// printPrioritization writes the --prioritize ordering and how many candidates
+// it discarded as a status line
+func (fs *ForumScraperGo) printPrioritization() {
+	if fs.prioritize == "" {
+		return
+	}
+	fs.statusf("🎯 Threads prioritized by %s: %d candidate(s) discarded\n", fs.prioritize, atomic.LoadInt64(&fs.stats.CandidatesDiscarded))
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// emailPattern matches email addresses in post content
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+
+// phoneCandidate matches digit runs that may be phone numbers; isPhoneNumber decides
+var phoneCandidate = regexp.MustCompile(`\+?\(?\d[\d\s().-]{6,}\d`)
+
+// datePattern excludes ISO dates and times, which phoneCandidate would otherwise catch
+var datePattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}|\d{1,2}:\d{2}`)
+
+// isPhoneNumber reports whether a phoneCandidate match has a phone number's digit count
+func isPhoneNumber(candidate string) bool {
+	if datePattern.MatchString(candidate) {
+		return false
+	}
+	digits := 0
+	for _, r := range candidate {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	return digits >= 9 && digits <= 15
+}
+
+// redactPII replaces email addresses and phone numbers with placeholders, counting each
+func (fs *ForumScraperGo) redactPII(content string) string {
+	content = emailPattern.ReplaceAllStringFunc(content, func(string) string {
+		atomic.AddInt64(&fs.stats.RedactedEmails, 1)
+		return "[email]"
+	})
+	return phoneCandidate.ReplaceAllStringFunc(content, func(match string) string {
+		// Keep surrounding whitespace the pattern may have swallowed
+		trimmed := strings.TrimSpace(match)
+		if !isPhoneNumber(trimmed) {
+			return match
+		}
+		atomic.AddInt64(&fs.stats.RedactedPhones, 1)
+		return strings.Replace(match, trimmed, "[phone]", 1)
+	})
+}
+
+// newAnonymizationSalt returns a random hex salt for runs that don't supply one
+func newAnonymizationSalt() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// anonymizeAuthor maps an author name to a stable token with an HMAC keyed by the
+// run's salt, so the same author keeps the same token within (and across) runs sharing a salt
+func (fs *ForumScraperGo) anonymizeAuthor(author string) string {
+	if author == "Anonymous" {
+		return author
+	}
+	mac := hmac.New(sha256.New, []byte(fs.anonymizeSalt))
+	mac.Write([]byte(author))
+	return "author_" + hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// applyPrivacy is the built-in processor for --anonymize-authors and --redact-pii. It runs
+// after the ignore rules (which need real author names) and the length check, and
+// before any other processor, so nothing downstream sees the original values.
+func (fs *ForumScraperGo) applyPrivacy(post *ForumPost) (*ForumPost, error) {
+	if fs.anonymizeSalt != "" {
+		post.Author = fs.anonymizeAuthor(post.Author)
+		if post.EditedBy != "" {
+			post.EditedBy = fs.anonymizeAuthor(post.EditedBy)
+		}
+		// Mentions take the same tokens as authors, so the mention graph still
+		// links up; the "@name" the post body shows for each goes too
+		for i, name := range post.Mentions {
+			post.Mentions[i] = fs.anonymizeAuthor(name)
+			post.Content = strings.ReplaceAll(post.Content, "@"+name, "@"+post.Mentions[i])
+		}
+	}
+	if fs.redactPIIEnabled {
+		post.Content = fs.redactPII(post.Content)
+	}
+	return post, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// privacyTopic is a phpBB topic in which alice @-mentions bob, who replies and
+// has his reply edited by the moderator carol
+const privacyTopic = `<!DOCTYPE html><html><head><meta charset="utf-8" /><title>Mount options</title></head><body>
+<h2 class="topic-title"><a href="./viewtopic.php?f=2&amp;t=7">Mount options</a></h2>
+<div id="p1" class="post"><div class="postbody"><p class="author">by <a class="username" href="./memberlist.php?u=1">alice</a> <time datetime="2024-03-11T08:00:00Z">Mon Mar 11, 2024</time></p>
+<div class="content"><a class="mention" href="./memberlist.php?u=2">@bob</a> which mount options did you end up using for the NVMe root?</div></div></div>
+<div id="p2" class="post"><div class="postbody"><p class="author">by <a class="username" href="./memberlist.php?u=2">bob</a> <time datetime="2024-03-11T09:00:00Z">Mon Mar 11, 2024</time></p>
+<div class="content">noatime and discard, nothing else; the defaults covered the rest of it for me.</div>
+<div class="notice">Last edited by carol on Mon Mar 11, 2024 10:00 am, edited 1 time in total.</div></div></div>
+</body></html>`
+
+// privacyServer serves page for every path
+func privacyServer(t *testing.T, page string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, page)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAnonymizedAuthorsLeaveNoNames(t *testing.T) {
+	server := privacyServer(t, privacyTopic)
+	scraper := NewForumScraper("phpbb", 0, WithAnonymizedAuthors("salt"))
+	scraper.statusOut = io.Discard
+	thread, err := scraper.scrapeThread(server.URL+"/viewtopic.php?f=2&t=7", fixtureMaxPosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(thread.Posts) != 2 {
+		t.Fatalf("got %d posts, want 2", len(thread.Posts))
+	}
+
+	// The mention links to the same token as bob's own posts
+	bob := scraper.anonymizeAuthor("bob")
+	if mentions := thread.Posts[0].Mentions; len(mentions) != 1 || mentions[0] != bob {
+		t.Errorf("mentions %v, want [%s]", mentions, bob)
+	}
+	if author := thread.Posts[1].Author; author != bob {
+		t.Errorf("reply author %q, want %s", author, bob)
+	}
+	if editor, want := thread.Posts[1].EditedBy, scraper.anonymizeAuthor("carol"); editor != want {
+		t.Errorf("EditedBy %q, want %s", editor, want)
+	}
+
+	data, err := json.Marshal(thread)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"alice", "bob", "carol"} {
+		if strings.Contains(string(data), name) {
+			t.Errorf("output contains the name %q:\n%s", name, data)
+		}
+	}
+}
+
+func TestPostLengthMeasuredBeforeRedaction(t *testing.T) {
+	scraper := NewForumScraper("phpbb", 0, WithPIIRedaction(true), WithPostLength(30, 0, false))
+	scraper.statusOut = io.Discard
+
+	// 35 runes as written, 16 once redacted
+	post, reason := scraper.processPost(&ForumPost{Content: "mail me: alice.longname@example.org"})
+	if post == nil {
+		t.Fatalf("post dropped (%s), want it measured before redaction and kept", reason)
+	}
+	if post.Content != "mail me: [email]" {
+		t.Errorf("content %q, want the address redacted", post.Content)
+	}
+
+	// Too short either way: dropped, and its address isn't counted
+	if post, _ := scraper.processPost(&ForumPost{Content: "bob@example.org"}); post != nil {
+		t.Errorf("short post kept: %q", post.Content)
+	}
+	if got := scraper.stats.RedactedEmails; got != 1 {
+		t.Errorf("RedactedEmails = %d, want 1 for the kept post only", got)
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// PostProcessor transforms a post after extraction and before it reaches any output.
+// Returning a nil post drops it; returning an error logs it and leaves the post unchanged.
+// Posts of one thread are processed concurrently, so implementations must be goroutine-safe.
+type PostProcessor interface {
+	Process(*ForumPost) (*ForumPost, error)
+}
+
+// ThreadFilter decides whether a fully scraped thread is kept
+type ThreadFilter interface {
+	Keep(*ForumThread) bool
+}
+
+// PostProcessorFunc adapts a function to the PostProcessor interface
+type PostProcessorFunc func(*ForumPost) (*ForumPost, error)
+
+// Process calls f(post)
+func (f PostProcessorFunc) Process(post *ForumPost) (*ForumPost, error) { return f(post) }
+
+// ThreadFilterFunc adapts a function to the ThreadFilter interface
+type ThreadFilterFunc func(*ForumThread) bool
+
+// Keep calls f(thread)
+func (f ThreadFilterFunc) Keep(thread *ForumThread) bool { return f(thread) }
+
+// SkipPost is returned by a PostProcessor to drop a post for a reason that is counted
+// in the thread's skipped_posts rather than logged as a processor error
+type SkipPost struct {
+	Reason string
+}
+
+func (s *SkipPost) Error() string { return "post skipped: " + s.Reason }
+
+// LengthProcessor enforces content length bounds measured in runes. Posts shorter
+// than MinRunes are dropped; posts longer than a non-zero MaxRunes are dropped, or
+// cut to MaxRunes with an ellipsis when Truncate is set.
+type LengthProcessor struct {
+	MinRunes int
+	MaxRunes int
+	Truncate bool
+}
+
+// Process implements PostProcessor
+func (p LengthProcessor) Process(post *ForumPost) (*ForumPost, error) {
+	length := utf8.RuneCountInString(post.Content)
+	if length < p.MinRunes {
+		return nil, &SkipPost{Reason: "too_short"}
+	}
+	if p.MaxRunes > 0 && length > p.MaxRunes {
+		if !p.Truncate {
+			return nil, &SkipPost{Reason: "too_long"}
+		}
+		post.Content = string([]rune(post.Content)[:p.MaxRunes]) + "…"
+	}
+	return post, nil
+}
+
+// MinLikesProcessor drops posts with fewer than MinLikes likes, counting a post
+// without a like count as having none. Accepted answers are kept regardless.
+type MinLikesProcessor struct {
+	MinLikes int
+}
+
+// Process implements PostProcessor
+func (p MinLikesProcessor) Process(post *ForumPost) (*ForumPost, error) {
+	if post.IsAcceptedAnswer || likesOf(post) >= p.MinLikes {
+		return post, nil
+	}
+	return nil, &SkipPost{Reason: "too_few_likes"}
+}
+
+// quoteHeaderPattern matches the attribution line boards put above a quoted reply
+var quoteHeaderPattern = regexp.MustCompile(`(?i)^\s*\S.{0,60}\s(wrote|said|schrieb|a écrit|escribió):\s*$|^\s*quote:?\s*$`)
+
+// QuoteStripProcessor removes quoted replies ("> ..." lines and "X wrote:" headers)
+// from a post's flattened text, for posts whose quotes aren't marked up.
+// --strip-quotes (WithQuoteStripping) removes quote elements before the text is
+// read instead, which this can't tell apart from a quote character in the text.
+type QuoteStripProcessor struct{}
+
+// Process implements PostProcessor
+func (QuoteStripProcessor) Process(post *ForumPost) (*ForumPost, error) {
+	post.Content = stripQuoteLines(post.Content)
+	return post, nil
+}
+
+// stripQuoteLines drops "> ..." lines and "X wrote:" headers from text
+func stripQuoteLines(text string) string {
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), ">") || quoteHeaderPattern.MatchString(line) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// fragmentQuotes are the quote elements of post bodies from JSON APIs: Stack
+// Exchange's blockquotes and Discourse's quote asides
+var fragmentQuotes = selectorChain{"blockquote", "aside.quote"}
+
+// withoutQuotes returns body minus the elements quotes matches, cloned only when
+// there are any
+func withoutQuotes(body *goquery.Selection, quotes selectorChain) *goquery.Selection {
+	if quotes.findAll(body).Length() == 0 {
+		return body
+	}
+	body = body.Clone()
+	quotes.findAll(body).Remove()
+	return body
+}
+
+// apiPostText turns the HTML body of a post from a JSON API into plain text,
+// minus quoted replies under --strip-quotes. Hacker News marks quotes with a
+// leading ">" rather than an element, so quote lines go too.
+func (fs *ForumScraperGo) apiPostText(fragment string) string {
+	if !fs.stripQuotes {
+		return htmlText(fragment)
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(fragment))
+	if err != nil {
+		return stripQuoteLines(htmlText(fragment))
+	}
+	// Paragraphs become lines, so a quoted paragraph is a quote line
+	body := withoutQuotes(doc.Selection, fragmentQuotes)
+	body.Find("p, br").BeforeHtml("\n")
+	return stripQuoteLines(validUTF8(body.Text()))
+}
+
+// processPost runs the post through the processor chain in order, stopping when one
+// drops it. A dropped post comes back nil with the reason it was skipped.
+func (fs *ForumScraperGo) processPost(post *ForumPost) (*ForumPost, string) {
+	for _, processor := range fs.postProcessors {
+		processed, err := processor.Process(post)
+		var skip *SkipPost
+		if errors.As(err, &skip) {
+			return nil, skip.Reason
+		}
+		if err != nil {
+			atomic.AddInt64(&fs.stats.ProcessorErrors, 1)
+			fs.statusf("⚠️ Post processor failed on %s: %v\n", post.URL, err)
+			continue
+		}
+		if processed == nil {
+			return nil, "filtered"
+		}
+		post = processed
+	}
+	return post, ""
+}
+
+// keepThread reports whether every thread filter keeps the thread
+func (fs *ForumScraperGo) keepThread(thread *ForumThread) bool {
+	for _, filter := range fs.threadFilters {
+		if !filter.Keep(thread) {
+			return false
+		}
+	}
+	return true
+}
+
+// ignoreRules are a platform's compiled IgnoreAuthorPatterns and IgnoreContentPatterns
+type ignoreRules struct {
+	authors  []*regexp.Regexp
+	contents []*regexp.Regexp
+}
+
+// compilePatterns compiles patterns, skipping any that are invalid
+func compilePatterns(patterns []string) []*regexp.Regexp {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		if re, err := regexp.Compile(pattern); err == nil {
+			compiled = append(compiled, re)
+		}
+	}
+	return compiled
+}
+
+// ignorePlaceholder is the built-in processor that skips bot, system and deleted-post
+// placeholders matching the platform's ignore patterns. The patterns are compiled on
+// first use so configs loaded after construction are honored.
+func (fs *ForumScraperGo) ignorePlaceholder(post *ForumPost) (*ForumPost, error) {
+	fs.ignoreRulesOnce.Do(func() {
+		config, exists := fs.configs[fs.platform]
+		if !exists {
+			config = fs.configs["generic"]
+		}
+		fs.ignoreRules = &ignoreRules{
+			authors:  compilePatterns(config.IgnoreAuthorPatterns),
+			contents: compilePatterns(config.IgnoreContentPatterns),
+		}
+	})
+
+	for _, re := range fs.ignoreRules.authors {
+		if re.MatchString(post.Author) {
+			return nil, &SkipPost{Reason: "ignored_author"}
+		}
+	}
+	for _, re := range fs.ignoreRules.contents {
+		if re.MatchString(post.Content) {
+			return nil, &SkipPost{Reason: "ignored_content"}
+		}
+	}
+	return post, nil
+}
+
+// bbcodeTag matches an opening or closing BBCode tag, e.g. [b], [/b], [font=Arial], [url=...]
+var bbcodeTag = regexp.MustCompile(`(?i)\[/?(?:b|i|u|s|url|img|font|size|color|colour|quote|code|center|left|right|justify|list|\*|spoiler|email|sub|sup|table|tr|td|th|hr|indent|highlight|youtube|media|attach)(?:=[^\]]*)?\]`)
+
+// invisibleChars are zero-width and bidi control characters that survive text extraction
+var invisibleChars = strings.NewReplacer(
+	"\u200b", "", "\u200c", "", "\u200d", "", "\u2060", "", "\ufeff", "",
+	"\u200e", "", "\u200f", "", "\u202a", "", "\u202b", "", "\u202c", "", "\u202d", "", "\u202e", "",
+	"\u2066", "", "\u2067", "", "\u2068", "", "\u2069", "",
+)
+
+// horizontalSpace matches runs of spaces, tabs and non-breaking spaces within a line
+var horizontalSpace = regexp.MustCompile(`[ \t\x{00a0}\f\v]+`)
+
+// NormalizeProcessor cleans extracted text: it strips raw BBCode tags (keeping their
+// inner text) and invisible control characters, collapses whitespace within lines,
+// trims each line and squeezes runs of blank lines down to one
+type NormalizeProcessor struct{}
+
+// Process implements PostProcessor
+func (NormalizeProcessor) Process(post *ForumPost) (*ForumPost, error) {
+	text := invisibleChars.Replace(post.Content)
+	text = bbcodeTag.ReplaceAllString(text, "")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(horizontalSpace.ReplaceAllString(line, " "))
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	post.Content = strings.Join(lines, "\n")
+	return post, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// quotedPhpbbPost is a phpBB post quoting another, whose own text has a line
+// starting with ">" and one ending "wrote:" that the text heuristics would drop
+const quotedPhpbbPost = `<div id="p1002" class="post"><div class="postbody">
+<p class="author"><a class="username">user2</a></p>
+<div class="content"><blockquote><div><cite>user1 wrote:</cite>Store them somewhere cool and dark.</div></blockquote>
+Agreed, and keep the temperature steady.<br>
+> 4°C is what my grandfather swore by<br>
+My neighbour wrote:</div>
+</div></div>`
+
+func newQuoteScraper(strip bool) *ForumScraperGo {
+	scraper := NewForumScraper("phpbb", 0, WithQuoteStripping(strip), WithPostLength(1, 0, false))
+	scraper.statusOut = io.Discard
+	return scraper
+}
+
+func TestStripQuotesRemovesQuoteElements(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(quotedPhpbbPost))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scraper := newQuoteScraper(true)
+	post, reason := scraper.scrapePost(doc.Find(".post"), scraper.configs["phpbb"], "Seed potatoes", "https://forum.example.com/viewtopic.php?t=7", 2)
+	if post == nil {
+		t.Fatalf("post skipped: %s", reason)
+	}
+	if strings.Contains(post.Content, "somewhere cool") || strings.Contains(post.Content, "user1 wrote") {
+		t.Errorf("quote kept: %q", post.Content)
+	}
+	for _, own := range []string{"keep the temperature steady", "> 4°C", "My neighbour wrote:"} {
+		if !strings.Contains(post.Content, own) {
+			t.Errorf("author's own text %q dropped: %q", own, post.Content)
+		}
+	}
+
+	unstripped, _ := newQuoteScraper(false).scrapePost(doc.Find(".post"), scraper.configs["phpbb"], "Seed potatoes", "https://forum.example.com/viewtopic.php?t=7", 2)
+	if unstripped == nil || !strings.Contains(unstripped.Content, "somewhere cool") {
+		t.Error("quote removed without --strip-quotes")
+	}
+}
+
+func TestStripQuotesQuoteOnlyPost(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<div class="post"><a class="username">user2</a><div class="content"><blockquote>Quoted only</blockquote></div></div>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scraper := newQuoteScraper(true)
+	if post, reason := scraper.scrapePost(doc.Find(".post"), scraper.configs["phpbb"], "", "https://forum.example.com/viewtopic.php?t=7", 1); post != nil || reason != "quote_only" {
+		t.Errorf("scrapePost = %v, %q; want skipped as quote_only", post, reason)
+	}
+}
+
+func TestAPIPostTextStripsQuotes(t *testing.T) {
+	tests := []struct {
+		name, fragment, want string
+	}{
+		{"stack exchange", `<blockquote><p>How do I store them?</p></blockquote><p>Somewhere cool.</p>`, "Somewhere cool."},
+		{"discourse", `<aside class="quote"><blockquote>Old post</blockquote></aside><p>New reply</p>`, "New reply"},
+		{"hacker news", `<p>&gt; quoted line<p>My answer`, "My answer"},
+	}
+	scraper := newQuoteScraper(true)
+	for _, tt := range tests {
+		if got := strings.TrimSpace(scraper.apiPostText(tt.fragment)); got != tt.want {
+			t.Errorf("%s: apiPostText = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeProcessor(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"empty", "", ""},
+		{"nested BBCode", `[quote="user1"][b]Bold[/b] and [i]italic[/i][/quote]`, "Bold and italic"},
+		{"BBCode with values", "[URL=https://example.com/a?b=1]link[/URL] in [COLOR=#ff0000]red[/color]", "link in red"},
+		{"mixed case tags", "[B]loud[/b]", "loud"},
+		{"unclosed tag", "[b]bold to the end", "bold to the end"},
+		{"bracket after a tag value", "[url=a]b]", "b]"},
+		{"BBCode only", "[b][/b][size=150][/size]", ""},
+		{"unknown tags kept", "[x] done, [notatag] kept", "[x] done, [notatag] kept"},
+		{"BBCode list", "[list]\n[*]one\n[*]two\n[/list]", "one\ntwo"},
+		{"horizontal whitespace", "  lots\t\tof \u00a0 space  ", "lots of space"},
+		{"form feed and vertical tab", "a\fb\vc", "a b c"},
+		{"CRLF blank run", "first\r\n\r\n\r\n\r\nsecond\r\n", "first\n\nsecond"},
+		{"leading blank lines", "\n\n\nbody", "body"},
+		{"whitespace-only lines", "a\n \t \n\u00a0\nb", "a\n\nb"},
+		{"zero-width and bidi", "pass\u200bword\ufeff and \u202eevil\u202c", "password and evil"},
+		{"zero-width-only line", "a\n\u200b\u2060\nb", "a\n\nb"},
+		{"zero-width between spaces", "a \u200b b", "a b"},
+	}
+	for _, tt := range tests {
+		post, err := NormalizeProcessor{}.Process(&ForumPost{Content: tt.content})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if post.Content != tt.want {
+			t.Errorf("%s: Normalize(%q) = %q, want %q", tt.name, tt.content, post.Content, tt.want)
+		}
+	}
+}
+
+func TestFilteredThreadsAreSkipped(t *testing.T) {
+	tests := []struct {
+		skipType string
+		opt      Option
+	}{
+		// The fixture topic is in English
+		{"language_filtered", WithLanguages("de")},
+		{"thread_filtered", WithThreadFilters(ThreadFilterFunc(func(*ForumThread) bool { return false }))},
+	}
+	for _, tt := range tests {
+		server := topicHost(t, 0)
+		scraper := NewForumScraper("phpbb", 0, tt.opt)
+		checkSkipped(t, scraper, server.URL+"/viewtopic.php?f=2&t=101", tt.skipType)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// scraperVersion and gitCommit identify the build in provenance records. Release
+// builds set them with:
+//
+//	go build -ldflags "-X main.scraperVersion=2.1.0 -X main.gitCommit=$(git rev-parse --short HEAD)"
+var (
+	scraperVersion = "dev"
+	gitCommit      = ""
+)
+
+// Provenance records how a thread was obtained, for auditing
+type Provenance struct {
+	RequestURL     string    `json:"request_url"`
+	FinalURL       string    `json:"final_url"`
+	HTTPStatus     int       `json:"http_status,omitempty"`
+	Server         string    `json:"server,omitempty"`
+	Rendered       bool      `json:"rendered,omitempty"`
+	FetchStartedAt time.Time `json:"fetch_started_at"`
+	FetchEndedAt   time.Time `json:"fetch_ended_at"`
+	PagesFetched   int       `json:"pages_fetched"`
+	Retries        int       `json:"retries"`
+	ScraperVersion string    `json:"scraper_version"`
+	GitCommit      string    `json:"git_commit,omitempty"`
+	// MetadataSources names where each thread-level field came from: "json-ld",
+	// "opengraph", "selector", "preloaded", "regex" or "index"
+	MetadataSources map[string]string `json:"metadata_sources,omitempty"`
+	// Note explains a thread cut short, such as by --thread-timeout
+	Note string `json:"note,omitempty"`
+}
+
+// provenanceKey carries a thread's *Provenance through the fetch layer in a context
+type provenanceKey struct{}
+
+// withProvenance starts a provenance record for a fetch of requestURL and returns
+// a context that the fetch layer records into, tagged with a new thread scrape number
+func (fs *ForumScraperGo) withProvenance(ctx context.Context, requestURL string) (context.Context, *Provenance) {
+	provenance := &Provenance{
+		RequestURL:     requestURL,
+		FetchStartedAt: fs.now(),
+		ScraperVersion: scraperVersion,
+		GitCommit:      gitCommit,
+	}
+	return context.WithValue(fs.withWorker(ctx), provenanceKey{}, provenance), provenance
+}
+
+// recordFetch notes a successful response in the provenance carried by ctx, if any
+func (fs *ForumScraperGo) recordFetch(ctx context.Context, resp *http.Response) {
+	provenance, ok := ctx.Value(provenanceKey{}).(*Provenance)
+	if !ok {
+		return
+	}
+	provenance.FinalURL = resp.Request.URL.String()
+	provenance.HTTPStatus = resp.StatusCode
+	provenance.Server = resp.Header.Get("Server")
+	provenance.PagesFetched++
+	provenance.FetchEndedAt = fs.now()
+}
+
+// recordRetry counts a retried request in the provenance carried by ctx, if any
+func (fs *ForumScraperGo) recordRetry(ctx context.Context) {
+	provenance, ok := ctx.Value(provenanceKey{}).(*Provenance)
+	if !ok {
+		return
+	}
+	provenance.Retries++
+}
+
+// recordRender notes a page loaded through the headless browser
+func (fs *ForumScraperGo) recordRender(ctx context.Context, finalURL string) {
+	provenance, ok := ctx.Value(provenanceKey{}).(*Provenance)
+	if !ok {
+		return
+	}
+	provenance.FinalURL = finalURL
+	provenance.Rendered = true
+	provenance.PagesFetched++
+	provenance.FetchEndedAt = fs.now()
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyTopicServer serves the phpBB fixture topic after failing the first
+// failures requests with status
+func flakyTopicServer(t *testing.T, failures int64, status int) (*httptest.Server, *int64) {
+	t.Helper()
+	page, err := os.ReadFile(filepath.Join(fixturesDir, "phpbb/viewtopic.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&requests, 1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// fastRetries shrinks the retry backoff for the length of a test
+func fastRetries(t *testing.T) {
+	base := retryBackoffBase
+	retryBackoffBase = time.Millisecond
+	t.Cleanup(func() { retryBackoffBase = base })
+}
+
+func TestRetriesRecordedInProvenance(t *testing.T) {
+	fastRetries(t)
+	server, requests := flakyTopicServer(t, 2, http.StatusServiceUnavailable)
+	scraper := NewForumScraper("phpbb", 0, WithRetries(3))
+	scraper.statusOut = io.Discard
+
+	thread, err := scraper.scrapeThread(server.URL+"/viewtopic.php?f=2&t=101", fixtureMaxPosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt64(requests); got != 3 {
+		t.Errorf("requests = %d, want 3", got)
+	}
+	if thread.Provenance.Retries != 2 {
+		t.Errorf("Provenance.Retries = %d, want 2", thread.Provenance.Retries)
+	}
+	if got := scraper.stats.snapshot().RequestRetries; got != 2 {
+		t.Errorf("RequestRetries = %d, want 2", got)
+	}
+}
+
+func TestRetriesGiveUp(t *testing.T) {
+	fastRetries(t)
+	server, requests := flakyTopicServer(t, 5, http.StatusServiceUnavailable)
+	scraper := NewForumScraper("phpbb", 0, WithRetries(1))
+	scraper.statusOut = io.Discard
+
+	_, err := scraper.scrapeThread(server.URL+"/viewtopic.php?f=2&t=101", fixtureMaxPosts)
+	var statusErr *httpStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("err = %v, want HTTP 503", err)
+	}
+	if got := atomic.LoadInt64(requests); got != 2 {
+		t.Errorf("requests = %d, want 2", got)
+	}
+}
+
+func TestNoRetryOnNotFound(t *testing.T) {
+	fastRetries(t)
+	server, requests := flakyTopicServer(t, 1, http.StatusNotFound)
+	scraper := NewForumScraper("phpbb", 0, WithRetries(3))
+	scraper.statusOut = io.Discard
+
+	if _, err := scraper.scrapeThread(server.URL+"/viewtopic.php?f=2&t=101", fixtureMaxPosts); err == nil {
+		t.Fatal("want the 404 reported")
+	}
+	if got := atomic.LoadInt64(requests); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
+
+func TestRetryableRequest(t *testing.T) {
+	refused := &url.Error{Op: "Get", URL: "http://forum.test/", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"503", &httpStatusError{StatusCode: 503}, true},
+		{"429", &httpStatusError{StatusCode: 429}, true},
+		{"404", &httpStatusError{StatusCode: 404}, false},
+		{"500", &httpStatusError{StatusCode: 500}, false},
+		{"timeout", fmt.Errorf("%w: http://forum.test/", ErrTimeout), true},
+		{"connection refused", refused, true},
+		{"connection dropped", &url.Error{Op: "Get", URL: "http://forum.test/", Err: io.ErrUnexpectedEOF}, true},
+		{"no such host", &url.Error{Op: "Get", URL: "http://forum.test/", Err: &net.DNSError{Err: "no such host", IsNotFound: true}}, false},
+		{"redirect loop", &url.Error{Op: "Get", URL: "http://forum.test/", Err: ErrRedirectLoop}, false},
+		{"budget", ErrBudgetExhausted, false},
+		{"bot challenge", ErrBotChallenge, false},
+		{"cancelled", &url.Error{Op: "Get", URL: "http://forum.test/", Err: context.Canceled}, false},
+	}
+	for _, tt := range tests {
+		if got := retryableRequest(tt.err); got != tt.want {
+			t.Errorf("%s: retryableRequest = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// --format qa-jsonl turns each thread into one question/answer pair: the opening
+// post asks, and the accepted answer, or failing that the most-liked reply,
+// answers. Which replies may answer is decided by qaAnswerProcessors (a reply any
+// of them drops is never chosen) and which threads yield a pair by
+// qaThreadFilters, so callers can swap the heuristics through the options.
+
+// Defaults for the built-in answer heuristics
+const (
+	defaultQAMinAnswerChars = 20
+	defaultQAMinAnswerLikes = 0
+)
+
+// Reasons a thread yields no Q&A pair
+const (
+	qaSkipTooFewPosts = "too_few_posts"
+	qaSkipFiltered    = "filtered"
+	qaSkipNoAnswer    = "no_answer"
+)
+
+// QAPair is one record of --format qa-jsonl
+type QAPair struct {
+	URL              string   `json:"url"`
+	Title            string   `json:"title"`
+	Category         string   `json:"category,omitempty"`
+	Tags             []string `json:"tags,omitempty"`
+	Language         string   `json:"language,omitempty"`
+	Question         string   `json:"question"`
+	Answer           string   `json:"answer"`
+	QuestionAuthor   string   `json:"question_author,omitempty"`
+	AnswerAuthor     string   `json:"answer_author,omitempty"`
+	AnswerURL        string   `json:"answer_url,omitempty"`
+	AnswerPostNumber int      `json:"answer_post_number"`
+	Accepted         bool     `json:"accepted"`
+	QuestionLikes    *int     `json:"question_likes,omitempty"`
+	AnswerLikes      *int     `json:"answer_likes,omitempty"`
+	AnswerScore      float64  `json:"answer_score,omitempty"`
+	ViewsCount       *int     `json:"views_count,omitempty"`
+	RepliesCount     int      `json:"replies_count"`
+}
+
+// qaPair builds thread's Q&A pair, or returns why it has none
+func (fs *ForumScraperGo) qaPair(thread *ForumThread) (*QAPair, string) {
+	if len(thread.Posts) < 2 {
+		return nil, qaSkipTooFewPosts
+	}
+	for _, filter := range fs.qaThreadFilters {
+		if !filter.Keep(thread) {
+			return nil, qaSkipFiltered
+		}
+	}
+
+	question := thread.Posts[0]
+	var answer *ForumPost
+	for _, reply := range thread.Posts[1:] {
+		// The asker's own follow-ups ("thanks, that fixed it") answer nothing unless accepted
+		if !reply.IsAcceptedAnswer && reply.Author != "" && reply.Author == question.Author {
+			continue
+		}
+		candidate, ok := fs.qaCandidate(reply)
+		if ok && (answer == nil || betterAnswer(candidate, answer)) {
+			answer = candidate
+		}
+	}
+	if answer == nil {
+		return nil, qaSkipNoAnswer
+	}
+
+	return &QAPair{
+		URL:              thread.URL,
+		Title:            thread.Title,
+		Category:         thread.Category,
+		Tags:             thread.Tags,
+		Language:         thread.Language,
+		Question:         strings.TrimSpace(thread.Title + "\n\n" + question.Content),
+		Answer:           answer.Content,
+		QuestionAuthor:   question.Author,
+		AnswerAuthor:     answer.Author,
+		AnswerURL:        answer.URL,
+		AnswerPostNumber: answer.PostNumber,
+		Accepted:         answer.IsAcceptedAnswer,
+		QuestionLikes:    question.LikesCount,
+		AnswerLikes:      answer.LikesCount,
+		AnswerScore:      answer.Score,
+		ViewsCount:       thread.ViewsCount,
+		RepliesCount:     len(thread.Posts) - 1,
+	}, ""
+}
+
+// qaCandidate runs a copy of reply through the answer processors, returning it
+// as they leave it and whether all of them kept it. As with the post processors,
+// one that fails is reported and passed over.
+func (fs *ForumScraperGo) qaCandidate(reply ForumPost) (*ForumPost, bool) {
+	post := &reply
+	for _, processor := range fs.qaAnswerProcessors {
+		processed, err := processor.Process(post)
+		var skip *SkipPost
+		if errors.As(err, &skip) {
+			return nil, false
+		}
+		if err != nil {
+			atomic.AddInt64(&fs.stats.ProcessorErrors, 1)
+			fs.statusf("⚠️ Answer processor failed on %s: %v\n", post.URL, err)
+			continue
+		}
+		if processed == nil {
+			return nil, false
+		}
+		post = processed
+	}
+	return post, true
+}
+
+// betterAnswer reports whether a should answer rather than b: an accepted answer
+// wins whatever its likes, then more likes win. Ties keep b, the earlier reply.
+func betterAnswer(a, b *ForumPost) bool {
+	if a.IsAcceptedAnswer != b.IsAcceptedAnswer {
+		return a.IsAcceptedAnswer
+	}
+	return likesOf(a) > likesOf(b)
+}
+
+// likesOf returns a post's like count, 0 when the platform shows none
+func likesOf(post *ForumPost) int {
+	if post.LikesCount == nil {
+		return 0
+	}
+	return *post.LikesCount
+}
+
+// qaPairs builds the pairs of threads in order, counting the threads without
+// one by reason
+func (fs *ForumScraperGo) qaPairs(threads []*ForumThread) ([]QAPair, map[string]int) {
+	var pairs []QAPair
+	skipped := make(map[string]int)
+	for _, thread := range threads {
+		pair, reason := fs.qaPair(thread)
+		if pair == nil {
+			fs.debugf("No Q&A pair for %s: %s", thread.URL, reason)
+			skipped[reason]++
+			continue
+		}
+		pairs = append(pairs, *pair)
+	}
+	return pairs, skipped
+}
+
+// writeQAPairs writes pairs to w, one JSON object per line
+func writeQAPairs(w io.Writer, pairs []QAPair) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, pair := range pairs {
+		if err := encoder.Encode(pair); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveQAPairs writes the --format qa-jsonl export named like the results file,
+// with a .jsonl extension, or to stdout for -
+func (fs *ForumScraperGo) saveQAPairs(threads []*ForumThread, name string) ([]string, error) {
+	threads, path := fs.jsonlExportTarget(threads, name)
+	pairs, skipped := fs.qaPairs(threads)
+
+	var reasons []string
+	for reason, count := range skipped {
+		reasons = append(reasons, fmt.Sprintf("%s %d", reason, count))
+	}
+	sort.Strings(reasons)
+	if len(reasons) > 0 {
+		fs.statusf("📊 Threads without a Q&A pair: %s\n", strings.Join(reasons, ", "))
+	}
+
+	err := writeJSONLExport(path, func(w io.Writer) error {
+		return writeQAPairs(w, pairs)
+	})
+	if err != nil || path == "-" {
+		return nil, err
+	}
+	fs.statusf("💾 Q&A pairs saved to: %s (%d pairs)\n", path, len(pairs))
+	return []string{path}, nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// The qa fixture is a results file of threads covering the answer heuristics
+// (an accepted answer with fewer likes than another reply, tied likes, a lone
+// post, a too-short reply, the asker thanking), checked against the
+// --format qa-jsonl pairs it should produce with the default heuristics
+const (
+	qaFixtureResults = "qa/results.jsonl"
+	qaFixtureGolden  = "qa/golden.jsonl"
+)
+
+func TestQAFixture(t *testing.T) {
+	scraper := NewForumScraper("generic", 0, WithQAMinAnswer(defaultQAMinAnswerChars, defaultQAMinAnswerLikes))
+	scraper.statusOut = io.Discard
+	pairs, _ := scraper.qaPairs(readFixtureThreads(t, qaFixtureResults))
+	var encoded bytes.Buffer
+	if err := writeQAPairs(&encoded, pairs); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, qaFixtureGolden, encoded.Bytes())
+}
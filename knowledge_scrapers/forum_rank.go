@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Ranking keys for --sort
+const (
+	rankByViews   = "views"
+	rankByReplies = "replies"
+	rankByRecent  = "recent"
+	rankByPosts   = "posts"
+)
+
+// rankEntry is the part of a thread ranking looks at, and one line of the rank
+// index written for streamed output
+type rankEntry struct {
+	Rank         int    `json:"rank"`
+	URL          string `json:"url"`
+	ThreadID     string `json:"thread_id,omitempty"`
+	Title        string `json:"title"`
+	ViewsCount   *int   `json:"views_count,omitempty"`
+	RepliesCount int    `json:"replies_count"`
+	LastPostAt   string `json:"last_post_at,omitempty"`
+	Posts        int    `json:"posts"`
+
+	// value, hasValue and sortURL are what rankLess compares, worked out once by
+	// scoreEntries before sorting
+	value    float64
+	hasValue bool
+	sortURL  string
+}
+
+// rankIndex is the file --sort writes beside streamed JSONL output, which can't be reordered
+type rankIndex struct {
+	SortedBy     string      `json:"sorted_by"`
+	Top          int         `json:"top,omitempty"`
+	TotalThreads int         `json:"total_threads"`
+	Threads      []rankEntry `json:"threads"`
+}
+
+func newRankEntry(thread *ForumThread) rankEntry {
+	return rankEntry{
+		URL:          thread.URL,
+		ThreadID:     thread.ThreadID,
+		Title:        thread.Title,
+		ViewsCount:   thread.ViewsCount,
+		RepliesCount: thread.RepliesCount,
+		LastPostAt:   thread.LastPostAt,
+		Posts:        len(thread.Posts),
+	}
+}
+
+// validRankKey reports whether key is a --sort value
+func validRankKey(key string) bool {
+	switch key {
+	case rankByViews, rankByReplies, rankByRecent, rankByPosts:
+		return true
+	}
+	return false
+}
+
+// rankValue returns an entry's value for the ranking key; false means it has
+// none (no view count, or a last post time the platform's formats can't parse)
+func (fs *ForumScraperGo) rankValue(entry rankEntry, key string) (float64, bool) {
+	switch key {
+	case rankByViews:
+		if entry.ViewsCount == nil {
+			return 0, false
+		}
+		return float64(*entry.ViewsCount), true
+	case rankByReplies:
+		return float64(entry.RepliesCount), true
+	case rankByRecent:
+		if t := fs.postTime(entry.LastPostAt); t != nil {
+			return float64(t.Unix()), true
+		}
+		return 0, false
+	case rankByPosts:
+		return float64(entry.Posts), true
+	}
+	return 0, false
+}
+
+// scoreEntries works out each entry's --sort value and tie-break URL, so sorting
+// doesn't parse dates or normalize URLs on every comparison
+func (fs *ForumScraperGo) scoreEntries(entries []rankEntry) {
+	for i := range entries {
+		entries[i].value, entries[i].hasValue = fs.rankValue(entries[i], fs.sortBy)
+		entries[i].sortURL = normalizeURL(entries[i].URL)
+	}
+}
+
+// rankLess orders scored entries highest value first, entries without a value
+// last, and ties by normalized URL so the order is deterministic
+func rankLess(a, b rankEntry) bool {
+	if a.hasValue != b.hasValue {
+		return a.hasValue
+	}
+	if a.value != b.value {
+		return a.value > b.value
+	}
+	return a.sortURL < b.sortURL
+}
+
+// rankThreads orders threads by the --sort key and keeps the --top highest
+func (fs *ForumScraperGo) rankThreads(threads []*ForumThread) []*ForumThread {
+	entries := make([]rankEntry, len(threads))
+	for i, thread := range threads {
+		entries[i] = newRankEntry(thread)
+	}
+	fs.scoreEntries(entries)
+	sort.Sort(rankedThreads{threads: threads, entries: entries})
+	if fs.top > 0 && len(threads) > fs.top {
+		threads = threads[:fs.top]
+	}
+	return threads
+}
+
+// rankedThreads sorts threads and their rank entries together
+type rankedThreads struct {
+	threads []*ForumThread
+	entries []rankEntry
+}
+
+func (r rankedThreads) Len() int { return len(r.threads) }
+func (r rankedThreads) Less(i, j int) bool {
+	return rankLess(r.entries[i], r.entries[j])
+}
+func (r rankedThreads) Swap(i, j int) {
+	r.threads[i], r.threads[j] = r.threads[j], r.threads[i]
+	r.entries[i], r.entries[j] = r.entries[j], r.entries[i]
+}
+
+// saveRankIndex ranks the entries of streamed threads and writes them as an index
+// file, named like a results file with an -index suffix unless filename is given
+func (fs *ForumScraperGo) saveRankIndex(entries []rankEntry, filename string) (string, error) {
+	total := len(entries)
+	fs.scoreEntries(entries)
+	sort.SliceStable(entries, func(i, j int) bool { return rankLess(entries[i], entries[j]) })
+	if fs.top > 0 && len(entries) > fs.top {
+		entries = entries[:fs.top]
+	}
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+
+	if filename == "" {
+		filename = fs.resultsFilename(nil, fs.now())
+		ext := filepath.Ext(filename)
+		filename = strings.TrimSuffix(filename, ext) + "-index" + ext
+	}
+	path := filename
+	if filepath.Base(filename) == filename {
+		path = filepath.Join(fs.outputDir, filename)
+	}
+
+	index := rankIndex{SortedBy: fs.sortBy, Top: fs.top, TotalThreads: total, Threads: entries}
+	if index.Threads == nil {
+		index.Threads = []rankEntry{}
+	}
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(path, data, 0644); err != nil {
+		return "", err
+	}
+	fs.statusf("💾 Rank index saved to: %s (%d of %d threads)\n", path, len(entries), total)
+	return path, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestRankByRecentPlatformTimestamps(t *testing.T) {
+	scraper := NewForumScraper("vbulletin", 0, WithRanking(rankByRecent, 0))
+	threads := []*ForumThread{
+		{URL: "http://forum.test/showthread.php?t=1", LastPostAt: "12-25-2023, 10:00 PM"},
+		{URL: "http://forum.test/showthread.php?t=2", LastPostAt: "Yesterday, 09:02 AM"},
+		{URL: "http://forum.test/showthread.php?t=3", LastPostAt: "03-11-2024, 09:02 AM"},
+		{URL: "http://forum.test/showthread.php?t=4", LastPostAt: "2024-01-15T08:00:00Z"},
+	}
+	ranked := scraper.rankThreads(threads)
+
+	want := []string{"t=3", "t=4", "t=1", "t=2"}
+	for i, thread := range ranked {
+		if got := thread.URL[len(thread.URL)-3:]; got != want[i] {
+			t.Errorf("rank %d = %s, want %s", i+1, got, want[i])
+		}
+	}
+}
+
+func TestSaveRankIndexOrder(t *testing.T) {
+	scraper := NewForumScraper("generic", 0, WithRanking(rankByViews, 2), WithOutputDir(t.TempDir()))
+	views := func(n int) *int { return &n }
+	entries := []rankEntry{
+		{URL: "http://forum.test/thread/b", ViewsCount: views(10)},
+		{URL: "http://forum.test/thread/c"},
+		{URL: "http://forum.test/thread/a", ViewsCount: views(10)},
+		{URL: "http://forum.test/thread/d", ViewsCount: views(99)},
+	}
+	if _, err := scraper.saveRankIndex(entries, ""); err != nil {
+		t.Fatal(err)
+	}
+	// Sorted in place: highest first, ties by URL, entries without views last
+	want := []string{"d", "a", "b", "c"}
+	for i, entry := range entries {
+		if got := entry.URL[len(entry.URL)-1:]; got != want[i] {
+			t.Errorf("entry %d = %s, want %s", i, got, want[i])
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// recoveredError prints a recovered panic with its stack and converts it to an
+// ErrPanic error, so one bad page fails its thread instead of the whole run
+func (fs *ForumScraperGo) recoveredError(threadURL string, value interface{}) error {
+	fs.statusf("💥 Panic while scraping %s: %v\n%s", threadURL, value, debug.Stack())
+	return fmt.Errorf("%w: %v", ErrPanic, value)
+}
+
+// scrapeThreadSafe runs scrapeThread, turning a panic into an ErrPanic error
+func (fs *ForumScraperGo) scrapeThreadSafe(threadURL string, maxPosts int) (thread *ForumThread, err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			thread, err = nil, fs.recoveredError(threadURL, value)
+		}
+	}()
+	return fs.scrapeThread(threadURL, maxPosts)
+}
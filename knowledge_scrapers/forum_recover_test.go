@@ -0,0 +1,55 @@
+package main
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPanicFailsOnlyItsThread(t *testing.T) {
+	server := topicHost(t, 0)
+	tests := []struct {
+		name string
+		opt  Option
+	}{
+		{"post processor", WithPostProcessors(PostProcessorFunc(func(post *ForumPost) (*ForumPost, error) {
+			if strings.Contains(post.URL, "t=2#") {
+				panic("processor bug")
+			}
+			return post, nil
+		}))},
+		{"thread filter", WithThreadFilters(ThreadFilterFunc(func(thread *ForumThread) bool {
+			if thread.ThreadID == "2" {
+				panic("filter bug")
+			}
+			return true
+		}))},
+	}
+	for _, tt := range tests {
+		scraper := NewForumScraper("phpbb", 0, WithConcurrency(2, 2), tt.opt)
+		scraper.statusOut = io.Discard
+		refs := []ThreadRef{
+			{URL: server.URL + "/viewtopic.php?f=2&t=1"},
+			{URL: server.URL + "/viewtopic.php?f=2&t=2"},
+			{URL: server.URL + "/viewtopic.php?f=2&t=3"},
+		}
+
+		var scraped []string
+		err := scraper.scrapeRefsEach(refs, fixtureMaxPosts, func(thread *ForumThread) error {
+			scraped = append(scraped, thread.ThreadID)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%s: run failed: %v", tt.name, err)
+		}
+		slices.Sort(scraped)
+		if !slices.Equal(scraped, []string{"1", "3"}) {
+			t.Errorf("%s: scraped threads %v, want 1 and 3", tt.name, scraped)
+		}
+		failures := scraper.failures.snapshot()
+		if len(failures) != 1 || failures[0].URL != refs[1].URL || failures[0].Type != "panic" {
+			t.Errorf("%s: failures %+v, want thread 2 as a panic", tt.name, failures)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// oldRedditHost serves Reddit's legacy markup, whose stable .thing/.entry/.usertext-body
+// classes the reddit selectors target; new Reddit's generated markup changes too often
+const oldRedditHost = "old.reddit.com"
+
+// redditURL rewrites www.reddit.com, reddit.com and new.reddit.com URLs to
+// old.reddit.com when the platform is reddit and the rewrite is on
+func (fs *ForumScraperGo) redditURL(rawURL string) string {
+	if fs.platform != "reddit" || !fs.oldReddit {
+		return rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	switch strings.ToLower(u.Host) {
+	case "reddit.com", "www.reddit.com", "new.reddit.com":
+		u.Host = oldRedditHost
+		return u.String()
+	}
+	return rawURL
+}
+
+// signedNumber matches the first integer in a score label such as "-3 points"
+var signedNumber = regexp.MustCompile(`-?\d[\d,]*`)
+
+// scoreValue reads a post's score from the ScoreSelector element: its title
+// attribute when numeric (old Reddit's exact score), otherwise its text
+func scoreValue(selection *goquery.Selection) *int {
+	if selection.Length() == 0 {
+		return nil
+	}
+	candidates := []string{selection.Text()}
+	if title, exists := selection.Attr("title"); exists {
+		candidates = append([]string{title}, candidates...)
+	}
+	for _, candidate := range candidates {
+		if match := signedNumber.FindString(candidate); match != "" {
+			if score, err := strconv.Atoi(strings.ReplaceAll(match, ",", "")); err == nil {
+				return &score
+			}
+		}
+	}
+	return nil
+}
+
+// countAwards totals the AwardsSelector elements of a post, each counting its
+// data-count attribute or 1; nil when the post has none
+func countAwards(selection *goquery.Selection) *int {
+	if selection.Length() == 0 {
+		return nil
+	}
+	total := 0
+	selection.Each(func(_ int, award *goquery.Selection) {
+		count := 1
+		if value, exists := award.Attr("data-count"); exists {
+			if n, err := strconv.Atoi(value); err == nil {
+				count = n
+			}
+		}
+		total += count
+	})
+	return &total
+}
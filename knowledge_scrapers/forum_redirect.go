@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// defaultMaxRedirects caps the redirects followed for one request
+const defaultMaxRedirects = 10
+
+// siteHostPrefixes are the subdomains a board serves its desktop and mobile
+// sites on alongside the bare host
+var siteHostPrefixes = []string{"www.", "m."}
+
+// sameSite reports whether two hosts are the same board, ignoring a leading
+// "www." or mobile "m."
+func sameSite(a, b string) bool {
+	return siteHost(a) == siteHost(b)
+}
+
+// siteHost strips a host's www. or m. subdomain
+func siteHost(host string) string {
+	for _, prefix := range siteHostPrefixes {
+		if trimmed := strings.TrimPrefix(host, prefix); trimmed != host {
+			return trimmed
+		}
+	}
+	return host
+}
+
+// checkRedirect is the client's redirect policy: it stops loops and long chains with
+// typed errors and refuses to leave the original host unless --allow-external is set.
+// Request hooks see each redirect as its own request.
+func (fs *ForumScraperGo) checkRedirect(req *http.Request, via []*http.Request) error {
+	// Each hop is its own line in the access log
+	previous := via[len(via)-1]
+	if access := fs.startAccess(req.Context(), previous); access != nil && req.Response != nil {
+		fs.finishAccess(access, strconv.Itoa(req.Response.StatusCode))
+	}
+
+	target := normalizeURL(req.URL.String())
+	for _, previous := range via {
+		if normalizeURL(previous.URL.String()) == target {
+			return fmt.Errorf("%w: %s", ErrRedirectLoop, req.URL)
+		}
+	}
+	if req.URL.Scheme == "file" && via[0].URL.Scheme != "file" {
+		// Only local input may read local files, never a server's redirect
+		return fmt.Errorf("%w: %s redirected to %s", ErrExternalRedirect, via[0].URL, req.URL)
+	}
+	if len(via) > fs.maxRedirects {
+		return fmt.Errorf("%w: more than %d redirects at %s", ErrTooManyRedirects, fs.maxRedirects, req.URL)
+	}
+	if origin := via[0].URL.Hostname(); !sameSite(strings.ToLower(origin), strings.ToLower(req.URL.Hostname())) {
+		if !fs.allowExternal {
+			return fmt.Errorf("%w: %s redirected to %s", ErrExternalRedirect, via[0].URL, req.URL)
+		}
+		fs.stripAuth(req)
+	}
+	return fs.runRequestHooks(req)
+}
+
+// isVisited reports whether url has been scraped, without recording it
+func (fs *ForumScraperGo) isVisited(rawURL string) bool {
+	key := normalizeURL(rawURL)
+	fs.visitedMutex.RLock()
+	visited := fs.visitedURLs[key]
+	fs.visitedMutex.RUnlock()
+	if visited || fs.sharedVisited == nil {
+		return visited
+	}
+	shared, err := fs.sharedVisited.Contains(fs.runContext(), visitedURLKey(rawURL))
+	return err == nil && shared
+}
+
+// markVisited records url as scraped, reporting false if it already was, here or
+// by another worker sharing the visited set
+func (fs *ForumScraperGo) markVisited(rawURL string) bool {
+	key := normalizeURL(rawURL)
+	fs.visitedMutex.Lock()
+	if fs.visitedURLs[key] {
+		fs.visitedMutex.Unlock()
+		return false
+	}
+	fs.visitedURLs[key] = true
+	fs.visitedMutex.Unlock()
+	return fs.claimShared(visitedURLKey(rawURL))
+}
+
+// visitedURLKey is a thread URL's key in the shared visited set
+func visitedURLKey(rawURL string) string {
+	return "url:" + normalizeURL(rawURL)
+}
+
+// visitedIDKey is a thread ID's key in the shared visited set
+func visitedIDKey(rawURL, threadID string) string {
+	return "id:" + threadIDKey(rawURL, threadID)
+}
+
+// claimShared adds key to the visited set shared between workers, reporting false
+// if another worker already claimed it. When the shared set can't be reached each
+// worker goes on with its own.
+func (fs *ForumScraperGo) claimShared(key string) bool {
+	if fs.sharedVisited == nil {
+		return true
+	}
+	added, err := fs.sharedVisited.Add(fs.runContext(), key)
+	if err != nil {
+		fs.statusf("⚠️ Shared visited set unavailable: %v\n", err)
+		return true
+	}
+	return added
+}
+
+// releaseClaims removes a failed thread's keys from the shared visited set, so
+// another worker or a later job can try it again
+func (fs *ForumScraperGo) releaseClaims(keys []string) {
+	if fs.sharedVisited == nil || len(keys) == 0 {
+		return
+	}
+	if err := fs.sharedVisited.Remove(context.Background(), keys...); err != nil {
+		fs.statusf("⚠️ Failed to release %d visited key(s): %v\n", len(keys), err)
+	}
+}
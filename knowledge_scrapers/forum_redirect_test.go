@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// phpbbTopicServer serves the phpBB fixture topic at /viewtopic.php?f=2&t=101,
+// redirects /topic-101 to it and counts requests for it
+func phpbbTopicServer(t *testing.T) (*httptest.Server, *int64) {
+	t.Helper()
+	page, err := os.ReadFile(filepath.Join(fixturesDir, "phpbb/viewtopic.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fetches int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/topic-101":
+			http.Redirect(w, r, "/viewtopic.php?f=2&t=101", http.StatusMovedPermanently)
+		case "/viewtopic.php":
+			atomic.AddInt64(&fetches, 1)
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write(page)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &fetches
+}
+
+func TestScrapeThreadRaceFetchesOnce(t *testing.T) {
+	server, fetches := phpbbTopicServer(t)
+	scraper := NewForumScraper("phpbb", 0, WithConcurrency(32, 32))
+	scraper.statusOut = io.Discard
+
+	var wg sync.WaitGroup
+	var scraped, visited int64
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Tracking parameters don't make a different thread
+			_, err := scraper.scrapeThread(fmt.Sprintf("%s/viewtopic.php?f=2&t=101&utm_source=s%d", server.URL, i), fixtureMaxPosts)
+			switch {
+			case err == nil:
+				atomic.AddInt64(&scraped, 1)
+			case errors.Is(err, ErrAlreadyVisited):
+				atomic.AddInt64(&visited, 1)
+			default:
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if *fetches != 1 || scraped != 1 || visited != 31 {
+		t.Errorf("%d fetches, %d scraped, %d already visited; want 1, 1 and 31", *fetches, scraped, visited)
+	}
+}
+
+func TestDuplicateThreadCountedAsDeduplicated(t *testing.T) {
+	server, _ := phpbbTopicServer(t)
+	scraper := NewForumScraper("phpbb", 0)
+	scraper.statusOut = io.Discard
+	if _, err := scraper.scrapeThread(server.URL+"/viewtopic.php?f=2&t=101", fixtureMaxPosts); err != nil {
+		t.Fatal(err)
+	}
+	_, err := scraper.scrapeThread(server.URL+"/topic-101", fixtureMaxPosts)
+	if !errors.Is(err, ErrDuplicateThread) {
+		t.Fatalf("scraping a redirect to a scraped thread: %v, want ErrDuplicateThread", err)
+	}
+	scraper.reportThreadError(server.URL+"/topic-101", err)
+	if n := scraper.stats.snapshot().DeduplicatedThreads; n != 1 {
+		t.Errorf("DeduplicatedThreads = %d, want 1", n)
+	}
+	if failures := scraper.failures.snapshot(); len(failures) != 0 {
+		t.Errorf("duplicate recorded as a failure: %+v", failures)
+	}
+}
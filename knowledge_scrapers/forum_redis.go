@@ -0,0 +1,115 @@
+//go:build redis
+
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func init() {
+	newRedisQueue = openRedisQueue
+}
+
+// redisQueue keeps the job queue, the jobs in progress and results in Redis
+// lists and the shared visited set in a Redis set
+type redisQueue struct {
+	client        *redis.Client
+	queueKey      string
+	processingKey string
+	resultsKey    string
+	visitedKey    string
+	visitedTTL    time.Duration
+}
+
+func openRedisQueue(redisURL, queueKey, resultsKey, visitedKey string, visitedTTL time.Duration) (workQueue, error) {
+	options, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(options)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &redisQueue{
+		client:        client,
+		queueKey:      queueKey,
+		processingKey: queueKey + ":processing",
+		resultsKey:    resultsKey,
+		visitedKey:    visitedKey,
+		visitedTTL:    visitedTTL,
+	}, nil
+}
+
+func (q *redisQueue) Pop(ctx context.Context, timeout time.Duration) ([]byte, error) {
+	// BLMOVE hands the job over atomically, so it is never only in a worker's memory
+	job, err := q.client.BLMove(ctx, q.queueKey, q.processingKey, "LEFT", "RIGHT", timeout).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(job), nil
+}
+
+func (q *redisQueue) Ack(ctx context.Context, job []byte) error {
+	return q.client.LRem(ctx, q.processingKey, 1, job).Err()
+}
+
+func (q *redisQueue) Push(ctx context.Context, job []byte) error {
+	return q.client.RPush(ctx, q.queueKey, job).Err()
+}
+
+func (q *redisQueue) Requeue(ctx context.Context, job []byte) error {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LRem(ctx, q.processingKey, 1, job)
+		pipe.LPush(ctx, q.queueKey, job)
+		return nil
+	})
+	return err
+}
+
+func (q *redisQueue) PushResult(ctx context.Context, record []byte) error {
+	return q.client.RPush(ctx, q.resultsKey, record).Err()
+}
+
+func (q *redisQueue) Add(ctx context.Context, key string) (bool, error) {
+	if q.visitedTTL <= 0 {
+		added, err := q.client.SAdd(ctx, q.visitedKey, key).Result()
+		return added == 1, err
+	}
+	// Each claim pushes the set's expiry back, so it lasts as long as the crawl
+	var added *redis.IntCmd
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		added = pipe.SAdd(ctx, q.visitedKey, key)
+		pipe.Expire(ctx, q.visitedKey, q.visitedTTL)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return added.Val() == 1, nil
+}
+
+func (q *redisQueue) Contains(ctx context.Context, key string) (bool, error) {
+	return q.client.SIsMember(ctx, q.visitedKey, key).Result()
+}
+
+func (q *redisQueue) Remove(ctx context.Context, keys ...string) error {
+	members := make([]interface{}, len(keys))
+	for i, key := range keys {
+		members[i] = key
+	}
+	return q.client.SRem(ctx, q.visitedKey, members...).Err()
+}
+
+func (q *redisQueue) Close() error {
+	return q.client.Close()
+}
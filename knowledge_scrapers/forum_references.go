@@ -0,0 +1,120 @@
+package main
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// mentionSelector matches @-mention markup: Discourse's a.mention and XenForo's .u-mention
+const mentionSelector = "a.mention, .u-mention"
+
+// extractMentions returns the names mentioned in a post body, in order and without repeats
+func extractMentions(body *goquery.Selection) []string {
+	var mentions []string
+	seen := make(map[string]bool)
+	body.Find(mentionSelector).Each(func(i int, s *goquery.Selection) {
+		name := strings.TrimPrefix(validUTF8(strings.TrimSpace(s.Text())), "@")
+		if name == "" || seen[strings.ToLower(name)] {
+			return
+		}
+		seen[strings.ToLower(name)] = true
+		mentions = append(mentions, name)
+	})
+	return mentions
+}
+
+// extractInternalThreadLinks returns the absolute URLs of other threads on the same board
+// that a post body links to, without repeats
+func (fs *ForumScraperGo) extractInternalThreadLinks(body *goquery.Selection, threadURL string) []string {
+	var links []string
+	seen := map[string]bool{normalizeURL(threadURL): true}
+	threadID := fs.extractThreadID(threadURL)
+	body.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		absolute, err := resolveURL(threadURL, href)
+		if err != nil || hostOf(absolute) != hostOf(threadURL) || !fs.isThreadURL(absolute) {
+			return
+		}
+		// Links to another post of this same thread aren't references
+		if threadID != "" && fs.extractThreadID(absolute) == threadID {
+			return
+		}
+		key := normalizeURL(absolute)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		links = append(links, absolute)
+	})
+	return links
+}
+
+// referenceCollector gathers the threads linked from threads as they are scraped,
+// so following references doesn't need a hop's threads kept in memory
+type referenceCollector struct {
+	fs   *ForumScraperGo
+	seen map[string]bool
+	refs []ThreadRef
+}
+
+func (fs *ForumScraperGo) newReferenceCollector() *referenceCollector {
+	return &referenceCollector{fs: fs, seen: make(map[string]bool)}
+}
+
+// add collects the threads linked from the posts of thread
+func (c *referenceCollector) add(thread *ForumThread) {
+	for _, post := range thread.Posts {
+		for _, link := range post.InternalThreadLinks {
+			link = c.fs.canonicalThreadURL(link)
+			key := normalizeURL(link)
+			if c.seen[key] || !c.fs.allowThreadURL(link, thread.URL) {
+				continue
+			}
+			c.seen[key] = true
+			c.refs = append(c.refs, ThreadRef{URL: link, SourceURL: thread.URL})
+		}
+	}
+}
+
+// take returns up to maxThreads collected threads that are not yet scraped
+func (c *referenceCollector) take(maxThreads int) []ThreadRef {
+	var refs []ThreadRef
+	for _, ref := range c.refs {
+		if len(refs) == maxThreads {
+			break
+		}
+		if !c.fs.isVisited(ref.URL) {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// followReferences scrapes the threads that the scraped threads link to, then the
+// threads those link to, up to fs.followHops hops, calling emit with each. Each hop
+// takes at most maxThreads threads; the visited set keeps cycles from being scraped
+// twice and the run budget still applies.
+func (fs *ForumScraperGo) followReferences(references *referenceCollector, maxThreads, maxPostsPerThread int, emit func(*ForumThread) error) error {
+	for hop := 1; hop <= fs.followHops && !fs.budget.exhausted(); hop++ {
+		refs := references.take(maxThreads)
+		if len(refs) == 0 {
+			break
+		}
+		fs.statusf("🔗 Following %d referenced thread(s), hop %d of %d\n", len(refs), hop, fs.followHops)
+
+		atomic.AddInt64(&fs.stats.ThreadsDiscovered, int64(len(refs)))
+		next := fs.newReferenceCollector()
+		err := fs.scrapeRefsEach(refs, maxPostsPerThread, func(thread *ForumThread) error {
+			atomic.AddInt64(&fs.stats.ThreadsFromReferences, 1)
+			next.add(thread)
+			return emit(thread)
+		})
+		if err != nil {
+			return err
+		}
+		references = next
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// regexRule is one of a PlatformConfig's metadata regex rules
+type regexRule struct {
+	field   string
+	key     string
+	pattern string
+	// count rules yield integers, with thousands separators dropped
+	count bool
+}
+
+// regexRules lists config's metadata regex rules, set or not
+func (config PlatformConfig) regexRules() []regexRule {
+	return []regexRule{
+		{field: "TitleRegex", key: "title", pattern: config.TitleRegex},
+		{field: "CategoryRegex", key: "category", pattern: config.CategoryRegex},
+		{field: "AuthorRegex", key: "author", pattern: config.AuthorRegex},
+		{field: "CreatedAtRegex", key: "created_at", pattern: config.CreatedAtRegex},
+		{field: "ViewsRegex", key: "views_count", pattern: config.ViewsRegex, count: true},
+		{field: "RepliesRegex", key: "replies_count", pattern: config.RepliesRegex, count: true},
+	}
+}
+
+// compiledRegexRules caches compiled rule patterns by pattern
+var compiledRegexRules sync.Map
+
+// compile compiles the rule's pattern, once per run, and checks it has exactly
+// one capture group
+func (rule regexRule) compile() (*regexp.Regexp, error) {
+	if re, ok := compiledRegexRules.Load(rule.pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(rule.pattern)
+	if err != nil {
+		return nil, err
+	}
+	if re.NumSubexp() != 1 {
+		return nil, fmt.Errorf("%q has %d capture groups, want exactly 1", rule.pattern, re.NumSubexp())
+	}
+	compiledRegexRules.Store(rule.pattern, re)
+	return re, nil
+}
+
+// validate reports a set rule that doesn't compile or capture one group
+func (rule regexRule) validate() error {
+	if rule.pattern == "" {
+		return nil
+	}
+	_, err := rule.compile()
+	return err
+}
+
+// applyRegexRules fills the metadata that nothing else found from the platform's
+// regex rules, matched against the page's HTML. The HTML is the parsed page
+// rendered back out, so attribute values escape quotes as &#34; while inline
+// script text is as served.
+func (fs *ForumScraperGo) applyRegexRules(doc *goquery.Document, config PlatformConfig, metadata map[string]interface{}, sources map[string]string) {
+	var page *string
+	for _, rule := range config.regexRules() {
+		if rule.pattern == "" {
+			continue
+		}
+		if _, found := metadata[rule.key]; found {
+			continue
+		}
+		re, err := rule.compile()
+		if err != nil {
+			fs.debugf("Skipping %s: %v", rule.field, err)
+			continue
+		}
+		if page == nil {
+			rendered, err := doc.Html()
+			if err != nil {
+				return
+			}
+			page = &rendered
+		}
+
+		matches := re.FindStringSubmatch(*page)
+		if len(matches) < 2 {
+			continue
+		}
+		value := validUTF8(strings.TrimSpace(html.UnescapeString(matches[1])))
+		if value == "" {
+			continue
+		}
+		if rule.count {
+			n, err := strconv.Atoi(strings.ReplaceAll(value, ",", ""))
+			if err != nil {
+				continue
+			}
+			metadata[rule.key] = n
+		} else {
+			metadata[rule.key] = value
+		}
+		sources[rule.key] = metadataSourceRegex
+		if _, logged := fs.matchedSelectors.LoadOrStore(rule.field, true); !logged {
+			fs.debugf("%s filled %s", rule.field, rule.key)
+		}
+	}
+}
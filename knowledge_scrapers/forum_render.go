@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// pageRenderer loads pages in a headless browser for forums that build their
+// markup client-side
+type pageRenderer interface {
+	// Render loads pageURL, waits up to timeout for waitSelector to appear, and
+	// returns the rendered HTML and the final URL
+	Render(pageURL, waitSelector string, timeout time.Duration) (html, finalURL string, err error)
+	Close()
+}
+
+// newRenderer starts a browser-backed renderer. It is only set in builds with
+// the chromedp tag, so the default binary carries no browser dependency.
+var newRenderer func() (pageRenderer, error)
+
+// renderDocument fetches rawURL through the headless browser and parses the
+// rendered HTML, holding one of the limited browser tabs while it runs
+func (fs *ForumScraperGo) renderDocument(ctx context.Context, rawURL string) (*goquery.Document, error) {
+	config, exists := fs.configs[fs.platform]
+	if !exists {
+		config = fs.configs["generic"]
+	}
+
+	if err := fs.spendRequest(); err != nil {
+		return nil, err
+	}
+	fs.renderSem <- struct{}{}
+	html, finalURL, err := fs.renderer.Render(rawURL, config.PostSelector.css(), fs.renderTimeout)
+	<-fs.renderSem
+	if err != nil {
+		return nil, fmt.Errorf("render %s: %w", rawURL, err)
+	}
+	fs.recordRender(ctx, finalURL)
+
+	doc, err := parseHTML(strings.NewReader(html), rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Url, err = url.Parse(finalURL); err != nil {
+		return nil, err
+	}
+	if isBotChallenge(doc) {
+		return nil, fmt.Errorf("%w: %s", ErrBotChallenge, rawURL)
+	}
+	return doc, nil
+}
+
+// fetchThreadDocument fetches a thread page, through the browser when rendering
+// is enabled, and prunes it when it is large
+func (fs *ForumScraperGo) fetchThreadDocument(ctx context.Context, rawURL string) (*goquery.Document, error) {
+	var doc *goquery.Document
+	var err error
+	if fs.renderer != nil {
+		doc, err = fs.renderDocument(ctx, rawURL)
+	} else {
+		doc, err = fs.fetchDocumentContext(ctx, rawURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	fs.compactThreadPage(doc, rawURL)
+	return doc, nil
+}
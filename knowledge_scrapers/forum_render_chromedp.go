@@ -0,0 +1,73 @@
+//go:build chromedp
+
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+func init() {
+	newRenderer = newChromeRenderer
+}
+
+// chromeRenderer renders pages in tabs of a single shared headless Chrome
+type chromeRenderer struct {
+	browserCtx    context.Context
+	cancelAlloc   context.CancelFunc
+	cancelBrowser context.CancelFunc
+}
+
+func newChromeRenderer() (pageRenderer, error) {
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(),
+		append(chromedp.DefaultExecAllocatorOptions[:], chromedp.UserAgent(userAgent))...)
+	browserCtx, cancelBrowser := chromedp.NewContext(allocCtx)
+
+	// Start the browser now so a missing Chrome fails at startup, not mid-run
+	if err := chromedp.Run(browserCtx); err != nil {
+		cancelBrowser()
+		cancelAlloc()
+		return nil, err
+	}
+
+	return &chromeRenderer{
+		browserCtx:    browserCtx,
+		cancelAlloc:   cancelAlloc,
+		cancelBrowser: cancelBrowser,
+	}, nil
+}
+
+func (r *chromeRenderer) Render(pageURL, waitSelector string, timeout time.Duration) (string, string, error) {
+	tabCtx, cancelTab := chromedp.NewContext(r.browserCtx)
+	defer cancelTab()
+
+	navCtx, cancelNav := context.WithTimeout(tabCtx, timeout)
+	defer cancelNav()
+	if err := chromedp.Run(navCtx, chromedp.Navigate(pageURL)); err != nil {
+		return "", "", err
+	}
+
+	// A page that never shows posts is still returned so soft-404 and
+	// interstitial detection can look at it
+	if waitSelector != "" {
+		err := chromedp.Run(navCtx, chromedp.WaitReady(waitSelector, chromedp.ByQuery))
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			return "", "", err
+		}
+	}
+
+	var html, finalURL string
+	err := chromedp.Run(tabCtx,
+		chromedp.Location(&finalURL),
+		chromedp.OuterHTML("html", &html, chromedp.ByQuery),
+	)
+	return html, finalURL, err
+}
+
+func (r *chromeRenderer) Close() {
+	r.cancelBrowser()
+	r.cancelAlloc()
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+)
+
+// GroupCount is the threads and posts of one category, author or month
+type GroupCount struct {
+	Name    string `json:"name"`
+	Threads int    `json:"threads"`
+	Posts   int    `json:"posts"`
+}
+
+// ThreadStat is one entry of the longest and most-viewed thread lists
+type ThreadStat struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+	Posts int    `json:"posts"`
+	Views int    `json:"views,omitempty"`
+}
+
+// ResultsStats is what the stats subcommand reports about a results file
+type ResultsStats struct {
+	Threads         int     `json:"threads"`
+	Posts           int     `json:"posts"`
+	AvgThreadLength float64 `json:"avg_thread_length"`
+	AvgPostLength   float64 `json:"avg_post_length"`
+	// Categories and Authors are ordered by posts, Months by date
+	Categories        []GroupCount `json:"categories"`
+	Authors           []GroupCount `json:"authors"`
+	Months            []GroupCount `json:"months"`
+	LongestThreads    []ThreadStat `json:"longest_threads"`
+	MostViewedThreads []ThreadStat `json:"most_viewed_threads"`
+}
+
+// groupList returns groups ordered by posts (ties by name), the first limit of
+// them when limit is positive
+func groupList(groups map[string]*GroupCount, limit int) []GroupCount {
+	list := make([]GroupCount, 0, len(groups))
+	for _, entry := range groups {
+		list = append(list, *entry)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Posts != list[j].Posts {
+			return list[i].Posts > list[j].Posts
+		}
+		return list[i].Name < list[j].Name
+	})
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list
+}
+
+// breakdown computes the stats subcommand's report from the aggregates so far,
+// listing at most limit categories and authors (all when limit is zero)
+func (a *summaryAccumulator) breakdown(limit int) ResultsStats {
+	s := a.summary()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	stats := ResultsStats{
+		Threads:           s.Threads,
+		Posts:             s.Posts,
+		AvgPostLength:     s.AvgPostLength,
+		Categories:        groupList(a.categories, limit),
+		Authors:           groupList(a.authors, limit),
+		Months:            groupList(a.months, 0),
+		LongestThreads:    append([]ThreadStat{}, a.longest...),
+		MostViewedThreads: append([]ThreadStat{}, a.mostViewed...),
+	}
+	if s.Threads > 0 {
+		stats.AvgThreadLength = float64(s.Posts) / float64(s.Threads)
+	}
+	sort.Slice(stats.Months, func(i, j int) bool { return stats.Months[i].Name < stats.Months[j].Name })
+	return stats
+}
+
+// printStats writes the report as text tables
+func printStats(w io.Writer, stats ResultsStats) {
+	fmt.Fprintf(w, "📊 Threads: %d\n", stats.Threads)
+	fmt.Fprintf(w, "📊 Posts: %d\n", stats.Posts)
+	fmt.Fprintf(w, "📊 Average thread length: %.1f posts\n", stats.AvgThreadLength)
+	fmt.Fprintf(w, "📊 Average post length: %.0f characters\n", stats.AvgPostLength)
+
+	groups := func(title string, list []GroupCount) {
+		if len(list) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "\n%s\n", title)
+		fmt.Fprintf(w, "   %-40s %8s %8s\n", "", "threads", "posts")
+		for _, entry := range list {
+			name := entry.Name
+			if name == "" {
+				name = "(none)"
+			}
+			fmt.Fprintf(w, "   %-40s %8d %8d\n", truncateRunes(name, 40), entry.Threads, entry.Posts)
+		}
+	}
+	groups("📁 By category", stats.Categories)
+	groups("👤 By author", stats.Authors)
+	groups("📅 By month", stats.Months)
+
+	threads := func(title string, list []ThreadStat, views bool) {
+		if len(list) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "\n%s\n", title)
+		for i, thread := range list {
+			count := fmt.Sprintf("%d posts", thread.Posts)
+			if views {
+				count = fmt.Sprintf("%d views", thread.Views)
+			}
+			fmt.Fprintf(w, "   %3d. %-12s %s\n        %s\n", i+1, count, thread.Title, thread.URL)
+		}
+	}
+	threads("📏 Longest threads", stats.LongestThreads, false)
+	threads("👀 Most viewed threads", stats.MostViewedThreads, true)
+}
+
+// runResultStats implements the stats subcommand: aggregate the threads of results
+// files by category, author and month, reading them one thread at a time
+func runResultStats(args []string) {
+	fset := flag.NewFlagSet("forum_scraper stats", flag.ExitOnError)
+	fset.Usage = func() {
+		fmt.Println("Usage: forum_scraper stats [flags] <results_file>...")
+		fmt.Println("Example: forum_scraper stats scraping_results/forum_scrape_phpbb.json")
+		fmt.Println("Example: forum_scraper stats --format json --top 0 threads.jsonl.gz | jq '.months'")
+		fset.PrintDefaults()
+	}
+	format := fset.String("format", "text", "output format: text or json")
+	top := fset.Int("top", summaryTopThreads, "how many categories, authors, longest and most-viewed threads to list (0 for every category and author)")
+
+	files, err := parseInterleaved(fset, args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(files) == 0 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	if *format != "text" && *format != "json" {
+		log.Fatalf("❌ Unsupported stats format: %s", *format)
+	}
+	if *top < 0 {
+		log.Fatalf("❌ --top must not be negative")
+	}
+
+	stats, err := computeResultStats(files, *top)
+	if err != nil {
+		log.Fatalf("❌ Stats failed: %v", err)
+	}
+	if *format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(stats); err != nil {
+			log.Fatalf("❌ Failed to write stats: %v", err)
+		}
+		return
+	}
+	printStats(os.Stdout, stats)
+}
+
+// computeResultStats aggregates every thread of files, keeping top longest and
+// most-viewed threads
+func computeResultStats(files []string, top int) (ResultsStats, error) {
+	accumulator := summaryAccumulator{topThreads: top}
+	if top == 0 {
+		accumulator.topThreads = summaryTopThreads
+	}
+	for _, path := range files {
+		_, err := readResultsFile(path, func(thread *ForumThread) error {
+			accumulator.add(thread)
+			return nil
+		})
+		if err != nil {
+			return ResultsStats{}, err
+		}
+	}
+	return accumulator.breakdown(top), nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+// The stats fixture is a results file with hand-counted aggregates, checked with
+// the stats subcommand's computation at statsFixtureTop
+const (
+	statsFixtureResults = "stats/results.jsonl"
+	statsFixtureGolden  = "stats/golden.json"
+	statsFixtureTop     = 3
+)
+
+func TestStatsFixture(t *testing.T) {
+	got, err := computeResultStats([]string{filepath.Join(fixturesDir, statsFixtureResults)}, statsFixtureTop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, statsFixtureGolden, append(encoded, '\n'))
+}
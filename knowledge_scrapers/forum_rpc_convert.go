@@ -0,0 +1,247 @@
+//go:build grpc
+
+package main
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Conversions between the results structs and their gRPC messages (forum_rpc.proto)
+
+func timestampToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func timestampFromProto(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+func countToProto(n *int) *int32 {
+	if n == nil {
+		return nil
+	}
+	v := int32(*n)
+	return &v
+}
+
+func countFromProto(n *int32) *int {
+	if n == nil {
+		return nil
+	}
+	v := int(*n)
+	return &v
+}
+
+func threadRefToProto(ref ThreadRef) *DiscoveredThread {
+	message := &DiscoveredThread{Url: ref.URL, Title: ref.Title, SourceUrl: ref.SourceURL}
+	if ref.LastActivity != nil {
+		message.LastActivity = timestamppb.New(*ref.LastActivity)
+	}
+	return message
+}
+
+func threadRefFromProto(message *DiscoveredThread) ThreadRef {
+	ref := ThreadRef{URL: message.GetUrl(), Title: message.GetTitle(), SourceURL: message.GetSourceUrl()}
+	if message.LastActivity != nil {
+		lastActivity := message.LastActivity.AsTime()
+		ref.LastActivity = &lastActivity
+	}
+	return ref
+}
+
+func threadToProto(thread *ForumThread) *Thread {
+	message := &Thread{
+		Url:                   thread.URL,
+		ThreadId:              thread.ThreadID,
+		Title:                 thread.Title,
+		Category:              thread.Category,
+		CategoryId:            int32(thread.CategoryID),
+		Tags:                  thread.Tags,
+		Author:                thread.Author,
+		ViewsCount:            countToProto(thread.ViewsCount),
+		RepliesCount:          int32(thread.RepliesCount),
+		TotalPagesSeen:        int32(thread.TotalPagesSeen),
+		Truncated:             thread.Truncated,
+		PartialWindow:         thread.PartialWindow,
+		CreatedAt:             thread.CreatedAt,
+		LastPostAt:            thread.LastPostAt,
+		FinalUrl:              thread.FinalURL,
+		CanonicalUrl:          thread.CanonicalURL,
+		SourceUrl:             thread.SourceURL,
+		Language:              thread.Language,
+		Score:                 thread.Score,
+		DuplicatePostsDropped: int32(thread.DuplicatePostsDropped),
+		ScrapedAt:             timestampToProto(thread.ScrapedAt),
+		RunId:                 thread.RunID,
+		RecordId:              thread.RecordID,
+	}
+	for _, post := range thread.Posts {
+		message.Posts = append(message.Posts, postToProto(post))
+	}
+	if len(thread.SkippedPosts) > 0 {
+		message.SkippedPosts = make(map[string]int32, len(thread.SkippedPosts))
+		for reason, n := range thread.SkippedPosts {
+			message.SkippedPosts[reason] = int32(n)
+		}
+	}
+	if p := thread.Provenance; p != nil {
+		message.Provenance = &FetchProvenance{
+			RequestUrl:      p.RequestURL,
+			FinalUrl:        p.FinalURL,
+			HttpStatus:      int32(p.HTTPStatus),
+			Server:          p.Server,
+			Rendered:        p.Rendered,
+			FetchStartedAt:  timestampToProto(p.FetchStartedAt),
+			FetchEndedAt:    timestampToProto(p.FetchEndedAt),
+			PagesFetched:    int32(p.PagesFetched),
+			Retries:         int32(p.Retries),
+			ScraperVersion:  p.ScraperVersion,
+			GitCommit:       p.GitCommit,
+			MetadataSources: p.MetadataSources,
+		}
+	}
+	return message
+}
+
+func threadFromProto(message *Thread) *ForumThread {
+	thread := &ForumThread{
+		URL:                   message.GetUrl(),
+		ThreadID:              message.GetThreadId(),
+		Title:                 message.GetTitle(),
+		Category:              message.GetCategory(),
+		CategoryID:            int(message.GetCategoryId()),
+		Tags:                  message.GetTags(),
+		Author:                message.GetAuthor(),
+		Posts:                 []ForumPost{},
+		ViewsCount:            countFromProto(message.ViewsCount),
+		RepliesCount:          int(message.GetRepliesCount()),
+		TotalPagesSeen:        int(message.GetTotalPagesSeen()),
+		Truncated:             message.GetTruncated(),
+		PartialWindow:         message.GetPartialWindow(),
+		CreatedAt:             message.GetCreatedAt(),
+		LastPostAt:            message.GetLastPostAt(),
+		FinalURL:              message.GetFinalUrl(),
+		CanonicalURL:          message.GetCanonicalUrl(),
+		SourceURL:             message.GetSourceUrl(),
+		Language:              message.GetLanguage(),
+		Score:                 message.GetScore(),
+		DuplicatePostsDropped: int(message.GetDuplicatePostsDropped()),
+		ScrapedAt:             timestampFromProto(message.GetScrapedAt()),
+		RunID:                 message.GetRunId(),
+		RecordID:              message.GetRecordId(),
+	}
+	for _, post := range message.GetPosts() {
+		thread.Posts = append(thread.Posts, postFromProto(post))
+	}
+	if len(message.GetSkippedPosts()) > 0 {
+		thread.SkippedPosts = make(map[string]int, len(message.GetSkippedPosts()))
+		for reason, n := range message.GetSkippedPosts() {
+			thread.SkippedPosts[reason] = int(n)
+		}
+	}
+	if p := message.GetProvenance(); p != nil {
+		thread.Provenance = &Provenance{
+			RequestURL:      p.GetRequestUrl(),
+			FinalURL:        p.GetFinalUrl(),
+			HTTPStatus:      int(p.GetHttpStatus()),
+			Server:          p.GetServer(),
+			Rendered:        p.GetRendered(),
+			FetchStartedAt:  timestampFromProto(p.GetFetchStartedAt()),
+			FetchEndedAt:    timestampFromProto(p.GetFetchEndedAt()),
+			PagesFetched:    int(p.GetPagesFetched()),
+			Retries:         int(p.GetRetries()),
+			ScraperVersion:  p.GetScraperVersion(),
+			GitCommit:       p.GetGitCommit(),
+			MetadataSources: p.GetMetadataSources(),
+		}
+	}
+	return thread
+}
+
+func postToProto(post ForumPost) *Post {
+	message := &Post{
+		Url:                 post.URL,
+		ThreadTitle:         post.ThreadTitle,
+		Author:              post.Author,
+		Content:             post.Content,
+		PostNumber:          int32(post.PostNumber),
+		PostId:              post.PostID,
+		ParentPostNumber:    int32(post.ParentPostNumber),
+		Timestamp:           post.Timestamp,
+		LikesCount:          countToProto(post.LikesCount),
+		Awards:              countToProto(post.Awards),
+		RepliesCount:        countToProto(post.RepliesCount),
+		ForumCategory:       post.ForumCategory,
+		ContentHash:         post.ContentHash,
+		Language:            post.Language,
+		Score:               post.Score,
+		IsAcceptedAnswer:    post.IsAcceptedAnswer,
+		Mentions:            post.Mentions,
+		InternalThreadLinks: post.InternalThreadLinks,
+		Edited:              post.Edited,
+		EditedAt:            post.EditedAt,
+		EditedBy:            post.EditedBy,
+		MatchedUser:         post.MatchedUser,
+		ScrapedAt:           timestampToProto(post.ScrapedAt),
+		RunId:               post.RunID,
+		RecordId:            post.RecordID,
+	}
+	for _, attachment := range post.Attachments {
+		message.Attachments = append(message.Attachments, &PostAttachment{
+			Name:      attachment.Name,
+			Url:       attachment.URL,
+			SizeBytes: attachment.SizeBytes,
+			MimeGuess: attachment.MimeGuess,
+			LocalPath: attachment.LocalPath,
+		})
+	}
+	return message
+}
+
+func postFromProto(message *Post) ForumPost {
+	post := ForumPost{
+		URL:                 message.GetUrl(),
+		ThreadTitle:         message.GetThreadTitle(),
+		Author:              message.GetAuthor(),
+		Content:             message.GetContent(),
+		PostNumber:          int(message.GetPostNumber()),
+		PostID:              message.GetPostId(),
+		ParentPostNumber:    int(message.GetParentPostNumber()),
+		Timestamp:           message.GetTimestamp(),
+		LikesCount:          countFromProto(message.LikesCount),
+		Awards:              countFromProto(message.Awards),
+		RepliesCount:        countFromProto(message.RepliesCount),
+		ForumCategory:       message.GetForumCategory(),
+		ContentHash:         message.GetContentHash(),
+		Language:            message.GetLanguage(),
+		Score:               message.GetScore(),
+		IsAcceptedAnswer:    message.GetIsAcceptedAnswer(),
+		Mentions:            message.GetMentions(),
+		InternalThreadLinks: message.GetInternalThreadLinks(),
+		Edited:              message.GetEdited(),
+		EditedAt:            message.GetEditedAt(),
+		EditedBy:            message.GetEditedBy(),
+		MatchedUser:         message.GetMatchedUser(),
+		ScrapedAt:           timestampFromProto(message.GetScrapedAt()),
+		RunID:               message.GetRunId(),
+		RecordID:            message.GetRecordId(),
+	}
+	for _, attachment := range message.GetAttachments() {
+		post.Attachments = append(post.Attachments, Attachment{
+			Name:      attachment.GetName(),
+			URL:       attachment.GetUrl(),
+			SizeBytes: attachment.SizeBytes,
+			MimeGuess: attachment.GetMimeGuess(),
+			LocalPath: attachment.GetLocalPath(),
+		})
+	}
+	return post
+}
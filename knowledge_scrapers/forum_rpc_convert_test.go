@@ -0,0 +1,57 @@
+//go:build grpc
+
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestThreadProtoRoundTrip(t *testing.T) {
+	scraped := time.Date(2024, 3, 2, 19, 45, 0, 0, time.UTC)
+	views, likes, size := 120, 3, int64(2048)
+	thread := &ForumThread{
+		URL:           "https://forum.example.com/t/topic/42",
+		ThreadID:      "42",
+		Title:         "Topic",
+		Category:      "General",
+		CategoryID:    7,
+		Tags:          []string{"go"},
+		Author:        "user1",
+		ViewsCount:    &views,
+		RepliesCount:  1,
+		PartialWindow: true,
+		CreatedAt:     "2024-03-02T19:45:00Z",
+		SkippedPosts:  map[string]int{"too_short": 1},
+		Provenance: &Provenance{
+			RequestURL:     "https://forum.example.com/t/topic/42",
+			FinalURL:       "https://forum.example.com/t/topic/42",
+			HTTPStatus:     200,
+			FetchStartedAt: scraped,
+			FetchEndedAt:   scraped,
+			PagesFetched:   1,
+			Retries:        2,
+			ScraperVersion: "dev",
+		},
+		RunID:     "0190f3a2-0000-7000-8000-000000000000",
+		RecordID:  "a1b2c3",
+		ScrapedAt: scraped,
+		Posts: []ForumPost{{
+			URL:         "https://forum.example.com/t/topic/42/1",
+			ThreadTitle: "Topic",
+			Author:      "user1",
+			Content:     "Hello",
+			PostNumber:  1,
+			PostID:      "p1001",
+			LikesCount:  &likes,
+			Attachments: []Attachment{{Name: "log.txt", URL: "https://forum.example.com/log.txt", SizeBytes: &size}},
+			RunID:       "0190f3a2-0000-7000-8000-000000000000",
+			RecordID:    "d4e5f6",
+			ScrapedAt:   scraped,
+		}},
+	}
+	if got := threadFromProto(threadToProto(thread)); !reflect.DeepEqual(got, thread) {
+		t.Errorf("round trip changed the thread:\n got %+v\nwant %+v", got, thread)
+	}
+}
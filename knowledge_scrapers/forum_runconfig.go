@@ -0,0 +1,311 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// A run config file (--run-config, TOML or YAML) sets flags by name, plus a
+// "sources" list standing in for the positional forum URLs. Tables only group
+// keys, so [limits] max-threads = 50 and max-threads = 50 are the same. Settings
+// resolve in this order, later ones winning: defaults, MARINA_* environment
+// variables, the file, then command-line flags and arguments.
+
+// envPrefix starts the environment variable of every flag: --max-threads is
+// MARINA_MAX_THREADS
+const envPrefix = "MARINA_"
+
+// envAliases are flags read from an environment variable other than their own
+var envAliases = map[string]string{
+	"bearer-token": envToken,
+}
+
+// secretFlags are masked wherever the effective configuration is shown or saved
+var secretFlags = map[string]bool{
+	"basic-auth":        true,
+	"bearer-token":      true,
+	"stackexchange-key": true,
+}
+
+// secretPathFlags are URLs whose path and query are the secret, as with chat
+// webhooks, so only their scheme and host are shown
+var secretPathFlags = map[string]bool{
+	"notify-webhook": true,
+}
+
+// dsnPasswordPattern finds the password in a key=value connection string
+var dsnPasswordPattern = regexp.MustCompile(`(password=)('[^']*'|\S+)`)
+
+// Where a setting came from, as recorded in RunConfig.Origins
+const (
+	originFlag = "flag"
+	originFile = "file"
+	originEnv  = "env"
+)
+
+// RunConfig is a run's effective configuration, with secrets masked, recorded in
+// the results envelope so the run can be repeated
+type RunConfig struct {
+	// File is the --run-config file, when one was given
+	File     string   `json:"file,omitempty"`
+	Platform string   `json:"platform"`
+	Sources  []string `json:"sources,omitempty"`
+	// Settings holds every flag's value by name, defaults included
+	Settings map[string]string `json:"settings"`
+	// Origins names where each setting that isn't a default came from: "flag",
+	// "file" or "env"
+	Origins map[string]string `json:"origins,omitempty"`
+}
+
+// envName returns the environment variable that sets flag name
+func envName(name string) string {
+	if alias, ok := envAliases[name]; ok {
+		return alias
+	}
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// runConfigFile is a parsed --run-config file: flag values by name, in the order
+// to set them, and the sources it lists
+type runConfigFile struct {
+	values  map[string][]string
+	sources []string
+}
+
+// loadRunConfigFile reads a TOML (.toml) or YAML (.yaml, .yml) run config.
+// Keys that aren't flags of fset are errors, so typos don't go unnoticed.
+func loadRunConfigFile(path string, fset *flag.FlagSet) (*runConfigFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	raw := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("%s: unknown format (want .toml, .yaml or .yml)", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	file := &runConfigFile{values: make(map[string][]string)}
+	if err := file.add(raw, "", fset); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return file, nil
+}
+
+// add collects the keys of one table; section is the enclosing table's name
+func (f *runConfigFile) add(table map[string]interface{}, section string, fset *flag.FlagSet) error {
+	keys := make([]string, 0, len(table))
+	for key := range table {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := table[key]
+		path := key
+		if section != "" {
+			path = section + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			if section != "" {
+				return fmt.Errorf("%s: tables only nest one level deep", path)
+			}
+			if err := f.add(nested, key, fset); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if key == "sources" {
+			list, err := configStrings(value, true)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			f.sources = append(f.sources, list...)
+			continue
+		}
+		target := fset.Lookup(key)
+		if target == nil || key == "run-config" {
+			return fmt.Errorf("unknown key %q", path)
+		}
+		if _, exists := f.values[key]; exists {
+			return fmt.Errorf("%s: set more than once", path)
+		}
+		_, repeatable := target.Value.(*stringList)
+		list, err := configStrings(value, repeatable)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		f.values[key] = list
+	}
+	return nil
+}
+
+// configStrings renders a file value as flag values; lists are only allowed
+// where the flag is repeatable
+func configStrings(value interface{}, allowList bool) ([]string, error) {
+	if list, ok := value.([]interface{}); ok {
+		if !allowList {
+			return nil, fmt.Errorf("takes a single value, not a list")
+		}
+		values := make([]string, 0, len(list))
+		for _, item := range list {
+			text, err := configString(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, text)
+		}
+		return values, nil
+	}
+	text, err := configString(value)
+	if err != nil {
+		return nil, err
+	}
+	return []string{text}, nil
+}
+
+func configString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case time.Time:
+		// Unquoted dates, such as since = 2024-01-01
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 && v.Nanosecond() == 0 {
+			return v.Format("2006-01-02"), nil
+		}
+		return v.Format(time.RFC3339), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v (%T)", value, value)
+	}
+}
+
+// resolveRunSettings fills every flag not given on the command line from the run
+// config file, then from its environment variable, and returns where each flag
+// that isn't a default came from
+func resolveRunSettings(fset *flag.FlagSet, file *runConfigFile) (map[string]string, error) {
+	origins := make(map[string]string)
+	fset.Visit(func(f *flag.Flag) {
+		origins[f.Name] = originFlag
+	})
+
+	var err error
+	fset.VisitAll(func(f *flag.Flag) {
+		if err != nil || origins[f.Name] != "" {
+			return
+		}
+		if file != nil {
+			if values, ok := file.values[f.Name]; ok {
+				for _, value := range values {
+					if setErr := fset.Set(f.Name, value); setErr != nil {
+						err = fmt.Errorf("run config %s: %w", f.Name, setErr)
+						return
+					}
+				}
+				origins[f.Name] = originFile
+				return
+			}
+		}
+		if value, ok := os.LookupEnv(envName(f.Name)); ok && value != "" {
+			if setErr := fset.Set(f.Name, value); setErr != nil {
+				err = fmt.Errorf("%s: %w", envName(f.Name), setErr)
+				return
+			}
+			origins[f.Name] = originEnv
+		}
+	})
+	return origins, err
+}
+
+// maskSetting hides a setting's secrets: the whole value of a secret flag, the
+// path of a secret URL, and any password in a URL or connection string
+func maskSetting(name, value string) string {
+	if value == "" {
+		return value
+	}
+	if secretFlags[name] {
+		return "****"
+	}
+	if secretPathFlags[name] {
+		return maskURLPath(value)
+	}
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			return u.Redacted()
+		}
+	}
+	return dsnPasswordPattern.ReplaceAllString(value, "${1}****")
+}
+
+// maskURLPath keeps only a URL's scheme and host
+func maskURLPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "****"
+	}
+	return u.Scheme + "://" + u.Host + "/****"
+}
+
+// effectiveRunConfig captures the resolved settings of fset, masked
+func effectiveRunConfig(fset *flag.FlagSet, file, platform string, sources []string, origins map[string]string) *RunConfig {
+	config := &RunConfig{
+		File:     file,
+		Platform: platform,
+		Sources:  sources,
+		Settings: make(map[string]string),
+		Origins:  origins,
+	}
+	fset.VisitAll(func(f *flag.Flag) {
+		config.Settings[f.Name] = maskSetting(f.Name, f.Value.String())
+	})
+	return config
+}
+
+// print writes the configuration to the status output: every setting that isn't
+// a default with its origin, or with --debug every setting
+func (c *RunConfig) print(fs *ForumScraperGo) {
+	fs.statusf("⚙️ Effective configuration: platform %s, %d source(s)\n", c.Platform, len(c.Sources))
+	names := make([]string, 0, len(c.Settings))
+	for name := range c.Settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		origin := c.Origins[name]
+		if origin == "" {
+			if !fs.debug {
+				continue
+			}
+			origin = "default"
+		}
+		fs.statusf("   %s = %s (%s)\n", name, c.Settings[name], origin)
+	}
+}
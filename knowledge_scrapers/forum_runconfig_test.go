@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestMaskSetting(t *testing.T) {
+	tests := []struct {
+		name, value, want string
+	}{
+		{"bearer-token", "abc123", "****"},
+		{"notify-webhook", "https://hooks.slack.com/services/T000/B000/XXXXSECRET", "https://hooks.slack.com/****"},
+		{"notify-webhook", "https://chat.example.com/hook?token=XXXXSECRET", "https://chat.example.com/****"},
+		{"notify-webhook", "not a url", "****"},
+		{"postgres", "postgres://scraper:hunter2@db/forums", "postgres://scraper:xxxxx@db/forums"},
+		{"postgres", "host=db password=hunter2 dbname=forums", "host=db password=**** dbname=forums"},
+		{"platform", "phpbb", "phpbb"},
+		{"notify-webhook", "", ""},
+	}
+	for _, tt := range tests {
+		if got := maskSetting(tt.name, tt.value); got != tt.want {
+			t.Errorf("maskSetting(%s, %q) = %q, want %q", tt.name, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestNotifyErrorHidesWebhookPath(t *testing.T) {
+	// A closed port makes the request fail with the URL in the error
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	webhook := "http://" + addr + "/services/T000/B000/XXXXSECRET"
+	sink, err := newNotifySink(regexp.MustCompile("x"), webhook, "", filepath.Join(t.TempDir(), "state.json"), io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sink.send(context.Background(), notifyEvent{})
+	if err == nil {
+		t.Fatal("send to a closed port succeeded")
+	}
+	if strings.Contains(err.Error(), "XXXXSECRET") {
+		t.Errorf("error reveals the webhook path: %v", err)
+	}
+}
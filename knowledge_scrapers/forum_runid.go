@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Every run gets a UUIDv7 run ID, stamped on each thread and post it produces
+// along with a record ID derived from it. Record IDs are stamped once, as a
+// thread is finished, so the results file, JSONL lines and database rows of one
+// run agree on them. Merging copies records as they are, keeping their run IDs.
+
+// RunMetadata identifies the run that produced a set of records
+type RunMetadata struct {
+	RunID          string     `json:"run_id"`
+	StartedAt      time.Time  `json:"started_at"`
+	EndedAt        *time.Time `json:"ended_at,omitempty"`
+	ScraperVersion string     `json:"scraper_version"`
+	GitCommit      string     `json:"git_commit,omitempty"`
+	// ConfigHash is the SHA-256 of the run's effective configuration, so runs
+	// with the same settings can be grouped
+	ConfigHash string `json:"config_hash,omitempty"`
+	Host       string `json:"host,omitempty"`
+}
+
+// newRunMetadata starts the metadata of a run beginning now
+func (fs *ForumScraperGo) newRunMetadata() *RunMetadata {
+	started := fs.now()
+	host, _ := os.Hostname()
+	run := &RunMetadata{
+		RunID:          fs.newRunID(started),
+		StartedAt:      started,
+		ScraperVersion: scraperVersion,
+		GitCommit:      gitCommit,
+		Host:           host,
+	}
+	if fs.runConfig != nil {
+		run.ConfigHash = fs.runConfig.hash()
+	}
+	return run
+}
+
+// newRunID returns a UUIDv7 for a run started at started. Under --fixed-timestamps
+// its random bits come from --seed, so golden runs get the same ID every time.
+func (fs *ForumScraperGo) newRunID(started time.Time) string {
+	var id [16]byte
+	if fs.fixedTimestamps {
+		for i := range id {
+			id[i] = byte(fs.rng.Intn(256))
+		}
+	} else if _, err := rand.Read(id[:]); err != nil {
+		panic(fmt.Sprintf("reading random bytes: %v", err))
+	}
+	var millis [8]byte
+	binary.BigEndian.PutUint64(millis[:], uint64(started.UnixMilli()))
+	copy(id[:6], millis[2:])
+	id[6] = id[6]&0x0f | 0x70 // version 7
+	id[8] = id[8]&0x3f | 0x80 // RFC 9562 variant
+
+	text := hex.EncodeToString(id[:])
+	return text[:8] + "-" + text[8:12] + "-" + text[12:16] + "-" + text[16:20] + "-" + text[20:]
+}
+
+// finished returns a copy of the metadata with the run's end time set to now
+func (m *RunMetadata) finished(fs *ForumScraperGo) *RunMetadata {
+	if m == nil {
+		return nil
+	}
+	ended := fs.now()
+	finished := *m
+	finished.EndedAt = &ended
+	return &finished
+}
+
+// hash returns the SHA-256 of the configuration's platform, sources and settings.
+// Secrets are already masked, so changing only a token keeps the hash.
+func (c *RunConfig) hash() string {
+	data, _ := json.Marshal(struct {
+		Platform string            `json:"platform"`
+		Sources  []string          `json:"sources"`
+		Settings map[string]string `json:"settings"`
+	}{c.Platform, c.Sources, c.Settings})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// recordID derives a record's ID from its run, its thread's key and, for posts,
+// the post's key: the first 128 bits of their SHA-256, in hex
+func recordID(runID, threadKey, postID string) string {
+	sum := sha256.Sum256([]byte(runID + "\x00" + threadKey + "\x00" + postID))
+	return hex.EncodeToString(sum[:16])
+}
+
+// stampRecords sets the run ID and record ID of a thread and each of its posts
+func (fs *ForumScraperGo) stampRecords(thread *ForumThread) {
+	if fs.run == nil {
+		return
+	}
+	key := threadKey(thread)
+	thread.RunID = fs.run.RunID
+	thread.RecordID = recordID(fs.run.RunID, key, "")
+	for i := range thread.Posts {
+		post := &thread.Posts[i]
+		post.RunID = fs.run.RunID
+		post.RecordID = recordID(fs.run.RunID, key, postKey(post))
+	}
+}
+
+// postKey identifies a post within its thread: the platform's post ID, or the
+// post number when the page gave none
+func postKey(post *ForumPost) string {
+	if post.PostID != "" {
+		return post.PostID
+	}
+	return strconv.Itoa(post.PostNumber)
+}
+
+// runSink is a threadSink that also keeps the metadata of the runs writing to it
+type runSink interface {
+	WriteRun(ctx context.Context, run *RunMetadata) error
+}
+
+// recordRun writes the run's metadata to every sink that keeps it: as the run
+// starts delivering threads, and again with its end time once it is done
+func (fs *ForumScraperGo) recordRun(ended bool) {
+	run := fs.run
+	if ended {
+		run = run.finished(fs)
+	}
+	if run == nil {
+		return
+	}
+	for _, sink := range fs.sinks {
+		if recorder, ok := sink.(runSink); ok {
+			if err := recorder.WriteRun(context.Background(), run); err != nil {
+				fs.statusf("⚠️ Failed to record run %s in %s: %v\n", run.RunID, sink.Name(), err)
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestStampRecordsKeysPostsByID(t *testing.T) {
+	scraper := NewForumScraper("phpbb", 0, WithSeed(1), WithFixedTimestamps(true))
+	scraper.run = scraper.newRunMetadata()
+	thread := &ForumThread{
+		URL: "https://forum.example.com/viewtopic.php?t=7",
+		Posts: []ForumPost{
+			{PostNumber: 1, PostID: "p1001"},
+			{PostNumber: 1, PostID: "p1002"},
+			{PostNumber: 2},
+		},
+	}
+	scraper.stampRecords(thread)
+	// The same post ID gets the same record ID whatever position it scraped at
+	moved := &ForumThread{URL: thread.URL, Posts: []ForumPost{{PostNumber: 5, PostID: "p1001"}}}
+	scraper.stampRecords(moved)
+
+	posts := thread.Posts
+	if posts[0].RecordID == posts[1].RecordID {
+		t.Error("posts with different IDs share a record ID")
+	}
+	if moved.Posts[0].RecordID != posts[0].RecordID {
+		t.Error("record ID of post p1001 depends on its post number")
+	}
+	if posts[2].RecordID == "" || posts[2].RecordID == thread.RecordID {
+		t.Error("post without an ID got no record ID of its own")
+	}
+}
+
+func TestPostAnchor(t *testing.T) {
+	tests := []struct {
+		name, html, selector, want string
+	}{
+		{"phpbb", `<div id="p1001" class="post"><div class="content">hi</div></div>`, ".post", "p1001"},
+		{"discourse", `<div class="topic-post" id="post_1"><article data-post-id="52001" id="post_1">hi</article></div>`, ".topic-post", "52001"},
+		{"reddit", `<div class="thing comment" data-fullname="t1_kt1c4"><div class="entry">hi</div></div>`, ".entry", "t1_kt1c4"},
+		{"xenforo", `<article class="message" id="js-post-88101">hi</article>`, "article.message", "js-post-88101"},
+		{"none", `<div class="post">hi</div>`, ".post", ""},
+	}
+	for _, tt := range tests {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := postAnchor(doc.Find(tt.selector).First()); got != tt.want {
+			t.Errorf("%s: postAnchor = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// A run configuration given at construction is hashed into the run metadata
+func TestRunConfigHashedAtConstruction(t *testing.T) {
+	config := &RunConfig{Platform: "phpbb", Sources: []string{"https://forum.example.com/"}, Settings: map[string]string{"delay": "1.5"}}
+	scraper := NewForumScraper("phpbb", 0, WithRunConfig(config))
+	if scraper.run.ConfigHash == "" || scraper.run.ConfigHash != config.hash() {
+		t.Errorf("config hash %q, want %q", scraper.run.ConfigHash, config.hash())
+	}
+	if NewForumScraper("phpbb", 0).run.ConfigHash != "" {
+		t.Error("run without a configuration got a config hash")
+	}
+}
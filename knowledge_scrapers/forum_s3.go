@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// objectStore uploads result files to S3-compatible object storage for --s3.
+// Upload and Complete return the URL of the object written.
+type objectStore interface {
+	Upload(ctx context.Context, key, localPath string) (string, error)
+	// Stream starts a multipart upload that sends parts as data is written
+	Stream(ctx context.Context, key string) (objectStream, error)
+}
+
+// objectStream is an object being written as a multipart upload. Complete finishes
+// it with the parts written so far and is safe to call from an interrupt handler
+// while writes are in progress; later writes fail.
+type objectStream interface {
+	io.Writer
+	Complete(ctx context.Context) (string, error)
+}
+
+// newObjectStore connects to the bucket with the standard AWS credential chain
+// (environment variables, shared config, instance role). It is only set in builds
+// with the s3 tag, so the default binary carries no AWS SDK.
+var newObjectStore func(bucket, endpoint string) (objectStore, error)
+
+// parseS3URL splits an s3://bucket/prefix URL into the bucket and key prefix
+func parseS3URL(raw string) (string, string, error) {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "", "", err
+	}
+	if parsed.Scheme != "s3" || parsed.Host == "" {
+		return "", "", fmt.Errorf("%q is not s3://bucket/prefix", raw)
+	}
+	return parsed.Host, strings.Trim(parsed.Path, "/"), nil
+}
+
+// objectKey is where a file lands under prefix: its path below the output
+// directory, or its base name when it was written elsewhere
+func (fs *ForumScraperGo) objectKey(prefix, localPath string) string {
+	name := filepath.Base(localPath)
+	if rel, err := filepath.Rel(fs.outputDir, localPath); err == nil && !strings.HasPrefix(rel, "..") {
+		name = filepath.ToSlash(rel)
+	}
+	return path.Join(prefix, name)
+}
+
+// uploadResults uploads finished result files, and every file under any
+// directory given, recording each object's URL for the run summary
+func (fs *ForumScraperGo) uploadResults(paths []string) error {
+	var files []string
+	for _, p := range paths {
+		err := filepath.Walk(p, func(file string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				files = append(files, file)
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, file := range files {
+		objectURL, err := fs.objectStore.Upload(context.Background(), fs.objectKey(fs.objectPrefix, file), file)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		fs.statusf("☁️ Uploaded %s to: %s\n", file, objectURL)
+		fs.uploads = append(fs.uploads, objectURL)
+	}
+	return nil
+}
+
+// streamResults starts the multipart upload --stdin streams its JSONL into. On
+// interrupt the upload is completed with the parts already written, not aborted.
+func (fs *ForumScraperGo) streamResults() (objectStream, error) {
+	name := fs.resultsFilename(nil, fs.now())
+	name = strings.TrimSuffix(name, filepath.Ext(name)) + ".jsonl"
+	stream, err := fs.objectStore.Stream(context.Background(), path.Join(fs.objectPrefix, name))
+	if err != nil {
+		return nil, err
+	}
+	onInterrupt(func() {
+		if objectURL, err := stream.Complete(context.Background()); err != nil {
+			fs.statusf("⚠️ Failed to complete upload: %v\n", err)
+		} else {
+			fs.statusf("☁️ Partial results uploaded to: %s\n", objectURL)
+		}
+	})
+	return stream, nil
+}
+
+// printUploads lists the objects --s3 wrote
+func (fs *ForumScraperGo) printUploads(w io.Writer) {
+	if len(fs.uploads) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "☁️ Uploaded objects: %d\n", len(fs.uploads))
+	for _, objectURL := range fs.uploads {
+		fmt.Fprintf(w, "   %s\n", objectURL)
+	}
+}
@@ -0,0 +1,159 @@
+//go:build s3
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// s3PartSize is how much streamed output is buffered before it is sent as one
+// part; S3 needs every part but the last to be at least 5 MiB
+const s3PartSize = 8 << 20
+
+// s3DefaultRegion is used when nothing in the environment names a region, which
+// is common with --s3-endpoint
+const s3DefaultRegion = "us-east-1"
+
+func init() {
+	newObjectStore = openS3Store
+}
+
+// s3Store uploads to one bucket
+type s3Store struct {
+	client   *s3.Client
+	uploader *manager.Uploader
+	bucket   string
+}
+
+func openS3Store(bucket, endpoint string) (objectStore, error) {
+	cfg, err := config.LoadDefaultConfig(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Region == "" {
+		cfg.Region = s3DefaultRegion
+	}
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			// Most S3-compatible servers only serve path-style URLs
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true
+		}
+	})
+	return &s3Store{client: client, uploader: manager.NewUploader(client), bucket: bucket}, nil
+}
+
+func (s *s3Store) Upload(ctx context.Context, key, localPath string) (string, error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	input := &s3.PutObjectInput{Bucket: aws.String(s.bucket), Key: aws.String(key), Body: f}
+	if contentType := mime.TypeByExtension(filepath.Ext(localPath)); contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	out, err := s.uploader.Upload(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	return out.Location, nil
+}
+
+func (s *s3Store) Stream(ctx context.Context, key string) (objectStream, error) {
+	out, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String("application/x-ndjson"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3Stream{store: s, key: key, uploadID: out.UploadId}, nil
+}
+
+// s3Stream sends each s3PartSize of written data as a part of a multipart upload
+type s3Stream struct {
+	store    *s3Store
+	key      string
+	uploadID *string
+
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	parts     []types.CompletedPart
+	completed bool
+	location  string
+}
+
+var errStreamCompleted = errors.New("upload already completed")
+
+func (s *s3Stream) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.completed {
+		return 0, errStreamCompleted
+	}
+	s.buf.Write(p)
+	if s.buf.Len() >= s3PartSize {
+		if err := s.uploadPart(context.Background()); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// uploadPart sends the buffer as the next part; callers hold mu
+func (s *s3Stream) uploadPart(ctx context.Context) error {
+	number := int32(len(s.parts) + 1)
+	out, err := s.store.client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:     aws.String(s.store.bucket),
+		Key:        aws.String(s.key),
+		UploadId:   s.uploadID,
+		PartNumber: aws.Int32(number),
+		Body:       bytes.NewReader(s.buf.Bytes()),
+	})
+	if err != nil {
+		return err
+	}
+	s.parts = append(s.parts, types.CompletedPart{ETag: out.ETag, PartNumber: aws.Int32(number)})
+	s.buf.Reset()
+	return nil
+}
+
+func (s *s3Stream) Complete(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.completed {
+		return s.location, nil
+	}
+	// The last part may be short; an upload needs at least one, even if empty
+	if s.buf.Len() > 0 || len(s.parts) == 0 {
+		if err := s.uploadPart(ctx); err != nil {
+			return "", err
+		}
+	}
+	out, err := s.store.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.store.bucket),
+		Key:             aws.String(s.key),
+		UploadId:        s.uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: s.parts},
+	})
+	if err != nil {
+		return "", err
+	}
+	s.completed = true
+	s.location = aws.ToString(out.Location)
+	return s.location, nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// resultsSchemaVersion is written into every results envelope and JSONL record as
+// "major.minor". Bump the minor version when ForumThread or ForumPost gains an
+// optional field, and the major version when a field is removed, renamed or changes
+// type. Readers refuse files whose major version differs from this build's.
+const resultsSchemaVersion = "1.29"
+
+// legacySchemaVersion is assumed for files written before the version field, or
+// with the bare integer 1 the first versioned files used
+const legacySchemaVersion = "1.0"
+
+// threadRecord is one line of JSONL output: a thread tagged with the schema version
+type threadRecord struct {
+	SchemaVersion string `json:"schema_version"`
+	*ForumThread
+}
+
+// parseSchemaVersion reads a schema_version value, accepting the legacy integer form
+func parseSchemaVersion(raw json.RawMessage) (string, error) {
+	var version string
+	if err := json.Unmarshal(raw, &version); err == nil {
+		if _, _, err := splitSchemaVersion(version); err != nil {
+			return "", err
+		}
+		return version, nil
+	}
+	var legacy int
+	if err := json.Unmarshal(raw, &legacy); err != nil || legacy != 1 {
+		return "", fmt.Errorf("invalid schema version %s", raw)
+	}
+	return legacySchemaVersion, nil
+}
+
+// splitSchemaVersion splits "major.minor" into its numbers
+func splitSchemaVersion(version string) (major, minor int, err error) {
+	majorText, minorText, found := strings.Cut(version, ".")
+	if major, err = strconv.Atoi(majorText); err == nil && found {
+		minor, err = strconv.Atoi(minorText)
+	}
+	if err != nil || !found {
+		return 0, 0, fmt.Errorf("invalid schema version %q (want major.minor)", version)
+	}
+	return major, minor, nil
+}
+
+// checkSchemaVersion rejects versions whose major number this build can't read
+func checkSchemaVersion(version string) error {
+	major, _, err := splitSchemaVersion(version)
+	if err != nil {
+		return err
+	}
+	if current, _, _ := splitSchemaVersion(resultsSchemaVersion); major != current {
+		return fmt.Errorf("incompatible schema version %s (this build reads %d.x)", version, current)
+	}
+	return nil
+}
+
+// jsonSchema is the subset of JSON Schema that describes results files
+type jsonSchema struct {
+	Schema     string                 `json:"$schema,omitempty"`
+	Title      string                 `json:"title,omitempty"`
+	Type       []string               `json:"type,omitempty"`
+	Format     string                 `json:"format,omitempty"`
+	Properties map[string]*jsonSchema `json:"properties,omitempty"`
+	Required   []string               `json:"required,omitempty"`
+	Items      *jsonSchema            `json:"items,omitempty"`
+	// AdditionalProperties is false for structs and the value schema for maps
+	AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+}
+
+// allows reports whether the schema accepts a JSON value of the given type.
+// A schema with no type accepts anything.
+func (s *jsonSchema) allows(jsonType string) bool {
+	if len(s.Type) == 0 {
+		return true
+	}
+	for _, t := range s.Type {
+		if t == jsonType || (t == "number" && jsonType == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor derives the JSON Schema of a Go type from its encoding/json shape:
+// omitempty fields are optional, pointers, slices and maps may be null, and
+// structs reject fields they don't declare
+func schemaFor(t reflect.Type) *jsonSchema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := schemaFor(t.Elem())
+		s.Type = append(s.Type, "null")
+		return s
+	case reflect.Struct:
+		if t == timeType {
+			return &jsonSchema{Type: []string{"string"}, Format: "date-time"}
+		}
+		s := &jsonSchema{Type: []string{"object"}, Properties: make(map[string]*jsonSchema), AdditionalProperties: false}
+		addStructFields(s, t)
+		return s
+	case reflect.Slice, reflect.Array:
+		return &jsonSchema{Type: []string{"array", "null"}, Items: schemaFor(t.Elem())}
+	case reflect.Map:
+		return &jsonSchema{Type: []string{"object", "null"}, AdditionalProperties: schemaFor(t.Elem())}
+	case reflect.String:
+		return &jsonSchema{Type: []string{"string"}}
+	case reflect.Bool:
+		return &jsonSchema{Type: []string{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: []string{"integer"}}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: []string{"number"}}
+	default:
+		return &jsonSchema{}
+	}
+}
+
+// addStructFields adds a struct's JSON fields to s, flattening embedded structs
+func addStructFields(s *jsonSchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		if field.Anonymous && tag == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			addStructFields(s, embedded)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		s.Properties[name] = schemaFor(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// resultsSchema returns the JSON Schema of a results envelope
+func resultsSchema() *jsonSchema {
+	s := schemaFor(reflect.TypeOf(ResultsEnvelope{}))
+	s.Schema = "https://json-schema.org/draft/2020-12/schema"
+	s.Title = "Forum scraper results " + resultsSchemaVersion
+	return s
+}
+
+// threadRecordSchema returns the JSON Schema of one JSONL thread record
+func threadRecordSchema() *jsonSchema {
+	s := schemaFor(reflect.TypeOf(threadRecord{}))
+	s.Schema = "https://json-schema.org/draft/2020-12/schema"
+	s.Title = "Forum scraper thread record " + resultsSchemaVersion
+	return s
+}
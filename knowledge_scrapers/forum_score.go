@@ -0,0 +1,87 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters. Without corpus statistics the average post length is a fixed
+// assumption, which keeps scores independent of what else was scraped.
+const (
+	scoreK1         = 1.2
+	scoreB          = 0.75
+	scoreAvgTokens  = 80.0
+	scoreTitleBoost = 0.5
+)
+
+// tokenize lowercases text and splits it into letter/digit runs
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// ScoreProcessor scores posts against query terms with a BM25-style term-frequency
+// formula, boosting terms that also appear in the thread title. Posts scoring below
+// MinScore are dropped when MinScore is positive.
+type ScoreProcessor struct {
+	Terms    []string
+	MinScore float64
+}
+
+// NewScoreProcessor builds a ScoreProcessor from a free-text query
+func NewScoreProcessor(query string, minScore float64) ScoreProcessor {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, term := range tokenize(query) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return ScoreProcessor{Terms: terms, MinScore: minScore}
+}
+
+// score computes the post's relevance; the result is rounded so output is stable
+func (p ScoreProcessor) score(post *ForumPost) float64 {
+	tokens := tokenize(post.Content)
+	counts := make(map[string]int, len(tokens))
+	for _, token := range tokens {
+		counts[token]++
+	}
+	title := make(map[string]bool)
+	for _, token := range tokenize(post.ThreadTitle) {
+		title[token] = true
+	}
+
+	norm := scoreK1 * (1 - scoreB + scoreB*float64(len(tokens))/scoreAvgTokens)
+	total := 0.0
+	for _, term := range p.Terms {
+		if tf := float64(counts[term]); tf > 0 {
+			total += tf * (scoreK1 + 1) / (tf + norm)
+		}
+		if title[term] {
+			total += scoreTitleBoost
+		}
+	}
+	return math.Round(total*10000) / 10000
+}
+
+// Process implements PostProcessor
+func (p ScoreProcessor) Process(post *ForumPost) (*ForumPost, error) {
+	post.Score = p.score(post)
+	if p.MinScore > 0 && post.Score < p.MinScore {
+		return nil, &SkipPost{Reason: "low_score"}
+	}
+	return post, nil
+}
+
+// threadScore aggregates post scores as the best-scoring post's score
+func threadScore(posts []ForumPost) float64 {
+	best := 0.0
+	for _, post := range posts {
+		best = math.Max(best, post.Score)
+	}
+	return best
+}
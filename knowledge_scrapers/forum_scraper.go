@@ -1,57 +1,205 @@
 package main
 
 import (
-	"encoding/json"
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"log"
+	"io"
+	"math"
 	"net/http"
+	"net/http/cookiejar"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
 )
 
 // ForumPost represents a forum post with extracted content
 type ForumPost struct {
-	URL           string    `json:"url"`
-	ThreadTitle   string    `json:"thread_title"`
-	Author        string    `json:"author"`
-	Content       string    `json:"content"`
-	PostNumber    int       `json:"post_number"`
-	Timestamp     string    `json:"timestamp,omitempty"`
-	LikesCount    *int      `json:"likes_count,omitempty"`
-	RepliesCount  *int      `json:"replies_count,omitempty"`
-	ForumCategory string    `json:"forum_category,omitempty"`
-	ScrapedAt     time.Time `json:"scraped_at"`
+	URL         string `json:"url"`
+	ThreadTitle string `json:"thread_title"`
+	Author      string `json:"author"`
+	Content     string `json:"content"`
+	PostNumber  int    `json:"post_number"`
+	// PostID is the platform's own ID for the post, or the anchor its permalink
+	// points to, where the page gives one
+	PostID string `json:"post_id,omitempty"`
+	// ParentPostNumber is the post this one replies to, where the platform records it
+	ParentPostNumber int    `json:"parent_post_number,omitempty"`
+	Timestamp        string `json:"timestamp,omitempty"`
+	LikesCount       *int   `json:"likes_count,omitempty"`
+	// Awards counts awards (Reddit gildings) given to the post
+	Awards        *int    `json:"awards,omitempty"`
+	RepliesCount  *int    `json:"replies_count,omitempty"`
+	ForumCategory string  `json:"forum_category,omitempty"`
+	ContentHash   string  `json:"content_hash,omitempty"`
+	Language      string  `json:"language,omitempty"`
+	Score         float64 `json:"score,omitempty"`
+	// IsAcceptedAnswer marks the post a Q&A thread's asker accepted as the solution
+	IsAcceptedAnswer bool `json:"is_accepted_answer,omitempty"`
+	// Mentions are the users the post @-mentions; InternalThreadLinks the other threads
+	// on the board it links to
+	Mentions            []string     `json:"mentions,omitempty"`
+	InternalThreadLinks []string     `json:"internal_thread_links,omitempty"`
+	Attachments         []Attachment `json:"attachments,omitempty"`
+	// Edited is set when the post shows an edit notice; EditedAt and EditedBy are
+	// what the notice says, when it says
+	Edited   bool   `json:"edited,omitempty"`
+	EditedAt string `json:"edited_at,omitempty"`
+	EditedBy string `json:"edited_by,omitempty"`
+	// MatchedUser marks posts by the --user member
+	MatchedUser bool `json:"matched_user,omitempty"`
+	// RunID is the run that scraped the post; RecordID identifies the post within it
+	RunID     string    `json:"run_id,omitempty"`
+	RecordID  string    `json:"record_id,omitempty"`
+	ScrapedAt time.Time `json:"scraped_at"`
 }
 
 // ForumThread represents a complete forum thread
 type ForumThread struct {
-	URL          string      `json:"url"`
-	Title        string      `json:"title"`
-	Category     string      `json:"category"`
-	Author       string      `json:"author"`
-	Posts        []ForumPost `json:"posts"`
-	ViewsCount   *int        `json:"views_count,omitempty"`
-	RepliesCount int         `json:"replies_count"`
-	CreatedAt    string      `json:"created_at,omitempty"`
-	LastPostAt   string      `json:"last_post_at,omitempty"`
-	ScrapedAt    time.Time   `json:"scraped_at"`
+	URL                   string         `json:"url"`
+	ThreadID              string         `json:"thread_id,omitempty"`
+	Title                 string         `json:"title"`
+	Category              string         `json:"category"`
+	CategoryID            int            `json:"category_id,omitempty"`
+	Tags                  []string       `json:"tags,omitempty"`
+	Author                string         `json:"author"`
+	Posts                 []ForumPost    `json:"posts"`
+	ViewsCount            *int           `json:"views_count,omitempty"`
+	RepliesCount          int            `json:"replies_count"`
+	TotalPagesSeen        int            `json:"total_pages_seen,omitempty"`
+	Truncated             bool           `json:"truncated,omitempty"`
+	PartialWindow         bool           `json:"partial_window,omitempty"`
+	CreatedAt             string         `json:"created_at,omitempty"`
+	LastPostAt            string         `json:"last_post_at,omitempty"`
+	FinalURL              string         `json:"final_url,omitempty"`
+	CanonicalURL          string         `json:"canonical_url,omitempty"`
+	SourceURL             string         `json:"source_url,omitempty"`
+	Language              string         `json:"language,omitempty"`
+	Score                 float64        `json:"score,omitempty"`
+	DuplicatePostsDropped int            `json:"duplicate_posts_dropped,omitempty"`
+	SkippedPosts          map[string]int `json:"skipped_posts,omitempty"`
+	Provenance            *Provenance    `json:"provenance,omitempty"`
+	RunID                 string         `json:"run_id,omitempty"`
+	RecordID              string         `json:"record_id,omitempty"`
+	ScrapedAt             time.Time      `json:"scraped_at"`
 }
 
-// PlatformConfig holds platform-specific configuration
+// ResultsEnvelope is the document written to a results file. Its shape is the
+// results schema, so changing it means bumping resultsSchemaVersion.
+type ResultsEnvelope struct {
+	SchemaVersion string       `json:"schema_version"`
+	ForumType     string       `json:"forum_type"`
+	TotalThreads  int          `json:"total_threads"`
+	TotalPosts    int          `json:"total_posts"`
+	ScrapedAt     string       `json:"scraped_at"`
+	SearchQuery   string       `json:"search_query,omitempty"`
+	RunConfig     *RunConfig   `json:"run_config,omitempty"`
+	Run           *RunMetadata `json:"run,omitempty"`
+	// SourceRuns are the runs behind a merged file's threads, as their files recorded them
+	SourceRuns      []*RunMetadata         `json:"source_runs,omitempty"`
+	Anonymization   map[string]string      `json:"anonymization,omitempty"`
+	Stats           RunSummary             `json:"stats"`
+	StoppedByBudget string                 `json:"stopped_by_budget,omitempty"`
+	RunStats        runStats               `json:"run_stats"`
+	AdaptiveDelays  map[string]HostDelay   `json:"adaptive_delays,omitempty"`
+	UserAgents      map[string]interface{} `json:"user_agents,omitempty"`
+	Languages       map[string]int         `json:"languages,omitempty"`
+	Failures        []Failure              `json:"failures,omitempty"`
+	SortedBy        string                 `json:"sorted_by,omitempty"`
+	Top             int                    `json:"top,omitempty"`
+	PrioritizedBy   string                 `json:"prioritized_by,omitempty"`
+	Part            int                    `json:"part,omitempty"`
+	Parts           int                    `json:"parts,omitempty"`
+	Threads         []ForumThread          `json:"threads"`
+}
+
+// PlatformConfig holds platform-specific configuration. Selector fields are
+// selectorChains, tried in order until one finds something.
 type PlatformConfig struct {
-	ThreadSelector    string
-	PostSelector      string
-	ContentSelector   string
-	AuthorSelector    string
-	TimestampSelector string
+	ThreadSelector    selectorChain
+	PostSelector      selectorChain
+	ContentSelector   selectorChain
+	AuthorSelector    selectorChain
+	TimestampSelector selectorChain
+	// TimestampAttr, when set, is the attribute of the timestamp element holding the
+	// full date where the text is relative ("2d"); TimestampLayouts are time.Parse
+	// layouts for the dates the platform displays, used by --window and --sort recent
+	TimestampAttr    string
+	TimestampLayouts []string
+	ThreadURLPattern string
+	// ThreadIDPattern extracts the platform's native thread ID from a thread URL;
+	// the first non-empty capture group is the ID
+	ThreadIDPattern         string
+	ThreadLinkSelector      selectorChain
+	IndexPaginationSelector selectorChain
+	SearchURLTemplate       string
+	ForumURLPattern         string
+	// MissingSelector and MissingMarkers identify "thread not found" pages served with HTTP 200
+	MissingSelector selectorChain
+	MissingMarkers  []string
+	// ConsentForm, when set, is auto-submitted to get past an age gate or consent interstitial
+	ConsentForm *ConsentForm
+	// IgnoreAuthorPatterns and IgnoreContentPatterns are regexes marking bot, system and
+	// deleted-post placeholders, which are skipped before the length check
+	IgnoreAuthorPatterns  []string
+	IgnoreContentPatterns []string
+	// TokenHeader carries --bearer-token bare instead of as "Authorization: Bearer";
+	// TokenUserHeader and TokenUser name the account it acts as (Discourse's Api-Key
+	// and Api-Username). TokenUser has no default, so a token never silently acts as
+	// an admin account; --token-user sets it. Platforms taking a standard bearer
+	// token, like NodeBB, leave these empty.
+	TokenHeader     string
+	TokenUserHeader string
+	TokenUser       string
+	// ScoreSelector and AwardsSelector read a post's native score (into LikesCount)
+	// and award count, where the platform shows them
+	ScoreSelector  selectorChain
+	AwardsSelector selectorChain
+	// PrintView, when set, is the single-document thread view --lightweight fetches
+	PrintView *PrintView
+	// UserHistory, when set, is the member post listing --user walks
+	UserHistory *UserHistory
+	// Attachments, when set, is how posts list their attached files
+	Attachments *AttachmentMarkup
+	// EditedSelector matches a post's "last edited" notice, which is kept out of the content
+	EditedSelector selectorChain
+	// QuoteSelector matches the quoted replies in a post's content, which
+	// --strip-quotes removes before the text is read
+	QuoteSelector selectorChain
+	// AcceptedAnswerSelector marks the accepted answer of a solved Q&A thread; it
+	// matches the post element itself or an element inside it
+	AcceptedAnswerSelector selectorChain
+	// The regex rules read thread metadata from the page HTML when neither the
+	// selectors nor structured data found it, such as counters that only appear in
+	// inline JavaScript. Each needs exactly one capture group. They are a last
+	// resort: a selector is preferred wherever one reaches the value.
+	TitleRegex     string
+	CategoryRegex  string
+	AuthorRegex    string
+	CreatedAtRegex string
+	ViewsRegex     string
+	RepliesRegex   string
+	// URLRewrites map alternate views of a thread to its own URL, applied in order
+	URLRewrites []URLRewrite
+	// IndexRepliesSelector and IndexViewsSelector read the counts an index lists in
+	// each thread link's row; a thread page stating no counts falls back on them
+	IndexRepliesSelector selectorChain
+	IndexViewsSelector   selectorChain
+	// IndexCategorySelector reads the category label of a thread link's row, on
+	// indexes that mix categories (search results, "what's new")
+	IndexCategorySelector selectorChain
+	// DateNavigation, when set, is how subforum index pages list threads by date for --window
+	DateNavigation *DateNavigation
 }
 
 // ForumScraperGo implements high-performance forum scraping with Go's concurrency
@@ -61,56 +209,492 @@ type ForumScraperGo struct {
 	client       *http.Client
 	visitedURLs  map[string]bool
 	visitedMutex sync.RWMutex
-	configs      map[string]PlatformConfig
+	// sharedVisited extends the visited sets to every worker pulling from one queue
+	sharedVisited visitedStore
+	// visitedThreadIDs holds host#ID keys of scraped threads, guarded by visitedMutex
+	visitedThreadIDs map[string]bool
+	// exported holds the url: and id: keys of threads in --skip-from files; it is
+	// filled before the run starts and only read after
+	exported map[string]bool
+	// threadCategories maps threads discovered through --category to it, guarded by visitedMutex
+	threadCategories map[string]discourseCategory
+	configs          map[string]PlatformConfig
+	// listedCounts maps discovered threads to the counts their index listed, guarded by visitedMutex
+	listedCounts map[string]threadListing
+
+	// threadPattern overrides the platform's ThreadURLPattern when set
+	threadPattern *regexp.Regexp
+	// threadSem caps concurrent thread scrapes across every source in a run;
+	// hostSlots caps them per host underneath it
+	threadSem chan struct{}
+	hostSlots *hostLimiter
+	// workers caps a pipeline's workers below the thread slots, when those are
+	// shared with other scrapes; 0 runs one per slot
+	workers int
+	// singleFile puts the whole --format markdown report in one file; excerptChars
+	// cuts each quoted post short there (0 for no limit)
+	singleFile   bool
+	excerptChars int
+	// accessLog records every request for --access-log; threadSeq numbers thread
+	// scrapes for it
+	accessLog *accessLog
+	threadSeq int64
+	// ctx is the context every request derives from; serve jobs set it so that
+	// cancelling a job stops its requests
+	ctx context.Context
+	// sinks receive each thread as it is scraped (--postgres, --kafka, --nats)
+	sinks []threadSink
+	// objectStore uploads results under objectPrefix for --s3; uploads are the
+	// URLs of the objects written
+	objectStore  objectStore
+	objectPrefix string
+	uploads      []string
+	// requestHooks and responseHooks are the library hooks from OnRequest and OnResponse
+	requestHooks  []RequestHook
+	responseHooks []ResponseHook
+	// maxIndexPages caps how many pages of one index discoverThreads will walk
+	maxIndexPages int
+	// maxPagesPerThread caps how many pages of one thread are fetched from its first
+	// page on; lastPage fetches the thread's final page as well
+	maxPagesPerThread int
+	lastPage          bool
+	// searchQuery discovers threads from the forum's search results instead of index pages
+	searchQuery string
+	// stackExchangeKey is the optional Stack Exchange API key, which raises the daily quota
+	stackExchangeKey string
+	// categoryName discovers threads from a Discourse category's JSON listing
+	categoryName string
+	// userName and userID discover the threads one member posted in, from --user
+	userName string
+	userID   string
+	// userPostsOnly emits only the member's posts, straight from their history where it shows whole posts
+	userPostsOnly bool
+	// userThreads holds the member's posts per thread for userPostsOnly, guarded by visitedMutex
+	userThreads map[string]*userThread
+	// feedURL discovers threads from an RSS/Atom feed; "auto" uses the one each page advertises
+	feedURL string
+	// maxDepth is how many levels of subforums discovery descends into; 0 disables crawling
+	maxDepth int
+	// maxForumsPerLevel caps the subforums queued at each crawl level
+	maxForumsPerLevel int
+	// useSitemap discovers threads from /sitemap.xml instead of index pages
+	useSitemap bool
+	// maxSitemaps caps sitemap files fetched per source, index files included
+	maxSitemaps int
+	// since drops discovered threads whose last activity predates it
+	since time.Time
+	// window keeps only posts dated inside it, and narrows discovery where the platform allows
+	window timeWindow
+	// urlFilter limits which discovered links are queued or followed
+	urlFilter URLFilter
+	// renderer loads thread pages in a headless browser when --render is on
+	renderer pageRenderer
+	// renderSem caps concurrent browser tabs, independently of threadSem
+	renderSem chan struct{}
+	// renderTimeout bounds how long a rendered page may take to show posts
+	renderTimeout time.Duration
+	// maxResponseSize caps response bodies in bytes; 0 disables the cap
+	maxResponseSize int64
+	// outputDir is where saveResults writes files named without a directory
+	outputDir string
+	// filenameTemplate names result files when saveResults gets no explicit filename
+	filenameTemplate string
+	// splitSize and splitThreads start a new results part once a part holds this
+	// many bytes of thread records or this many threads; 0 disables either limit
+	splitSize    int64
+	splitThreads int
+	// downloadDir is where --download-attachments saves files, one subdirectory per thread
+	downloadDir string
+	// maxAttachmentSize caps each downloaded attachment in bytes
+	maxAttachmentSize int64
+	// followHops is how many hops of thread-to-thread links --follow-references scrapes
+	followHops int
+	// oldReddit rewrites reddit URLs to old.reddit.com
+	oldReddit bool
+	// lightweight fetches threads through the platform's PrintView when it has one
+	lightweight bool
+	// selectiveParse prunes thread pages over selectivePageBytes to their header and posts
+	selectiveParse bool
+	// dns resolves, caches and dials the hosts every request connects to
+	dns *dnsCache
+	// precheck asks for each thread's headers before fetching it
+	precheck bool
+	// localInput serves file:// URLs from disk for directory and file:// sources;
+	// fileGlob picks a directory's thread files instead of the thread URL pattern
+	localInput bool
+	fileGlob   string
+	// postsMode is all, first (opening post only) or none (thread metadata only)
+	postsMode string
+	// sortBy ranks saved threads by engagement instead of URL; top keeps only the
+	// highest-ranked threads, 0 for all
+	sortBy string
+	top    int
+	// prioritize orders each index page's discovered threads (recent, active or
+	// title-match) before --max-threads cuts them; priorityPattern scores titles
+	// for title-match
+	prioritize      string
+	priorityPattern *regexp.Regexp
+	// dedupePosts drops posts whose content hash was already seen in this run
+	dedupePosts bool
+	// stripQuotes removes quoted replies from post content (--strip-quotes)
+	stripQuotes    bool
+	seenPosts      map[string]bool
+	seenPostsMutex sync.Mutex
+	// anonymizeSalt, when set, replaces author names with salted HMAC tokens
+	anonymizeSalt string
+	// redactPIIEnabled replaces email addresses and phone numbers in post content
+	redactPIIEnabled bool
+	// minPostLength and maxPostLength bound post content in runes; maxPostLength 0 means
+	// no limit, and truncateLongPosts cuts long posts instead of dropping them
+	minPostLength     int
+	maxPostLength     int
+	truncateLongPosts bool
+	// scoreQuery, when set, scores every post against its terms; posts under minScore are dropped
+	scoreQuery string
+	minScore   float64
+	// skipNormalize turns off the built-in whitespace and BBCode cleanup
+	skipNormalize bool
+	// ignoreRules caches the platform's compiled ignore patterns
+	ignoreRules     *ignoreRules
+	ignoreRulesOnce sync.Once
+	// postProcessors run in order on every extracted post; threadFilters on every thread
+	postProcessors []PostProcessor
+	threadFilters  []ThreadFilter
+	// languages, when non-empty, limits output to posts and threads in these languages
+	languages map[string]bool
+	// languageCounts histograms the languages of kept posts
+	languageCounts languageHistogram
+	// categories holds the --include-category and --exclude-category patterns and tallies
+	categories categoryFilter
+	// jitter randomizes each delay by ± this fraction; adaptiveDelay backs off per host
+	// when responses turn slow or rate-limited
+	jitter        float64
+	adaptiveDelay bool
+	pacing        *hostPacingTable
+	// retries is how many times a request is made again after a transient failure
+	retries int
+	// userAgents is the --user-agent-file pool; hostUA pins one per host unless
+	// uaRotate is per-request
+	userAgents  []string
+	uaRotate    string
+	hostUA      map[string]string
+	hostUAMutex sync.Mutex
+	// debug enables 🐛 debug lines on statusOut; matchedSelectors holds the
+	// field and selector pairs already logged by selectorMatched
+	debug            bool
+	matchedSelectors sync.Map
+	// invalidLinks holds the hrefs resolveLink has dropped, so each counts once
+	invalidLinks sync.Map
+	// fields, when set, collects what each config field found, for check-config
+	fields *fieldRecorder
+	// runConfig is the run's effective configuration, recorded in the results envelope
+	runConfig *RunConfig
+	// run identifies the run in its records and outputs; merges have none and list
+	// their inputs' runs in sourceRuns. runRecorded says the sinks have seen it.
+	run         *RunMetadata
+	sourceRuns  []*RunMetadata
+	runRecorded sync.Once
+	// auth holds --basic-auth and --bearer-token credentials
+	auth credentials
+	// requestTimeout bounds each request from dial to the end of its body
+	requestTimeout time.Duration
+	// threadTimeout bounds each thread's fetches; posts from pages fetched in time are kept
+	threadTimeout time.Duration
+	// bandwidth caps response throughput across all requests; nil means unlimited
+	bandwidth *bandwidthLimiter
+	// seed drives rng and per-host choices; fixedTimestamps freezes recorded times
+	seed            int64
+	rng             *lockedRand
+	fixedTimestamps bool
+	// maxRedirects caps redirects per request; allowExternal permits redirects to other hosts
+	maxRedirects  int
+	allowExternal bool
+	// budget caps total posts, requests and wall time for the run
+	budget *runBudget
+	// summary aggregates the analytical "stats" block as threads complete
+	summary summaryAccumulator
+	// authors builds the --emit-authors profiles written to authorsPath
+	authors     authorAccumulator
+	authorsPath string
+	// qaAnswerProcessors and qaThreadFilters are the --format qa-jsonl heuristics:
+	// which replies may answer, and which threads yield a pair at all
+	qaAnswerProcessors []PostProcessor
+	qaThreadFilters    []ThreadFilter
+	// chunkTokens and chunkOverlap are the --format chunks budgets, counted by tokenCounter
+	chunkTokens  int
+	chunkOverlap int
+	tokenCounter TokenCounter
+	// stats holds the run's shared counters
+	stats runStats
+	// failures collects threads that could not be scraped, by error type
+	failures failureReport
+	// statusOut receives human-facing progress lines (see statusf); stderr by default
+	statusOut io.Writer
 }
 
-// NewForumScraper creates a new forum scraper instance
-func NewForumScraper(platform string, delaySeconds float64) *ForumScraperGo {
-	configs := map[string]PlatformConfig{
+// defaultPlatformConfigs returns the built-in configuration of every platform
+func defaultPlatformConfigs() map[string]PlatformConfig {
+	return map[string]PlatformConfig{
 		"phpbb": {
-			ThreadSelector:    ".topictitle",
-			PostSelector:      ".post",
-			ContentSelector:   ".content",
-			AuthorSelector:    ".username",
-			TimestampSelector: ".author .responsive-hide",
+			ThreadSelector:          selectorChain{".topictitle"},
+			PostSelector:            selectorChain{".post"},
+			ContentSelector:         selectorChain{".content"},
+			AuthorSelector:          selectorChain{".username"},
+			TimestampSelector:       selectorChain{".author time", ".author .responsive-hide"},
+			ThreadURLPattern:        `viewtopic\.php\?.*\b[tp]=\d+`,
+			ThreadIDPattern:         `viewtopic\.php\?(?:.*&)?t=(\d+)`,
+			ForumURLPattern:         `viewforum\.php\?.*\bf=\d+`,
+			ThreadLinkSelector:      selectorChain{"a.topictitle"},
+			IndexPaginationSelector: selectorChain{".pagination .next a", ".pagination a[rel=\"next\"]"},
+			SearchURLTemplate:       "search.php?keywords={query}&sr=topics",
+			MissingMarkers:          []string{"The requested topic does not exist", "The requested forum does not exist"},
+			EditedSelector:          selectorChain{".notice"},
+			QuoteSelector:           selectorChain{"blockquote"},
+			Attachments: &AttachmentMarkup{
+				ItemSelector: ".attachbox dl.file",
+				LinkSelector: "a.postlink, a[href*=\"file.php\"], img.postimage",
+			},
+			// Post-mode search results show each post in full under its topic link
+			UserHistory: &UserHistory{
+				URLTemplate:        "search.php?author={user}&sr=posts",
+				IDURLTemplate:      "search.php?author_id={user_id}&sr=posts",
+				PostSelector:       selectorChain{".search.post"},
+				ThreadLinkSelector: selectorChain{".postprofile dd a[href*=\"viewtopic.php\"]", ".postbody h3 a"},
+				AuthorSelector:     selectorChain{".postprofile .username", ".postprofile .username-coloured"},
+				ContentSelector:    selectorChain{".content"},
+				TimestampSelector:  selectorChain{".search-result-date"},
+				FullPosts:          true,
+			},
+			URLRewrites: []URLRewrite{
+				{Pattern: `([?&])view=(?:print|unread)(?:&|$)`, Replace: "${1}"},
+				{Pattern: `([?&])start=0(?:&|$)`, Replace: "${1}"},
+			},
+			IndexRepliesSelector:  selectorChain{"dd.posts"},
+			IndexViewsSelector:    selectorChain{"dd.views"},
+			IndexCategorySelector: selectorChain{".responsive-hide a[href*=\"viewforum.php\"]"},
+			// "Display topics from previous", sorted by last post time
+			DateNavigation: &DateNavigation{
+				DaysParam:  "st",
+				Days:       []int{1, 7, 14, 30, 90, 180, 365},
+				SortParams: map[string]string{"sk": "t", "sd": "d"},
+			},
 		},
 		"vbulletin": {
-			ThreadSelector:    ".threadtitle",
-			PostSelector:      "[id^=\"post_\"]",
-			ContentSelector:   ".postcontent",
-			AuthorSelector:    ".username_container",
-			TimestampSelector: ".postdate",
+			ThreadSelector:          selectorChain{".threadtitle"},
+			PostSelector:            selectorChain{"[id^=\"post_\"]"},
+			ContentSelector:         selectorChain{".postcontent"},
+			AuthorSelector:          selectorChain{".username_container"},
+			TimestampSelector:       selectorChain{".postdate"},
+			TimestampLayouts:        []string{"01-02-2006, 03:04 PM", "01-02-2006 03:04 PM", "01-02-2006"},
+			ThreadURLPattern:        `showthread\.php|/threads?/\d+`,
+			ThreadIDPattern:         `showthread\.php\?(?:.*&)?t=(\d+)|showthread\.php/(\d+)|/threads?/(\d+)`,
+			ForumURLPattern:         `forumdisplay\.php|/forums/\d+`,
+			ThreadLinkSelector:      selectorChain{".threadtitle a", "a.title"},
+			IndexPaginationSelector: selectorChain{"a[rel=\"next\"]", ".pagination .prev_next a[rel=\"next\"]"},
+			SearchURLTemplate:       "search.php?do=process&query={query}",
+			MissingMarkers:          []string{"No Thread specified", "Invalid Thread specified"},
+			EditedSelector:          selectorChain{".lastedited"},
+			QuoteSelector:           selectorChain{".bbcode_container", "blockquote"},
+			// printthread.php: vB3 lays posts out as td.page tables with the author in
+			// large type and the date in the smallfont cell; vB4 uses li.postbit
+			PrintView: &PrintView{
+				URLTemplate:       "printthread.php?t={id}&pp=1000",
+				TitleSelector:     selectorChain{"td.navbar strong", "h1"},
+				PostSelector:      selectorChain{"td.page", "li.postbit"},
+				ContentSelector:   selectorChain{"td.page > div:last-child", ".content"},
+				AuthorSelector:    selectorChain{"td[style*=\"14pt\"]", ".username"},
+				TimestampSelector: selectorChain{"td.smallfont", ".datetime"},
+			},
+			URLRewrites: []URLRewrite{
+				{Pattern: `/printthread\.php`, Replace: "/showthread.php"},
+				{Pattern: `([?&])(?:pp|mode)=[^&]*`, Replace: "${1}"},
+				{Pattern: `([?&])page=1(?:&|$)`, Replace: "${1}"},
+			},
+			// forumdisplay.php's "Show threads from the", sorted by last post
+			DateNavigation: &DateNavigation{
+				DaysParam:  "daysprune",
+				Days:       []int{1, 2, 7, 10, 14, 30, 45, 60, 75, 100, 365},
+				SortParams: map[string]string{"sort": "lastpost", "order": "desc"},
+			},
 		},
 		"discourse": {
-			ThreadSelector:    ".topic-title",
-			PostSelector:      ".topic-post",
-			ContentSelector:   ".cooked",
-			AuthorSelector:    ".username",
-			TimestampSelector: ".relative-date",
+			ThreadSelector:          selectorChain{".topic-title"},
+			PostSelector:            selectorChain{".topic-post"},
+			ContentSelector:         selectorChain{".cooked"},
+			AuthorSelector:          selectorChain{".username"},
+			TimestampSelector:       selectorChain{".relative-date"},
+			TimestampAttr:           "title",
+			TimestampLayouts:        []string{"Jan 2, 2006 3:04 pm"},
+			QuoteSelector:           selectorChain{"aside.quote"},
+			ThreadURLPattern:        `/t/[^/]+/\d+`,
+			ThreadIDPattern:         `/t/(?:[^/]*[^/\d][^/]*/)?(\d+)`,
+			ForumURLPattern:         `/c/[^/]+`,
+			ThreadLinkSelector:      selectorChain{"a.raw-topic-link"},
+			IndexPaginationSelector: selectorChain{"a[rel=\"next\"]"},
+			SearchURLTemplate:       "/search?q={query}",
+			MissingSelector:         selectorChain{".page-not-found"},
+			MissingMarkers:          []string{"Oops! That page doesn’t exist or is private", "The page you requested doesn't exist"},
+			IgnoreAuthorPatterns:    []string{`^system$`, `^discobot$`},
+			IgnoreContentPatterns:   []string{`^\(post (deleted|withdrawn) by author`, `This post was flagged by the community and is temporarily hidden`},
+			TokenHeader:             "Api-Key",
+			TokenUserHeader:         "Api-Username",
+			AcceptedAnswerSelector:  selectorChain{".accepted-answer", "[itemprop=\"acceptedAnswer\"]"},
+			// Uploads are links in the post body, followed by their size
+			Attachments: &AttachmentMarkup{ItemSelector: "a.attachment"},
+			// /t/slug/123/45 links post 45 of topic 123; u= tags share links
+			URLRewrites: []URLRewrite{
+				{Pattern: `(/t/[^/?]+/\d+)/(?:\d+|print)/?`, Replace: "${1}"},
+				{Pattern: `([?&])u=[^&]*`, Replace: "${1}"},
+				{Pattern: `([?&])page=1(?:&|$)`, Replace: "${1}"},
+			},
 		},
 		"reddit": {
-			ThreadSelector:    "[data-testid=\"post-content\"]",
-			PostSelector:      ".Comment",
-			ContentSelector:   "[data-testid=\"comment\"]",
-			AuthorSelector:    "[data-testid=\"comment_author_link\"]",
-			TimestampSelector: "[data-testid=\"comment_timestamp\"]",
+			// Chains try old.reddit.com first, which reddit URLs are rewritten to by default;
+			// the new Reddit selectors after it cover runs with --old-reddit=false. The
+			// submission and its comments are one selector group, as both are posts.
+			ThreadSelector:          selectorChain{"a.title", "[data-testid=\"post-content\"] h1"},
+			PostSelector:            selectorChain{".thing.link > .entry, .thing.comment > .entry", ".Comment"},
+			ContentSelector:         selectorChain{".usertext-body .md", "[data-testid=\"comment\"]"},
+			AuthorSelector:          selectorChain{".tagline .author", "[data-testid=\"comment_author_link\"]"},
+			TimestampSelector:       selectorChain{".tagline time", "[data-testid=\"comment_timestamp\"]"},
+			ScoreSelector:           selectorChain{".tagline .score.unvoted"},
+			AwardsSelector:          selectorChain{".awardings-bar .awarding-link"},
+			EditedSelector:          selectorChain{".tagline .edited-timestamp"},
+			QuoteSelector:           selectorChain{"blockquote"},
+			ThreadURLPattern:        `/comments/[a-z0-9]+`,
+			ThreadIDPattern:         `/comments/([a-z0-9]+)`,
+			ThreadLinkSelector:      selectorChain{".thing.link a.comments", "a[data-click-id=\"body\"]"},
+			IndexPaginationSelector: selectorChain{".next-button a", "a[rel~=\"next\"]"},
+			SearchURLTemplate:       "search?q={query}&restrict_sr=1",
+			MissingMarkers:          []string{"there doesn't seem to be anything here", "Sorry, nobody on Reddit goes by that name"},
+			IgnoreAuthorPatterns:    []string{`^AutoModerator$`, `^\[deleted\]$`},
+			IgnoreContentPatterns:   []string{`^\[deleted\]$`, `^\[removed\]$`, `^Comment (deleted|removed) by (user|moderator)$`},
+		},
+		"xenforo": {
+			ThreadSelector:          selectorChain{".p-title-value"},
+			PostSelector:            selectorChain{"article.message"},
+			ContentSelector:         selectorChain{".message-body .bbWrapper"},
+			AuthorSelector:          selectorChain{".message-name"},
+			TimestampSelector:       selectorChain{"time.u-dt"},
+			ThreadURLPattern:        `/threads/[^/]+\.\d+`,
+			ThreadIDPattern:         `/threads/(?:[^/]*\.)?(\d+)`,
+			ForumURLPattern:         `/forums/[^/]+\.\d+`,
+			ThreadLinkSelector:      selectorChain{".structItem-title a"},
+			IndexPaginationSelector: selectorChain{"a.pageNav-jump--next"},
+			SearchURLTemplate:       "search/search?keywords={query}",
+			MissingMarkers:          []string{"The requested thread could not be found"},
+			EditedSelector:          selectorChain{".message-lastEdit"},
+			QuoteSelector:           selectorChain{".bbCodeBlock--quote"},
+			ConsentForm: &ConsentForm{
+				Selector: "form.ageGate, form[action*=\"age-confirm\"]",
+				Fields:   map[string]string{"confirm": "1"},
+			},
+			AcceptedAnswerSelector: selectorChain{".message--solution", "[itemprop=\"acceptedAnswer\"]"},
+			Attachments: &AttachmentMarkup{
+				ItemSelector: ".message-attachments .attachment, .message-attachments li.file",
+				LinkSelector: "a.file-preview, a[href*=\"/attachments/\"]",
+				NameSelector: ".file-name, .attachment-name",
+			},
+			// Member search results link each post and show a snippet of it
+			UserHistory: &UserHistory{
+				IDURLTemplate:      "search/member?user_id={user_id}",
+				PostSelector:       selectorChain{"li.block-row"},
+				ThreadLinkSelector: selectorChain{".contentRow-title a"},
+				AuthorSelector:     selectorChain{".contentRow-minor .username"},
+				ContentSelector:    selectorChain{".contentRow-snippet"},
+				TimestampSelector:  selectorChain{"time.u-dt"},
+			},
+			URLRewrites: []URLRewrite{
+				{Pattern: `(/threads/[^/?]+\.\d+)/(?:post-\d+|page-1|unread|latest)\b/?`, Replace: "${1}/"},
+			},
+			// The meta cell pairs Replies, then Views as the minor pair
+			IndexRepliesSelector:  selectorChain{".structItem-cell--meta dl.pairs:not(.structItem-minor) dd"},
+			IndexViewsSelector:    selectorChain{".structItem-cell--meta dl.structItem-minor dd"},
+			IndexCategorySelector: selectorChain{".structItem-parts a[href*=\"/forums/\"]"},
+			// The thread list filter's "Last updated", sorted by last message
+			DateNavigation: &DateNavigation{
+				DaysParam:  "last_days",
+				Days:       []int{7, 14, 30, 60, 90, 182, 365},
+				SortParams: map[string]string{"order": "last_post_date", "direction": "desc"},
+			},
+		},
+		"hackernews": {
+			// Items come from the Hacker News Firebase API rather than HTML, so only URL patterns apply
+			ThreadURLPattern: `news\.ycombinator\.com/item\?id=\d+`,
+			ThreadIDPattern:  `[?&]id=(\d+)`,
+		},
+		"stackexchange": {
+			// Questions come from the Stack Exchange API rather than HTML, so only URL patterns apply
+			ThreadURLPattern: `/(?:questions|q)/\d+`,
+			ThreadIDPattern:  `/(?:questions|q)/(\d+)`,
+			ForumURLPattern:  `/questions/tagged/`,
 		},
 		"generic": {
-			ThreadSelector:    "h1, .thread-title, .topic-title",
-			PostSelector:      ".post, .message, .comment",
-			ContentSelector:   ".content, .message-content, .post-content",
-			AuthorSelector:    ".author, .username, .user",
-			TimestampSelector: ".timestamp, .date, .time",
+			ThreadSelector:    selectorChain{"h1", ".thread-title", ".topic-title"},
+			PostSelector:      selectorChain{".post", ".message", ".comment"},
+			ContentSelector:   selectorChain{".content", ".message-content", ".post-content"},
+			AuthorSelector:    selectorChain{".author", ".username", ".user"},
+			TimestampSelector: selectorChain{".timestamp", ".date", ".time"},
+			QuoteSelector:     selectorChain{"blockquote", ".quote"},
+			ThreadURLPattern:  `/(thread|threads|topic|t)/|viewtopic\.php|showthread\.php`,
+			ForumURLPattern:   `/(forum|forums|c|category|categories)/|viewforum\.php|forumdisplay\.php`,
+			SearchURLTemplate: "/search?q={query}",
+			MissingMarkers:    []string{"thread not found", "topic not found", "topic does not exist", "thread does not exist"},
+			// schema.org QAPage markup, which most Q&A boards emit
+			AcceptedAnswerSelector: selectorChain{"[itemprop=\"acceptedAnswer\"]"},
+			URLRewrites: []URLRewrite{
+				{Pattern: `/printthread\.php`, Replace: "/showthread.php"},
+				{Pattern: `([?&])view=print(?:&|$)`, Replace: "${1}"},
+				{Pattern: `([?&])start=0(?:&|$)`, Replace: "${1}"},
+			},
 		},
 	}
+}
 
-	return &ForumScraperGo{
-		platform:    strings.ToLower(platform),
-		delay:       time.Duration(delaySeconds * float64(time.Second)),
-		visitedURLs: make(map[string]bool),
-		configs:     configs,
+// NewForumScraper creates a new forum scraper instance
+func NewForumScraper(platform string, delaySeconds float64, opts ...Option) *ForumScraperGo {
+	configs := defaultPlatformConfigs()
+
+	// The cookie jar keeps consent and session cookies across requests
+	jar, _ := cookiejar.New(nil)
+
+	fs := &ForumScraperGo{
+		platform:          strings.ToLower(platform),
+		delay:             time.Duration(delaySeconds * float64(time.Second)),
+		visitedURLs:       make(map[string]bool),
+		visitedThreadIDs:  make(map[string]bool),
+		threadCategories:  make(map[string]discourseCategory),
+		listedCounts:      make(map[string]threadListing),
+		userThreads:       make(map[string]*userThread),
+		seenPosts:         make(map[string]bool),
+		minPostLength:     10,
+		configs:           configs,
+		threadSem:         make(chan struct{}, 5),
+		hostSlots:         &hostLimiter{},
+		budget:            &runBudget{},
+		pacing:            &hostPacingTable{},
+		maxIndexPages:     10,
+		maxPagesPerThread: 1,
+		excerptChars:      defaultExcerptChars,
+		chunkTokens:       defaultChunkTokens,
+		chunkOverlap:      defaultChunkOverlap,
+		tokenCounter:      TokenCounterFunc(approxTokens),
+		maxSitemaps:       20,
+		maxForumsPerLevel: 20,
+		maxResponseSize:   defaultMaxResponseSize,
+		maxAttachmentSize: defaultMaxAttachmentSize,
+		maxRedirects:      defaultMaxRedirects,
+		outputDir:         defaultOutputDir,
+		filenameTemplate:  defaultFilenameTemplate,
+		statusOut:         os.Stderr,
+		seed:              time.Now().UnixNano(),
+		requestTimeout:    defaultRequestTimeout,
+		threadTimeout:     defaultThreadTimeout,
+		postsMode:         postsModeAll,
+		oldReddit:         true,
+		selectiveParse:    true,
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Jar: jar,
 			Transport: &http.Transport{
 				MaxIdleConns:        100,
 				MaxIdleConnsPerHost: 10,
@@ -118,20 +702,68 @@ func NewForumScraper(platform string, delaySeconds float64) *ForumScraperGo {
 			},
 		},
 	}
+	fs.client.CheckRedirect = fs.checkRedirect
+	fs.dns = newDNSCache(defaultConnectTimeout, &fs.stats)
+	fs.client.Transport.(*http.Transport).DialContext = fs.dns.DialContext
+	for _, opt := range opts {
+		opt(fs)
+	}
+	fs.rng = newLockedRand(fs.seed)
+	fs.run = fs.newRunMetadata()
+
+	// Built-in processors run ahead of any registered with WithPostProcessors
+	var builtin []PostProcessor
+	if !fs.skipNormalize {
+		builtin = append(builtin, NormalizeProcessor{})
+	}
+	builtin = append(builtin,
+		PostProcessorFunc(fs.ignorePlaceholder),
+		// Lengths are of what the author wrote, and posts the check drops never
+		// count toward the redaction totals
+		LengthProcessor{MinRunes: fs.minPostLength, MaxRunes: fs.maxPostLength, Truncate: fs.truncateLongPosts},
+		PostProcessorFunc(fs.applyPrivacy),
+	)
+	if fs.scoreQuery != "" {
+		builtin = append(builtin, NewScoreProcessor(fs.scoreQuery, fs.minScore))
+	}
+	fs.postProcessors = append(builtin, fs.postProcessors...)
+	return fs
+}
+
+// numberPatterns caches extractNumber's compiled patterns per keyword
+var numberPatterns sync.Map
+
+// keywordNumberPatterns returns the patterns that find a count next to keyword
+func keywordNumberPatterns(keyword string) []*regexp.Regexp {
+	if cached, ok := numberPatterns.Load(keyword); ok {
+		return cached.([]*regexp.Regexp)
+	}
+	patterns := []*regexp.Regexp{
+		regexp.MustCompile(fmt.Sprintf(`(\d+)\s*%s`, keyword)),
+		regexp.MustCompile(fmt.Sprintf(`%s:?\s*(\d+)`, keyword)),
+		regexp.MustCompile(fmt.Sprintf(`%s\s*\((\d+)\)`, keyword)),
+	}
+	numberPatterns.Store(keyword, patterns)
+	return patterns
+}
+
+// maxNumberText caps the text extractNumber searches. Counts sit in a post's
+// header or footer, so longer text keeps its first and last maxNumberText/2 bytes.
+const maxNumberText = 16 << 10
+
+// clampText keeps the first and last limit/2 bytes of text longer than limit
+func clampText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	return text[:limit/2] + "\n" + text[len(text)-limit/2:]
 }
 
 // extractNumber extracts numerical values from text using regex patterns
 func (fs *ForumScraperGo) extractNumber(text string, keywords []string) *int {
-	text = strings.ToLower(text)
+	text = strings.ToLower(clampText(text, maxNumberText))
 	for _, keyword := range keywords {
-		patterns := []string{
-			fmt.Sprintf(`(\d+)\s*%s`, keyword),
-			fmt.Sprintf(`%s:?\s*(\d+)`, keyword),
-			fmt.Sprintf(`%s\s*\((\d+)\)`, keyword),
-		}
-
-		for _, pattern := range patterns {
-			re := regexp.MustCompile(pattern)
+		for _, re := range keywordNumberPatterns(keyword) {
 			matches := re.FindStringSubmatch(text)
 			if len(matches) > 1 {
 				if num, err := strconv.Atoi(matches[1]); err == nil {
@@ -143,33 +775,85 @@ func (fs *ForumScraperGo) extractNumber(text string, keywords []string) *int {
 	return nil
 }
 
-// extractThreadMetadata extracts thread-level metadata
+// maxMetadataText caps the page text searched for view and reply counts
+const maxMetadataText = 256 << 10
+
+// Patterns for the view and reply counts a thread page states
+var (
+	viewCountPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)Views?:?\s*(\d+)`),
+		regexp.MustCompile(`(?i)(\d+)\s*views?`),
+	}
+	replyCountPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)Repl(?:y|ies):?\s*(\d+)`),
+		regexp.MustCompile(`(?i)(\d+)\s*repl(?:y|ies)`),
+	}
+)
+
+// visibleText returns up to limit bytes of the selection's text, leaving out
+// scripts and styles, which can run to megabytes of inline data
+func visibleText(selection *goquery.Selection, limit int) string {
+	var text strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if text.Len() >= limit {
+			return
+		}
+		switch n.Type {
+		case html.TextNode:
+			text.WriteString(n.Data)
+		case html.ElementNode:
+			switch n.DataAtom {
+			case atom.Script, atom.Style, atom.Template, atom.Noscript:
+				return
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	for _, n := range selection.Nodes {
+		walk(n)
+	}
+	if text.Len() > limit {
+		return text.String()[:limit]
+	}
+	return text.String()
+}
+
+// extractThreadMetadata extracts thread-level metadata. Values from JSON-LD win over
+// OpenGraph tags, which win over CSS selectors; metadata["sources"] records where
+// each field came from.
 func (fs *ForumScraperGo) extractThreadMetadata(doc *goquery.Document, url string) map[string]interface{} {
 	metadata := make(map[string]interface{})
+	sources := make(map[string]string)
 
-	// Extract thread title
-	titleSelectors := []string{".thread-title", ".topic-title", "h1", ".topictitle"}
-	for _, selector := range titleSelectors {
-		if title := doc.Find(selector).First().Text(); title != "" {
-			metadata["title"] = strings.TrimSpace(title)
-			break
-		}
+	config, exists := fs.configs[fs.platform]
+	if !exists {
+		config = fs.configs["generic"]
+	}
+
+	// Extract thread title, trying the platform's own chain last
+	titleSelectors := append(selectorChain{".thread-title", ".topic-title", "h1", ".topictitle"}, config.ThreadSelector...)
+	title, selector := titleSelectors.text(doc.Selection)
+	fs.selectorMatched("ThreadSelector", titleSelectors, selector)
+	if fs.fields != nil {
+		// check-config reports the config's own chain, not the defaults tried ahead of it
+		ownTitle, ownSelector := config.ThreadSelector.text(doc.Selection)
+		fs.recordField("ThreadSelector", config.ThreadSelector, doc.Selection, ownSelector, ownTitle)
+	}
+	if title != "" {
+		metadata["title"] = validUTF8(title)
 	}
 
 	// Extract category/forum name
-	categorySelectors := []string{".breadcrumb a", ".forum-name", ".category-name"}
-	for _, selector := range categorySelectors {
-		if category := doc.Find(selector).First().Text(); category != "" {
-			metadata["category"] = strings.TrimSpace(category)
-			break
-		}
+	if category := pageCategory(doc); category != "" {
+		metadata["category"] = category
 	}
 
 	// Extract view count
-	pageText := doc.Text()
-	viewPatterns := []string{`Views?:?\s*(\d+)`, `(\d+)\s*views?`}
-	for _, pattern := range viewPatterns {
-		re := regexp.MustCompile(`(?i)` + pattern)
+	pageText := visibleText(doc.Selection, maxMetadataText)
+	for _, re := range viewCountPatterns {
 		if matches := re.FindStringSubmatch(pageText); len(matches) > 1 {
 			if views, err := strconv.Atoi(matches[1]); err == nil {
 				metadata["views_count"] = views
@@ -178,41 +862,152 @@ func (fs *ForumScraperGo) extractThreadMetadata(doc *goquery.Document, url strin
 		}
 	}
 
+	// Extract reply count, used when not every post is scraped
+	for _, re := range replyCountPatterns {
+		if matches := re.FindStringSubmatch(pageText); len(matches) > 1 {
+			if replies, err := strconv.Atoi(matches[1]); err == nil {
+				metadata["replies_count"] = replies
+				break
+			}
+		}
+	}
+
+	for key := range metadata {
+		sources[key] = metadataSourceSelector
+	}
+	applyOpenGraph(doc, metadata, sources)
+	applyJSONLD(doc, metadata, sources)
+	if fs.platform == "discourse" {
+		fs.applyDiscoursePreloaded(doc, url, metadata, sources)
+	}
+	fs.applyRegexRules(doc, config, metadata, sources)
+	metadata["sources"] = sources
+
 	return metadata
 }
 
-// scrapePost extracts data from a single forum post element
-func (fs *ForumScraperGo) scrapePost(selection *goquery.Selection, threadTitle, threadURL string, postNumber int) *ForumPost {
-	config, exists := fs.configs[fs.platform]
-	if !exists {
-		config = fs.configs["generic"]
+// withoutNestedPosts returns the post element without any other posts inside it.
+// Unclosed tags on truncated pages (and threaded layouts) can nest every later
+// post in an earlier one, which would otherwise take their content and authors;
+// the nested posts are still scraped in their own turn. A nested match with no
+// author of its own, such as vBulletin's post_message_ body, is part of the post.
+func withoutNestedPosts(selection *goquery.Selection, config PlatformConfig) *goquery.Selection {
+	nested := config.PostSelector.findAll(selection)
+	if nested.Length() == 0 {
+		return selection
+	}
+	// Only the outermost nested posts need removing; the rest go with them
+	inNested := make(map[*html.Node]bool, nested.Length())
+	for _, n := range nested.Nodes {
+		inNested[n] = true
+	}
+	root := selection.Get(0)
+	var remove []int
+	nested.Each(func(i int, s *goquery.Selection) {
+		for parent := s.Get(0).Parent; parent != nil && parent != root; parent = parent.Parent {
+			if inNested[parent] {
+				return
+			}
+		}
+		if config.AuthorSelector.findAll(s).Length() > 0 {
+			remove = append(remove, i)
+		}
+	})
+	if len(remove) == 0 {
+		return selection
 	}
+	post := selection.Clone()
+	cloned := config.PostSelector.findAll(post)
+	for _, i := range remove {
+		cloned.Eq(i).Remove()
+	}
+	return post
+}
 
-	// Extract post content
-	content := strings.TrimSpace(selection.Find(config.ContentSelector).Text())
-	if len(content) < 10 {
-		return nil // Skip very short posts
+// postIDAttrs are the attributes that carry a post's platform ID
+var postIDAttrs = []string{"data-post-id", "data-fullname"}
+
+// postAnchor returns a post element's platform ID: an ID attribute on the
+// element, on the first element inside it with one (Discourse's article) or on
+// its parent (old reddit's .thing around each .entry), else the element's id,
+// which its permalink anchors to
+func postAnchor(selection *goquery.Selection) string {
+	for _, s := range []*goquery.Selection{selection, selection.Find("[data-post-id]").First(), selection.Parent()} {
+		for _, attr := range postIDAttrs {
+			if id := strings.TrimSpace(s.AttrOr(attr, "")); id != "" {
+				return id
+			}
+		}
+	}
+	return strings.TrimSpace(selection.AttrOr("id", ""))
+}
+
+// scrapePost extracts data from a single forum post element. A skipped post
+// is returned as nil along with the reason it was skipped.
+func (fs *ForumScraperGo) scrapePost(selection *goquery.Selection, config PlatformConfig, threadTitle, threadURL string, postNumber int) (*ForumPost, string) {
+	postID := postAnchor(selection)
+	selection = withoutNestedPosts(selection, config)
+
+	// Extract post content, minus any edit notice and, under --strip-quotes, quotes
+	contentElem, selector := config.ContentSelector.findText(selection)
+	if fs.stripQuotes && contentElem.Length() > 0 {
+		contentElem = withoutQuotes(contentElem, config.QuoteSelector)
+	}
+	edit, body, content := extractEdit(selection, contentElem, config)
+	fs.fieldMatched("ContentSelector", config.ContentSelector, selection, selector, content)
+	if content == "" {
+		if selector != "" {
+			return nil, "quote_only" // Nothing but quotes
+		}
+		return nil, "" // Not a post body
 	}
 
-	// Extract author
-	author := strings.TrimSpace(selection.Find(config.AuthorSelector).Text())
+	// Extract author; themes such as prosilver show the name in more than one place
+	author, selector := config.AuthorSelector.text(selection)
+	fs.fieldMatched("AuthorSelector", config.AuthorSelector, selection, selector, author)
+	author = validUTF8(author)
 	if author == "" {
 		author = "Anonymous"
 	}
 
-	// Extract timestamp
-	var timestamp string
-	if timestampElem := selection.Find(config.TimestampSelector); timestampElem.Length() > 0 {
+	// Extract timestamp: a datetime attribute, the platform's timestamp attribute,
+	// or else the element's text
+	var timestamp, timestampSelector string
+	for _, selector := range config.TimestampSelector {
+		timestampElem := findSelector(selection, selector)
 		if datetime, exists := timestampElem.Attr("datetime"); exists {
 			timestamp = datetime
+		} else if full := timestampElem.AttrOr(config.TimestampAttr, ""); config.TimestampAttr != "" && full != "" {
+			timestamp = full
 		} else {
 			timestamp = strings.TrimSpace(timestampElem.Text())
 		}
+		if timestamp != "" {
+			timestampSelector = selector
+			break
+		}
 	}
+	fs.fieldMatched("TimestampSelector", config.TimestampSelector, selection, timestampSelector, timestamp)
 
 	// Extract engagement metrics
 	postText := selection.Text()
 	likesCount := fs.extractNumber(postText, []string{"like", "upvote", "thumbs"})
+	var scoreSelector, scoreText string
+	for _, selector := range config.ScoreSelector {
+		if score := scoreValue(findSelector(selection, selector).First()); score != nil {
+			likesCount, scoreSelector, scoreText = score, selector, strconv.Itoa(*score)
+			break
+		}
+	}
+	fs.fieldMatched("ScoreSelector", config.ScoreSelector, selection, scoreSelector, scoreText)
+	var awards *int
+	awardElems, selector := config.AwardsSelector.find(selection)
+	var awardsText string
+	if awardElems.Length() > 0 {
+		awards = countAwards(awardElems)
+		awardsText = strconv.Itoa(*awards)
+	}
+	fs.fieldMatched("AwardsSelector", config.AwardsSelector, selection, selector, awardsText)
 	repliesCount := fs.extractNumber(postText, []string{"reply", "response"})
 
 	// Extract forum category if available
@@ -221,209 +1016,445 @@ func (fs *ForumScraperGo) scrapePost(selection *goquery.Selection, threadTitle,
 		forumCategory = strings.TrimSpace(categoryElem.Text())
 	}
 
-	return &ForumPost{
+	post := &ForumPost{
 		URL:           fmt.Sprintf("%s#post%d", threadURL, postNumber),
 		ThreadTitle:   threadTitle,
 		Author:        author,
 		Content:       content,
 		PostNumber:    postNumber,
+		PostID:        postID,
 		Timestamp:     timestamp,
 		LikesCount:    likesCount,
+		Awards:        awards,
 		RepliesCount:  repliesCount,
 		ForumCategory: forumCategory,
-		ScrapedAt:     time.Now(),
+		ScrapedAt:     fs.now(),
+		// Checked before processing, which may strip the marker's element
+		IsAcceptedAnswer: isAcceptedAnswer(selection, config),
+		// and before --anonymize-authors replaces the name
+		MatchedUser:         fs.matchesUser(author),
+		Mentions:            extractMentions(body),
+		InternalThreadLinks: fs.extractInternalThreadLinks(body, threadURL),
+		Attachments:         extractAttachments(selection, config.Attachments, threadURL),
+		Edited:              edit.edited,
+		EditedAt:            edit.at,
+		EditedBy:            edit.by,
+	}
+
+	// Hash and language describe the content as processed
+	post, reason := fs.processPost(post)
+	if post == nil {
+		return nil, reason
 	}
+	post.ContentHash = contentHash(post.Content)
+	post.Language = detectLanguage(post.Content)
+	return post, ""
 }
 
-// scrapeThread scrapes a complete forum thread
-func (fs *ForumScraperGo) scrapeThread(threadURL string, maxPosts int) (*ForumThread, error) {
-	// Check if already visited
-	fs.visitedMutex.RLock()
-	if fs.visitedURLs[threadURL] {
-		fs.visitedMutex.RUnlock()
-		return nil, fmt.Errorf("thread already visited")
+// scrapeThread scrapes a complete forum thread. The keys it claims in a shared
+// visited set are released again if it fails.
+func (fs *ForumScraperGo) scrapeThread(threadURL string, maxPosts int) (_ *ForumThread, err error) {
+	if fs.budget.exhausted() {
+		return nil, ErrBudgetExhausted
+	}
+	if err := fs.runContext().Err(); err != nil {
+		return nil, err
 	}
-	fs.visitedMutex.RUnlock()
 
-	// Mark as visited
-	fs.visitedMutex.Lock()
-	fs.visitedURLs[threadURL] = true
-	fs.visitedMutex.Unlock()
+	// Threads in a --skip-from file were exported by an earlier run
+	if fs.previouslyExported(threadURL, "") {
+		return nil, fmt.Errorf("%w: %s", ErrPreviouslyExported, threadURL)
+	}
 
-	fmt.Printf("🔍 Scraping forum thread: %s\n", threadURL)
+	// Check if already visited, keyed on the normalized URL so session IDs
+	// and tracking parameters don't defeat the check, and on the thread's own
+	// URL so its print view or a deep link to one of its posts doesn't either
+	threadURL = fs.canonicalThreadURL(threadURL)
+	if !fs.markVisited(threadURL) {
+		return nil, fmt.Errorf("%w: %s", ErrAlreadyVisited, threadURL)
+	}
+	claims := []string{visitedURLKey(threadURL)}
+	defer func() {
+		if err != nil && threadFailed(err) {
+			fs.releaseClaims(claims)
+		}
+	}()
 
-	// Rate limiting
-	time.Sleep(fs.delay)
+	fs.statusf("🔍 Scraping forum thread: %s\n", threadURL)
 
-	// Fetch the page
-	req, err := http.NewRequest("GET", threadURL, nil)
-	if err != nil {
-		return nil, err
+	// --user-posts-only emits the member's posts collected from their history
+	if collected, exists := fs.takeUserThread(threadURL); exists {
+		return fs.scrapeUserPosts(threadURL, collected, maxPosts)
 	}
-	req.Header.Set("User-Agent", "Marina-ForumScraper/2.0 (Educational Research)")
 
-	resp, err := fs.client.Do(req)
-	if err != nil {
-		return nil, err
+	// API platforms build threads from JSON instead of pages, claiming their IDs
+	if fs.platform == "stackexchange" || fs.platform == "hackernews" {
+		if threadID := fs.extractThreadID(threadURL); threadID != "" {
+			claims = append(claims, visitedIDKey(threadURL, threadID))
+		}
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	switch fs.platform {
+	case "stackexchange":
+		return fs.scrapeStackExchange(threadURL, maxPosts)
+	case "hackernews":
+		return fs.scrapeHackerNews(threadURL, maxPosts)
 	}
 
-	// Parse the HTML
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	// Fetch and parse the page, recording how it was obtained. --thread-timeout
+	// covers every fetch of the thread and the waits before them, pagination included.
+	threadCtx, cancel := fs.threadContext()
+	defer cancel()
+	ctx, provenance := fs.withProvenance(threadCtx, threadURL)
+
+	// Rate limiting
+	fs.politeWaitContext(threadCtx, threadURL)
+	// --precheck skips threads whose headers rule them out, then waits its turn again
+	var checkedURL string
+	if fs.precheck {
+		if checkedURL, err = fs.precheckThread(threadCtx, threadURL); err != nil {
+			return nil, fs.threadTimeoutError(threadCtx, err, threadURL)
+		}
+		if normalizeURL(checkedURL) != normalizeURL(threadURL) {
+			claims = append(claims, visitedURLKey(checkedURL))
+		}
+		fs.politeWaitContext(threadCtx, threadURL)
+	}
+	// --lightweight reads the whole thread from the platform's print view when it has one
+	doc, err := fs.fetchPrintView(ctx, threadURL)
 	if err != nil {
+		return nil, fs.threadTimeoutError(threadCtx, err, threadURL)
+	}
+	lightweight := doc != nil
+	if !lightweight {
+		if doc, err = fs.fetchThreadDocument(ctx, threadURL); err != nil {
+			return nil, fs.threadTimeoutError(threadCtx, err, threadURL)
+		}
+		if doc, err = fs.passConsentWall(ctx, doc, threadURL); err != nil {
+			return nil, fs.threadTimeoutError(threadCtx, err, threadURL)
+		}
+	}
+	finalURL := doc.Url.String()
+	// Record the redirect target too, so it isn't scraped again under its own URL,
+	// unless the pre-check already did
+	redirected := normalizeURL(finalURL) != normalizeURL(threadURL) && normalizeURL(finalURL) != normalizeURL(checkedURL)
+	if redirected && !fs.markVisited(finalURL) {
+		return nil, fmt.Errorf("%w: %s redirected to %s", ErrDuplicateThread, threadURL, finalURL)
+	}
+	claims = append(claims, visitedURLKey(finalURL))
+	// A slug change or category move gives the same thread a new URL but keeps its ID
+	threadID := fs.extractThreadID(finalURL)
+	if threadID != "" && !fs.markThreadID(finalURL, threadID) {
+		return nil, fmt.Errorf("%w: %s is thread %s", ErrDuplicateThread, threadURL, threadID)
+	}
+	if threadID != "" {
+		claims = append(claims, visitedIDKey(finalURL, threadID))
+	}
+	// Aliases of a scraped thread name it as their canonical URL
+	canonicalURL := fs.threadCanonicalURL(doc, finalURL)
+	// An exported thread can hide behind a redirect or an alias until fetched
+	if fs.previouslyExported(finalURL, threadID) || (canonicalURL != "" && fs.previouslyExported(canonicalURL, "")) {
+		return nil, fmt.Errorf("%w: %s", ErrPreviouslyExported, threadURL)
+	}
+	if canonicalURL != "" && !fs.markCanonical(canonicalURL, finalURL) {
+		return nil, fmt.Errorf("%w: %s is %s", ErrDuplicateThread, threadURL, canonicalURL)
+	}
+	if canonicalURL != "" {
+		claims = append(claims, visitedURLKey(canonicalURL))
+	}
+	if err := fs.checkThreadMissing(doc, threadURL); err != nil {
 		return nil, err
 	}
 
+	config, exists := fs.configs[fs.platform]
+	if !exists {
+		config = fs.configs["generic"]
+	}
+
 	// Extract thread metadata
 	metadata := fs.extractThreadMetadata(doc, threadURL)
+	fs.applyIndexCounts(threadURL, metadata)
 	threadTitle, _ := metadata["title"].(string)
+	if lightweight {
+		if threadTitle == "" {
+			title, _ := config.PrintView.TitleSelector.text(doc.Selection)
+			threadTitle = validUTF8(title)
+		}
+		config = config.PrintView.apply(config)
+	}
 	if threadTitle == "" {
 		threadTitle = "Unknown Thread"
 	}
 
-	// Extract posts using goroutines for concurrent processing
-	config, exists := fs.configs[fs.platform]
-	if !exists {
-		config = fs.configs["generic"]
+	// Every page of a thread shares a theme, so the selector that finds posts on the
+	// first page is used for the rest
+	_, postSelector := config.PostSelector.find(doc.Selection)
+	if postSelector == "" && len(config.PostSelector) > 0 {
+		postSelector = config.PostSelector[0]
 	}
+	fs.selectorMatched("PostSelector", config.PostSelector, postSelector)
 
-	postElements := doc.Find(config.PostSelector)
-	posts := make([]*ForumPost, 0, maxPosts)
-	postsChan := make(chan *ForumPost, maxPosts)
-	var wg sync.WaitGroup
-
-	// Limit concurrent goroutines
-	semaphore := make(chan struct{}, 10)
-
+	// --posts-mode first stops after the opening post and none extracts no posts
+	// --max-pages-per-thread follows the thread's pagination; a print view is the whole thread
+	pages := threadPages{leading: []*goquery.Document{doc}, total: 1}
+	if !lightweight {
+		pages = fs.fetchThreadPages(ctx, doc, finalURL, postSelector, fs.postLimit(maxPosts, math.MaxInt32))
+	}
+	// A thread that outlasts --thread-timeout keeps the pages fetched so far
+	if fs.threadTimedOut(threadCtx) {
+		pages.truncated = true
+		fs.noteThreadTimeout(threadURL, provenance, fmt.Sprintf("%d of %d pages", len(pages.leading), pages.total))
+	}
+	postElements := findSelector(doc.Selection, postSelector)
+	for _, page := range pages.leading[1:] {
+		postElements = postElements.AddSelection(findSelector(page.Selection, postSelector))
+	}
+	postLimit := fs.postLimit(maxPosts, postElements.Length())
+	// The last page's posts follow the capped leading posts, numbered as if every page were full
+	leadingPosts, lastPageFirst := postLimit, 0
+	if pages.last != nil && postLimit > 0 {
+		if postElements.Length() < leadingPosts {
+			leadingPosts = postElements.Length()
+		}
+		lastPageFirst = (pages.total-1)*findSelector(doc.Selection, postSelector).Length() + 1
+		postElements = postElements.Slice(0, leadingPosts).AddSelection(findSelector(pages.last.Selection, postSelector))
+		postLimit = postElements.Length()
+	}
+	// Extraction is cheap next to the fetch, so posts are extracted in page order
+	posts := make([]*ForumPost, 0, postLimit)
+	skipped := make(map[string]int)
 	postElements.Each(func(i int, s *goquery.Selection) {
-		if i >= maxPosts {
+		if i >= postLimit {
 			return
 		}
-
-		wg.Add(1)
-		go func(index int, selection *goquery.Selection) {
-			defer wg.Done()
-			semaphore <- struct{}{} // Acquire semaphore
-			defer func() { <-semaphore }() // Release semaphore
-
-			if post := fs.scrapePost(selection, threadTitle, threadURL, index+1); post != nil {
-				postsChan <- post
-			}
-		}(i, s)
+		postNumber := i + 1
+		if lastPageFirst > 0 && i >= leadingPosts {
+			postNumber = lastPageFirst + i - leadingPosts
+		}
+		post, reason := fs.scrapePost(s, config, threadTitle, threadURL, postNumber)
+		if post != nil {
+			posts = append(posts, post)
+		} else if reason != "" {
+			skipped[reason]++
+			atomic.AddInt64(&fs.stats.PostsSkipped, 1)
+		}
 	})
 
-	// Close channel when all goroutines complete
-	go func() {
-		wg.Wait()
-		close(postsChan)
-	}()
-
-	// Collect posts
-	for post := range postsChan {
-		posts = append(posts, post)
+	if len(posts) == 0 && (fs.postsMode != postsModeNone || postElements.Length() == 0) {
+		if fs.hasMissingMarker(doc) {
+			return nil, fmt.Errorf("%w: %s", ErrThreadMissing, threadURL)
+		}
+		if isLoginWall(doc) {
+			return nil, fmt.Errorf("%w: %s", ErrLoginRequired, threadURL)
+		}
+		if fs.postsMode != postsModeNone {
+			return nil, ErrNoPosts
+		}
 	}
 
-	if len(posts) == 0 {
-		return nil, fmt.Errorf("no posts found in thread")
+	// Pages without a breadcrumb have no category; threads from a --category
+	// listing take that category instead
+	category, _ := metadata["category"].(string)
+	var categoryID int
+	if assigned, exists := fs.assignedCategory(threadURL); exists {
+		category, categoryID = assigned.Name, assigned.ID
 	}
 
 	// Build thread object
 	thread := &ForumThread{
-		URL:          threadURL,
-		Title:        threadTitle,
-		Category:     metadata["category"].(string),
-		Author:       posts[0].Author,
-		Posts:        make([]ForumPost, len(posts)),
-		RepliesCount: len(posts) - 1,
-		ScrapedAt:    time.Now(),
+		URL:            threadURL,
+		ThreadID:       threadID,
+		CanonicalURL:   canonicalURL,
+		Title:          threadTitle,
+		Category:       category,
+		CategoryID:     categoryID,
+		RepliesCount:   fs.repliesCount(metadata, postElements, posts),
+		TotalPagesSeen: pages.total,
+		Truncated:      pages.truncated,
+		Provenance:     provenance,
+		ScrapedAt:      fs.now(),
 	}
-
-	// Convert post pointers to values
-	for i, post := range posts {
-		thread.Posts[i] = *post
+	if len(skipped) > 0 {
+		thread.SkippedPosts = skipped
+	}
+	if normalizeURL(finalURL) != normalizeURL(threadURL) {
+		thread.FinalURL = finalURL
 	}
-
-	// Set optional fields
 	if viewsCount, ok := metadata["views_count"].(int); ok {
 		thread.ViewsCount = &viewsCount
 	}
-	if len(posts) > 0 {
-		thread.CreatedAt = posts[0].Timestamp
-		thread.LastPostAt = posts[len(posts)-1].Timestamp
+	// Structured data names the thread's author and creation date even when the
+	// opening post isn't scraped
+	if author, ok := metadata["author"].(string); ok {
+		thread.Author = author
+		if fs.anonymizeSalt != "" {
+			thread.Author = fs.anonymizeAuthor(author)
+		}
 	}
-
-	fmt.Printf("✅ Scraped thread with %d posts\n", len(posts))
-	return thread, nil
+	thread.CreatedAt, _ = metadata["created_at"].(string)
+	if sources, ok := metadata["sources"].(map[string]string); ok && len(sources) > 0 {
+		provenance.MetadataSources = sources
+	}
+	return fs.finishThread(thread, posts, maxPosts)
 }
 
-// discoverThreads discovers thread URLs from a forum index or category page
-func (fs *ForumScraperGo) discoverThreads(forumURL string, maxThreads int) ([]string, error) {
-	fmt.Printf("🔍 Discovering threads from: %s\n", forumURL)
+// finishThread runs a thread's extracted posts through the run's thread-level
+// modes and filters and completes the thread, which arrives with its page-level
+// fields set. Every platform's thread path ends here.
+func (fs *ForumScraperGo) finishThread(thread *ForumThread, posts []*ForumPost, maxPosts int) (*ForumThread, error) {
+	// --window drops posts dated outside it before the thread modes see them
+	posts, outside := fs.windowPosts(posts)
+	if outside > 0 {
+		if len(posts) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrOutsideWindow, thread.URL)
+		}
+		thread.PartialWindow = true
+		fs.debugf("Dropped %d posts outside the window: %s", outside, thread.URL)
+	}
 
-	req, err := http.NewRequest("GET", forumURL, nil)
-	if err != nil {
-		return nil, err
+	// Solved mode keeps the question and its accepted answer, and skips unsolved threads
+	if fs.postsMode == postsModeSolved {
+		if posts = solvedPosts(posts, maxPosts); posts == nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnsolved, thread.URL)
+		}
 	}
-	req.Header.Set("User-Agent", "Marina-ForumScraper/2.0 (Educational Research)")
 
-	resp, err := fs.client.Do(req)
-	if err != nil {
-		return nil, err
+	thread.Language = majorityLanguage(posts)
+	if !fs.languageAllowed(thread.Language) {
+		return nil, fmt.Errorf("%w: %s is %s", ErrLanguageFiltered, thread.URL, thread.Language)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	// Convert post pointers to values
+	thread.Posts = make([]ForumPost, 0, len(posts))
+	for _, post := range posts {
+		if !fs.languageAllowed(post.Language) {
+			continue
+		}
+		if fs.dedupePosts && fs.isDuplicatePost(post) {
+			thread.DuplicatePostsDropped++
+			continue
+		}
+		fs.languageCounts.record(post.Language)
+		thread.Posts = append(thread.Posts, *post)
 	}
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		return nil, err
+	// Set optional fields
+	if len(thread.Posts) > 0 {
+		if thread.Author == "" {
+			thread.Author = thread.Posts[0].Author
+		}
+		if thread.CreatedAt == "" {
+			thread.CreatedAt = thread.Posts[0].Timestamp
+		}
+		thread.LastPostAt = thread.Posts[len(thread.Posts)-1].Timestamp
 	}
 
-	var threadURLs []string
-	selectors := []string{
-		"a[href*=\"/thread/\"]",
-		"a[href*=\"/topic/\"]",
-		"a[href*=\"/t/\"]",
-		"a[href*=\"/viewtopic.php\"]",
-		".threadtitle a",
-		".topictitle",
+	if fs.scoreQuery != "" {
+		thread.Score = threadScore(thread.Posts)
 	}
 
-	for _, selector := range selectors {
-		doc.Find(selector).Each(func(i int, s *goquery.Selection) {
-			if len(threadURLs) >= maxThreads {
-				return
+	if !fs.categories.keepScraped(thread) {
+		return nil, fmt.Errorf("%w: %s is in %q", ErrCategoryFiltered, thread.URL, thread.Category)
+	}
+	if !fs.keepThread(thread) {
+		return nil, fmt.Errorf("%w: %s", ErrThreadFiltered, thread.URL)
+	}
+	fs.downloadAttachments(thread)
+	fs.stampRecords(thread)
+
+	fs.summary.add(thread)
+	fs.categories.recordScraped(thread)
+	if fs.authorsPath != "" {
+		fs.authors.add(thread)
+	}
+	fs.spendPosts(len(thread.Posts))
+	if fs.postsMode == postsModeNone {
+		fs.statusf("✅ Scraped thread metadata (%d replies)\n", thread.RepliesCount)
+	} else {
+		fs.statusf("✅ Scraped thread with %d posts\n", len(thread.Posts))
+	}
+	return thread, nil
+}
+
+// discoverThreads discovers thread URLs from a forum index or category page,
+// following next-page links until maxThreads URLs or the index page cap is reached
+func (fs *ForumScraperGo) discoverThreads(forumURL string, maxThreads int) ([]ThreadRef, error) {
+	refs, _, err := fs.walkIndex(forumURL, "", maxThreads)
+	return refs, err
+}
+
+// walkIndex walks the pages of one index, returning its thread links and the
+// subforum/category links found along the way. Threads whose row names no
+// category take section, the crawl path to the index, or else the index page's
+// breadcrumb when it is a subforum page.
+func (fs *ForumScraperGo) walkIndex(forumURL, section string, maxThreads int) ([]ThreadRef, []forumLink, error) {
+	fs.statusf("🔍 Discovering threads from: %s\n", forumURL)
+
+	var forumLinks []forumLink
+	seenForums := make(map[string]int)
+	seen := make(map[string]bool)
+	visitedPages := make(map[string]bool)
+	var unique []ThreadRef
+	pagesWalked := 0
+
+	for pageURL := fs.windowIndexURL(forumURL, time.Now()); pageURL != "" && len(unique) < maxThreads; {
+		if pagesWalked >= fs.maxIndexPages {
+			break
+		}
+		visitedPages[normalizeURL(pageURL)] = true
+
+		if pagesWalked > 0 {
+			// Rate limiting
+			fs.politeWait(pageURL)
+		}
+
+		doc, err := fs.fetchDocument(pageURL)
+		if err != nil {
+			if pagesWalked == 0 {
+				return nil, nil, err
 			}
+			fs.statusf("⚠️ Stopped index pagination at %s: %v\n", pageURL, err)
+			break
+		}
+		pagesWalked++
+		atomic.AddInt64(&fs.stats.IndexPagesFetched, 1)
 
-			if href, exists := s.Attr("href"); exists {
-				// Convert relative URLs to absolute
-				if strings.HasPrefix(href, "/") {
-					href = strings.TrimSuffix(forumURL, "/") + href
-				} else if !strings.HasPrefix(href, "http") {
-					href = strings.TrimSuffix(forumURL, "/") + "/" + href
+		category := section
+		if category == "" && fs.isForumURL(pageURL) {
+			category = indexPageCategory(doc)
+		}
+		// Remove duplicates, including stickies repeated on every page
+		for _, ref := range fs.extractThreadLinks(doc, pageURL, forumURL) {
+			key := normalizeURL(ref.URL)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if ref.Category == "" {
+				ref.Category = category
+			}
+			if fs.allowThreadURL(ref.URL, forumURL) && fs.categories.keepRef(ref) {
+				unique = append(unique, ref)
+			}
+		}
+		for _, link := range fs.extractForumLinks(doc, pageURL) {
+			key := normalizeURL(link.URL)
+			if i, seen := seenForums[key]; seen {
+				// An icon link may come before the one carrying the forum's name
+				if forumLinks[i].Text == "" {
+					forumLinks[i].Text = link.Text
 				}
-				threadURLs = append(threadURLs, href)
+				continue
 			}
-		})
-
-		if len(threadURLs) > 0 {
-			break // Found threads with this selector
+			seenForums[key] = len(forumLinks)
+			forumLinks = append(forumLinks, link)
 		}
-	}
 
-	// Remove duplicates
-	seen := make(map[string]bool)
-	unique := make([]string, 0, len(threadURLs))
-	for _, url := range threadURLs {
-		if !seen[url] {
-			seen[url] = true
-			unique = append(unique, url)
+		pageURL = fs.nextIndexPage(doc, pageURL)
+		if fs.searchQuery != "" && pageURL != "" {
+			pageURL = fs.reencodeSearchPage(pageURL)
+		}
+		if visitedPages[normalizeURL(pageURL)] || (pageURL != "" && !fs.allowPageURL(pageURL, forumURL)) {
+			break
 		}
 	}
 
@@ -431,147 +1462,280 @@ func (fs *ForumScraperGo) discoverThreads(forumURL string, maxThreads int) ([]st
 		unique = unique[:maxThreads]
 	}
 
-	fmt.Printf("📊 Discovered %d thread URLs\n", len(unique))
-	return unique, nil
+	fs.statusf("📊 Discovered %d thread URLs across %d index page(s)\n", len(unique), pagesWalked)
+	return unique, forumLinks, nil
 }
 
-// scrapeForum scrapes multiple threads from a forum with concurrent processing
+// scrapeForum scrapes multiple threads from a forum with concurrent processing,
+// returning them all at the end. Large runs should use scrapeForumEach.
 func (fs *ForumScraperGo) scrapeForum(forumURL string, maxThreads, maxPostsPerThread int) ([]*ForumThread, error) {
-	fmt.Printf("🚀 Starting forum scraping from: %s\n", forumURL)
+	var threads []*ForumThread
+	err := fs.scrapeForumEach(forumURL, maxThreads, maxPostsPerThread, func(thread *ForumThread) error {
+		threads = append(threads, thread)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return threads, nil
+}
+
+// scrapeForumEach scrapes multiple threads from a forum, calling emit with each
+// thread as it completes instead of keeping them
+func (fs *ForumScraperGo) scrapeForumEach(forumURL string, maxThreads, maxPostsPerThread int, emit func(*ForumThread) error) error {
+	fs.statusf("🚀 Starting forum scraping from: %s\n", forumURL)
 
 	// Discover thread URLs
-	threadURLs, err := fs.discoverThreads(forumURL, maxThreads)
+	refs, err := fs.discoverIndex(forumURL, maxThreads)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// Scrape threads concurrently
-	threads := make([]*ForumThread, 0, len(threadURLs))
-	threadsChan := make(chan *ForumThread, len(threadURLs))
-	var wg sync.WaitGroup
+	scraped := 0
+	err = fs.scrapeRefsEach(refs, maxPostsPerThread, func(thread *ForumThread) error {
+		scraped++
+		return emit(thread)
+	})
+	fs.statusf("✅ Scraped %d threads from forum\n", scraped)
+	return err
+}
 
-	// Limit concurrent threads to avoid overwhelming the server
-	semaphore := make(chan struct{}, 5)
+// pipelineBuffer is the buffer between pipeline stages. It is small and fixed so a
+// run's memory stays flat however many threads it has.
+const pipelineBuffer = 16
 
-	for _, url := range threadURLs {
+// scrapePipeline scrapes the threads feed sends. feed hands refs to a fixed pool of
+// workers, each ref once its host has a free slot, and a single consumer passes each finished thread to the sinks and then
+// to emit. A slow sink or emit holds up the workers, and they hold up feed. emit is
+// never called concurrently; once it fails, stop is closed, feed must return, and
+// the threads still in flight are dropped.
+func (fs *ForumScraperGo) scrapePipeline(feed func(refs chan<- ThreadRef, stop <-chan struct{}), maxPostsPerThread int, emit func(*ForumThread) error) error {
+	refs := make(chan ThreadRef, pipelineBuffer)
+	results := make(chan *ForumThread, pipelineBuffer)
+	stop := make(chan struct{})
+
+	go func() {
+		defer close(refs)
+		feed(refs, stop)
+	}()
+
+	// Workers take a global slot for each thread whose host slot the dispatcher holds
+	freed := make(chan struct{}, 1)
+	ready := fs.dispatchByHost(refs, freed)
+	workers := cap(fs.threadSem)
+	if fs.workers > 0 && fs.workers < workers {
+		workers = fs.workers
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
 		wg.Add(1)
-		go func(threadURL string) {
+		go func() {
 			defer wg.Done()
-			semaphore <- struct{}{} // Acquire semaphore
-			defer func() { <-semaphore }() // Release semaphore
-
-			if thread, err := fs.scrapeThread(threadURL, maxPostsPerThread); err == nil {
-				threadsChan <- thread
-			} else {
-				fmt.Printf("❌ Failed to scrape thread %s: %v\n", threadURL, err)
+			for dispatch := range ready {
+				ref := dispatch.ref
+				select {
+				case <-stop:
+					dispatch.release()
+					continue
+				default:
+				}
+				fs.threadSem <- struct{}{}
+				thread, err := fs.scrapeThreadSafe(ref.URL, maxPostsPerThread)
+				<-fs.threadSem
+				dispatch.release()
+				select {
+				case freed <- struct{}{}:
+				default:
+				}
+				if err != nil {
+					fs.reportThreadError(ref.URL, err)
+					continue
+				}
+				thread.SourceURL = ref.SourceURL
+				results <- thread
 			}
-		}(url)
+		}()
 	}
-
-	// Close channel when all goroutines complete
 	go func() {
 		wg.Wait()
-		close(threadsChan)
+		close(results)
 	}()
 
-	// Collect threads
-	for thread := range threadsChan {
-		threads = append(threads, thread)
+	var emitErr error
+	for thread := range results {
+		if emitErr != nil {
+			// Drain, so the workers can finish
+			continue
+		}
+		fs.deliver(thread)
+		if emitErr = emit(thread); emitErr != nil {
+			close(stop)
+		}
 	}
-
-	fmt.Printf("✅ Scraped %d threads from forum\n", len(threads))
-	return threads, nil
+	return emitErr
 }
 
-// saveResults saves scraped forum threads to JSON file
-func (fs *ForumScraperGo) saveResults(threads []*ForumThread, filename string) error {
-	if filename == "" {
-		timestamp := time.Now().Format("20060102_150405")
-		filename = fmt.Sprintf("forum_scrape_%s_%s.json", fs.platform, timestamp)
-	}
-
-	// Ensure results directory exists
-	resultsDir := filepath.Join(".", "scraping_results")
-	if err := os.MkdirAll(resultsDir, 0755); err != nil {
-		return err
-	}
-
-	filepath := filepath.Join(resultsDir, filename)
-
-	// Convert pointers to values for JSON serialization
-	threadsData := make([]ForumThread, len(threads))
-	for i, thread := range threads {
-		threadsData[i] = *thread
+// scrapeRefsEach scrapes the given threads through scrapePipeline, calling emit with
+// each as it completes. The pipeline's workers share the scraper-wide thread
+// semaphore so limits hold across every source in a run.
+func (fs *ForumScraperGo) scrapeRefsEach(refs []ThreadRef, maxPostsPerThread int, emit func(*ForumThread) error) error {
+	feed := func(work chan<- ThreadRef, stop <-chan struct{}) {
+		for _, ref := range refs {
+			if fs.budget.exhausted() {
+				return
+			}
+			select {
+			case work <- ref:
+			case <-stop:
+				return
+			}
+		}
 	}
+	return fs.scrapePipeline(feed, maxPostsPerThread, emit)
+}
 
-	totalPosts := 0
-	for _, thread := range threadsData {
-		totalPosts += len(thread.Posts)
+// reportThreadError records a thread that could not be scraped. Threads already
+// scraped in this run, under this URL or another, are counted as deduplicated,
+// threads in --skip-from files as previously exported, threads skipped on purpose
+// (filtered, too large) are counted by type, and budget stops are reported once at
+// the end, so none is logged as a failure; nor are threads of a cancelled serve job.
+func (fs *ForumScraperGo) reportThreadError(threadURL string, err error) {
+	switch {
+	case errors.Is(err, ErrBudgetExhausted), errors.Is(err, context.Canceled):
+	case errors.Is(err, ErrAlreadyVisited):
+		atomic.AddInt64(&fs.stats.DeduplicatedThreads, 1)
+	case errors.Is(err, ErrDuplicateThread):
+		atomic.AddInt64(&fs.stats.DeduplicatedThreads, 1)
+		fs.debugf("Skipped duplicate thread: %v", err)
+	case errors.Is(err, ErrPreviouslyExported):
+		atomic.AddInt64(&fs.stats.PreviouslyExported, 1)
+	case isSkip(err):
+		fs.debugf("Skipped thread %s: %v", threadURL, err)
+		fs.failures.record(threadURL, err)
+	default:
+		fs.statusf("❌ Failed to scrape thread %s: %v\n", threadURL, err)
+		fs.failures.record(threadURL, err)
 	}
+}
 
-	results := map[string]interface{}{
-		"forum_type":    fs.platform,
-		"total_threads": len(threadsData),
-		"total_posts":   totalPosts,
-		"scraped_at":    time.Now().Format(time.RFC3339),
-		"threads":       threadsData,
+// threadFailed reports whether a thread's error is a failure, rather than a skip
+// of a thread scraped or exported elsewhere, a deliberate skip or the run stopping.
+// A deliberately skipped thread keeps its visited claim, so no other source
+// fetches it only to skip it again.
+func threadFailed(err error) bool {
+	switch {
+	case errors.Is(err, ErrBudgetExhausted), errors.Is(err, context.Canceled),
+		errors.Is(err, ErrAlreadyVisited), errors.Is(err, ErrDuplicateThread),
+		errors.Is(err, ErrPreviouslyExported), isSkip(err):
+		return false
 	}
+	return true
+}
 
-	data, err := json.MarshalIndent(results, "", "  ")
-	if err != nil {
-		return err
+// saveResults saves scraped forum threads to JSON file and returns the paths written,
+// one per part when --split-size or --split-threads divides the output
+func (fs *ForumScraperGo) saveResults(threads []*ForumThread, filename string) ([]string, error) {
+	sortThreads(threads)
+	if fs.sortBy != "" {
+		threads = fs.rankThreads(threads)
 	}
-
-	if err := ioutil.WriteFile(filepath, data, 0644); err != nil {
-		return err
+	if filename == "-" {
+		// --output - writes the whole document to stdout, unsplit
+		out := bufio.NewWriter(os.Stdout)
+		if err := writeEnvelope(out, fs.resultsEnvelope(threads), threads); err != nil {
+			return nil, err
+		}
+		io.WriteString(out, "\n")
+		return nil, out.Flush()
 	}
-
-	fmt.Printf("💾 Results saved to: %s\n", filepath)
-	return nil
-}
-
-// CLI interface
-func main() {
-	if len(os.Args) < 4 {
-		fmt.Println("Usage: go run forum_scraper.go <platform> <forum_url> <max_threads> [max_posts_per_thread]")
-		fmt.Println("Example: go run forum_scraper.go phpbb https://forum.example.com/ 10 25")
-		os.Exit(1)
+	if filename == "" {
+		filename = fs.resultsFilename(threads, fs.now())
 	}
+	path := fs.resultsPath(filename)
 
-	platform := os.Args[1]
-	forumURL := os.Args[2]
-	maxThreads, err := strconv.Atoi(os.Args[3])
+	parts, err := fs.splitParts(threads)
 	if err != nil {
-		log.Fatal("Invalid max_threads value")
+		return nil, err
 	}
 
-	maxPostsPerThread := 25
-	if len(os.Args) > 4 {
-		if val, err := strconv.Atoi(os.Args[4]); err == nil {
-			maxPostsPerThread = val
+	var paths []string
+	for i, part := range parts {
+		partPath := path
+		if len(parts) > 1 {
+			partPath = partFilename(path, i+1)
 		}
-	}
 
-	// Create scraper
-	scraper := NewForumScraper(platform, 1.5) // 1.5 second delay
+		results := fs.resultsEnvelope(part)
+		if len(parts) > 1 {
+			results.Part = i + 1
+			results.Parts = len(parts)
+		}
 
-	// Scrape forum
-	threads, err := scraper.scrapeForum(forumURL, maxThreads, maxPostsPerThread)
-	if err != nil {
-		log.Fatalf("❌ Scraping failed: %v", err)
-	}
+		err := writeFileAtomicFunc(partPath, 0644, func(w io.Writer) error {
+			return writeEnvelope(w, results, part)
+		})
+		if err != nil {
+			return paths, err
+		}
 
-	// Save results
-	if err := scraper.saveResults(threads, ""); err != nil {
-		log.Fatalf("❌ Failed to save results: %v", err)
+		if len(parts) > 1 {
+			fs.statusf("💾 Results part %d/%d saved to: %s (%d threads, %d posts)\n", i+1, len(parts), partPath, len(part), results.TotalPosts)
+		} else {
+			fs.statusf("💾 Results saved to: %s\n", partPath)
+		}
+		paths = append(paths, partPath)
 	}
+	return paths, nil
+}
 
-	fmt.Printf("\n✅ Forum scraping completed successfully!\n")
-	fmt.Printf("📊 Threads scraped: %d\n", len(threads))
+// resultsPath places a results filename: a bare filename goes into the output
+// directory, and a path is used as given
+func (fs *ForumScraperGo) resultsPath(filename string) string {
+	if filepath.Base(filename) == filename {
+		return filepath.Join(fs.outputDir, filename)
+	}
+	return filename
+}
 
+// resultsEnvelope builds the JSON document for one results file, leaving out the
+// threads themselves; writeEnvelope streams them in after the envelope's fields
+func (fs *ForumScraperGo) resultsEnvelope(threads []*ForumThread) *ResultsEnvelope {
 	totalPosts := 0
 	for _, thread := range threads {
 		totalPosts += len(thread.Posts)
 	}
-	fmt.Printf("📊 Total posts: %d\n", totalPosts)
+	return fs.resultsEnvelopeFor(len(threads), totalPosts)
+}
+
+// resultsEnvelopeFor is resultsEnvelope for a file of threadCount threads holding
+// totalPosts posts, for callers that stream the threads rather than hold them
+func (fs *ForumScraperGo) resultsEnvelopeFor(threadCount, totalPosts int) *ResultsEnvelope {
+	results := &ResultsEnvelope{
+		SchemaVersion:   resultsSchemaVersion,
+		ForumType:       fs.platform,
+		TotalThreads:    threadCount,
+		TotalPosts:      totalPosts,
+		ScrapedAt:       fs.now().Format(time.RFC3339),
+		SearchQuery:     fs.searchQuery,
+		RunConfig:       fs.runConfig,
+		Run:             fs.run.finished(fs),
+		SourceRuns:      fs.sourceRuns,
+		Stats:           fs.summary.summary(),
+		StoppedByBudget: fs.budgetStopReason(),
+		RunStats:        fs.stats.snapshot(),
+		UserAgents:      fs.userAgentMetadata(),
+		Languages:       fs.languageCounts.snapshot(),
+		Failures:        fs.failures.snapshot(),
+		SortedBy:        fs.sortBy,
+		Top:             fs.top,
+		PrioritizedBy:   fs.prioritize,
+		Threads:         []ForumThread{},
+	}
+	if fs.anonymizeSalt != "" {
+		results.Anonymization = map[string]string{"method": "hmac-sha256", "salt": fs.anonymizeSalt}
+	}
+	if fs.adaptiveDelay {
+		results.AdaptiveDelays = fs.hostDelays()
+	}
+	return results
 }
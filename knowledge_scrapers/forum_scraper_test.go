@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// syntheticWords pads synthetic posts out to a realistic length
+var syntheticWords = strings.Repeat("The initramfs was regenerated and GRUB points at the new image. ", 12)
+
+// syntheticThreadPage returns a phpBB topic page with posts posts
+func syntheticThreadPage(topic, posts int) string {
+	var page strings.Builder
+	fmt.Fprintf(&page, `<!DOCTYPE html><html><head><meta charset="utf-8" /><title>Topic %d</title></head><body>`, topic)
+	fmt.Fprintf(&page, `<h2 class="topic-title"><a class="topictitle" href="./viewtopic.php?f=2&amp;t=%d">Topic %d</a></h2>`, topic, topic)
+	for i := 1; i <= posts; i++ {
+		fmt.Fprintf(&page, `<div id="p%d" class="post"><div class="postbody"><p class="author"><span class="responsive-hide">by <a class="username" href="./memberlist.php?u=%d">user%d</a></span> `+
+			`<time datetime="2024-03-11T08:%02d:%02dZ">Mon Mar 11, 2024</time></p><div class="content">Post %d of topic %d. %s</div></div></div>`,
+			i, i%50, i%50, i/60%60, i%60, i, topic, syntheticWords)
+	}
+	page.WriteString(`</body></html>`)
+	return page.String()
+}
+
+// syntheticForum serves a synthetic topic of posts posts for every viewtopic.php?t=N
+func syntheticForum(t testing.TB, posts int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		topic, err := strconv.Atoi(r.URL.Query().Get("t"))
+		if r.URL.Path != "/viewtopic.php" || err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, syntheticThreadPage(topic, posts))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// peakHeap runs run and returns the most heap in use, in bytes, while it ran
+func peakHeap(run func()) uint64 {
+	runtime.GC()
+	done := make(chan struct{})
+	peak := make(chan uint64)
+	go func() {
+		var stats runtime.MemStats
+		var max uint64
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			runtime.ReadMemStats(&stats)
+			if stats.HeapInuse > max {
+				max = stats.HeapInuse
+			}
+			select {
+			case <-done:
+				peak <- max
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	run()
+	close(done)
+	return <-peak
+}
+
+// BenchmarkPipelineMemory scrapes 1,000 synthetic threads, either streaming each
+// to emit as scrapeSourcesEach does, streaming them through a results spill and
+// saving the JSON results as the CLI does, or keeping them all as scrapeForum
+// does. Streaming's peak heap stays flat as the run grows.
+func BenchmarkPipelineMemory(b *testing.B) {
+	const threads, postsPerThread = 1000, 10
+	server := syntheticForum(b, postsPerThread)
+	refs := make([]ThreadRef, threads)
+	for i := range refs {
+		refs[i] = ThreadRef{URL: fmt.Sprintf("%s/viewtopic.php?f=2&t=%d", server.URL, i+1)}
+	}
+
+	for _, mode := range []string{"stream", "spill", "keep"} {
+		b.Run(mode, func(b *testing.B) {
+			var peak uint64
+			for i := 0; i < b.N; i++ {
+				scraper := NewForumScraper("phpbb", 0, WithConcurrency(8, 8), WithStatusOutput(io.Discard), WithOutputDir(b.TempDir()))
+				var spill *resultsSpill
+				if mode == "spill" {
+					var err error
+					if spill, err = newResultsSpill(scraper.outputDir); err != nil {
+						b.Fatal(err)
+					}
+				}
+				var kept []*ForumThread
+				scraped := 0
+				heap := peakHeap(func() {
+					err := scraper.scrapeRefsEach(refs, postsPerThread, func(thread *ForumThread) error {
+						scraped++
+						switch mode {
+						case "spill":
+							return spill.add(thread)
+						case "keep":
+							kept = append(kept, thread)
+						}
+						return nil
+					})
+					if err == nil && spill != nil {
+						_, err = scraper.saveSpilledResults(spill, "")
+						spill.Close()
+					}
+					if err != nil {
+						b.Fatal(err)
+					}
+				})
+				if scraped != threads {
+					b.Fatalf("scraped %d threads, want %d", scraped, threads)
+				}
+				peak = max(peak, heap)
+				runtime.KeepAlive(kept)
+			}
+			b.ReportMetric(float64(peak)/(1<<20), "peak-heap-MB")
+		})
+	}
+}
+
+// Posts are extracted in page order, however many a thread has
+func TestScrapeThreadKeepsPostOrder(t *testing.T) {
+	const posts = 500
+	server := syntheticForum(t, posts)
+	scraper := NewForumScraper("phpbb", 0, WithStatusOutput(io.Discard))
+	thread, err := scraper.scrapeThread(server.URL+"/viewtopic.php?f=2&t=7", posts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(thread.Posts) != posts {
+		t.Fatalf("scraped %d posts, want %d", len(thread.Posts), posts)
+	}
+	for i, post := range thread.Posts {
+		if want := fmt.Sprintf("Post %d of topic 7.", i+1); post.PostNumber != i+1 || !strings.HasPrefix(post.Content, want) {
+			t.Fatalf("post %d is number %d: %.30q", i+1, post.PostNumber, post.Content)
+		}
+	}
+}
+
+// BenchmarkScrapeThread scrapes a 500-post synthetic thread: fetched and parsed
+// whole by scrapeThread, and each post alone through scrapePost
+func BenchmarkScrapeThread(b *testing.B) {
+	const posts = 500
+	server := syntheticForum(b, posts)
+
+	b.Run("thread", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			scraper := NewForumScraper("phpbb", 0, WithStatusOutput(io.Discard))
+			thread, err := scraper.scrapeThread(fmt.Sprintf("%s/viewtopic.php?f=2&t=%d", server.URL, i+1), posts)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(thread.Posts) != posts {
+				b.Fatalf("scraped %d posts, want %d", len(thread.Posts), posts)
+			}
+		}
+	})
+
+	b.Run("posts", func(b *testing.B) {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(syntheticThreadPage(1, posts)))
+		if err != nil {
+			b.Fatal(err)
+		}
+		scraper := NewForumScraper("phpbb", 0, WithStatusOutput(io.Discard))
+		config := scraper.configs["phpbb"]
+		selections := config.PostSelector.findAll(doc.Selection)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			selections.Each(func(n int, s *goquery.Selection) {
+				if post, reason := scraper.scrapePost(s, config, "Topic 1", "https://forum.example.com/viewtopic.php?t=1", n+1); post == nil {
+					b.Fatalf("post %d skipped: %s", n+1, reason)
+				}
+			})
+		}
+	})
+}
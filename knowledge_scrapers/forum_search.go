@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// searchQueryPlaceholder marks where the encoded query goes in SearchURLTemplate
+const searchQueryPlaceholder = "{query}"
+
+// searchURL builds the platform's search URL for query, resolved against the forum URL
+func (fs *ForumScraperGo) searchURL(forumURL, query string) (string, error) {
+	config, exists := fs.configs[fs.platform]
+	if !exists {
+		config = fs.configs["generic"]
+	}
+	if config.SearchURLTemplate == "" {
+		return "", fmt.Errorf("platform %s has no search URL template", fs.platform)
+	}
+
+	relative := strings.ReplaceAll(config.SearchURLTemplate, searchQueryPlaceholder, url.QueryEscape(query))
+	return resolveURL(forumURL, relative)
+}
+
+// searchParam returns the query parameter SearchURLTemplate puts the query in
+func (fs *ForumScraperGo) searchParam() string {
+	config, exists := fs.configs[fs.platform]
+	if !exists {
+		config = fs.configs["generic"]
+	}
+
+	template, err := url.Parse(config.SearchURLTemplate)
+	if err != nil {
+		return ""
+	}
+	for key, values := range template.Query() {
+		for _, value := range values {
+			if value == searchQueryPlaceholder {
+				return key
+			}
+		}
+	}
+	return ""
+}
+
+// reencodeSearchPage re-applies the search query to a result page URL. Boards often
+// emit next-page links with the query half-decoded, which the server then rejects.
+func (fs *ForumScraperGo) reencodeSearchPage(pageURL string) string {
+	param := fs.searchParam()
+	u, err := url.Parse(pageURL)
+	if param == "" || err != nil {
+		return pageURL
+	}
+
+	query := u.Query()
+	if _, exists := query[param]; !exists {
+		return pageURL
+	}
+	query.Set(param, fs.searchQuery)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// discoverFromSearch collects thread URLs from the forum's search results for the
+// configured query, walking result pages like any other index
+func (fs *ForumScraperGo) discoverFromSearch(forumURL string, maxThreads int) ([]ThreadRef, error) {
+	searchURL, err := fs.searchURL(forumURL, fs.searchQuery)
+	if err != nil {
+		return nil, err
+	}
+	fs.statusf("🔎 Searching %s for %q\n", forumURL, fs.searchQuery)
+
+	return fs.discoverThreads(searchURL, maxThreads)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Reproducible runs: with --seed, jitter and User-Agent choices repeat between runs,
+// and results are written in a stable order. Fields that record when a run happened
+// (scraped_at on the envelope, threads and posts, and the default filename) still
+// differ unless --fixed-timestamps freezes them, which also derives the run ID from
+// the seed. A random --anonymize-salt, duplicate detection across concurrently
+// scraped threads, and the pacing and byte counters in run_stats also vary between
+// runs.
+
+// fixedTimestamp is the time --fixed-timestamps freezes every scraped_at to
+var fixedTimestamp = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// lockedRand is a seeded random source safe for use from many workers
+type lockedRand struct {
+	mutex sync.Mutex
+	rng   *rand.Rand
+}
+
+func newLockedRand(seed int64) *lockedRand {
+	return &lockedRand{rng: rand.New(rand.NewSource(seed))}
+}
+
+func (r *lockedRand) Float64() float64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.rng.Float64()
+}
+
+func (r *lockedRand) Intn(n int) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.rng.Intn(n)
+}
+
+// seededIndex picks an index below n from key and the run's seed, so a choice keyed
+// by host doesn't depend on which host happened to be reached first
+func (fs *ForumScraperGo) seededIndex(key string, n int) int {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return int((h.Sum64() ^ uint64(fs.seed)) % uint64(n))
+}
+
+// now returns the time recorded in output, frozen under --fixed-timestamps
+func (fs *ForumScraperGo) now() time.Time {
+	if fs.fixedTimestamps {
+		return fixedTimestamp
+	}
+	return time.Now()
+}
+
+// sortThreads orders threads by normalized URL so output doesn't depend on which
+// worker finished first
+func sortThreads(threads []*ForumThread) {
+	sort.SliceStable(threads, func(i, j int) bool {
+		return normalizeURL(threads[i].URL) < normalizeURL(threads[j].URL)
+	})
+}
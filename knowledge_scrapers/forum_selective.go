@@ -0,0 +1,155 @@
+package main
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Archived threads can run to megabytes of HTML, most of it markup around the
+// posts: member popups, quick-reply editors, sidebars and inline scripts. Every
+// selector lookup and text scan would walk all of it, so thread pages larger than
+// selectivePageBytes are pruned to what extraction reads before anything runs on
+// them.
+
+// selectivePageBytes is the size past which --selective-parse prunes a thread page
+const selectivePageBytes = 1 << 20
+
+// maxMarkerText caps the page text searched for interstitial and missing-thread
+// markers. The pages carrying them are small, with the marker near the top.
+const maxMarkerText = 64 << 10
+
+// leadingText returns the selection's text as Selection.Text does, scripts
+// included, cut after limit bytes
+func leadingText(selection *goquery.Selection, limit int) string {
+	var text strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if text.Len() >= limit {
+			return
+		}
+		if n.Type == html.TextNode {
+			text.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	for _, n := range selection.Nodes {
+		walk(n)
+	}
+	if text.Len() > limit {
+		return text.String()[:limit]
+	}
+	return text.String()
+}
+
+// markupExceeds reports whether the text, tag names and attributes under n add
+// up to more than limit bytes, stopping as soon as they do
+func markupExceeds(n *html.Node, limit int) bool {
+	size := 0
+	var walk func(*html.Node) bool
+	walk = func(n *html.Node) bool {
+		size += len(n.Data)
+		for _, attr := range n.Attr {
+			size += len(attr.Key) + len(attr.Val)
+		}
+		if size > limit {
+			return true
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if walk(child) {
+				return true
+			}
+		}
+		return false
+	}
+	return walk(n)
+}
+
+// compactThreadPage prunes a large thread page in place to the parts extraction
+// reads: everything before the first post (the head, and the header area with the
+// title, breadcrumb, counts and top pagination), the posts and the elements
+// enclosing them, and past the first post only what links to the thread's other
+// pages or holds JSON-LD. Pages the post selector finds nothing on stay whole for
+// missing-thread and login detection, as do pages of platforms with regex rules,
+// which match against the whole page.
+func (fs *ForumScraperGo) compactThreadPage(doc *goquery.Document, pageURL string) {
+	if !fs.selectiveParse || len(doc.Nodes) == 0 || !markupExceeds(doc.Nodes[0], selectivePageBytes) {
+		return
+	}
+	config, exists := fs.configs[fs.platform]
+	if !exists {
+		config = fs.configs["generic"]
+	}
+	for _, rule := range config.regexRules() {
+		if rule.pattern != "" {
+			return
+		}
+	}
+	posts := config.PostSelector.findAll(doc.Selection)
+	if posts.Length() == 0 {
+		return
+	}
+
+	isPost := make(map[*html.Node]bool, posts.Length())
+	enclosesPost := make(map[*html.Node]bool)
+	for _, n := range posts.Nodes {
+		isPost[n] = true
+		for parent := n.Parent; parent != nil && !enclosesPost[parent]; parent = parent.Parent {
+			enclosesPost[parent] = true
+		}
+	}
+
+	pastFirstPost := false
+	var prune func(*html.Node)
+	prune = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; {
+			next := child.NextSibling
+			switch {
+			case isPost[child]:
+				pastFirstPost = true
+			case enclosesPost[child]:
+				prune(child)
+			case pastFirstPost && !keptAfterPosts(child):
+				n.RemoveChild(child)
+			}
+			child = next
+		}
+	}
+	prune(doc.Nodes[0])
+
+	atomic.AddInt64(&fs.stats.PagesCompacted, 1)
+	fs.debugf("Parsed %s selectively: kept its header and %d posts", pageURL, posts.Length())
+}
+
+// keptAfterPosts reports whether markup past a page's first post survives
+// compaction: it links to another page of the thread or holds JSON-LD
+func keptAfterPosts(n *html.Node) bool {
+	if n.Type == html.ElementNode {
+		switch n.DataAtom {
+		case atom.A:
+			for _, attr := range n.Attr {
+				if attr.Key == "href" && (strings.Contains(attr.Val, "start=") || strings.Contains(attr.Val, "page")) {
+					return true
+				}
+			}
+		case atom.Script:
+			for _, attr := range n.Attr {
+				if attr.Key == "type" && strings.EqualFold(attr.Val, "application/ld+json") {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if keptAfterPosts(child) {
+			return true
+		}
+	}
+	return false
+}
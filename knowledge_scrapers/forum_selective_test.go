@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// The selective parse tests inflate each golden case's thread page to a large
+// archived thread and scrape it with and without --selective-parse
+
+// benchNoiseLinks is how many member-menu links surround each post of an inflated page
+const benchNoiseLinks = 150
+
+// benchPageSize is the size each thread page is inflated to
+const benchPageSize = 2 << 20
+
+// benchNoise is the markup an inflated page carries after each post, like the
+// hidden member popups of real archived threads. It takes the post's own tag so
+// it stays a sibling of the post wherever the post sits, table cells included.
+func benchNoise(tag string, i int) string {
+	var noise strings.Builder
+	fmt.Fprintf(&noise, `<%s class="bench-noise" style="display:none"><div class="popupmenu"><ul>`, tag)
+	for j := 0; j < benchNoiseLinks; j++ {
+		fmt.Fprintf(&noise, `<li><a href="member.php?u=%d&amp;tab=%d" class="siteicon"><span>Member menu %d</span></a></li>`, i, j, j)
+	}
+	fmt.Fprintf(&noise, `</ul></div></%s>`, tag)
+	return noise.String()
+}
+
+// inflateThreadPage repeats a thread page's posts, each followed by benchNoise,
+// until the page is about size bytes
+func inflateThreadPage(page string, config PlatformConfig, size int) (string, int, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		return "", 0, err
+	}
+	posts := config.PostSelector.findAll(doc.Selection)
+	if posts.Length() == 0 {
+		return "", 0, fmt.Errorf("no posts to repeat")
+	}
+
+	var round strings.Builder
+	posts.Each(func(i int, s *goquery.Selection) {
+		post, _ := goquery.OuterHtml(s)
+		round.WriteString(post)
+		round.WriteString(benchNoise(s.Get(0).Data, i))
+	})
+	rounds := (size-len(page))/round.Len() + 1
+	last := posts.Last()
+	for n := last.Get(0); n.Parent != nil && n.Parent.Type == html.ElementNode; n = n.Parent {
+		// Nested posts repeat with their outermost post
+		if posts.IndexOfNode(n.Parent) >= 0 {
+			last = last.Parent()
+		}
+	}
+	last.AfterHtml(strings.Repeat(round.String(), rounds))
+
+	inflated, err := doc.Html()
+	if err != nil {
+		return "", 0, err
+	}
+	return inflated, posts.Length() * (rounds + 1), nil
+}
+
+// inflatedCaseServer serves a case's thread page inflated to size bytes,
+// returning the server and how many posts the page holds
+func inflatedCaseServer(t testing.TB, c fixtureCase, size int) (*httptest.Server, int) {
+	t.Helper()
+	file, exists := c.Routes[c.Thread]
+	if !exists {
+		t.Fatalf("no route for thread %s", c.Thread)
+	}
+	data, err := os.ReadFile(filepath.Join(fixturesDir, file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scraper, err := newFixtureScraper(fixturesDir, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, exists := scraper.configs[c.Platform]
+	if !exists {
+		config = scraper.configs["generic"]
+	}
+	page, posts, err := inflateThreadPage(string(data), config, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RequestURI() != c.Thread {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}))
+	t.Cleanup(server.Close)
+	return server, posts
+}
+
+// scrapeInflated scrapes a case's inflated thread page and returns it encoded
+func scrapeInflated(t testing.TB, c fixtureCase, server *httptest.Server, posts int, selectiveParse bool) []byte {
+	t.Helper()
+	scraper, err := newFixtureScraper(fixturesDir, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scraper.selectiveParse = selectiveParse
+	thread, err := scraper.scrapeThread(server.URL+c.Thread, posts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := json.Marshal(thread)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encoded
+}
+
+func TestSelectiveParseMatchesWhole(t *testing.T) {
+	if testing.Short() {
+		t.Skip("inflates every fixture thread")
+	}
+	for _, c := range loadFixtureCases(t) {
+		c := c
+		t.Run(c.name(), func(t *testing.T) {
+			server, posts := inflatedCaseServer(t, c, benchPageSize)
+			whole := scrapeInflated(t, c, server, posts, false)
+			selective := scrapeInflated(t, c, server, posts, true)
+			if string(whole) != string(selective) {
+				t.Error("selective parsing changed the extracted thread")
+			}
+		})
+	}
+}
+
+func BenchmarkSelectiveParse(b *testing.B) {
+	for _, c := range loadFixtureCases(b) {
+		c := c
+		server, posts := inflatedCaseServer(b, c, benchPageSize)
+		for _, mode := range []struct {
+			name      string
+			selective bool
+		}{{"whole", false}, {"selective", true}} {
+			b.Run(c.name()+"/"+mode.name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					scrapeInflated(b, c, server, posts, mode.selective)
+				}
+			})
+		}
+	}
+}
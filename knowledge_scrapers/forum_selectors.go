@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"golang.org/x/net/html"
+)
+
+// xpathPrefix marks a selector as an XPath expression rather than CSS. XPath
+// selectors must select elements (or text nodes), and are evaluated from each
+// element they search under, so ".//td[3]" rather than "//td[3]" reads best.
+const xpathPrefix = "xpath:"
+
+// compiledXPaths caches compiled XPath selectors by expression
+var compiledXPaths sync.Map
+
+// compileXPath compiles an XPath selector's expression, once per run
+func compileXPath(expr string) (*xpath.Expr, error) {
+	if compiled, ok := compiledXPaths.Load(expr); ok {
+		return compiled.(*xpath.Expr), nil
+	}
+	compiled, err := xpath.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	compiledXPaths.Store(expr, compiled)
+	return compiled, nil
+}
+
+// findSelector returns the matches of one CSS or XPath selector under s.
+// Invalid XPath matches nothing; loadPlatformConfigs rejects it up front.
+func findSelector(s *goquery.Selection, selector string) *goquery.Selection {
+	if !strings.HasPrefix(selector, xpathPrefix) {
+		return s.Find(selector)
+	}
+	compiled, err := compileXPath(strings.TrimPrefix(selector, xpathPrefix))
+	if err != nil {
+		return s.Slice(0, 0)
+	}
+	var nodes []*html.Node
+	for _, node := range s.Nodes {
+		nodes = append(nodes, htmlquery.QuerySelectorAll(node, compiled)...)
+	}
+	// FindNodes keeps document order and drops anything outside s, such as
+	// attribute values the expression selected
+	return s.FindNodes(nodes...)
+}
+
+// validateSelector reports an XPath selector that doesn't compile
+func validateSelector(selector string) error {
+	if !strings.HasPrefix(selector, xpathPrefix) {
+		return nil
+	}
+	if _, err := compileXPath(strings.TrimPrefix(selector, xpathPrefix)); err != nil {
+		return fmt.Errorf("invalid XPath %q: %w", selector, err)
+	}
+	return nil
+}
+
+// selectorChain is a field's selectors in order of preference: the first one
+// that finds something wins, so a theme that moves an element only needs its
+// selector added after the stock one. Entries are CSS unless prefixed with
+// xpathPrefix, and a chain may mix both. Config files may give a plain string
+// for a chain of one.
+type selectorChain []string
+
+func (c *selectorChain) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*c = nil
+		if single != "" {
+			*c = selectorChain{single}
+		}
+		return nil
+	}
+	var chain []string
+	if err := json.Unmarshal(data, &chain); err != nil {
+		return err
+	}
+	*c = chain
+	return nil
+}
+
+// css returns one selector group of the chain's CSS selectors, for consumers
+// that can't evaluate XPath
+func (c selectorChain) css() string {
+	var css []string
+	for _, selector := range c {
+		if !strings.HasPrefix(selector, xpathPrefix) {
+			css = append(css, selector)
+		}
+	}
+	return strings.Join(css, ", ")
+}
+
+// findAll returns everything under s that any selector in the chain matches, for
+// checks that only ask whether something is there
+func (c selectorChain) findAll(s *goquery.Selection) *goquery.Selection {
+	// Not s.Slice(0, 0): adding to it would write over s's own nodes
+	matches := s.FindNodes()
+	if css := c.css(); css != "" {
+		matches = s.Find(css)
+	}
+	for _, selector := range c {
+		if strings.HasPrefix(selector, xpathPrefix) {
+			matches = matches.AddSelection(findSelector(s, selector))
+		}
+	}
+	return matches
+}
+
+// matches reports whether s itself, or anything under it, matches the chain
+func (c selectorChain) matches(s *goquery.Selection) bool {
+	if c.findAll(s).Length() > 0 {
+		return true
+	}
+	// s is one of its parent's matches when it isn't under its own
+	parent := s.Parent()
+	if parent.Length() == 0 {
+		return false
+	}
+	return c.findAll(parent).Intersection(s).Length() > 0
+}
+
+// validate reports the chain's first invalid selector
+func (c selectorChain) validate() error {
+	for _, selector := range c {
+		if err := validateSelector(selector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// find returns the matches under s of the first selector that matches anything,
+// and that selector; an empty selection and "" when none does
+func (c selectorChain) find(s *goquery.Selection) (*goquery.Selection, string) {
+	for _, selector := range c {
+		if matches := findSelector(s, selector); matches.Length() > 0 {
+			return matches, selector
+		}
+	}
+	return s.Slice(0, 0), ""
+}
+
+// findText is find skipping selectors whose matches hold no text, so an element
+// that is present but empty falls through to the next selector
+func (c selectorChain) findText(s *goquery.Selection) (*goquery.Selection, string) {
+	for _, selector := range c {
+		if matches := findSelector(s, selector); strings.TrimSpace(matches.Text()) != "" {
+			return matches, selector
+		}
+	}
+	return s.Slice(0, 0), ""
+}
+
+// text returns the trimmed text of the first match of the first selector whose
+// first match has any, and that selector
+func (c selectorChain) text(s *goquery.Selection) (string, string) {
+	for _, selector := range c {
+		if text := strings.TrimSpace(findSelector(s, selector).First().Text()); text != "" {
+			return text, selector
+		}
+	}
+	return "", ""
+}
+
+// selectorMatched logs under --debug which selector of a chain matched for a
+// field, once per run for each field and selector
+func (fs *ForumScraperGo) selectorMatched(field string, chain selectorChain, selector string) {
+	if !fs.debug || selector == "" {
+		return
+	}
+	if _, logged := fs.matchedSelectors.LoadOrStore(field+"\x00"+selector, true); logged {
+		return
+	}
+	position := 0
+	for i, candidate := range chain {
+		if candidate == selector {
+			position = i + 1
+			break
+		}
+	}
+	fs.debugf("%s matched %q (selector %d of %d)", field, selector, position, len(chain))
+}
+
+// fieldOutcome is what one config field found: the selector that matched, its
+// match count summed over every element searched, and the first value it yielded
+type fieldOutcome struct {
+	Field    string
+	Selector string
+	Matches  int
+	Value    string
+}
+
+// fieldRecorder collects field outcomes in the order fields are first reached
+type fieldRecorder struct {
+	mu       sync.Mutex
+	outcomes []*fieldOutcome
+	byField  map[string]*fieldOutcome
+}
+
+func newFieldRecorder() *fieldRecorder {
+	return &fieldRecorder{byField: make(map[string]*fieldOutcome)}
+}
+
+func (r *fieldRecorder) record(field, selector string, matches int, value string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	outcome, exists := r.byField[field]
+	if !exists {
+		outcome = &fieldOutcome{Field: field}
+		r.byField[field] = outcome
+		r.outcomes = append(r.outcomes, outcome)
+	}
+	if outcome.Selector == "" {
+		outcome.Selector = selector
+	}
+	outcome.Matches += matches
+	if outcome.Value == "" {
+		outcome.Value = value
+	}
+}
+
+// reached reports whether field has been recorded
+func (r *fieldRecorder) reached(field string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, exists := r.byField[field]
+	return exists
+}
+
+// fieldMatched is selectorMatched for extraction steps, also recording for
+// check-config what the field yielded from selector's matches under scope
+// ("" when no selector matched)
+func (fs *ForumScraperGo) fieldMatched(field string, chain selectorChain, scope *goquery.Selection, selector, value string) {
+	fs.selectorMatched(field, chain, selector)
+	fs.recordField(field, chain, scope, selector, value)
+}
+
+// recordField records for check-config what field yielded from selector's
+// matches under scope, when chain is set
+func (fs *ForumScraperGo) recordField(field string, chain selectorChain, scope *goquery.Selection, selector, value string) {
+	if fs.fields == nil || len(chain) == 0 {
+		return
+	}
+	matches := 0
+	if selector != "" {
+		matches = findSelector(scope, selector).Length()
+	}
+	fs.fields.record(field, selector, matches, value)
+}
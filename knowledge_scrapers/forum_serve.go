@@ -0,0 +1,463 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Job states reported by GET /jobs/{id}
+const (
+	jobQueued    = "queued"
+	jobRunning   = "running"
+	jobDone      = "done"
+	jobFailed    = "failed"
+	jobCancelled = "cancelled"
+)
+
+// jobRequest is the body of POST /jobs
+type jobRequest struct {
+	Platform   string `json:"platform"`
+	URL        string `json:"url"`
+	MaxThreads int    `json:"max_threads"`
+	MaxPosts   int    `json:"max_posts"`
+}
+
+// jobProgress are a job's counters while it runs
+type jobProgress struct {
+	ThreadsDiscovered int64 `json:"threads_discovered"`
+	ThreadsScraped    int   `json:"threads_scraped"`
+	PostsScraped      int   `json:"posts_scraped"`
+	Failures          int   `json:"failures"`
+	BytesTransferred  int64 `json:"bytes_transferred"`
+}
+
+// jobStatus is the body of GET /jobs/{id}
+type jobStatus struct {
+	ID         string      `json:"id"`
+	Status     string      `json:"status"`
+	Request    jobRequest  `json:"request"`
+	Progress   jobProgress `json:"progress"`
+	CreatedAt  time.Time   `json:"created_at"`
+	StartedAt  *time.Time  `json:"started_at,omitempty"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+	// Results is where the finished job's results file was saved
+	Results string `json:"results,omitempty"`
+	// StopReason names the budget that cut the job short, such as "deadline"
+	StopReason string `json:"stop_reason,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// scrapeJob is one scrape requested through the API. Its threads are kept as they
+// complete so GET /jobs/{id}/results can stream them while the job runs.
+type scrapeJob struct {
+	id      string
+	request jobRequest
+	created time.Time
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	mu       sync.Mutex
+	changed  *sync.Cond
+	status   string
+	started  time.Time
+	finished time.Time
+	threads  []*ForumThread
+	posts    int
+	results  string
+	err      error
+	scraper  *ForumScraperGo
+}
+
+// over reports whether the job has stopped for good; callers hold mu
+func (j *scrapeJob) over() bool {
+	return j.status == jobDone || j.status == jobFailed || j.status == jobCancelled
+}
+
+// jobSink collects a job's threads as the scraper delivers them
+type jobSink struct{ job *scrapeJob }
+
+func (s jobSink) Name() string { return "job " + s.job.id }
+
+func (s jobSink) Write(ctx context.Context, thread *ForumThread) error {
+	s.job.mu.Lock()
+	defer s.job.mu.Unlock()
+	s.job.threads = append(s.job.threads, thread)
+	s.job.posts += len(thread.Posts)
+	s.job.changed.Broadcast()
+	return nil
+}
+
+func (s jobSink) Close() error { return nil }
+
+// scrapeServer runs API jobs on a fixed pool of workers. Every job's scraper shares
+// the server's thread slots, per-host slots, pacing and budget, so limits hold
+// across jobs that hit the same host. Finished jobs are forgotten after jobTTL.
+type scrapeServer struct {
+	shared *ForumScraperGo
+	opts   []Option
+	delay  float64
+	queue  chan *scrapeJob
+	// jobDeadline stops a job starting new requests this long after it starts
+	jobDeadline time.Duration
+	jobTTL      time.Duration
+
+	mu   sync.Mutex
+	jobs map[string]*scrapeJob
+}
+
+// withSharedLimits makes a scraper take its concurrency and pacing from base, and
+// spend from base's budget on top of its own
+func withSharedLimits(base *ForumScraperGo) Option {
+	return func(fs *ForumScraperGo) {
+		fs.threadSem = base.threadSem
+		fs.hostSlots = base.hostSlots
+		fs.pacing = base.pacing
+		fs.budget = &runBudget{parent: base.budget}
+	}
+}
+
+func newJobID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// worker runs queued jobs one at a time
+func (s *scrapeServer) worker() {
+	for job := range s.queue {
+		s.run(job)
+	}
+}
+
+// run scrapes one job's URL and saves its results under the job ID
+func (s *scrapeServer) run(job *scrapeJob) {
+	job.mu.Lock()
+	if job.status != jobQueued {
+		job.mu.Unlock()
+		return
+	}
+	opts := append(append([]Option{}, s.opts...), withSharedLimits(s.shared),
+		WithStatusOutput(io.Discard), WithSinks(jobSink{job: job}))
+	scraper := NewForumScraper(job.request.Platform, s.delay, opts...)
+	scraper.ctx = job.ctx
+	job.scraper = scraper
+	job.status = jobRunning
+	job.started = time.Now()
+	if s.jobDeadline > 0 {
+		scraper.budget.deadline = job.started.Add(s.jobDeadline)
+	}
+	job.mu.Unlock()
+	log.Printf("▶️ Job %s: %s %s", job.id, job.request.Platform, job.request.URL)
+
+	threads, err := scraper.scrapeSources([]string{job.request.URL}, job.request.MaxThreads, job.request.MaxPosts)
+	var results []string
+	if err == nil && job.ctx.Err() == nil {
+		results, err = scraper.saveResults(threads, "job_"+job.id+".json")
+	}
+
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	job.finished = time.Now()
+	switch {
+	case job.ctx.Err() != nil:
+		job.status = jobCancelled
+	case err != nil:
+		job.status = jobFailed
+		job.err = err
+	default:
+		job.status = jobDone
+		if len(results) > 0 {
+			job.results = results[0]
+		}
+	}
+	job.cancel()
+	job.changed.Broadcast()
+	log.Printf("⏹️ Job %s %s: %d threads, %d posts", job.id, job.status, len(job.threads), job.posts)
+}
+
+// evictFinished forgets jobs that ended more than jobTTL before now, with the
+// threads they hold; their results files stay on disk
+func (s *scrapeServer) evictFinished(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, job := range s.jobs {
+		job.mu.Lock()
+		expired := job.over() && now.Sub(job.finished) > s.jobTTL
+		job.mu.Unlock()
+		if expired {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// evictLoop runs evictFinished until the process exits
+func (s *scrapeServer) evictLoop() {
+	interval := min(s.jobTTL, time.Minute)
+	for now := range time.Tick(interval) {
+		s.evictFinished(now)
+	}
+}
+
+// snapshot reports a job's state for the API
+func (j *scrapeJob) snapshot() jobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	status := jobStatus{
+		ID:        j.id,
+		Status:    j.status,
+		Request:   j.request,
+		CreatedAt: j.created,
+		Results:   j.results,
+		Progress:  jobProgress{ThreadsScraped: len(j.threads), PostsScraped: j.posts},
+	}
+	if !j.started.IsZero() {
+		started := j.started
+		status.StartedAt = &started
+	}
+	if !j.finished.IsZero() {
+		finished := j.finished
+		status.FinishedAt = &finished
+	}
+	if j.err != nil {
+		status.Error = j.err.Error()
+	}
+	if j.scraper != nil {
+		status.StopReason = j.scraper.budgetStopReason()
+		status.Progress.ThreadsDiscovered = atomic.LoadInt64(&j.scraper.stats.ThreadsDiscovered)
+		status.Progress.BytesTransferred = atomic.LoadInt64(&j.scraper.stats.BytesTransferred)
+		status.Progress.Failures = len(j.scraper.failures.snapshot())
+	}
+	return status
+}
+
+// isHTTPURL reports whether raw is an absolute http(s) URL, as a job's url must be
+func isHTTPURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, map[string]string{"error": message})
+}
+
+func (s *scrapeServer) job(r *http.Request) *scrapeJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jobs[r.PathValue("id")]
+}
+
+// handleCreate is POST /jobs
+func (s *scrapeServer) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var request jobRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if !isHTTPURL(request.URL) {
+		writeError(w, http.StatusBadRequest, "url must be an http(s) URL")
+		return
+	}
+	if request.Platform == "" {
+		request.Platform = "generic"
+	}
+	if _, known := defaultPlatformConfigs()[request.Platform]; !known {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown platform %q", request.Platform))
+		return
+	}
+	if request.MaxThreads <= 0 {
+		request.MaxThreads = 10
+	}
+	if request.MaxPosts <= 0 {
+		request.MaxPosts = 25
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &scrapeJob{id: newJobID(), request: request, created: time.Now(), ctx: ctx, cancel: cancel, status: jobQueued}
+	job.changed = sync.NewCond(&job.mu)
+	s.mu.Lock()
+	s.jobs[job.id] = job
+	s.mu.Unlock()
+
+	select {
+	case s.queue <- job:
+	default:
+		cancel()
+		s.mu.Lock()
+		delete(s.jobs, job.id)
+		s.mu.Unlock()
+		writeError(w, http.StatusServiceUnavailable, "job queue is full")
+		return
+	}
+	w.Header().Set("Location", "/jobs/"+job.id)
+	writeJSON(w, http.StatusAccepted, job.snapshot())
+}
+
+// handleStatus is GET /jobs/{id}
+func (s *scrapeServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	job := s.job(r)
+	if job == nil {
+		writeError(w, http.StatusNotFound, "no such job")
+		return
+	}
+	writeJSON(w, http.StatusOK, job.snapshot())
+}
+
+// handleCancel is DELETE /jobs/{id}. Requests in flight are aborted; threads
+// already scraped stay available from /results.
+func (s *scrapeServer) handleCancel(w http.ResponseWriter, r *http.Request) {
+	job := s.job(r)
+	if job == nil {
+		writeError(w, http.StatusNotFound, "no such job")
+		return
+	}
+	job.mu.Lock()
+	if job.status == jobQueued {
+		job.status = jobCancelled
+		job.finished = time.Now()
+		job.changed.Broadcast()
+	}
+	job.mu.Unlock()
+	job.cancel()
+	writeJSON(w, http.StatusOK, job.snapshot())
+}
+
+// handleResults is GET /jobs/{id}/results: the job's threads as JSONL, streamed as
+// they are scraped until the job ends
+func (s *scrapeServer) handleResults(w http.ResponseWriter, r *http.Request) {
+	job := s.job(r)
+	if job == nil {
+		writeError(w, http.StatusNotFound, "no such job")
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+
+	// Wake the wait below when the client goes away
+	stop := context.AfterFunc(r.Context(), func() {
+		job.mu.Lock()
+		job.changed.Broadcast()
+		job.mu.Unlock()
+	})
+	defer stop()
+
+	sent := 0
+	for {
+		job.mu.Lock()
+		for sent == len(job.threads) && !job.over() && r.Context().Err() == nil {
+			job.changed.Wait()
+		}
+		pending := job.threads[sent:]
+		over := job.over()
+		job.mu.Unlock()
+		if r.Context().Err() != nil {
+			return
+		}
+
+		for _, thread := range pending {
+			if err := encoder.Encode(threadRecord{SchemaVersion: resultsSchemaVersion, ForumThread: thread}); err != nil {
+				return
+			}
+		}
+		sent += len(pending)
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if over {
+			return
+		}
+	}
+}
+
+// runServe starts the HTTP API for on-demand scrapes
+func runServe(args []string) {
+	fset := flag.NewFlagSet("forum_scraper serve", flag.ExitOnError)
+	fset.Usage = func() {
+		fmt.Println("Usage: forum_scraper serve [flags]")
+		fmt.Println("Example: forum_scraper serve --addr :8080 --workers 4")
+		fmt.Println("  POST   /jobs               {\"platform\", \"url\", \"max_threads\", \"max_posts\"} starts a job")
+		fmt.Println("  GET    /jobs/{id}          job status, progress and results location")
+		fmt.Println("  GET    /jobs/{id}/results  the job's threads as JSONL, streamed while it runs")
+		fmt.Println("  DELETE /jobs/{id}          cancels a job")
+		fset.PrintDefaults()
+	}
+	addr := fset.String("addr", "127.0.0.1:8080", "address to listen on")
+	workers := fset.Int("workers", 2, "jobs run at once; further jobs wait in the queue")
+	queueSize := fset.Int("queue", 100, "jobs that may wait before POST /jobs is refused")
+	outputDir := fset.String("output-dir", defaultOutputDir, "directory for job result files")
+	delay := fset.Float64("delay", 1.5, "delay in seconds before each request")
+	concurrency := fset.Int("concurrency", 5, "concurrent thread scrapes across all jobs")
+	perHostConcurrency := fset.Int("per-host-concurrency", defaultPerHostConcurrency, "concurrent thread scrapes against one host, across all jobs")
+	adaptiveDelay := fset.Bool("adaptive-delay", false, "back off per host when responses are slow or rate-limited (429/503)")
+	retries := fset.Int("retries", 0, "retry a request up to this many times after a timeout, a dropped connection or a 429/502/503/504")
+	maxTotalPosts := fset.Int("max-total-posts", 0, "stop starting new requests once the server has scraped this many posts (0 for no limit)")
+	maxRequests := fset.Int("max-requests", 0, "stop after the server has made this many HTTP requests (0 for no limit)")
+	deadline := fset.Duration("deadline", 0, "stop a job starting new requests this long after it starts (0 for no limit)")
+	jobTTL := fset.Duration("job-ttl", time.Hour, "forget a finished job, and the threads it holds, this long after it ends")
+
+	if _, err := parseInterleaved(fset, args); err != nil {
+		log.Fatal(err)
+	}
+	if *workers < 1 {
+		log.Fatalf("❌ Invalid --workers: %d (must be at least 1)", *workers)
+	}
+	if *queueSize < 0 {
+		log.Fatalf("❌ Invalid --queue: %d (must not be negative)", *queueSize)
+	}
+	if *retries < 0 {
+		log.Fatalf("❌ Invalid --retries: %d (must not be negative)", *retries)
+	}
+	if *jobTTL <= 0 {
+		log.Fatalf("❌ Invalid --job-ttl: %v (must be positive)", *jobTTL)
+	}
+
+	server := &scrapeServer{
+		shared: NewForumScraper("generic", *delay,
+			WithConcurrency(*concurrency, *perHostConcurrency),
+			WithBudget(*maxTotalPosts, *maxRequests, 0)),
+		opts: []Option{
+			WithOutputDir(*outputDir),
+			WithAdaptiveDelay(*adaptiveDelay),
+			WithRetries(*retries),
+		},
+		delay:       *delay,
+		queue:       make(chan *scrapeJob, *queueSize),
+		jobDeadline: *deadline,
+		jobTTL:      *jobTTL,
+		jobs:        make(map[string]*scrapeJob),
+	}
+	for i := 0; i < *workers; i++ {
+		go server.worker()
+	}
+	go server.evictLoop()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /jobs", server.handleCreate)
+	mux.HandleFunc("GET /jobs/{id}", server.handleStatus)
+	mux.HandleFunc("DELETE /jobs/{id}", server.handleCancel)
+	mux.HandleFunc("GET /jobs/{id}/results", server.handleResults)
+
+	fmt.Fprintf(os.Stderr, "🌐 Serving scrape jobs on http://%s\n", *addr)
+	if err := http.ListenAndServe(*addr, mux); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("❌ Server failed: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testScrapeServer is a scrapeServer with one worker writing under a temp dir
+func testScrapeServer(t *testing.T, jobDeadline time.Duration) *scrapeServer {
+	t.Helper()
+	server := &scrapeServer{
+		shared:      NewForumScraper("generic", 0, WithConcurrency(2, 2)),
+		opts:        []Option{WithOutputDir(t.TempDir())},
+		queue:       make(chan *scrapeJob, 10),
+		jobDeadline: jobDeadline,
+		jobTTL:      time.Hour,
+		jobs:        make(map[string]*scrapeJob),
+	}
+	go server.worker()
+	t.Cleanup(func() { close(server.queue) })
+	return server
+}
+
+func postJob(t *testing.T, server *scrapeServer, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	server.handleCreate(recorder, httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(body)))
+	return recorder
+}
+
+// waitForJob returns the job's status once it has ended
+func waitForJob(t *testing.T, server *scrapeServer, id string) jobStatus {
+	t.Helper()
+	server.mu.Lock()
+	job := server.jobs[id]
+	server.mu.Unlock()
+	if job == nil {
+		t.Fatalf("no job %s", id)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if status := job.snapshot(); status.FinishedAt != nil {
+			return status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return jobStatus{}
+}
+
+func TestServeRejectsUnknownPlatform(t *testing.T) {
+	server := testScrapeServer(t, 0)
+	recorder := postJob(t, server, `{"platform": "phpbbb", "url": "https://forum.example.com/"}`)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("unknown platform: HTTP %d, want 400", recorder.Code)
+	}
+	if len(server.jobs) != 0 {
+		t.Errorf("unknown platform queued %d job(s)", len(server.jobs))
+	}
+}
+
+func TestServeDeadlineIsPerJob(t *testing.T) {
+	forum := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><a href="/t/1">One</a></body></html>`))
+	}))
+	defer forum.Close()
+	server := testScrapeServer(t, time.Nanosecond)
+
+	for i := 0; i < 2; i++ {
+		recorder := postJob(t, server, `{"platform": "generic", "url": "`+forum.URL+`/"}`)
+		if recorder.Code != http.StatusAccepted {
+			t.Fatalf("job %d: HTTP %d: %s", i+1, recorder.Code, recorder.Body)
+		}
+		id := strings.TrimPrefix(recorder.Header().Get("Location"), "/jobs/")
+		if status := waitForJob(t, server, id); status.StopReason != "deadline" {
+			t.Errorf("job %d: stop reason %q, want deadline", i+1, status.StopReason)
+		}
+	}
+	// Each job ran out of its own time; the server's budget is untouched
+	if server.shared.budget.exhausted() {
+		t.Error("a job's deadline exhausted the server's budget")
+	}
+}
+
+func TestServeEvictsFinishedJobs(t *testing.T) {
+	server := &scrapeServer{jobTTL: time.Hour, jobs: make(map[string]*scrapeJob)}
+	now := time.Now()
+	add := func(id, status string, finished time.Time) {
+		ctx, cancel := context.WithCancel(context.Background())
+		job := &scrapeJob{id: id, status: status, finished: finished, ctx: ctx, cancel: cancel}
+		job.changed = sync.NewCond(&job.mu)
+		server.jobs[id] = job
+	}
+	add("old", jobDone, now.Add(-2*time.Hour))
+	add("old-cancelled", jobCancelled, now.Add(-2*time.Hour))
+	add("recent", jobFailed, now.Add(-time.Minute))
+	add("running", jobRunning, time.Time{})
+
+	server.evictFinished(now)
+	for _, id := range []string{"old", "old-cancelled"} {
+		if server.jobs[id] != nil {
+			t.Errorf("job %s kept past --job-ttl", id)
+		}
+	}
+	for _, id := range []string{"recent", "running"} {
+		if server.jobs[id] == nil {
+			t.Errorf("job %s evicted early", id)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// threadSink stores each thread as soon as it is scraped, alongside the results
+// file. Write is called from the scrape pipeline's single consumer, so a slow
+// sink holds the scrape back rather than letting finished threads pile up.
+type threadSink interface {
+	Name() string
+	Write(ctx context.Context, thread *ForumThread) error
+	Close() error
+}
+
+// newPostgresSink connects to a PostgreSQL database and brings its tables up to
+// date. It is only set in builds with the postgres tag, so the default binary
+// carries no database driver.
+var newPostgresSink func(dsn string, status io.Writer) (threadSink, error)
+
+// deliver hands a finished thread to every sink. A sink that fails is reported and
+// the run goes on; the thread is still in the results file.
+func (fs *ForumScraperGo) deliver(thread *ForumThread) {
+	fs.runRecorded.Do(func() { fs.recordRun(false) })
+	for _, sink := range fs.sinks {
+		if err := sink.Write(context.Background(), thread); err != nil {
+			fs.statusf("⚠️ Failed to write %s to %s: %v\n", thread.URL, sink.Name(), err)
+		}
+	}
+}
+
+// pendingSpiller is a sink that buffers writes and can save them synchronously
+// when the run is interrupted
+type pendingSpiller interface {
+	spillPending()
+}
+
+// spillSinksOnInterrupt saves what the sinks still buffer if the run is
+// interrupted, since Close won't run. Worker mode handles its own signals.
+func (fs *ForumScraperGo) spillSinksOnInterrupt() {
+	onInterrupt(func() {
+		for _, sink := range fs.sinks {
+			if spiller, ok := sink.(pendingSpiller); ok {
+				spiller.spillPending()
+			}
+		}
+	})
+}
+
+// closeSinks records the end of the run and closes every sink, reporting failures
+func (fs *ForumScraperGo) closeSinks() {
+	fs.recordRun(true)
+	for _, sink := range fs.sinks {
+		if err := sink.Close(); err != nil {
+			fs.statusf("⚠️ Failed to close %s: %v\n", sink.Name(), err)
+		}
+	}
+}
+
+// sinkFlags are the flags that add sinks, shared by scrapes and worker mode
+type sinkFlags struct {
+	postgres  *string
+	kafka     *string
+	nats      *string
+	perThread *bool
+	busAcks   *string
+
+	notifyMatch   *string
+	notifyWebhook *string
+	notifyCommand *string
+	notifyState   *string
+	notifyTest    *bool
+}
+
+func registerSinkFlags(fset *flag.FlagSet) *sinkFlags {
+	return &sinkFlags{
+		postgres:  fset.String("postgres", "", "also upsert every thread into this PostgreSQL database (DSN or URL; needs a build with -tags postgres)"),
+		kafka:     fset.String("kafka", "", "also publish to Kafka as <broker,broker>/<topic> (needs a build with -tags kafka)"),
+		nats:      fset.String("nats", "", "also publish to NATS as <url>/<subject> (needs a build with -tags nats)"),
+		perThread: fset.Bool("per-thread", false, "publish one message per thread to --kafka/--nats instead of one per post"),
+		busAcks:   fset.String("bus-acks", busAcksAll, "broker acknowledgement for --kafka/--nats: all, one or none"),
+
+		notifyMatch:   fset.String("notify-match", "", "notify --notify-webhook or --notify-command once about each new post matching this regexp"),
+		notifyWebhook: fset.String("notify-webhook", "", "POST each --notify-match event as JSON to this URL"),
+		notifyCommand: fset.String("notify-command", "", "run this shell command for each --notify-match event, with the JSON on stdin"),
+		notifyState:   fset.String("notify-state", "", "file recording the posts already notified, across runs (default: notify-state.json in the output directory)"),
+		notifyTest:    fset.Bool("notify-test", false, "send a synthetic event to --notify-webhook or --notify-command and exit"),
+	}
+}
+
+// open connects the sinks the flags name, exiting on failure. Spill files go in
+// outputDir and the sinks' status lines to status.
+func (f *sinkFlags) open(outputDir string, status io.Writer) []threadSink {
+	var sinks []threadSink
+	if *f.postgres != "" {
+		if newPostgresSink == nil {
+			log.Fatal("❌ --postgres needs a build with PostgreSQL support: go build -tags postgres")
+		}
+		sink, err := newPostgresSink(*f.postgres, status)
+		if err != nil {
+			log.Fatalf("❌ Failed to connect to --postgres: %v", err)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	switch *f.busAcks {
+	case busAcksAll, busAcksOne, busAcksNone:
+	default:
+		log.Fatalf("❌ Invalid --bus-acks: %q (use all, one or none)", *f.busAcks)
+	}
+	if notifier := f.notifier(outputDir, status); notifier != nil {
+		sinks = append(sinks, notifier)
+	}
+	if *f.kafka != "" {
+		if newKafkaPublisher == nil {
+			log.Fatal("❌ --kafka needs a build with Kafka support: go build -tags kafka")
+		}
+		brokers, topic, err := splitBusTarget(*f.kafka)
+		if err != nil {
+			log.Fatalf("❌ Invalid --kafka: %v", err)
+		}
+		publisher, err := newKafkaPublisher(strings.Split(brokers, ","), topic, *f.busAcks)
+		if err != nil {
+			log.Fatalf("❌ Failed to connect to --kafka: %v", err)
+		}
+		spillPath := filepath.Join(outputDir, "kafka-spill.jsonl")
+		sinks = append(sinks, newBusSink("kafka", publisher, *f.perThread, spillPath, status))
+	}
+	if *f.nats != "" {
+		if newNATSPublisher == nil {
+			log.Fatal("❌ --nats needs a build with NATS support: go build -tags nats")
+		}
+		url, subject, err := splitBusTarget(*f.nats)
+		if err != nil {
+			log.Fatalf("❌ Invalid --nats: %v", err)
+		}
+		publisher, err := newNATSPublisher(url, subject, *f.busAcks)
+		if err != nil {
+			log.Fatalf("❌ Failed to connect to --nats: %v", err)
+		}
+		spillPath := filepath.Join(outputDir, "nats-spill.jsonl")
+		sinks = append(sinks, newBusSink("nats", publisher, *f.perThread, spillPath, status))
+	}
+	return sinks
+}
+
+// notifier returns the --notify-match sink, nil without the flag, exiting when
+// the notify flags don't fit together
+func (f *sinkFlags) notifier(outputDir string, status io.Writer) *notifySink {
+	if *f.notifyWebhook != "" && *f.notifyCommand != "" {
+		log.Fatal("❌ Use one of --notify-webhook and --notify-command")
+	}
+	hasTarget := *f.notifyWebhook != "" || *f.notifyCommand != ""
+	if *f.notifyTest && !hasTarget {
+		log.Fatal("❌ --notify-test needs --notify-webhook or --notify-command")
+	}
+	if *f.notifyMatch == "" && !*f.notifyTest {
+		if hasTarget {
+			log.Fatal("❌ --notify-webhook and --notify-command need --notify-match")
+		}
+		return nil
+	}
+	if *f.notifyMatch != "" && !hasTarget {
+		log.Fatal("❌ --notify-match needs --notify-webhook or --notify-command")
+	}
+
+	var match *regexp.Regexp
+	if *f.notifyMatch != "" {
+		var err error
+		if match, err = regexp.Compile(*f.notifyMatch); err != nil {
+			log.Fatalf("❌ Invalid --notify-match: %v", err)
+		}
+	}
+	statePath := *f.notifyState
+	if statePath == "" {
+		statePath = filepath.Join(outputDir, "notify-state.json")
+	}
+	notifier, err := newNotifySink(match, *f.notifyWebhook, *f.notifyCommand, statePath, status)
+	if err != nil {
+		log.Fatalf("❌ Failed to load --notify-state: %v", err)
+	}
+	return notifier
+}
+
+// runNotifyTest sends --notify-test's synthetic event, reporting whether it did
+func (f *sinkFlags) runNotifyTest(outputDir string, status io.Writer) bool {
+	if !*f.notifyTest {
+		return false
+	}
+	if err := f.notifier(outputDir, status).sendTest(); err != nil {
+		log.Fatalf("❌ Test notification failed: %v", err)
+	}
+	fmt.Fprintln(status, "🔔 Test notification sent")
+	return true
+}
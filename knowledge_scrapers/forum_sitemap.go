@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// sitemapEntry is a <url> or <sitemap> element of a sitemap document
+type sitemapEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// sitemapDoc decodes both urlset and sitemapindex documents
+type sitemapDoc struct {
+	URLs     []sitemapEntry `xml:"url"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+// lastModLayouts are the W3C datetime forms allowed in <lastmod> plus the RFC 822
+// forms RSS uses for <pubDate>, whose day of the month may be a single digit
+var lastModLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+}
+
+// parseLastMod parses a sitemap or feed date, returning nil when it can't be understood
+func parseLastMod(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range lastModLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// parseSince parses a --since value: a date, an RFC 3339 timestamp, or a
+// duration back from now such as 72h or 7d
+func parseSince(value string) (time.Time, error) {
+	if strings.HasSuffix(value, "d") {
+		var days int
+		if _, err := fmt.Sscanf(value, "%dd", &days); err == nil {
+			return time.Now().AddDate(0, 0, -days), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t := parseLastMod(value); t != nil {
+		return *t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid since value %q (want a date, timestamp or duration)", value)
+}
+
+// tooOld reports whether a ref's last activity predates the --since cutoff or
+// the --window start
+func (fs *ForumScraperGo) tooOld(ref ThreadRef) bool {
+	if ref.LastActivity == nil {
+		return false
+	}
+	return (!fs.since.IsZero() && ref.LastActivity.Before(fs.since)) ||
+		(!fs.window.start.IsZero() && ref.LastActivity.Before(fs.window.start))
+}
+
+// sitemapURL returns the conventional /sitemap.xml location for a forum's host
+func sitemapURL(forumURL string) (string, error) {
+	u, err := url.Parse(forumURL)
+	if err != nil {
+		return "", err
+	}
+	return u.Scheme + "://" + u.Host + "/sitemap.xml", nil
+}
+
+// fetchSitemap fetches and decodes one sitemap file, transparently handling gzip
+func (fs *ForumScraperGo) fetchSitemap(rawURL string) (*sitemapDoc, error) {
+	resp, err := fs.doRequest(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// .xml.gz files are served as-is rather than with Content-Encoding, so sniff the magic bytes
+	var body io.Reader = bufio.NewReader(resp.Body)
+	if magic, err := body.(*bufio.Reader).Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	var doc sitemapDoc
+	if err := xml.NewDecoder(body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid sitemap %s: %w", rawURL, err)
+	}
+	return &doc, nil
+}
+
+// discoverFromSitemap collects thread URLs from the forum host's sitemap,
+// following sitemap index files breadth-first up to the sitemap file cap
+func (fs *ForumScraperGo) discoverFromSitemap(forumURL string, maxThreads int) ([]ThreadRef, error) {
+	root, err := sitemapURL(forumURL)
+	if err != nil {
+		return nil, err
+	}
+	fs.statusf("🗺️ Discovering threads from sitemap: %s\n", root)
+
+	threadPattern := fs.threadURLRegexp()
+	queue := []string{root}
+	queued := map[string]bool{root: true}
+	seen := make(map[string]bool)
+	var refs []ThreadRef
+	fetched := 0
+
+	for len(queue) > 0 && len(refs) < maxThreads && fetched < fs.maxSitemaps {
+		sitemap := queue[0]
+		queue = queue[1:]
+
+		// Rate limiting
+		fs.politeWait(sitemap)
+
+		doc, err := fs.fetchSitemap(sitemap)
+		fetched++
+		atomic.AddInt64(&fs.stats.IndexPagesFetched, 1)
+		if err != nil {
+			if sitemap == root {
+				return nil, err
+			}
+			fs.statusf("⚠️ Skipping sitemap %s: %v\n", sitemap, err)
+			continue
+		}
+
+		for _, child := range doc.Sitemaps {
+			loc := strings.TrimSpace(child.Loc)
+			if loc == "" || queued[loc] || !fs.allowPageURL(loc, forumURL) {
+				continue
+			}
+			// Child sitemaps untouched since the cutoff can't hold newer threads
+			if lastMod := parseLastMod(child.LastMod); lastMod != nil && !fs.since.IsZero() && lastMod.Before(fs.since) {
+				continue
+			}
+			queued[loc] = true
+			queue = append(queue, loc)
+		}
+
+		for _, entry := range doc.URLs {
+			if len(refs) >= maxThreads {
+				break
+			}
+			loc := strings.TrimSpace(entry.Loc)
+			if threadPattern != nil && !threadPattern.MatchString(loc) {
+				continue
+			}
+			key := normalizeURL(loc)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			ref := ThreadRef{URL: loc, SourceURL: forumURL, LastActivity: parseLastMod(entry.LastMod)}
+			if fs.tooOld(ref) || !fs.allowThreadURL(loc, forumURL) {
+				continue
+			}
+			refs = append(refs, ref)
+		}
+	}
+
+	fs.statusf("📊 Discovered %d thread URLs across %d sitemap file(s)\n", len(refs), fetched)
+	return refs, nil
+}
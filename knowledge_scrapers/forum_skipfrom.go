@@ -0,0 +1,46 @@
+package main
+
+// loadSkipFrom reads the threads of earlier results files, JSON or JSONL and
+// gzipped or not, into the set of previously exported threads that the run skips.
+// Files are streamed a thread at a time and only the threads' keys are kept, so
+// long histories fit in memory. It returns how many threads the files held.
+func (fs *ForumScraperGo) loadSkipFrom(paths []string) (int, error) {
+	if fs.exported == nil {
+		fs.exported = make(map[string]bool)
+	}
+	count := 0
+	for _, path := range paths {
+		_, err := readResultsFile(path, func(thread *ForumThread) error {
+			count++
+			for _, rawURL := range []string{thread.URL, thread.FinalURL, thread.CanonicalURL} {
+				if rawURL != "" {
+					fs.exported["url:"+normalizeURL(rawURL)] = true
+				}
+			}
+			if thread.ThreadID != "" {
+				fs.exported["id:"+threadIDKey(thread.URL, thread.ThreadID)] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// previouslyExported reports whether a --skip-from file holds the thread at
+// rawURL, by normalized URL or by native thread ID. threadID is read from the
+// URL when not given.
+func (fs *ForumScraperGo) previouslyExported(rawURL, threadID string) bool {
+	if len(fs.exported) == 0 {
+		return false
+	}
+	if fs.exported["url:"+normalizeURL(rawURL)] {
+		return true
+	}
+	if threadID == "" {
+		threadID = fs.extractThreadID(rawURL)
+	}
+	return threadID != "" && fs.exported["id:"+threadIDKey(rawURL, threadID)]
+}
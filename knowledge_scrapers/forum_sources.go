@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ThreadRef is a thread URL queued for scraping along with the source it came from
+type ThreadRef struct {
+	URL          string     `json:"url"`
+	Title        string     `json:"title,omitempty"`
+	SourceURL    string     `json:"source_url,omitempty"`
+	LastActivity *time.Time `json:"last_activity,omitempty"`
+	// Replies and Views are the counts the index showed, when it showed them
+	Replies *int `json:"replies,omitempty"`
+	Views   *int `json:"views,omitempty"`
+	// Category is the category discovery found the thread under, when it found one
+	Category string `json:"category,omitempty"`
+}
+
+// threadURLRegexp returns the pattern identifying thread URLs: the --thread-pattern
+// override when set, otherwise the platform's ThreadURLPattern (nil if it has none)
+func (fs *ForumScraperGo) threadURLRegexp() *regexp.Regexp {
+	if fs.threadPattern != nil {
+		return fs.threadPattern
+	}
+
+	config, exists := fs.configs[fs.platform]
+	if !exists {
+		config = fs.configs["generic"]
+	}
+	return compiledConfigPattern(config.ThreadURLPattern)
+}
+
+// configPatterns caches compiled platform config patterns by pattern
+var configPatterns sync.Map
+
+// compiledConfigPattern compiles a platform config pattern once per run, nil if
+// it's empty or doesn't compile
+func compiledConfigPattern(pattern string) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+	if re, ok := configPatterns.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		re = nil
+	}
+	configPatterns.Store(pattern, re)
+	return re
+}
+
+// isThreadURL reports whether a URL points at a thread page rather than an index page
+func (fs *ForumScraperGo) isThreadURL(rawURL string) bool {
+	re := fs.threadURLRegexp()
+	return re != nil && re.MatchString(urlPatternTarget(rawURL))
+}
+
+// discoverIndex discovers threads under one index page, using a member's history, a category listing, search, the feed or
+// the sitemap when asked to, or a Discourse board's latest listing for --window, and falling back to the sitemap when the
+// page yields no thread links
+func (fs *ForumScraperGo) discoverIndex(forumURL string, maxThreads int) ([]ThreadRef, error) {
+	if fs.localInput && isFileURL(forumURL) {
+		if dir, ok := localDirectory(forumURL); ok {
+			return fs.discoverLocal(dir, maxThreads)
+		}
+	}
+	switch fs.platform {
+	case "stackexchange":
+		return fs.discoverFromStackExchange(forumURL, maxThreads)
+	case "hackernews":
+		return fs.discoverFromHackerNews(forumURL, maxThreads)
+	}
+	if fs.userName != "" || fs.userID != "" {
+		return fs.discoverFromUser(forumURL, maxThreads)
+	}
+	if fs.categoryName != "" {
+		return fs.discoverFromCategory(forumURL, maxThreads)
+	}
+	if fs.searchQuery != "" {
+		return fs.discoverFromSearch(forumURL, maxThreads)
+	}
+	if fs.feedURL != "" {
+		refs, err := fs.discoverFromFeed(forumURL, fs.feedURL, maxThreads)
+		if err == nil {
+			return refs, nil
+		}
+		fs.statusf("⚠️ Feed discovery failed, falling back to HTML discovery: %v\n", err)
+	}
+	if fs.useSitemap {
+		return fs.discoverFromSitemap(forumURL, maxThreads)
+	}
+	if fs.window.active() && fs.platform == "discourse" {
+		return fs.discoverFromLatest(forumURL, maxThreads)
+	}
+
+	var refs []ThreadRef
+	var err error
+	if fs.maxDepth > 0 {
+		refs, err = fs.crawlForums(forumURL, maxThreads)
+	} else {
+		refs, err = fs.discoverThreads(forumURL, maxThreads)
+	}
+	if err != nil || len(refs) > 0 {
+		return refs, err
+	}
+
+	fs.statusf("⚠️ No thread links on %s, falling back to the sitemap\n", forumURL)
+	sitemapRefs, err := fs.discoverFromSitemap(forumURL, maxThreads)
+	if err != nil {
+		fs.statusf("⚠️ Sitemap fallback failed: %v\n", err)
+		return refs, nil
+	}
+	return sitemapRefs, nil
+}
+
+// discoverSources resolves every source into thread refs without fetching any
+// thread pages. Thread URLs pass straight through; index pages run discovery,
+// and with --prioritize each one's candidates are ordered before being cut to
+// maxThreads.
+func (fs *ForumScraperGo) discoverSources(sources []string, maxThreads int) ([]ThreadRef, error) {
+	var refs []ThreadRef
+	var lastErr error
+	failed := 0
+	for _, source := range sources {
+		source = fs.hackerNewsURL(fs.redditURL(source))
+		if fs.isThreadURL(source) {
+			refs = append(refs, ThreadRef{URL: source, SourceURL: source})
+			continue
+		}
+
+		discovered, err := fs.discoverIndex(source, fs.discoveryLimit(maxThreads))
+		if errors.Is(err, ErrBudgetExhausted) {
+			// Keep what was discovered so far so the run can still save results
+			break
+		}
+		if errors.Is(err, ErrUnknownCategory) {
+			return nil, err
+		}
+		if err != nil {
+			fs.statusf("❌ Failed to discover threads from %s: %v\n", source, err)
+			lastErr = err
+			failed++
+			continue
+		}
+		discovered = fs.filterRefsByCategory(discovered)
+		discovered = fs.prioritizeRefs(discovered, maxThreads)
+		fs.recordListedCounts(discovered)
+		refs = append(refs, discovered...)
+	}
+
+	if failed == len(sources) {
+		return nil, lastErr
+	}
+	return refs, nil
+}
+
+// scrapeSources scrapes a mix of index pages and thread pages into one result set.
+// Large runs should use scrapeSourcesEach, which keeps no threads.
+func (fs *ForumScraperGo) scrapeSources(sources []string, maxThreads, maxPostsPerThread int) ([]*ForumThread, error) {
+	var threads []*ForumThread
+	err := fs.scrapeSourcesEach(sources, maxThreads, maxPostsPerThread, func(thread *ForumThread) error {
+		threads = append(threads, thread)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return threads, nil
+}
+
+// scrapeSourcesEach scrapes a mix of index pages and thread pages, calling emit
+// with each thread as it completes. Index pages run through discovery; thread
+// pages are scraped directly.
+func (fs *ForumScraperGo) scrapeSourcesEach(sources []string, maxThreads, maxPostsPerThread int, emit func(*ForumThread) error) error {
+	fs.statusf("🚀 Starting forum scraping from %d source(s)\n", len(sources))
+
+	refs, err := fs.discoverSources(sources, maxThreads)
+	if err != nil {
+		return err
+	}
+
+	atomic.AddInt64(&fs.stats.ThreadsDiscovered, int64(len(refs)))
+	var references *referenceCollector
+	if fs.followHops > 0 {
+		references = fs.newReferenceCollector()
+	}
+	scraped := 0
+	counted := func(thread *ForumThread) error {
+		scraped++
+		return emit(thread)
+	}
+	err = fs.scrapeRefsEach(refs, maxPostsPerThread, func(thread *ForumThread) error {
+		if references != nil {
+			references.add(thread)
+		}
+		return counted(thread)
+	})
+	if err == nil && references != nil {
+		err = fs.followReferences(references, maxThreads, maxPostsPerThread, counted)
+	}
+	fs.statusf("✅ Scraped %d threads from %d source(s)\n", scraped, len(sources))
+	return err
+}
+
+// scrapeStream reads newline-delimited thread URLs from r and scrapes them through
+// scrapePipeline, calling emit as each thread completes. URLs are handed to the
+// workers as they are read so the input is never buffered in memory.
+func (fs *ForumScraperGo) scrapeStream(r io.Reader, maxPostsPerThread int, emit func(*ForumThread) error) error {
+	scanner := bufio.NewScanner(r)
+	feed := func(refs chan<- ThreadRef, stop <-chan struct{}) {
+		for {
+			// Once an emit error stops the pipeline or the budget runs out, no
+			// more input is read, so no line is read only to be dropped
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if fs.budget.exhausted() || !scanner.Scan() {
+				return
+			}
+			line := strings.TrimSpace(scanner.Text())
+			// The visited set already holds every URL scraped, so a repeated
+			// line is skipped without keeping a second copy of the input
+			if line == "" {
+				continue
+			}
+			threadURL := fs.hackerNewsURL(fs.redditURL(line))
+			if fs.isVisited(fs.canonicalThreadURL(threadURL)) {
+				continue
+			}
+			atomic.AddInt64(&fs.stats.ThreadsDiscovered, 1)
+			select {
+			case refs <- ThreadRef{URL: threadURL, SourceURL: "stdin"}:
+			case <-stop:
+				return
+			}
+		}
+	}
+
+	emitErr := fs.scrapePipeline(feed, maxPostsPerThread, emit)
+	if emitErr != nil {
+		return emitErr
+	}
+	return scanner.Err()
+}
+
+// readURLList reads one URL per line from a file, ignoring blank lines and # comments.
+// A JSON array of thread refs, as written by --dry-run --format json, is also accepted.
+func readURLList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	if first, err := reader.Peek(1); err == nil && first[0] == '[' {
+		var refs []ThreadRef
+		if err := json.NewDecoder(reader).Decode(&refs); err != nil {
+			return nil, fmt.Errorf("invalid JSON URL list: %w", err)
+		}
+		urls := make([]string, 0, len(refs))
+		for _, ref := range refs {
+			urls = append(urls, ref.URL)
+		}
+		return urls, nil
+	}
+
+	var urls []string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	return urls, scanner.Err()
+}
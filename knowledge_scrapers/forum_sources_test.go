@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// topicLines is an input of distinct thread URLs, handed out one line per Read so
+// the lines read can be counted. After the first urls lines it waits for hold to
+// close, then reads as blank lines until max.
+type topicLines struct {
+	host string
+	urls int
+	hold chan struct{}
+	read int
+	max  int
+}
+
+func (l *topicLines) Read(p []byte) (int, error) {
+	if l.read == l.max {
+		return 0, io.EOF
+	}
+	l.read++
+	if l.read > l.urls {
+		<-l.hold
+		return copy(p, "\n"), nil
+	}
+	return copy(p, fmt.Sprintf("%s/viewtopic.php?f=2&t=%d\n", l.host, l.read)), nil
+}
+
+func TestStdinStopsReadingOnEmitError(t *testing.T) {
+	server := topicHost(t, 0)
+	input := &topicLines{host: server.URL, urls: 2, hold: make(chan struct{}), max: 10000}
+	scraper := NewForumScraper("phpbb", 0, WithConcurrency(2, 2))
+	scraper.statusOut = io.Discard
+
+	emitErr := errors.New("disk full")
+	var once sync.Once
+	err := scraper.scrapeStream(input, fixtureMaxPosts, func(*ForumThread) error {
+		once.Do(func() { close(input.hold) })
+		return emitErr
+	})
+	if !errors.Is(err, emitErr) {
+		t.Fatalf("scrapeStream returned %v, want the emit error", err)
+	}
+	// The read waiting on hold completes, but no more lines are read after it
+	if input.read > input.urls+2 {
+		t.Errorf("read %d lines after the emit error stopped the run", input.read-input.urls)
+	}
+}
+
+// Once the budget has run out no line is read, rather than one read and dropped
+func TestStdinReadsNothingOnceBudgetExhausted(t *testing.T) {
+	input := &topicLines{host: "http://forum.example.com", urls: 10, max: 10}
+	scraper := NewForumScraper("phpbb", 0)
+	scraper.statusOut = io.Discard
+	scraper.tripBudget("max-requests")
+
+	err := scraper.scrapeStream(input, fixtureMaxPosts, func(*ForumThread) error {
+		t.Error("emitted a thread after the budget ran out")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.read != 0 {
+		t.Errorf("read %d lines after the budget ran out", input.read)
+	}
+}
+
+// A line already scraped is skipped by the visited set before it's queued
+func TestStdinSkipsVisitedLines(t *testing.T) {
+	server := topicHost(t, 0)
+	scraper := NewForumScraper("phpbb", 0)
+	scraper.statusOut = io.Discard
+	emitted := 0
+	emit := func(*ForumThread) error {
+		emitted++
+		return nil
+	}
+
+	first := server.URL + "/viewtopic.php?f=2&t=1\n"
+	if err := scraper.scrapeStream(strings.NewReader(first), fixtureMaxPosts, emit); err != nil {
+		t.Fatal(err)
+	}
+	again := server.URL + "/viewtopic.php?t=1&f=2\n\n" + server.URL + "/viewtopic.php?f=2&t=2\n"
+	if err := scraper.scrapeStream(strings.NewReader(again), fixtureMaxPosts, emit); err != nil {
+		t.Fatal(err)
+	}
+	if discovered := atomic.LoadInt64(&scraper.stats.ThreadsDiscovered); discovered != 2 || emitted != 2 {
+		t.Errorf("queued %d lines and emitted %d threads, want 2 of each", discovered, emitted)
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// spillEntry is where one thread's record sits in a resultsSpill
+type spillEntry struct {
+	// key is the thread's normalized URL, the order results files list threads in
+	key    string
+	offset int64
+	size   int64
+	posts  int
+}
+
+// resultsSpill holds a run's threads on disk as they complete, laid out as
+// records of the envelope's threads array, so writing the default JSON results
+// never needs the threads in memory together. Only each thread's sort key and
+// place in the file stay in memory; the envelope's counts come from those.
+type resultsSpill struct {
+	file    *os.File
+	out     *bufio.Writer
+	offset  int64
+	entries []spillEntry
+}
+
+// newResultsSpill creates a spill file in dir, which is created if need be
+func newResultsSpill(dir string) (*resultsSpill, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.CreateTemp(dir, ".forum-results-spill-*")
+	if err != nil {
+		return nil, err
+	}
+	return &resultsSpill{file: file, out: bufio.NewWriterSize(file, 64<<10)}, nil
+}
+
+// add appends thread to the spill; emit callbacks are called one at a time
+func (s *resultsSpill) add(thread *ForumThread) error {
+	record, err := marshalThreadRecord(thread)
+	if err != nil {
+		return err
+	}
+	if _, err := s.out.Write(record); err != nil {
+		return err
+	}
+	s.entries = append(s.entries, spillEntry{
+		key:    normalizeURL(thread.URL),
+		offset: s.offset,
+		size:   int64(len(record)),
+		posts:  len(thread.Posts),
+	})
+	s.offset += int64(len(record))
+	return nil
+}
+
+// spillCounts returns how many threads and posts entries hold
+func spillCounts(entries []spillEntry) (threads, posts int) {
+	for _, entry := range entries {
+		posts += entry.posts
+	}
+	return len(entries), posts
+}
+
+// finish flushes the spill and sorts its entries into results order, as
+// sortThreads orders a slice of threads
+func (s *resultsSpill) finish() error {
+	if err := s.out.Flush(); err != nil {
+		return err
+	}
+	sort.SliceStable(s.entries, func(i, j int) bool { return s.entries[i].key < s.entries[j].key })
+	return nil
+}
+
+// record reads back one thread's record
+func (s *resultsSpill) record(entry spillEntry) ([]byte, error) {
+	record := make([]byte, entry.size)
+	if _, err := s.file.ReadAt(record, entry.offset); err != nil {
+		return nil, fmt.Errorf("read results spill: %w", err)
+	}
+	return record, nil
+}
+
+// thread reads back one thread
+func (s *resultsSpill) thread(entry spillEntry) (*ForumThread, error) {
+	record, err := s.record(entry)
+	if err != nil {
+		return nil, err
+	}
+	var thread ForumThread
+	if err := json.Unmarshal(record, &thread); err != nil {
+		return nil, fmt.Errorf("read results spill: %w", err)
+	}
+	return &thread, nil
+}
+
+// writeEnvelope writes entries as a results document
+func (s *resultsSpill) writeEnvelope(w io.Writer, results *ResultsEnvelope, entries []spillEntry) error {
+	return writeEnvelopeRecords(w, results, func(emit func([]byte) error) error {
+		for _, entry := range entries {
+			record, err := s.record(entry)
+			if err != nil {
+				return err
+			}
+			if err := emit(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Close removes the spill file
+func (s *resultsSpill) Close() error {
+	s.file.Close()
+	return os.Remove(s.file.Name())
+}
+
+// saveSpilledResults is saveResults for threads collected in a spill. The files
+// it writes are byte for byte those saveResults writes for the same threads.
+// Ranking needs every thread at once, so --sort runs keep to saveResults.
+func (fs *ForumScraperGo) saveSpilledResults(spill *resultsSpill, filename string) ([]string, error) {
+	if err := spill.finish(); err != nil {
+		return nil, err
+	}
+	entries := spill.entries
+	if filename == "-" {
+		// --output - writes the whole document to stdout, unsplit
+		out := bufio.NewWriter(os.Stdout)
+		if err := spill.writeEnvelope(out, fs.resultsEnvelopeFor(spillCounts(entries)), entries); err != nil {
+			return nil, err
+		}
+		io.WriteString(out, "\n")
+		return nil, out.Flush()
+	}
+	if filename == "" {
+		var first *ForumThread
+		if len(entries) > 0 {
+			var err error
+			if first, err = spill.thread(entries[0]); err != nil {
+				return nil, err
+			}
+		}
+		filename = fs.resultsFilenameFor(first, len(entries), fs.now())
+	}
+	path := fs.resultsPath(filename)
+
+	bounds, err := fs.splitBounds(len(entries), func(i int) (int64, error) { return entries[i].size, nil })
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for i, b := range bounds {
+		part := entries[b[0]:b[1]]
+		partPath := path
+		if len(bounds) > 1 {
+			partPath = partFilename(path, i+1)
+		}
+
+		results := fs.resultsEnvelopeFor(spillCounts(part))
+		if len(bounds) > 1 {
+			results.Part = i + 1
+			results.Parts = len(bounds)
+		}
+
+		err := writeFileAtomicFunc(partPath, 0644, func(w io.Writer) error {
+			return spill.writeEnvelope(w, results, part)
+		})
+		if err != nil {
+			return paths, err
+		}
+
+		if len(bounds) > 1 {
+			fs.statusf("💾 Results part %d/%d saved to: %s (%d threads, %d posts)\n", i+1, len(bounds), partPath, len(part), results.TotalPosts)
+		} else {
+			fs.statusf("💾 Results saved to: %s\n", partPath)
+		}
+		paths = append(paths, partPath)
+	}
+	return paths, nil
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// stackExchangeAPI is the Stack Exchange API root the stackexchange platform reads from
+var stackExchangeAPI = "https://api.stackexchange.com/2.3"
+
+// stackExchangePageSize is the API's largest page
+const stackExchangePageSize = 100
+
+// stackExchangeEnvelope is the wrapper around every Stack Exchange API response
+type stackExchangeEnvelope struct {
+	Items          json.RawMessage `json:"items"`
+	HasMore        bool            `json:"has_more"`
+	QuotaRemaining *int            `json:"quota_remaining"`
+	// Backoff is how many seconds the API wants left before the next request
+	Backoff int `json:"backoff"`
+}
+
+type stackExchangeOwner struct {
+	DisplayName string `json:"display_name"`
+}
+
+type stackExchangeQuestion struct {
+	QuestionID       int                `json:"question_id"`
+	Title            string             `json:"title"`
+	Body             string             `json:"body"`
+	Link             string             `json:"link"`
+	Owner            stackExchangeOwner `json:"owner"`
+	CreationDate     int64              `json:"creation_date"`
+	LastActivityDate int64              `json:"last_activity_date"`
+	Score            int                `json:"score"`
+	ViewCount        int                `json:"view_count"`
+	AnswerCount      int                `json:"answer_count"`
+	Tags             []string           `json:"tags"`
+}
+
+type stackExchangeAnswer struct {
+	AnswerID     int                `json:"answer_id"`
+	Body         string             `json:"body"`
+	Owner        stackExchangeOwner `json:"owner"`
+	CreationDate int64              `json:"creation_date"`
+	Score        int                `json:"score"`
+	IsAccepted   bool               `json:"is_accepted"`
+}
+
+// stackExchangeSite returns the API's site parameter for a Stack Exchange URL:
+// stackoverflow for stackoverflow.com, unix for unix.stackexchange.com
+func stackExchangeSite(rawURL string) string {
+	host := strings.TrimPrefix(hostOf(rawURL), "www.")
+	return strings.TrimSuffix(strings.TrimSuffix(host, ".com"), ".stackexchange")
+}
+
+// stackExchangeGet requests one API method for the site of rawURL and decodes
+// its items. The API's backoff instruction holds the API host in the rate
+// limiter, and an exhausted quota stops the run like any other budget.
+func (fs *ForumScraperGo) stackExchangeGet(ctx context.Context, rawURL, method string, params url.Values, items interface{}) (bool, error) {
+	params.Set("site", stackExchangeSite(rawURL))
+	if fs.stackExchangeKey != "" {
+		params.Set("key", fs.stackExchangeKey)
+	}
+	requestURL := stackExchangeAPI + method + "?" + params.Encode()
+
+	// Rate limiting
+	fs.politeWaitContext(ctx, requestURL)
+
+	var envelope stackExchangeEnvelope
+	if err := fs.fetchJSON(ctx, requestURL, &envelope); err != nil {
+		return false, err
+	}
+	if envelope.Backoff > 0 {
+		fs.statusf("⏳ Stack Exchange asked for a %ds backoff\n", envelope.Backoff)
+		fs.holdHost(requestURL, time.Duration(envelope.Backoff)*time.Second)
+	}
+	if envelope.QuotaRemaining != nil && *envelope.QuotaRemaining <= 0 {
+		fs.tripBudget("stackexchange-quota")
+	}
+
+	if len(envelope.Items) > 0 {
+		if err := json.Unmarshal(envelope.Items, items); err != nil {
+			return false, fmt.Errorf("invalid Stack Exchange response from %s: %w", method, err)
+		}
+	}
+	return envelope.HasMore, nil
+}
+
+// stackExchangePost builds a post from API fields and runs it through the post
+// pipeline; a skipped post is returned as nil with the reason
+func (fs *ForumScraperGo) stackExchangePost(threadURL, threadTitle string, postNumber, postID int, owner stackExchangeOwner, body string, created int64, score int, accepted bool) (*ForumPost, string) {
+	content := fs.apiPostText(body)
+	if content == "" {
+		return nil, ""
+	}
+	author := html.UnescapeString(owner.DisplayName)
+	if author == "" {
+		author = "Anonymous"
+	}
+
+	post := &ForumPost{
+		URL:              fmt.Sprintf("%s#post%d", threadURL, postNumber),
+		ThreadTitle:      threadTitle,
+		Author:           author,
+		Content:          content,
+		PostNumber:       postNumber,
+		PostID:           strconv.Itoa(postID),
+		Timestamp:        time.Unix(created, 0).UTC().Format(time.RFC3339),
+		LikesCount:       &score,
+		ScrapedAt:        fs.now(),
+		IsAcceptedAnswer: accepted,
+	}
+	post, reason := fs.processPost(post)
+	if post == nil {
+		return nil, reason
+	}
+	post.ContentHash = contentHash(post.Content)
+	post.Language = detectLanguage(post.Content)
+	return post, ""
+}
+
+// scrapeStackExchange builds a thread from a Stack Exchange question through the
+// API: the question is post 1 and its answers follow, highest score first. The
+// result has the same shape as an HTML-scraped thread.
+func (fs *ForumScraperGo) scrapeStackExchange(threadURL string, maxPosts int) (*ForumThread, error) {
+	threadID := fs.extractThreadID(threadURL)
+	if threadID == "" {
+		return nil, fmt.Errorf("no question ID in %s", threadURL)
+	}
+	if !fs.markThreadID(threadURL, threadID) {
+		return nil, fmt.Errorf("%w: %s is question %s", ErrDuplicateThread, threadURL, threadID)
+	}
+	// --thread-timeout covers the question and every page of answers
+	threadCtx, cancel := fs.threadContext()
+	defer cancel()
+	ctx, provenance := fs.withProvenance(threadCtx, threadURL)
+
+	var questions []stackExchangeQuestion
+	if _, err := fs.stackExchangeGet(ctx, threadURL, "/questions/"+threadID, url.Values{"filter": {"withbody"}}, &questions); err != nil {
+		return nil, fs.threadTimeoutError(threadCtx, err, threadURL)
+	}
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrThreadMissing, threadURL)
+	}
+	question := questions[0]
+	title := validUTF8(html.UnescapeString(question.Title))
+
+	// --posts-mode first stops after the question and none reads no posts
+	var answers []stackExchangeAnswer
+	wanted := fs.postLimit(maxPosts, question.AnswerCount+1) - 1
+	for page := 1; len(answers) < wanted; page++ {
+		params := url.Values{
+			"filter":   {"withbody"},
+			"sort":     {"votes"},
+			"order":    {"desc"},
+			"page":     {strconv.Itoa(page)},
+			"pagesize": {strconv.Itoa(stackExchangePageSize)},
+		}
+		var pageAnswers []stackExchangeAnswer
+		hasMore, err := fs.stackExchangeGet(ctx, threadURL, "/questions/"+threadID+"/answers", params, &pageAnswers)
+		// A thread that outlasts --thread-timeout keeps the answers fetched so far
+		if err != nil && fs.threadTimedOut(threadCtx) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, pageAnswers...)
+		if !hasMore || len(pageAnswers) == 0 {
+			break
+		}
+	}
+	sort.SliceStable(answers, func(i, j int) bool { return answers[i].Score > answers[j].Score })
+	if len(answers) > wanted && wanted >= 0 {
+		answers = answers[:wanted]
+	}
+
+	var posts []*ForumPost
+	skipped := make(map[string]int)
+	addPost := func(post *ForumPost, reason string) {
+		if post != nil {
+			posts = append(posts, post)
+		} else if reason != "" {
+			skipped[reason]++
+			atomic.AddInt64(&fs.stats.PostsSkipped, 1)
+		}
+	}
+	if fs.postsMode != postsModeNone {
+		addPost(fs.stackExchangePost(threadURL, title, 1, question.QuestionID, question.Owner, question.Body, question.CreationDate, question.Score, false))
+	}
+	for i, answer := range answers {
+		addPost(fs.stackExchangePost(threadURL, title, i+2, answer.AnswerID, answer.Owner, answer.Body, answer.CreationDate, answer.Score, answer.IsAccepted))
+	}
+	if len(posts) == 0 && fs.postsMode != postsModeNone {
+		return nil, ErrNoPosts
+	}
+
+	viewsCount := question.ViewCount
+	thread := &ForumThread{
+		URL:          threadURL,
+		ThreadID:     threadID,
+		Title:        title,
+		RepliesCount: question.AnswerCount,
+		ViewsCount:   &viewsCount,
+		Tags:         question.Tags,
+		Provenance:   provenance,
+		ScrapedAt:    fs.now(),
+	}
+	if len(skipped) > 0 {
+		thread.SkippedPosts = skipped
+	}
+	if fs.threadTimedOut(threadCtx) {
+		thread.Truncated = true
+		fs.noteThreadTimeout(threadURL, provenance, fmt.Sprintf("%d of %d answers", len(answers), question.AnswerCount))
+	}
+	if question.Link != "" && normalizeURL(question.Link) != normalizeURL(threadURL) {
+		thread.FinalURL = question.Link
+	}
+	return fs.finishThread(thread, posts, maxPosts)
+}
+
+// stackExchangeTagPath matches a tag listing such as /questions/tagged/go+concurrency
+var stackExchangeTagPath = regexp.MustCompile(`/questions/tagged/([^/?#]+)`)
+
+// discoverFromStackExchange lists a tag's questions through the API, highest
+// voted first, paging until maxThreads or the index page cap
+func (fs *ForumScraperGo) discoverFromStackExchange(forumURL string, maxThreads int) ([]ThreadRef, error) {
+	u, err := url.Parse(forumURL)
+	if err != nil {
+		return nil, err
+	}
+	matches := stackExchangeTagPath.FindStringSubmatch(u.EscapedPath())
+	if matches == nil {
+		return nil, fmt.Errorf("%s is neither a question nor a /questions/tagged/ listing", forumURL)
+	}
+	tags, err := url.PathUnescape(matches[1])
+	if err != nil {
+		return nil, err
+	}
+	tags = strings.Join(strings.FieldsFunc(tags, func(r rune) bool { return r == '+' || r == ' ' }), ";")
+	fs.statusf("🔍 Discovering %s questions tagged %s\n", stackExchangeSite(forumURL), tags)
+
+	var refs []ThreadRef
+	for page := 1; len(refs) < maxThreads && page <= fs.maxIndexPages; page++ {
+		params := url.Values{
+			"tagged":   {tags},
+			"sort":     {"votes"},
+			"order":    {"desc"},
+			"page":     {strconv.Itoa(page)},
+			"pagesize": {strconv.Itoa(stackExchangePageSize)},
+		}
+		var questions []stackExchangeQuestion
+		hasMore, err := fs.stackExchangeGet(context.Background(), forumURL, "/questions", params, &questions)
+		if err != nil {
+			if page == 1 {
+				return nil, err
+			}
+			fs.statusf("⚠️ Stopped tag pagination at page %d: %v\n", page, err)
+			break
+		}
+		atomic.AddInt64(&fs.stats.IndexPagesFetched, 1)
+
+		for _, question := range questions {
+			if len(refs) >= maxThreads {
+				break
+			}
+			lastActivity := time.Unix(question.LastActivityDate, 0).UTC()
+			answers := question.AnswerCount
+			ref := ThreadRef{URL: question.Link, Title: html.UnescapeString(question.Title), SourceURL: forumURL, LastActivity: &lastActivity, Replies: &answers}
+			if fs.tooOld(ref) || !fs.allowThreadURL(ref.URL, forumURL) {
+				continue
+			}
+			refs = append(refs, ref)
+		}
+		if !hasMore {
+			break
+		}
+	}
+
+	fs.statusf("📊 Discovered %d questions\n", len(refs))
+	return refs, nil
+}
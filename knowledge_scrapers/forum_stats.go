@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// runStats holds counters shared by every worker in a run. Fields are updated
+// with sync/atomic and serialize into the output envelope.
+type runStats struct {
+	// ThreadsDiscovered counts thread URLs queued for scraping, from discovery or input
+	ThreadsDiscovered int64 `json:"threads_discovered"`
+	// ThreadsFromReferences counts threads scraped because another thread linked to them
+	ThreadsFromReferences int64 `json:"threads_from_references"`
+	// IndexPagesFetched counts index pages, feeds and sitemap files fetched during discovery
+	IndexPagesFetched int64 `json:"index_pages_fetched"`
+	// ExcludedURLs counts thread links rejected by the URL filters
+	ExcludedURLs int64 `json:"excluded_urls"`
+	// DuplicatePosts counts posts dropped by --dedupe-posts
+	DuplicatePosts int64 `json:"duplicate_posts"`
+	// RedactedEmails and RedactedPhones count --redact-pii replacements
+	RedactedEmails int64 `json:"redacted_emails"`
+	RedactedPhones int64 `json:"redacted_phones"`
+	// PostsSkipped counts posts dropped by the post processors, e.g. for length
+	PostsSkipped int64 `json:"posts_skipped"`
+	// ProcessorErrors counts errors returned by post processors
+	ProcessorErrors int64 `json:"processor_errors"`
+	// BackoffIncreases counts times a host's adaptive delay was doubled
+	BackoffIncreases int64 `json:"backoff_increases"`
+	// BytesTransferred counts response bytes as received, before decompression
+	BytesTransferred int64 `json:"bytes_transferred"`
+	// BytesDecoded counts response bytes after decompression
+	BytesDecoded int64 `json:"bytes_decoded"`
+	// Timeouts counts requests that hit a connect, header or request timeout
+	Timeouts int64 `json:"timeouts"`
+	// DeduplicatedThreads counts thread URLs skipped because the run already scraped them
+	DeduplicatedThreads int64 `json:"deduplicated_threads"`
+	// PreviouslyExported counts threads skipped because a --skip-from file holds them
+	PreviouslyExported int64 `json:"previously_exported,omitempty"`
+	// CandidatesDiscarded counts discovered threads --prioritize ranked below --max-threads
+	CandidatesDiscarded int64 `json:"candidates_discarded,omitempty"`
+	// PagesCompacted counts large thread pages --selective-parse pruned before extraction
+	PagesCompacted int64 `json:"pages_compacted,omitempty"`
+	// RequestRetries counts requests made again after a transient failure, with --retries
+	RequestRetries int64 `json:"request_retries,omitempty"`
+	// DNSLookups counts host resolutions; DNSCacheHits counts dials that reused one,
+	// saving the DNSTimeSavedUS microseconds the original lookups took
+	DNSLookups     int64 `json:"dns_lookups,omitempty"`
+	DNSCacheHits   int64 `json:"dns_cache_hits,omitempty"`
+	DNSTimeSavedUS int64 `json:"dns_time_saved_us,omitempty"`
+	// DNSFallbacks counts failed lookups retried against the system resolver
+	DNSFallbacks int64 `json:"dns_fallbacks,omitempty"`
+	// PrecheckRequests counts --precheck header requests; PrecheckAvoided counts
+	// threads they skipped without a full fetch
+	PrecheckRequests int64 `json:"precheck_requests,omitempty"`
+	PrecheckAvoided  int64 `json:"precheck_avoided,omitempty"`
+	// InvalidLinks counts distinct hrefs dropped during discovery for a scheme that
+	// can't be fetched (javascript:, mailto:, tel:) or for failing to parse
+	InvalidLinks int64 `json:"invalid_links,omitempty"`
+	// UndatedPosts counts posts --window kept because their timestamp couldn't be parsed
+	UndatedPosts int64 `json:"undated_posts,omitempty"`
+}
+
+// snapshot returns a consistent copy of the counters
+func (s *runStats) snapshot() runStats {
+	return runStats{
+		ThreadsDiscovered:     atomic.LoadInt64(&s.ThreadsDiscovered),
+		ThreadsFromReferences: atomic.LoadInt64(&s.ThreadsFromReferences),
+		IndexPagesFetched:     atomic.LoadInt64(&s.IndexPagesFetched),
+		ExcludedURLs:          atomic.LoadInt64(&s.ExcludedURLs),
+		DuplicatePosts:        atomic.LoadInt64(&s.DuplicatePosts),
+		RedactedEmails:        atomic.LoadInt64(&s.RedactedEmails),
+		RedactedPhones:        atomic.LoadInt64(&s.RedactedPhones),
+		PostsSkipped:          atomic.LoadInt64(&s.PostsSkipped),
+		ProcessorErrors:       atomic.LoadInt64(&s.ProcessorErrors),
+		BackoffIncreases:      atomic.LoadInt64(&s.BackoffIncreases),
+		BytesTransferred:      atomic.LoadInt64(&s.BytesTransferred),
+		BytesDecoded:          atomic.LoadInt64(&s.BytesDecoded),
+		Timeouts:              atomic.LoadInt64(&s.Timeouts),
+		DeduplicatedThreads:   atomic.LoadInt64(&s.DeduplicatedThreads),
+		PreviouslyExported:    atomic.LoadInt64(&s.PreviouslyExported),
+		CandidatesDiscarded:   atomic.LoadInt64(&s.CandidatesDiscarded),
+		PagesCompacted:        atomic.LoadInt64(&s.PagesCompacted),
+		RequestRetries:        atomic.LoadInt64(&s.RequestRetries),
+		DNSLookups:            atomic.LoadInt64(&s.DNSLookups),
+		DNSCacheHits:          atomic.LoadInt64(&s.DNSCacheHits),
+		DNSTimeSavedUS:        atomic.LoadInt64(&s.DNSTimeSavedUS),
+		DNSFallbacks:          atomic.LoadInt64(&s.DNSFallbacks),
+		PrecheckRequests:      atomic.LoadInt64(&s.PrecheckRequests),
+		PrecheckAvoided:       atomic.LoadInt64(&s.PrecheckAvoided),
+		InvalidLinks:          atomic.LoadInt64(&s.InvalidLinks),
+		UndatedPosts:          atomic.LoadInt64(&s.UndatedPosts),
+	}
+}
+
+// printSummary writes the counters as status lines
+func (s *runStats) printSummary(w io.Writer) {
+	stats := s.snapshot()
+	fmt.Fprintf(w, "📊 Threads discovered: %d\n", stats.ThreadsDiscovered)
+	if stats.ThreadsFromReferences > 0 {
+		fmt.Fprintf(w, "🔗 Threads added by following references: %d\n", stats.ThreadsFromReferences)
+	}
+	fmt.Fprintf(w, "📊 Index pages crawled: %d\n", stats.IndexPagesFetched)
+	fmt.Fprintf(w, "📊 URLs excluded by filters: %d\n", stats.ExcludedURLs)
+	if stats.InvalidLinks > 0 {
+		fmt.Fprintf(w, "🚫 Links skipped as unfetchable or malformed: %d\n", stats.InvalidLinks)
+	}
+	if stats.Timeouts > 0 {
+		fmt.Fprintf(w, "⏱️ Requests timed out: %d\n", stats.Timeouts)
+	}
+	if stats.DeduplicatedThreads > 0 {
+		fmt.Fprintf(w, "📊 Threads deduplicated: %d\n", stats.DeduplicatedThreads)
+	}
+	if stats.PreviouslyExported > 0 {
+		fmt.Fprintf(w, "⏭️ Threads skipped as previously exported: %d\n", stats.PreviouslyExported)
+	}
+	if stats.PagesCompacted > 0 {
+		fmt.Fprintf(w, "✂️ Large pages parsed selectively: %d\n", stats.PagesCompacted)
+	}
+	if stats.DuplicatePosts > 0 {
+		fmt.Fprintf(w, "📊 Duplicate posts dropped: %d\n", stats.DuplicatePosts)
+	}
+	if stats.RedactedEmails > 0 || stats.RedactedPhones > 0 {
+		fmt.Fprintf(w, "📊 PII redacted: %d email(s), %d phone number(s)\n", stats.RedactedEmails, stats.RedactedPhones)
+	}
+	if stats.PostsSkipped > 0 {
+		fmt.Fprintf(w, "📊 Posts skipped by processors: %d\n", stats.PostsSkipped)
+	}
+	if stats.UndatedPosts > 0 {
+		fmt.Fprintf(w, "📅 Posts kept by --window with an unparseable date: %d\n", stats.UndatedPosts)
+	}
+	if stats.ProcessorErrors > 0 {
+		fmt.Fprintf(w, "⚠️ Post processor errors: %d\n", stats.ProcessorErrors)
+	}
+	if stats.BackoffIncreases > 0 {
+		fmt.Fprintf(w, "📊 Adaptive backoff increases: %d\n", stats.BackoffIncreases)
+	}
+	if stats.PrecheckRequests > 0 {
+		fmt.Fprintf(w, "🪶 Pre-check avoided %d full fetch(es) with %d header request(s)\n", stats.PrecheckAvoided, stats.PrecheckRequests)
+	}
+	if stats.RequestRetries > 0 {
+		fmt.Fprintf(w, "🔁 Requests retried: %d\n", stats.RequestRetries)
+	}
+	if stats.DNSLookups > 0 {
+		fmt.Fprintf(w, "🌐 DNS: %d lookup(s), %d cache hit(s), %v saved\n",
+			stats.DNSLookups, stats.DNSCacheHits, time.Duration(stats.DNSTimeSavedUS)*time.Microsecond)
+	}
+	if stats.DNSFallbacks > 0 {
+		fmt.Fprintf(w, "⚠️ DNS lookups retried against the system resolver: %d\n", stats.DNSFallbacks)
+	}
+	if stats.BytesDecoded > 0 {
+		saved := 100 * float64(stats.BytesDecoded-stats.BytesTransferred) / float64(stats.BytesDecoded)
+		fmt.Fprintf(w, "📊 Bandwidth: %.1f KB transferred, %.1f KB decoded (%.0f%% saved by compression)\n",
+			float64(stats.BytesTransferred)/1024, float64(stats.BytesDecoded)/1024, saved)
+	}
+}
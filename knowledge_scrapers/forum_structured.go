@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Metadata sources recorded in Provenance.MetadataSources, in order of precedence
+const (
+	metadataSourceJSONLD    = "json-ld"
+	metadataSourceOpenGraph = "opengraph"
+	metadataSourceSelector  = "selector"
+	// metadataSourcePreloaded marks counts read from a Discourse page's preloaded topic JSON
+	metadataSourcePreloaded = "preloaded"
+	metadataSourceRegex     = "regex"
+	// metadataSourceIndex marks counts taken from the index the thread was listed on
+	metadataSourceIndex = "index"
+)
+
+// structuredThreadTypes are the schema.org types that describe a thread
+var structuredThreadTypes = map[string]bool{
+	"DiscussionForumPosting": true,
+	"SocialMediaPosting":     true,
+	"Question":               true,
+}
+
+// jsonLDNodes flattens the JSON-LD blocks of a page into their nodes, looking
+// inside arrays, @graph and QAPage mainEntity. Blocks that don't parse are skipped.
+func jsonLDNodes(doc *goquery.Document) []map[string]interface{} {
+	var nodes []map[string]interface{}
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		switch v := value.(type) {
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		case map[string]interface{}:
+			nodes = append(nodes, v)
+			walk(v["@graph"])
+			walk(v["mainEntity"])
+		}
+	}
+	doc.Find(`script[type="application/ld+json"]`).Each(func(i int, s *goquery.Selection) {
+		var value interface{}
+		if err := json.Unmarshal([]byte(s.Text()), &value); err == nil {
+			walk(value)
+		}
+	})
+	return nodes
+}
+
+// jsonLDTypes returns a node's @type, which may be a string or a list
+func jsonLDTypes(node map[string]interface{}) []string {
+	switch v := node["@type"].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var types []string
+		for _, t := range v {
+			if s, ok := t.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// jsonLDString reads a text value, taking the name of an object (as authors are
+// given) and the first entry of a list
+func jsonLDString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return validUTF8(strings.TrimSpace(v))
+	case map[string]interface{}:
+		return jsonLDString(v["name"])
+	case []interface{}:
+		if len(v) > 0 {
+			return jsonLDString(v[0])
+		}
+	}
+	return ""
+}
+
+// jsonLDInt reads a count given as a number or a numeric string
+func jsonLDInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int(v), true
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// interactionCounts reads view and reply counts from a node's interactionStatistic,
+// whose interactionType may be a URL, a bare type name or a typed object
+func interactionCounts(node map[string]interface{}) (views, replies int, hasViews, hasReplies bool) {
+	statistics, ok := node["interactionStatistic"].([]interface{})
+	if !ok {
+		if single, isMap := node["interactionStatistic"].(map[string]interface{}); isMap {
+			statistics = []interface{}{single}
+		}
+	}
+	for _, entry := range statistics {
+		statistic, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		interactionType := jsonLDString(statistic["interactionType"])
+		if typed, isMap := statistic["interactionType"].(map[string]interface{}); isMap {
+			interactionType = jsonLDString(typed["@type"])
+		}
+		interactionType = interactionType[strings.LastIndex(interactionType, "/")+1:]
+		count, ok := jsonLDInt(statistic["userInteractionCount"])
+		if !ok {
+			continue
+		}
+		switch interactionType {
+		case "ViewAction", "WatchAction":
+			views, hasViews = count, true
+		case "CommentAction", "ReplyAction":
+			replies, hasReplies = count, true
+		}
+	}
+	return views, replies, hasViews, hasReplies
+}
+
+// applyJSONLD overlays the first thread node's title, author, creation date and
+// counts onto metadata
+func applyJSONLD(doc *goquery.Document, metadata map[string]interface{}, sources map[string]string) {
+	for _, node := range jsonLDNodes(doc) {
+		isThread := false
+		for _, t := range jsonLDTypes(node) {
+			isThread = isThread || structuredThreadTypes[t]
+		}
+		if !isThread {
+			continue
+		}
+
+		set := func(key string, value interface{}) {
+			metadata[key] = value
+			sources[key] = metadataSourceJSONLD
+		}
+		if title := jsonLDString(node["headline"]); title != "" {
+			set("title", title)
+		} else if title := jsonLDString(node["name"]); title != "" {
+			set("title", title)
+		}
+		if author := jsonLDString(node["author"]); author != "" {
+			set("author", author)
+		}
+		if created := jsonLDString(node["datePublished"]); created != "" {
+			set("created_at", created)
+		} else if created := jsonLDString(node["dateCreated"]); created != "" {
+			set("created_at", created)
+		}
+		views, replies, hasViews, hasReplies := interactionCounts(node)
+		if hasViews {
+			set("views_count", views)
+		}
+		if hasReplies {
+			set("replies_count", replies)
+		} else if count, ok := jsonLDInt(node["commentCount"]); ok {
+			set("replies_count", count)
+		} else if count, ok := jsonLDInt(node["answerCount"]); ok {
+			set("replies_count", count)
+		}
+		return
+	}
+}
+
+// applyOpenGraph overlays og:title, article:author and article:published_time onto
+// metadata. Authors given as profile URLs are ignored.
+func applyOpenGraph(doc *goquery.Document, metadata map[string]interface{}, sources map[string]string) {
+	meta := func(property string) string {
+		content, _ := doc.Find(`meta[property="` + property + `"]`).First().Attr("content")
+		return validUTF8(strings.TrimSpace(content))
+	}
+	for key, value := range map[string]string{
+		"title":      meta("og:title"),
+		"author":     meta("article:author"),
+		"created_at": meta("article:published_time"),
+	} {
+		if value == "" || (key == "author" && strings.Contains(value, "://")) {
+			continue
+		}
+		metadata[key] = value
+		sources[key] = metadataSourceOpenGraph
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// summaryTopAuthors is how many authors the run summary ranks
+const summaryTopAuthors = 20
+
+// AuthorCount is one entry of the summary's top-authors list
+type AuthorCount struct {
+	Author string `json:"author"`
+	Posts  int    `json:"posts"`
+}
+
+// RunSummary is the analytical summary written under "stats" in the results envelope
+type RunSummary struct {
+	Threads              int           `json:"threads"`
+	Posts                int           `json:"posts"`
+	PostsPerThreadMin    int           `json:"posts_per_thread_min"`
+	PostsPerThreadMed    float64       `json:"posts_per_thread_median"`
+	PostsPerThreadMax    int           `json:"posts_per_thread_max"`
+	TopAuthors           []AuthorCount `json:"top_authors"`
+	BusiestCategory      string        `json:"busiest_category,omitempty"`
+	BusiestCategoryPosts int           `json:"busiest_category_posts,omitempty"`
+	EarliestPost         *time.Time    `json:"earliest_post,omitempty"`
+	LatestPost           *time.Time    `json:"latest_post,omitempty"`
+	AvgPostLength        float64       `json:"avg_post_length"`
+}
+
+// summaryTopThreads is how many longest and most-viewed threads are kept by default
+const summaryTopThreads = 10
+
+// summaryAccumulator keeps running aggregates as threads complete, so the summary
+// never needs a second pass over the output. Only the per-thread post counts are
+// kept individually, for the median. The in-run summary, merge and the stats
+// subcommand all aggregate through it.
+type summaryAccumulator struct {
+	mu             sync.Mutex
+	postsPerThread []int
+	authors        map[string]*GroupCount
+	categories     map[string]*GroupCount
+	months         map[string]*GroupCount
+	totalRunes     int
+	earliest       *time.Time
+	latest         *time.Time
+	// topThreads is how many longest and most-viewed threads to keep, summaryTopThreads when zero
+	topThreads int
+	longest    []ThreadStat
+	mostViewed []ThreadStat
+}
+
+// group returns the named entry of groups, adding it on first use
+func group(groups map[string]*GroupCount, name string) *GroupCount {
+	entry, exists := groups[name]
+	if !exists {
+		entry = &GroupCount{Name: name}
+		groups[name] = entry
+	}
+	return entry
+}
+
+// add folds one thread into the aggregates
+func (a *summaryAccumulator) add(thread *ForumThread) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.authors == nil {
+		a.authors = make(map[string]*GroupCount)
+		a.categories = make(map[string]*GroupCount)
+		a.months = make(map[string]*GroupCount)
+	}
+
+	a.postsPerThread = append(a.postsPerThread, len(thread.Posts))
+	if thread.Category != "" {
+		category := group(a.categories, thread.Category)
+		category.Threads++
+		category.Posts += len(thread.Posts)
+	}
+	var started *time.Time
+	threadAuthors := make(map[string]bool)
+	for _, post := range thread.Posts {
+		author := group(a.authors, post.Author)
+		author.Posts++
+		if !threadAuthors[post.Author] {
+			threadAuthors[post.Author] = true
+			author.Threads++
+		}
+		a.totalRunes += utf8.RuneCountInString(post.Content)
+		if t := parseLastMod(post.Timestamp); t != nil {
+			group(a.months, t.Format("2006-01")).Posts++
+			if started == nil || t.Before(*started) {
+				started = t
+			}
+			if a.earliest == nil || t.Before(*a.earliest) {
+				a.earliest = t
+			}
+			if a.latest == nil || t.After(*a.latest) {
+				a.latest = t
+			}
+		}
+	}
+	// A thread counts toward the month its earliest dated post was written in
+	if started != nil {
+		group(a.months, started.Format("2006-01")).Threads++
+	}
+
+	limit := a.topThreads
+	if limit == 0 {
+		limit = summaryTopThreads
+	}
+	stat := ThreadStat{URL: thread.URL, Title: thread.Title, Posts: len(thread.Posts)}
+	if thread.ViewsCount != nil {
+		stat.Views = *thread.ViewsCount
+	}
+	a.longest = keepTop(a.longest, stat, limit, func(x, y ThreadStat) bool { return x.Posts > y.Posts })
+	if thread.ViewsCount != nil {
+		a.mostViewed = keepTop(a.mostViewed, stat, limit, func(x, y ThreadStat) bool { return x.Views > y.Views })
+	}
+}
+
+// keepTop inserts stat into top, which is ordered by ranks (ties by URL), and
+// trims it to limit entries
+func keepTop(top []ThreadStat, stat ThreadStat, limit int, ranks func(x, y ThreadStat) bool) []ThreadStat {
+	before := func(x, y ThreadStat) bool {
+		if ranks(x, y) != ranks(y, x) {
+			return ranks(x, y)
+		}
+		return x.URL < y.URL
+	}
+	i := sort.Search(len(top), func(i int) bool { return before(stat, top[i]) })
+	if i >= limit {
+		return top
+	}
+	top = append(top, ThreadStat{})
+	copy(top[i+1:], top[i:])
+	top[i] = stat
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	return top
+}
+
+// summary computes the summary from the aggregates so far
+func (a *summaryAccumulator) summary() RunSummary {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	s := RunSummary{Threads: len(a.postsPerThread), EarliestPost: a.earliest, LatestPost: a.latest, TopAuthors: []AuthorCount{}}
+	if s.Threads == 0 {
+		return s
+	}
+
+	counts := append([]int(nil), a.postsPerThread...)
+	sort.Ints(counts)
+	for _, n := range counts {
+		s.Posts += n
+	}
+	s.PostsPerThreadMin = counts[0]
+	s.PostsPerThreadMax = counts[len(counts)-1]
+	if mid := len(counts) / 2; len(counts)%2 == 1 {
+		s.PostsPerThreadMed = float64(counts[mid])
+	} else {
+		s.PostsPerThreadMed = float64(counts[mid-1]+counts[mid]) / 2
+	}
+	if s.Posts > 0 {
+		s.AvgPostLength = float64(a.totalRunes) / float64(s.Posts)
+	}
+
+	for author, counts := range a.authors {
+		s.TopAuthors = append(s.TopAuthors, AuthorCount{Author: author, Posts: counts.Posts})
+	}
+	sort.Slice(s.TopAuthors, func(i, j int) bool {
+		if s.TopAuthors[i].Posts != s.TopAuthors[j].Posts {
+			return s.TopAuthors[i].Posts > s.TopAuthors[j].Posts
+		}
+		return s.TopAuthors[i].Author < s.TopAuthors[j].Author
+	})
+	if len(s.TopAuthors) > summaryTopAuthors {
+		s.TopAuthors = s.TopAuthors[:summaryTopAuthors]
+	}
+
+	for category, counts := range a.categories {
+		if posts := counts.Posts; posts > s.BusiestCategoryPosts || (posts == s.BusiestCategoryPosts && category < s.BusiestCategory) {
+			s.BusiestCategory, s.BusiestCategoryPosts = category, posts
+		}
+	}
+	return s
+}
+
+// printTable writes the summary as a human-readable table
+func (a *summaryAccumulator) printTable(w io.Writer) {
+	s := a.summary()
+	if s.Threads == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n📈 Run summary\n")
+	fmt.Fprintf(w, "   %-28s %d / %.1f / %d\n", "Posts/thread (min/med/max):", s.PostsPerThreadMin, s.PostsPerThreadMed, s.PostsPerThreadMax)
+	fmt.Fprintf(w, "   %-28s %.0f characters\n", "Average post length:", s.AvgPostLength)
+	if s.BusiestCategory != "" {
+		fmt.Fprintf(w, "   %-28s %s (%d posts)\n", "Busiest category:", s.BusiestCategory, s.BusiestCategoryPosts)
+	}
+	if s.EarliestPost != nil {
+		fmt.Fprintf(w, "   %-28s %s to %s\n", "Date range:", s.EarliestPost.Format("2006-01-02"), s.LatestPost.Format("2006-01-02"))
+	}
+	fmt.Fprintf(w, "   Top authors:\n")
+	for i, author := range s.TopAuthors {
+		fmt.Fprintf(w, "   %3d. %-30s %d\n", i+1, author.Author, author.Posts)
+	}
+}
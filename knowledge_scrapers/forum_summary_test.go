@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// summaryThread returns a thread in category whose posts are author/content/timestamp triples
+func summaryThread(url, category string, posts ...[3]string) *ForumThread {
+	thread := &ForumThread{URL: url, Title: url, Category: category}
+	for i, p := range posts {
+		thread.Posts = append(thread.Posts, ForumPost{Author: p[0], Content: p[1], Timestamp: p[2], PostNumber: i + 1})
+	}
+	return thread
+}
+
+func TestRunSummary(t *testing.T) {
+	threads := []*ForumThread{
+		summaryThread("t1", "Hardware",
+			[3]string{"alice", "abcd", "2024-01-05T10:00:00Z"},
+			[3]string{"bob", "héllo", "2024-01-06T10:00:00Z"},
+			[3]string{"alice", "xy", "2024-02-01T10:00:00Z"}),
+		summaryThread("t2", "Software",
+			[3]string{"bob", "123456", "2023-12-31T23:00:00Z"},
+			[3]string{"carol", "z", ""}),
+		summaryThread("t3", "Hardware",
+			[3]string{"dave", "ab", "2024-03-01T00:00:00Z"}),
+		// Uncategorised and undated
+		summaryThread("t4", "",
+			[3]string{"erin", "a", ""}, [3]string{"erin", "a", ""},
+			[3]string{"erin", "a", ""}, [3]string{"erin", "a", ""}),
+	}
+	var acc summaryAccumulator
+	for _, thread := range threads {
+		acc.add(thread)
+	}
+	s := acc.summary()
+
+	earliest := time.Date(2023, 12, 31, 23, 0, 0, 0, time.UTC)
+	latest := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	want := RunSummary{
+		Threads:           4,
+		Posts:             10,
+		PostsPerThreadMin: 1,
+		PostsPerThreadMed: 2.5,
+		PostsPerThreadMax: 4,
+		// Ties rank alphabetically
+		TopAuthors:           []AuthorCount{{"erin", 4}, {"alice", 2}, {"bob", 2}, {"carol", 1}, {"dave", 1}},
+		BusiestCategory:      "Hardware",
+		BusiestCategoryPosts: 4,
+		EarliestPost:         &earliest,
+		LatestPost:           &latest,
+		// 24 characters, counting é as one
+		AvgPostLength: 2.4,
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("summary = %+v\nwant %+v", s, want)
+	}
+
+	var longest []string
+	for _, stat := range acc.longest {
+		longest = append(longest, stat.URL)
+	}
+	if want := []string{"t4", "t1", "t2", "t3"}; !reflect.DeepEqual(longest, want) {
+		t.Errorf("longest threads %v, want %v", longest, want)
+	}
+}
+
+func TestRunSummaryEmpty(t *testing.T) {
+	var acc summaryAccumulator
+	s := acc.summary()
+	if s.Threads != 0 || s.TopAuthors == nil || len(s.TopAuthors) != 0 || s.EarliestPost != nil {
+		t.Errorf("empty summary = %+v, want no threads and an empty top-authors list", s)
+	}
+}
+
+func TestRunSummaryTopAuthorsCapped(t *testing.T) {
+	var acc summaryAccumulator
+	thread := &ForumThread{URL: "t1"}
+	for i := 0; i < summaryTopAuthors+5; i++ {
+		// author00 writes the most posts, author24 the fewest
+		for n := 0; n <= summaryTopAuthors+5-i; n++ {
+			thread.Posts = append(thread.Posts, ForumPost{Author: fmt.Sprintf("author%02d", i), Content: "post"})
+		}
+	}
+	acc.add(thread)
+
+	top := acc.summary().TopAuthors
+	if len(top) != summaryTopAuthors {
+		t.Fatalf("got %d top authors, want %d", len(top), summaryTopAuthors)
+	}
+	if first, last := top[0].Author, top[len(top)-1].Author; first != "author00" || last != fmt.Sprintf("author%02d", summaryTopAuthors-1) {
+		t.Errorf("top authors run %s to %s, want author00 to author%02d", first, last, summaryTopAuthors-1)
+	}
+}
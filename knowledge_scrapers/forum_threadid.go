@@ -0,0 +1,107 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// extractThreadID returns the platform's native ID for a thread URL (the first
+// non-empty group of its ThreadIDPattern), or "" when the platform has none
+func (fs *ForumScraperGo) extractThreadID(rawURL string) string {
+	config, exists := fs.configs[fs.platform]
+	if !exists {
+		config = fs.configs["generic"]
+	}
+	re := compiledConfigPattern(config.ThreadIDPattern)
+	if re == nil {
+		return ""
+	}
+	return matchThreadID(re, rawURL)
+}
+
+// matchThreadID returns the first non-empty group re matches in rawURL
+func matchThreadID(re *regexp.Regexp, rawURL string) string {
+	matches := re.FindStringSubmatch(urlPatternTarget(rawURL))
+	if matches == nil {
+		return ""
+	}
+	for _, group := range matches[1:] {
+		if group != "" {
+			return group
+		}
+	}
+	return ""
+}
+
+// builtinThreadIDPatterns are the built-in platforms' compiled ThreadIDPatterns,
+// nil for those without one, and the names of those with one in order
+var builtinThreadIDPatterns = sync.OnceValues(func() (map[string]*regexp.Regexp, []string) {
+	patterns := make(map[string]*regexp.Regexp)
+	var names []string
+	for name, config := range defaultPlatformConfigs() {
+		patterns[name] = nil
+		if config.ThreadIDPattern != "" {
+			patterns[name] = regexp.MustCompile(config.ThreadIDPattern)
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return patterns, names
+})
+
+// threadIDFromURL returns the thread ID in rawURL by platform's built-in
+// ThreadIDPattern, or for a platform that isn't built in (a merged or JSONL
+// file), by the first pattern in name order that matches
+func threadIDFromURL(platform, rawURL string) string {
+	patterns, names := builtinThreadIDPatterns()
+	if re, builtin := patterns[platform]; builtin {
+		if re == nil {
+			return ""
+		}
+		return matchThreadID(re, rawURL)
+	}
+	for _, name := range names {
+		if id := matchThreadID(patterns[name], rawURL); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// backfillThreadID gives a thread read from a file written before thread_id was
+// recorded the ID of its URL, so threadKey matches it with newer scrapes of it
+func backfillThreadID(thread *ForumThread, platform string) {
+	if thread.ThreadID == "" {
+		thread.ThreadID = threadIDFromURL(platform, thread.URL)
+	}
+}
+
+// threadIDKey combines a thread's host and native ID into a dedup key
+func threadIDKey(rawURL, threadID string) string {
+	return siteHost(hostOf(rawURL)) + "#" + threadID
+}
+
+// threadKey identifies a thread across runs: its host and native ID when known,
+// so a thread moved to another category or renamed keeps its identity, and its
+// normalized URL otherwise
+func threadKey(thread *ForumThread) string {
+	if thread.ThreadID == "" {
+		return normalizeURL(thread.URL)
+	}
+	return threadIDKey(thread.URL, thread.ThreadID)
+}
+
+// markThreadID records a thread's native ID as scraped, reporting false if the
+// same thread was already scraped under another URL
+func (fs *ForumScraperGo) markThreadID(rawURL, threadID string) bool {
+	key := threadIDKey(rawURL, threadID)
+	fs.visitedMutex.Lock()
+	if fs.visitedThreadIDs[key] {
+		fs.visitedMutex.Unlock()
+		return false
+	}
+	fs.visitedThreadIDs[key] = true
+	fs.visitedMutex.Unlock()
+	return fs.claimShared(visitedIDKey(rawURL, threadID))
+}
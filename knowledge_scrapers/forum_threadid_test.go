@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestThreadIDFromURL(t *testing.T) {
+	tests := []struct {
+		platform, url, want string
+	}{
+		{"phpbb", "https://forum.example.com/viewtopic.php?f=2&t=101", "101"},
+		{"discourse", "https://meta.example.com/t/some-topic/4417", "4417"},
+		{"generic", "https://forum.example.com/viewtopic.php?t=101", ""},
+		// Merged and JSONL files name no single platform
+		{"", "https://forum.example.com/viewtopic.php?f=2&t=101", "101"},
+		{"mixed", "https://news.ycombinator.com/item?id=8863", "8863"},
+		{"", "https://forum.example.com/about", ""},
+	}
+	for _, tt := range tests {
+		if got := threadIDFromURL(tt.platform, tt.url); got != tt.want {
+			t.Errorf("threadIDFromURL(%q, %s) = %q, want %q", tt.platform, tt.url, got, tt.want)
+		}
+	}
+}
+
+// An old file without thread_id still matches a newer scrape of the thread
+// after it moved to another forum
+func TestMergeMatchesThreadsWithoutID(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.json")
+	newPath := filepath.Join(dir, "new.jsonl")
+	old := `{"schema_version": "1.0", "forum_type": "phpbb", "threads": [
+		{"url": "https://forum.example.com/viewtopic.php?f=2&t=101", "title": "Router drops", "posts": [{"post_number": 1, "content": "first"}]}
+	]}`
+	current := `{"url": "https://forum.example.com/viewtopic.php?f=7&t=101", "thread_id": "101", "title": "Router drops", "posts": [{"post_number": 2, "content": "second"}]}` + "\n"
+	if err := os.WriteFile(oldPath, []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(newPath, []byte(current), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	merger := newThreadMerger()
+	var merged []*ForumThread
+	for _, pass := range []func(*ForumThread){merger.index, func(thread *ForumThread) {
+		if thread = merger.add(thread); thread != nil {
+			merged = append(merged, thread)
+		}
+	}} {
+		for _, path := range []string{oldPath, newPath} {
+			if _, err := readResultsFile(path, func(thread *ForumThread) error {
+				pass(thread)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if len(merged) != 1 || merger.duplicates != 1 {
+		t.Fatalf("merged into %d threads with %d duplicates, want 1 and 1", len(merged), merger.duplicates)
+	}
+	if posts := merged[0].Posts; len(posts) != 2 {
+		t.Errorf("merged thread has %d posts, want both copies' 2", len(posts))
+	}
+}
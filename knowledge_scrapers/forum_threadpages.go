@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// pagePathSuffix matches the page part of paths such as XenForo's /threads/x.123/page-2
+var pagePathSuffix = regexp.MustCompile(`/page-?\d+/?$`)
+
+// threadPages are the pages of one thread that were fetched
+type threadPages struct {
+	// leading holds the first page and the pages after it, in order
+	leading []*goquery.Document
+	// last is the thread's final page when --last-page fetched it past the leading pages
+	last *goquery.Document
+	// total is the thread's page count as far as its pagination showed
+	total int
+	// truncated reports that some of the thread's pages weren't fetched
+	truncated bool
+}
+
+// pageBase reduces a thread page URL to the thread's URL, without its page part
+func pageBase(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := u.Query()
+	query.Del("start")
+	query.Del("page")
+	u.RawQuery = query.Encode()
+	u.Path = pagePathSuffix.ReplaceAllString(u.Path, "")
+	return normalizeURL(u.String())
+}
+
+// threadPageLinks returns the 1-based number of pageURL and the links to other pages
+// of the same thread by page number, read from start= offsets or page numbers
+func threadPageLinks(doc *goquery.Document, pageURL string) (int, map[int]string) {
+	base := pageBase(pageURL)
+	starts := make(map[int]string)
+	numbers := make(map[int]string)
+	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		absolute, err := resolveURL(pageURL, href)
+		if err != nil || hostOf(absolute) != hostOf(pageURL) || pageBase(absolute) != base {
+			return
+		}
+		if matches := startParamPattern.FindStringSubmatch(absolute); len(matches) > 1 {
+			if start, err := strconv.Atoi(matches[1]); err == nil {
+				starts[start] = absolute
+			}
+		} else if matches := pageNumberPattern.FindStringSubmatch(absolute); len(matches) > 1 {
+			if number, err := strconv.Atoi(matches[1]); err == nil && number > 0 {
+				numbers[number] = absolute
+			}
+		}
+	})
+
+	currentStart := -1
+	if matches := startParamPattern.FindStringSubmatch(pageURL); len(matches) > 1 {
+		currentStart, _ = strconv.Atoi(matches[1])
+	}
+	if len(starts) > 0 || currentStart >= 0 {
+		// Offsets step by the page size, which is the smallest one seen
+		step := 0
+		for start := range starts {
+			if start > 0 && (step == 0 || start < step) {
+				step = start
+			}
+		}
+		if currentStart > 0 && (step == 0 || currentStart < step) {
+			step = currentStart
+		}
+		if step == 0 {
+			return 1, map[int]string{}
+		}
+		links := make(map[int]string)
+		for start, link := range starts {
+			links[start/step+1] = link
+		}
+		if currentStart < 0 {
+			currentStart = 0
+		}
+		return currentStart/step + 1, links
+	}
+
+	current := 1
+	if matches := pageNumberPattern.FindStringSubmatch(pageURL); len(matches) > 1 {
+		current, _ = strconv.Atoi(matches[1])
+	}
+	return current, numbers
+}
+
+// fetchThreadPages follows a thread's pagination from its first page, fetching up to
+// fs.maxPagesPerThread pages or until wantedPosts post elements are in hand, then
+// the final page too with --last-page. A page that fails to load ends the walk
+// with the pages fetched so far, as does ctx running out.
+func (fs *ForumScraperGo) fetchThreadPages(ctx context.Context, doc *goquery.Document, pageURL, postSelector string, wantedPosts int) threadPages {
+	pages := threadPages{leading: []*goquery.Document{doc}}
+	current, links := threadPageLinks(doc, pageURL)
+	fetched := map[int]bool{current: true}
+	total := current
+	for number := range links {
+		if number > total {
+			total = number
+		}
+	}
+
+	collected := findSelector(doc.Selection, postSelector).Length()
+	for len(pages.leading) < fs.maxPagesPerThread && collected < wantedPosts && !fs.budget.exhausted() && ctx.Err() == nil {
+		nextURL, exists := links[current+1]
+		if !exists {
+			break
+		}
+		// Rate limiting
+		fs.politeWaitContext(ctx, nextURL)
+
+		next, err := fs.fetchThreadDocument(ctx, nextURL)
+		if err != nil {
+			fs.statusf("⚠️ Failed to fetch thread page %d of %s: %v\n", current+1, pageURL, err)
+			break
+		}
+		current++
+		fetched[current] = true
+		pages.leading = append(pages.leading, next)
+		collected += findSelector(next.Selection, postSelector).Length()
+		_, nextLinks := threadPageLinks(next, nextURL)
+		for number, link := range nextLinks {
+			if _, exists := links[number]; !exists {
+				links[number] = link
+			}
+			if number > total {
+				total = number
+			}
+		}
+	}
+
+	// The last page keeps LastPostAt true to the thread when the middle is skipped
+	if lastURL, exists := links[total]; fs.lastPage && wantedPosts > 1 && !fetched[total] && exists && !fs.budget.exhausted() && ctx.Err() == nil {
+		// Rate limiting
+		fs.politeWaitContext(ctx, lastURL)
+
+		if last, err := fs.fetchThreadDocument(ctx, lastURL); err != nil {
+			fs.statusf("⚠️ Failed to fetch the last page of %s: %v\n", pageURL, err)
+		} else {
+			pages.last = last
+			fetched[total] = true
+		}
+	}
+
+	pages.total = total
+	pages.truncated = len(fetched) < total
+	return pages
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// Timeout defaults match the single 30s client timeout requests used to share
+const (
+	defaultConnectTimeout = 30 * time.Second
+	defaultRequestTimeout = 30 * time.Second
+)
+
+// defaultThreadTimeout leaves room for long threads fetched at a polite pace
+const defaultThreadTimeout = 10 * time.Minute
+
+// cancelOnClose releases a request's timeout context once its body is closed
+type cancelOnClose struct {
+	io.Closer
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	err := c.Closer.Close()
+	c.cancel()
+	return err
+}
+
+// requestContext bounds one request, body included, by --request-timeout
+func (fs *ForumScraperGo) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if fs.requestTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, fs.requestTimeout)
+}
+
+// threadContext bounds one thread's fetches, pagination included, by --thread-timeout
+func (fs *ForumScraperGo) threadContext() (context.Context, context.CancelFunc) {
+	if fs.threadTimeout <= 0 {
+		return context.WithCancel(fs.runContext())
+	}
+	return context.WithTimeout(fs.runContext(), fs.threadTimeout)
+}
+
+// threadTimedOut reports whether ctx, from threadContext, ran out of time while
+// the run itself carries on
+func (fs *ForumScraperGo) threadTimedOut(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.DeadlineExceeded) && fs.runContext().Err() == nil
+}
+
+// threadTimeoutError reports a first-page failure caused by --thread-timeout as
+// ErrThreadTimeout; other errors pass through
+func (fs *ForumScraperGo) threadTimeoutError(ctx context.Context, err error, threadURL string) error {
+	if err == nil || !fs.threadTimedOut(ctx) {
+		return err
+	}
+	return fmt.Errorf("%w after %v: %s: %v", ErrThreadTimeout, fs.threadTimeout, threadURL, err)
+}
+
+// noteThreadTimeout records in provenance that --thread-timeout cut the thread
+// short after progress, such as "2 of 5 pages", keeping what was gathered
+func (fs *ForumScraperGo) noteThreadTimeout(threadURL string, provenance *Provenance, progress string) {
+	provenance.Note = fmt.Sprintf("thread timeout (%v) after %s", fs.threadTimeout, progress)
+	fs.statusf("⏱️ %s: %s\n", threadURL, provenance.Note)
+}
+
+// isTimeout reports whether err is a connect, header or request timeout
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// classifyTimeout wraps timeouts in ErrTimeout and counts them, so network stalls
+// are reported apart from parse failures
+func (fs *ForumScraperGo) classifyTimeout(err error, rawURL string) error {
+	if err == nil || !isTimeout(err) || errors.Is(err, ErrTimeout) {
+		return err
+	}
+	atomic.AddInt64(&fs.stats.Timeouts, 1)
+	return fmt.Errorf("%w: %s: %v", ErrTimeout, rawURL, err)
+}
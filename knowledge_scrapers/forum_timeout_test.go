@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testThreadTimeout is short enough to keep the tests quick and far below
+// slowResponse, which stands in for a server trickling bytes
+const (
+	testThreadTimeout = 300 * time.Millisecond
+	slowResponse      = 5 * time.Second
+)
+
+// stall holds a response until slowResponse passes or the client gives up
+func stall(r *http.Request) {
+	select {
+	case <-r.Context().Done():
+	case <-time.After(slowResponse):
+	}
+}
+
+// slowPagesServer serves the phpBB fixture topic as the first of three pages;
+// the later pages stall
+func slowPagesServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(fixturesDir, "phpbb/viewtopic.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pagination := `<div class="pagination"><a href="./viewtopic.php?f=2&amp;t=101&amp;start=3">2</a> <a href="./viewtopic.php?f=2&amp;t=101&amp;start=6">3</a></div></body>`
+	page := strings.Replace(string(data), "</body>", pagination, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("start") != "" {
+			stall(r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, page)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestThreadTimeoutKeepsFetchedPages(t *testing.T) {
+	server := slowPagesServer(t)
+	scraper := NewForumScraper("phpbb", 0, WithThreadTimeout(testThreadTimeout))
+	scraper.statusOut = io.Discard
+	scraper.maxPagesPerThread = 3
+
+	started := time.Now()
+	thread, err := scraper.scrapeThread(server.URL+"/viewtopic.php?f=2&t=101", fixtureMaxPosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(started); elapsed > slowResponse/2 {
+		t.Errorf("scrape took %v, want it cut short near %v", elapsed, testThreadTimeout)
+	}
+	if len(thread.Posts) != 3 {
+		t.Errorf("got %d posts, want the first page's 3", len(thread.Posts))
+	}
+	if !thread.Truncated {
+		t.Error("thread not marked truncated")
+	}
+	if want := "thread timeout (300ms) after 1 of 3 pages"; thread.Provenance.Note != want {
+		t.Errorf("Note = %q, want %q", thread.Provenance.Note, want)
+	}
+}
+
+func TestThreadTimeoutCoversPoliteWait(t *testing.T) {
+	server, _ := phpbbTopicServer(t)
+	// A delay far past the timeout: the thread must give up during the wait
+	scraper := NewForumScraper("phpbb", slowResponse.Seconds(), WithThreadTimeout(testThreadTimeout))
+	scraper.statusOut = io.Discard
+
+	started := time.Now()
+	_, err := scraper.scrapeThread(server.URL+"/viewtopic.php?f=2&t=101", fixtureMaxPosts)
+	if !errors.Is(err, ErrThreadTimeout) {
+		t.Errorf("err = %v, want ErrThreadTimeout", err)
+	}
+	if elapsed := time.Since(started); elapsed > slowResponse/2 {
+		t.Errorf("scrape took %v, want the wait cut short near %v", elapsed, testThreadTimeout)
+	}
+}
+
+// slowHackerNewsAPI serves a story with three comments, the last of which stalls
+func slowHackerNewsAPI(t *testing.T) {
+	t.Helper()
+	items := map[string]hackerNewsItem{
+		"1": {ID: 1, Type: "story", By: "user1", Title: "Show HN: a tiny init system", Text: "It boots in 40ms.", Descendants: 3, Kids: []int{2, 3, 4}},
+		"2": {ID: 2, Type: "comment", By: "user2", Text: "How does it reap zombies?"},
+		"3": {ID: 3, Type: "comment", By: "user3", Text: "Nice work, the source is very readable."},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/item/"), ".json")
+		item, ok := items[id]
+		if !ok {
+			stall(r)
+			return
+		}
+		json.NewEncoder(w).Encode(item)
+	}))
+	t.Cleanup(server.Close)
+	api := hackerNewsAPI
+	hackerNewsAPI = server.URL
+	t.Cleanup(func() { hackerNewsAPI = api })
+}
+
+func TestThreadTimeoutHackerNews(t *testing.T) {
+	slowHackerNewsAPI(t)
+	scraper := NewForumScraper("hackernews", 0, WithThreadTimeout(testThreadTimeout))
+	scraper.statusOut = io.Discard
+
+	thread, err := scraper.scrapeThread(hackerNewsItemURL+"1", fixtureMaxPosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(thread.Posts) != 3 {
+		t.Errorf("got %d posts, want the story and the 2 comments that answered", len(thread.Posts))
+	}
+	if !thread.Truncated {
+		t.Error("thread not marked truncated")
+	}
+	if want := fmt.Sprintf("thread timeout (%v) after 3 of 4 posts", testThreadTimeout); thread.Provenance.Note != want {
+		t.Errorf("Note = %q, want %q", thread.Provenance.Note, want)
+	}
+}
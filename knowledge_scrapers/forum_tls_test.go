@@ -0,0 +1,86 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCustomCATrustsServer(t *testing.T) {
+	page, err := os.ReadFile(filepath.Join(fixturesDir, "phpbb/viewtopic.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page)
+	}))
+	// The refused handshake is expected; keep it out of the test output
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	topicURL := server.URL + "/viewtopic.php?f=2&t=101"
+
+	// The test server's self-signed certificate isn't in the system roots
+	config, err := loadTLSConfig("", "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scraper := NewForumScraper("phpbb", 0, WithTLSConfig(config), WithRetries(0))
+	scraper.statusOut = io.Discard
+	_, err = scraper.fetchDocument(topicURL)
+	var unknownAuthority x509.UnknownAuthorityError
+	if !errors.As(err, &unknownAuthority) {
+		t.Fatalf("fetch without the CA: err = %v, want an unknown authority error", err)
+	}
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caPath, caPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config, err = loadTLSConfig(caPath, "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scraper = NewForumScraper("phpbb", 0, WithTLSConfig(config))
+	scraper.statusOut = io.Discard
+	doc, err := scraper.fetchDocument(topicURL)
+	if err != nil {
+		t.Fatalf("fetch with the server's certificate as CA: %v", err)
+	}
+	if title := doc.Find("title").Text(); !strings.HasPrefix(title, "Kernel panic") {
+		t.Errorf("fetched page title %q, want the fixture topic", title)
+	}
+}
+
+func TestLoadTLSConfigRejectsBadFiles(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.txt")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name                          string
+		caCert, clientCert, clientKey string
+	}{
+		{"missing CA", filepath.Join(dir, "missing.pem"), "", ""},
+		{"CA without PEM", notPEM, "", ""},
+		{"cert without key", "", notPEM, ""},
+		{"key without cert", "", "", notPEM},
+		{"malformed key pair", "", notPEM, notPEM},
+	}
+	for _, tt := range tests {
+		if _, err := loadTLSConfig(tt.caCert, tt.clientCert, tt.clientKey, false); err == nil {
+			t.Errorf("%s: loadTLSConfig succeeded, want an error", tt.name)
+		}
+	}
+}
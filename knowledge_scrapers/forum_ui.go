@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Human-facing output goes through statusf and debugf to fs.statusOut, which is
+// stderr unless --quiet discards it. Stdout is left to data: --stdin JSONL,
+// --output -, discover's thread list.
+
+// statusf writes a status line
+func (fs *ForumScraperGo) statusf(format string, args ...interface{}) {
+	fmt.Fprintf(fs.statusOut, format, args...)
+}
+
+// debugf writes a debug line when --debug is on
+func (fs *ForumScraperGo) debugf(format string, args ...interface{}) {
+	if fs.debug {
+		fmt.Fprintf(fs.statusOut, "🐛 "+format+"\n", args...)
+	}
+}
+
+// Run outcomes reported by --summary-json
+const (
+	runCompleted      = "completed"
+	runBudgetStopped  = "stopped_by_budget"
+	summaryJSONStderr = "-"
+)
+
+// runReport is the machine-readable end-of-run summary --summary-json writes
+type runReport struct {
+	Status          string                   `json:"status"`
+	StoppedByBudget string                   `json:"stopped_by_budget,omitempty"`
+	Threads         int                      `json:"threads"`
+	Posts           int                      `json:"posts"`
+	Results         []string                 `json:"results,omitempty"`
+	Uploads         []string                 `json:"uploads,omitempty"`
+	Failures        map[string]int           `json:"failures,omitempty"`
+	Languages       map[string]int           `json:"languages,omitempty"`
+	Categories      map[string]categoryCount `json:"categories,omitempty"`
+	RunStats        runStats                 `json:"run_stats"`
+	Run             *RunMetadata             `json:"run,omitempty"`
+	PrioritizedBy   string                   `json:"prioritized_by,omitempty"`
+}
+
+// finishRun writes any --emit-authors profiles, prints the end-of-run summary,
+// writes --summary-json when summaryPath is set, and exits with exitBudgetStopped if the budget cut the run short
+func (fs *ForumScraperGo) finishRun(threads, posts int, results []string, summaryPath string) {
+	fs.saveAuthorProfiles()
+	fs.statusf("\n✅ Forum scraping completed successfully!\n")
+	fs.statusf("📊 Threads scraped: %d\n", threads)
+	fs.statusf("📊 Total posts: %d\n", posts)
+	fs.stats.printSummary(fs.statusOut)
+	fs.printPrioritization()
+	fs.languageCounts.printSummary(fs.statusOut)
+	fs.categories.printSummary(fs.statusOut)
+	fs.printPacingSummary(fs.statusOut)
+	fs.failures.printSummary(fs.statusOut)
+	fs.printUploads(fs.statusOut)
+	fs.summary.printTable(fs.statusOut)
+
+	if summaryPath != "" {
+		report := runReport{
+			Status:        runCompleted,
+			Threads:       threads,
+			Posts:         posts,
+			Results:       results,
+			Uploads:       fs.uploads,
+			Failures:      fs.failures.countSnapshot(),
+			Languages:     fs.languageCounts.snapshot(),
+			Categories:    fs.categories.snapshot(),
+			RunStats:      fs.stats.snapshot(),
+			Run:           fs.run.finished(fs),
+			PrioritizedBy: fs.prioritize,
+		}
+		if reason := fs.budgetStopReason(); reason != "" {
+			report.Status, report.StoppedByBudget = runBudgetStopped, reason
+		}
+		if err := writeRunReport(summaryPath, report); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️ Failed to write --summary-json: %v\n", err)
+		}
+	}
+	exitIfBudgetStopped(fs)
+}
+
+// writeRunReport writes the report as one JSON line on stderr for "-", or as an
+// indented document to a file
+func writeRunReport(path string, report runReport) error {
+	if path == summaryJSONStderr {
+		return json.NewEncoder(os.Stderr).Encode(report)
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'), 0644)
+}
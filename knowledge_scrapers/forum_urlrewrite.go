@@ -0,0 +1,76 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// URLRewrite is one of a platform's rules mapping alternate views of a thread
+// (print and display-mode views, post deep links, explicit first pages) to the
+// thread's own URL. Every match of Pattern is replaced with Replace, which may
+// refer to Pattern's groups as ${1}.
+type URLRewrite struct {
+	Pattern string
+	Replace string
+}
+
+// compiledURLRewrites caches compiled rewrite patterns by pattern
+var compiledURLRewrites sync.Map
+
+// compile compiles the rule's pattern, once per run
+func (rule URLRewrite) compile() (*regexp.Regexp, error) {
+	if re, ok := compiledURLRewrites.Load(rule.Pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(rule.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	compiledURLRewrites.Store(rule.Pattern, re)
+	return re, nil
+}
+
+// canonicalThreadURL rewrites rawURL with the platform's URLRewrites, after
+// dropping its fragment: an anchor only scrolls within the thread. It runs on
+// discovered links before they are queued and on every thread URL before the
+// visited check, so one thread reached through several views is scraped once.
+func (fs *ForumScraperGo) canonicalThreadURL(rawURL string) string {
+	config, exists := fs.configs[fs.platform]
+	if !exists {
+		config = fs.configs["generic"]
+	}
+
+	rewritten, _, _ := strings.Cut(rawURL, "#")
+	for _, rule := range config.URLRewrites {
+		re, err := rule.compile()
+		if err != nil {
+			continue
+		}
+		rewritten = re.ReplaceAllString(rewritten, rule.Replace)
+	}
+	rewritten = tidyQuery(rewritten)
+	if rewritten != rawURL {
+		fs.debugf("Rewrote %s to %s", rawURL, rewritten)
+	}
+	return rewritten
+}
+
+// tidyQuery drops the empty parameters that removing one from a query string
+// leaves behind, as in "?&t=5", "?t=5&&f=2" or a bare trailing "?"
+func tidyQuery(rawURL string) string {
+	base, query, found := strings.Cut(rawURL, "?")
+	if !found {
+		return rawURL
+	}
+	var params []string
+	for _, param := range strings.Split(query, "&") {
+		if param != "" {
+			params = append(params, param)
+		}
+	}
+	if len(params) == 0 {
+		return base
+	}
+	return base + "?" + strings.Join(params, "&")
+}
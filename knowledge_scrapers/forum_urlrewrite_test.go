@@ -0,0 +1,71 @@
+package main
+
+import (
+	"io"
+	"testing"
+)
+
+func TestCanonicalThreadURLRewrites(t *testing.T) {
+	tests := []struct {
+		platform, url, want string
+	}{
+		{"phpbb", "https://forum.example.com/viewtopic.php?f=2&t=101&view=print", "https://forum.example.com/viewtopic.php?f=2&t=101"},
+		{"phpbb", "https://forum.example.com/viewtopic.php?view=print&f=2&t=101", "https://forum.example.com/viewtopic.php?f=2&t=101"},
+		{"phpbb", "https://forum.example.com/viewtopic.php?f=2&t=101&view=unread#unread", "https://forum.example.com/viewtopic.php?f=2&t=101"},
+		{"phpbb", "https://forum.example.com/viewtopic.php?f=2&t=101&start=0", "https://forum.example.com/viewtopic.php?f=2&t=101"},
+		{"phpbb", "https://forum.example.com/viewtopic.php?f=2&t=101#p1002", "https://forum.example.com/viewtopic.php?f=2&t=101"},
+		// Later pages are their own URLs
+		{"phpbb", "https://forum.example.com/viewtopic.php?f=2&t=101&start=10", "https://forum.example.com/viewtopic.php?f=2&t=101&start=10"},
+
+		{"vbulletin", "https://forum.example.com/printthread.php?t=55", "https://forum.example.com/showthread.php?t=55"},
+		{"vbulletin", "https://forum.example.com/showthread.php?t=55&pp=40", "https://forum.example.com/showthread.php?t=55"},
+		{"vbulletin", "https://forum.example.com/showthread.php?t=55&mode=linear&page=2", "https://forum.example.com/showthread.php?t=55&page=2"},
+		{"vbulletin", "https://forum.example.com/showthread.php?t=55&page=1", "https://forum.example.com/showthread.php?t=55"},
+		{"vbulletin", "https://forum.example.com/printthread.php?t=55&pp=40&page=1", "https://forum.example.com/showthread.php?t=55"},
+		{"vbulletin", "https://forum.example.com/showthread.php?t=55&page=12", "https://forum.example.com/showthread.php?t=55&page=12"},
+
+		{"discourse", "https://forum.example.com/t/static-binary/4412/7", "https://forum.example.com/t/static-binary/4412"},
+		{"discourse", "https://forum.example.com/t/static-binary/4412/print", "https://forum.example.com/t/static-binary/4412"},
+		{"discourse", "https://forum.example.com/t/static-binary/4412/7?u=alice", "https://forum.example.com/t/static-binary/4412"},
+		{"discourse", "https://forum.example.com/t/static-binary/4412?page=1", "https://forum.example.com/t/static-binary/4412"},
+		{"discourse", "https://forum.example.com/t/static-binary/4412?page=2", "https://forum.example.com/t/static-binary/4412?page=2"},
+
+		{"xenforo", "https://forum.example.com/threads/kernel-panic.123/post-456", "https://forum.example.com/threads/kernel-panic.123/"},
+		{"xenforo", "https://forum.example.com/threads/kernel-panic.123/post-456#post-456", "https://forum.example.com/threads/kernel-panic.123/"},
+		{"xenforo", "https://forum.example.com/threads/kernel-panic.123/page-1", "https://forum.example.com/threads/kernel-panic.123/"},
+		{"xenforo", "https://forum.example.com/threads/kernel-panic.123/unread", "https://forum.example.com/threads/kernel-panic.123/"},
+		{"xenforo", "https://forum.example.com/threads/kernel-panic.123/latest", "https://forum.example.com/threads/kernel-panic.123/"},
+		{"xenforo", "https://forum.example.com/threads/kernel-panic.123/page-12", "https://forum.example.com/threads/kernel-panic.123/page-12"},
+
+		{"generic", "https://forum.example.com/printthread.php?t=9", "https://forum.example.com/showthread.php?t=9"},
+		{"generic", "https://forum.example.com/viewtopic.php?t=9&view=print", "https://forum.example.com/viewtopic.php?t=9"},
+		{"generic", "https://forum.example.com/viewtopic.php?t=9&start=0", "https://forum.example.com/viewtopic.php?t=9"},
+
+		// Platforms without rules only lose the fragment
+		{"reddit", "https://www.reddit.com/r/linux/comments/abc123/kernel_panic/?sort=top#comment", "https://www.reddit.com/r/linux/comments/abc123/kernel_panic/?sort=top"},
+	}
+	for _, tt := range tests {
+		scraper := NewForumScraper(tt.platform, 0)
+		scraper.statusOut = io.Discard
+		if got := scraper.canonicalThreadURL(tt.url); got != tt.want {
+			t.Errorf("%s: canonicalThreadURL(%s) = %s, want %s", tt.platform, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestTidyQuery(t *testing.T) {
+	tests := []struct {
+		url, want string
+	}{
+		{"https://forum.example.com/viewtopic.php?&t=5", "https://forum.example.com/viewtopic.php?t=5"},
+		{"https://forum.example.com/viewtopic.php?t=5&&f=2", "https://forum.example.com/viewtopic.php?t=5&f=2"},
+		{"https://forum.example.com/viewtopic.php?t=5&", "https://forum.example.com/viewtopic.php?t=5"},
+		{"https://forum.example.com/viewtopic.php?", "https://forum.example.com/viewtopic.php"},
+		{"https://forum.example.com/t/topic/1", "https://forum.example.com/t/topic/1"},
+	}
+	for _, tt := range tests {
+		if got := tidyQuery(tt.url); got != tt.want {
+			t.Errorf("tidyQuery(%s) = %s, want %s", tt.url, got, tt.want)
+		}
+	}
+}
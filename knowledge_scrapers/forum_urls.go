@@ -0,0 +1,152 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// sessionParams are query parameters that identify a visitor rather than a page
+var sessionParams = map[string]bool{
+	"sid":       true,
+	"s":         true,
+	"phpsessid": true,
+	"fbclid":    true,
+	"gclid":     true,
+}
+
+// resolveURL resolves href against the page it was found on
+func resolveURL(base, href string) (string, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return "", err
+	}
+	return baseURL.ResolveReference(ref).String(), nil
+}
+
+// resolveLink resolves an href found on pageURL for discovery, dropping it unless
+// it parses and resolves to an http(s) URL, or a file:// URL with local input.
+// Links to javascript:, mailto:, tel: and the like would otherwise be queued and
+// fail as fetch errors. Each dropped href is logged and counted once per run.
+func (fs *ForumScraperGo) resolveLink(pageURL, href string) (string, bool) {
+	href = strings.TrimSpace(href)
+	ref, err := url.Parse(href)
+	if err != nil {
+		fs.skipLink(href, pageURL, err.Error())
+		return "", false
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return "", false
+	}
+	resolved := base.ResolveReference(ref)
+	switch resolved.Scheme {
+	case "http", "https":
+		if resolved.Host != "" {
+			return resolved.String(), true
+		}
+	case "file":
+		if fs.localInput {
+			return resolved.String(), true
+		}
+	}
+	fs.skipLink(href, pageURL, "not a fetchable URL")
+	return "", false
+}
+
+// skipLink records an href resolveLink dropped, the first time it is seen
+func (fs *ForumScraperGo) skipLink(href, pageURL, reason string) {
+	if _, seen := fs.invalidLinks.LoadOrStore(href, true); seen {
+		return
+	}
+	atomic.AddInt64(&fs.stats.InvalidLinks, 1)
+	fs.debugf("Skipping link %q on %s: %s", href, pageURL, reason)
+}
+
+// normalizeURL reduces a URL to the form used for visited checks and deduplication:
+// session and tracking parameters removed, remaining query parameters sorted,
+// scheme and host lowercased, fragment and trailing slashes stripped.
+// URLs that fail to parse are returned unchanged.
+func normalizeURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return rawURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+
+	query := u.Query()
+	for key := range query {
+		lower := strings.ToLower(key)
+		if sessionParams[lower] || strings.HasPrefix(lower, "utm_") {
+			query.Del(key)
+		}
+	}
+	// Encode sorts by key
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// hostOf returns the lowercased host of rawURL, or "" if it can't be parsed
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// URLFilter restricts which discovered links may be followed
+type URLFilter struct {
+	// AllowHosts lists hosts links may point at; empty means the source's own host
+	AllowHosts []string
+	// Include, when set, must match a thread URL for it to be queued
+	Include *regexp.Regexp
+	// Exclude rejects any matching thread or pagination URL
+	Exclude *regexp.Regexp
+}
+
+// hostAllowed reports whether rawURL stays on an allowed host for links found under sourceURL
+func (f *URLFilter) hostAllowed(rawURL, sourceURL string) bool {
+	host := hostOf(rawURL)
+	if len(f.AllowHosts) == 0 {
+		return host == hostOf(sourceURL)
+	}
+	for _, allowed := range f.AllowHosts {
+		if host == strings.ToLower(allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowThreadURL applies the host allowlist and include/exclude patterns to a
+// thread link, counting rejections in the run stats
+func (fs *ForumScraperGo) allowThreadURL(rawURL, sourceURL string) bool {
+	f := &fs.urlFilter
+	allowed := f.hostAllowed(rawURL, sourceURL) &&
+		(f.Include == nil || f.Include.MatchString(rawURL)) &&
+		(f.Exclude == nil || !f.Exclude.MatchString(rawURL))
+	if !allowed {
+		atomic.AddInt64(&fs.stats.ExcludedURLs, 1)
+	}
+	return allowed
+}
+
+// allowPageURL applies the host allowlist and exclude pattern to a pagination
+// link so discovery never walks off-site
+func (fs *ForumScraperGo) allowPageURL(rawURL, sourceURL string) bool {
+	f := &fs.urlFilter
+	return f.hostAllowed(rawURL, sourceURL) && (f.Exclude == nil || !f.Exclude.MatchString(rawURL))
+}
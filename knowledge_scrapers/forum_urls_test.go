@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestNormalizeURL(t *testing.T) {
+	const canonical = "https://forum.example.com/viewtopic.php?f=2&t=101"
+	variants := []string{
+		"https://forum.example.com/viewtopic.php?f=2&t=101",
+		"https://forum.example.com/viewtopic.php?t=101&f=2",
+		"https://forum.example.com/viewtopic.php?f=2&t=101&sid=0123456789abcdef0123456789abcdef",
+		"https://forum.example.com/viewtopic.php?sid=0123456789abcdef0123456789abcdef&t=101&f=2",
+		"https://forum.example.com/viewtopic.php?f=2&t=101&SID=0123456789ABCDEF0123456789ABCDEF",
+		"https://forum.example.com/viewtopic.php?f=2&t=101&s=5f4dcc3b5aa765d61d8327deb882cf99",
+		"https://forum.example.com/viewtopic.php?f=2&t=101&PHPSESSID=abc123",
+		"https://forum.example.com/viewtopic.php?f=2&t=101&utm_source=newsletter&utm_medium=email",
+		"https://forum.example.com/viewtopic.php?f=2&t=101&fbclid=IwAR0abc&gclid=Cj0KCQ",
+		"https://forum.example.com/viewtopic.php?f=2&t=101#p1234",
+		"HTTPS://Forum.Example.COM/viewtopic.php?f=2&t=101",
+		"https://forum.example.com/viewtopic.php/?f=2&t=101",
+		"  https://forum.example.com/viewtopic.php?f=2&t=101&sid=deadbeef#unread  ",
+	}
+	for _, variant := range variants {
+		if got := normalizeURL(variant); got != canonical {
+			t.Errorf("normalizeURL(%q) = %q, want %q", variant, got, canonical)
+		}
+	}
+
+	// Parameters that pick a page or post are kept
+	distinct := []string{
+		"https://forum.example.com/viewtopic.php?f=2&t=101&start=25",
+		"https://forum.example.com/viewtopic.php?f=2&t=102",
+		"http://forum.example.com/viewtopic.php?f=2&t=101",
+	}
+	for _, other := range distinct {
+		if got := normalizeURL(other); got == canonical {
+			t.Errorf("normalizeURL(%q) = %q, the same as a different page", other, got)
+		}
+	}
+}
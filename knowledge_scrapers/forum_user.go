@@ -0,0 +1,424 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// UserHistory describes a platform's listing of one member's posts, which --user
+// walks to find the threads they posted in
+type UserHistory struct {
+	// URLTemplate lists posts by member name ({user}); IDURLTemplate by member ID ({user_id}).
+	// IDURLTemplate is preferred when --user carries an ID.
+	URLTemplate   string
+	IDURLTemplate string
+	// PostSelector matches one post in the listing; the other selectors apply inside it
+	PostSelector       selectorChain
+	ThreadLinkSelector selectorChain
+	AuthorSelector     selectorChain
+	ContentSelector    selectorChain
+	TimestampSelector  selectorChain
+	// FullPosts is set when the listing shows whole posts rather than snippets, so
+	// --user-posts-only can emit them without fetching the threads
+	FullPosts bool
+}
+
+// apply returns config with its post selectors replaced by the listing's
+func (h *UserHistory) apply(config PlatformConfig) PlatformConfig {
+	config.ContentSelector = h.ContentSelector
+	config.AuthorSelector = h.AuthorSelector
+	config.TimestampSelector = h.TimestampSelector
+	config.AcceptedAnswerSelector = nil
+	return config
+}
+
+// parseUserSpec splits --user into a member name and ID: "alice" is a name,
+// "42" an ID, and "alice.42" (a XenForo member slug) both
+func parseUserSpec(spec string) (name, id string) {
+	spec = strings.TrimSpace(spec)
+	if _, err := strconv.Atoi(spec); err == nil {
+		return "", spec
+	}
+	if i := strings.LastIndex(spec, "."); i > 0 {
+		if _, err := strconv.Atoi(spec[i+1:]); err == nil {
+			return spec[:i], spec[i+1:]
+		}
+	}
+	return spec, ""
+}
+
+// checkUserHistory fails when a member's threads are asked for on a platform
+// without a member post listing
+func (fs *ForumScraperGo) checkUserHistory() error {
+	if fs.userName == "" && fs.userID == "" {
+		return nil
+	}
+	config, exists := fs.configs[fs.platform]
+	if !exists || (config.UserHistory == nil && fs.platform != "discourse") {
+		return fmt.Errorf("%s has no member post listing (want phpbb, discourse or xenforo)", fs.platform)
+	}
+	return nil
+}
+
+// matchesUser reports whether author is the --user member
+func (fs *ForumScraperGo) matchesUser(author string) bool {
+	return fs.userName != "" && strings.EqualFold(strings.TrimSpace(author), fs.userName)
+}
+
+// postSuffix matches the post part of a link into a thread: XenForo's /post-123
+// and phpBB's #p123 anchors
+var postSuffix = regexp.MustCompile(`/post-\d+/?$|#.*$`)
+
+// permalinkPostID returns the post a link into a thread points at: XenForo's
+// /post-123 as "123", phpBB's #p123 anchor as "p123"; empty when it names none
+func permalinkPostID(link string) string {
+	suffix := postSuffix.FindString(link)
+	if strings.HasPrefix(suffix, "#") {
+		return suffix[1:]
+	}
+	return strings.Trim(strings.TrimPrefix(suffix, "/post-"), "/")
+}
+
+// userThread is the --user member's posts in one thread, collected from their
+// history for --user-posts-only
+type userThread struct {
+	Title   string
+	Posts   []*ForumPost
+	Skipped map[string]int
+	// PostIDs are Discourse posts still to be fetched
+	PostIDs []int
+}
+
+// recordUserPost files a post from the member's history under its thread
+func (fs *ForumScraperGo) recordUserPost(threadURL, title string, post *ForumPost, reason string, postID int) {
+	fs.visitedMutex.Lock()
+	defer fs.visitedMutex.Unlock()
+	key := normalizeURL(threadURL)
+	thread, exists := fs.userThreads[key]
+	if !exists {
+		thread = &userThread{Title: title, Skipped: make(map[string]int)}
+		fs.userThreads[key] = thread
+	}
+	switch {
+	case post != nil:
+		thread.Posts = append(thread.Posts, post)
+	case postID != 0:
+		thread.PostIDs = append(thread.PostIDs, postID)
+	case reason != "":
+		thread.Skipped[reason]++
+	}
+}
+
+// takeUserThread returns the member's collected posts in a thread, if any
+func (fs *ForumScraperGo) takeUserThread(threadURL string) (*userThread, bool) {
+	fs.visitedMutex.Lock()
+	defer fs.visitedMutex.Unlock()
+	key := normalizeURL(threadURL)
+	thread, exists := fs.userThreads[key]
+	delete(fs.userThreads, key)
+	return thread, exists
+}
+
+// discoverFromUser collects the threads the --user member posted in from their
+// post history, paging until maxThreads or the index page cap
+func (fs *ForumScraperGo) discoverFromUser(forumURL string, maxThreads int) ([]ThreadRef, error) {
+	if fs.platform == "discourse" {
+		return fs.discoverFromDiscourseUser(forumURL, maxThreads)
+	}
+
+	config, exists := fs.configs[fs.platform]
+	if !exists {
+		config = fs.configs["generic"]
+	}
+	history := config.UserHistory
+	if history == nil {
+		return nil, fmt.Errorf("platform %s has no member post listing", fs.platform)
+	}
+	template, placeholder, value := history.URLTemplate, "{user}", fs.userName
+	if fs.userID != "" && history.IDURLTemplate != "" || template == "" {
+		template, placeholder, value = history.IDURLTemplate, "{user_id}", fs.userID
+	}
+	if template == "" || value == "" {
+		return nil, fmt.Errorf("platform %s lists member posts by ID only; pass --user name.ID", fs.platform)
+	}
+	listURL, err := resolveURL(forumURL, strings.ReplaceAll(template, placeholder, url.QueryEscape(value)))
+	if err != nil {
+		return nil, err
+	}
+	postsOnly := fs.userPostsOnly && history.FullPosts
+	if fs.userPostsOnly && !postsOnly {
+		fs.statusf("⚠️ %s lists post snippets only, fetching full threads for --user-posts-only\n", fs.platform)
+	}
+	fs.statusf("👤 Discovering threads posted in by %s\n", value)
+
+	postConfig := history.apply(config)
+	var refs []ThreadRef
+	accepted := make(map[string]string)
+	postCounts := make(map[string]int)
+	visitedPages := make(map[string]bool)
+	pagesWalked := 0
+	for pageURL := listURL; pageURL != "" && len(refs) < maxThreads && pagesWalked < fs.maxIndexPages; {
+		visitedPages[normalizeURL(pageURL)] = true
+		if pagesWalked > 0 {
+			// Rate limiting
+			fs.politeWait(pageURL)
+		}
+
+		doc, err := fs.fetchDocument(pageURL)
+		if err != nil {
+			if pagesWalked == 0 {
+				return nil, err
+			}
+			fs.statusf("⚠️ Stopped history pagination at %s: %v\n", pageURL, err)
+			break
+		}
+		pagesWalked++
+		atomic.AddInt64(&fs.stats.IndexPagesFetched, 1)
+
+		posts, selector := history.PostSelector.find(doc.Selection)
+		fs.selectorMatched("UserHistory.PostSelector", history.PostSelector, selector)
+		posts.Each(func(i int, selection *goquery.Selection) {
+			links, _ := history.ThreadLinkSelector.find(selection)
+			link := links.First()
+			href, exists := link.Attr("href")
+			if !exists {
+				return
+			}
+			threadURL, ok := fs.resolveLink(pageURL, href)
+			if !ok {
+				return
+			}
+			permalink := threadURL
+			threadURL = postSuffix.ReplaceAllString(threadURL, "")
+			key := fs.extractThreadID(threadURL)
+			if key == "" {
+				key = normalizeURL(threadURL)
+			}
+
+			// A member given by ID alone is matched by the name their listing shows
+			if fs.userName == "" {
+				name, _ := history.AuthorSelector.text(selection)
+				fs.userName = validUTF8(name)
+			}
+
+			if _, exists := accepted[key]; !exists {
+				if len(refs) >= maxThreads || !fs.allowThreadURL(threadURL, forumURL) {
+					return
+				}
+				accepted[key] = threadURL
+				refs = append(refs, ThreadRef{URL: threadURL, Title: strings.TrimSpace(link.Text()), SourceURL: forumURL})
+			}
+			if postsOnly {
+				threadURL = accepted[key]
+				// The listing doesn't show where a post sits in its thread, so posts are
+				// numbered in listing order and identified by their permalink's anchor
+				postCounts[key]++
+				post, reason := fs.scrapePost(selection, postConfig, strings.TrimSpace(link.Text()), threadURL, postCounts[key])
+				if post != nil {
+					if anchor := permalinkPostID(permalink); anchor != "" {
+						post.PostID = anchor
+					}
+					if permalink != threadURL {
+						post.URL = permalink
+					}
+				}
+				fs.recordUserPost(threadURL, strings.TrimSpace(link.Text()), post, reason, 0)
+			}
+		})
+
+		pageURL = fs.nextIndexPage(doc, pageURL)
+		if visitedPages[normalizeURL(pageURL)] || (pageURL != "" && !fs.allowPageURL(pageURL, forumURL)) {
+			break
+		}
+	}
+
+	fs.statusf("📊 Discovered %d threads across %d history page(s)\n", len(refs), pagesWalked)
+	return refs, nil
+}
+
+// discourseUserActions is one page of Discourse's /user_actions.json
+type discourseUserActions struct {
+	UserActions []struct {
+		PostID     int    `json:"post_id"`
+		PostNumber int    `json:"post_number"`
+		TopicID    int    `json:"topic_id"`
+		Slug       string `json:"slug"`
+		Title      string `json:"title"`
+	} `json:"user_actions"`
+}
+
+// discoursePost is the body of Discourse's /posts/{id}.json
+type discoursePost struct {
+	ID         int    `json:"id"`
+	Username   string `json:"username"`
+	Cooked     string `json:"cooked"`
+	CreatedAt  string `json:"created_at"`
+	PostNumber int    `json:"post_number"`
+	// Version counts revisions; anything above 1 has been edited
+	Version int `json:"version"`
+}
+
+// discourseUserActionsPageSize is how many actions Discourse returns per page
+const discourseUserActionsPageSize = 30
+
+// discoverFromDiscourseUser collects the topics a Discourse user started or replied
+// in from /user_actions.json. The /u/{user}/activity page is rendered from the
+// same endpoint; its own .json is the profile and lists no posts.
+func (fs *ForumScraperGo) discoverFromDiscourseUser(forumURL string, maxThreads int) ([]ThreadRef, error) {
+	if fs.userName == "" {
+		return nil, fmt.Errorf("discourse lists member posts by username; pass --user with a name")
+	}
+	fs.statusf("👤 Discovering threads posted in by %s\n", fs.userName)
+
+	var refs []ThreadRef
+	accepted := make(map[int]string)
+	for page := 0; len(refs) < maxThreads && page < fs.maxIndexPages; page++ {
+		// filter 4 is topics started, 5 replies
+		pageURL, err := resolveURL(forumURL, fmt.Sprintf("/user_actions.json?offset=%d&username=%s&filter=4,5", page*discourseUserActionsPageSize, url.QueryEscape(fs.userName)))
+		if err != nil {
+			return refs, err
+		}
+		// Rate limiting
+		fs.politeWait(pageURL)
+
+		var actions discourseUserActions
+		if err := fs.fetchJSON(context.Background(), pageURL, &actions); err != nil {
+			if page == 0 {
+				return nil, err
+			}
+			fs.statusf("⚠️ Stopped history pagination at %s: %v\n", pageURL, err)
+			break
+		}
+		atomic.AddInt64(&fs.stats.IndexPagesFetched, 1)
+		if len(actions.UserActions) == 0 {
+			break
+		}
+
+		for _, action := range actions.UserActions {
+			threadURL, exists := accepted[action.TopicID]
+			if !exists {
+				if len(refs) >= maxThreads {
+					continue
+				}
+				if threadURL, err = resolveURL(forumURL, fmt.Sprintf("/t/%s/%d", url.PathEscape(action.Slug), action.TopicID)); err != nil {
+					continue
+				}
+				if !fs.allowThreadURL(threadURL, forumURL) {
+					continue
+				}
+				accepted[action.TopicID] = threadURL
+				refs = append(refs, ThreadRef{URL: threadURL, Title: action.Title, SourceURL: forumURL})
+			}
+			if fs.userPostsOnly {
+				fs.recordUserPost(threadURL, action.Title, nil, "", action.PostID)
+			}
+		}
+	}
+
+	fs.statusf("📊 Discovered %d threads\n", len(refs))
+	return refs, nil
+}
+
+// fetchDiscoursePost fetches one post by ID and runs it through the post pipeline
+func (fs *ForumScraperGo) fetchDiscoursePost(ctx context.Context, threadURL, title string, postID int) (*ForumPost, string, error) {
+	postURL, err := resolveURL(threadURL, fmt.Sprintf("/posts/%d.json", postID))
+	if err != nil {
+		return nil, "", err
+	}
+	// Rate limiting
+	fs.politeWaitContext(ctx, postURL)
+
+	var body discoursePost
+	if err := fs.fetchJSON(ctx, postURL, &body); err != nil {
+		return nil, "", err
+	}
+	content := fs.apiPostText(body.Cooked)
+	if content == "" {
+		return nil, "", nil
+	}
+	edit := fs.discourseEdit(ctx, postURL, postID, body.Version)
+	post := &ForumPost{
+		URL:         fmt.Sprintf("%s/%d", strings.TrimSuffix(threadURL, "/"), body.PostNumber),
+		ThreadTitle: title,
+		Author:      body.Username,
+		Content:     content,
+		PostNumber:  body.PostNumber,
+		PostID:      strconv.Itoa(body.ID),
+		Timestamp:   body.CreatedAt,
+		ScrapedAt:   fs.now(),
+		MatchedUser: fs.matchesUser(body.Username),
+		Edited:      edit.edited,
+		EditedAt:    edit.at,
+		EditedBy:    edit.by,
+	}
+	post, reason := fs.processPost(post)
+	if post == nil {
+		return nil, reason, nil
+	}
+	post.ContentHash = contentHash(post.Content)
+	post.Language = detectLanguage(post.Content)
+	return post, "", nil
+}
+
+// scrapeUserPosts builds a thread from the member's own posts in it, as collected
+// from their history, without fetching the thread itself
+func (fs *ForumScraperGo) scrapeUserPosts(threadURL string, collected *userThread, maxPosts int) (*ForumThread, error) {
+	// --thread-timeout covers the post fetches
+	threadCtx, cancel := fs.threadContext()
+	defer cancel()
+	ctx, provenance := fs.withProvenance(threadCtx, threadURL)
+	posts := collected.Posts
+	skipped := collected.Skipped
+	fetched := 0
+	for _, postID := range collected.PostIDs {
+		if len(posts) >= fs.postLimit(maxPosts, len(collected.PostIDs)) {
+			break
+		}
+		post, reason, err := fs.fetchDiscoursePost(ctx, threadURL, collected.Title, postID)
+		if err != nil && fs.threadTimedOut(threadCtx) {
+			break
+		}
+		fetched++
+		if err != nil {
+			fs.statusf("⚠️ Skipping post %d of %s: %v\n", postID, threadURL, err)
+			reason = "unavailable"
+		}
+		if post != nil {
+			posts = append(posts, post)
+		} else if reason != "" {
+			skipped[reason]++
+		}
+	}
+	for _, count := range skipped {
+		atomic.AddInt64(&fs.stats.PostsSkipped, int64(count))
+	}
+	if limit := fs.postLimit(maxPosts, len(posts)); len(posts) > limit {
+		posts = posts[:limit]
+	}
+	if len(posts) == 0 && fs.postsMode != postsModeNone {
+		return nil, ErrNoPosts
+	}
+
+	thread := &ForumThread{
+		URL:        threadURL,
+		ThreadID:   fs.extractThreadID(threadURL),
+		Title:      collected.Title,
+		Provenance: provenance,
+		ScrapedAt:  fs.now(),
+	}
+	if len(skipped) > 0 {
+		thread.SkippedPosts = skipped
+	}
+	if fs.threadTimedOut(threadCtx) {
+		thread.Truncated = true
+		fs.noteThreadTimeout(threadURL, provenance, fmt.Sprintf("%d of %d posts", fetched, len(collected.PostIDs)))
+	}
+	return fs.finishThread(thread, posts, maxPosts)
+}
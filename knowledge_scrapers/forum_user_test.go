@@ -0,0 +1,66 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPermalinkPostID(t *testing.T) {
+	tests := map[string]string{
+		"https://forum.example.com/viewtopic.php?t=7&p=1234#p1234": "p1234",
+		"https://forum.example.com/threads/topic.88/post-88101":    "88101",
+		"https://forum.example.com/threads/topic.88/post-88101/":   "88101",
+		"https://forum.example.com/viewtopic.php?t=7":              "",
+	}
+	for link, want := range tests {
+		if got := permalinkPostID(link); got != want {
+			t.Errorf("permalinkPostID(%q) = %q, want %q", link, got, want)
+		}
+	}
+}
+
+// userSearchPage is a phpBB post-mode search listing two of user1's posts in one
+// topic, newest first
+const userSearchPage = `<html><body>
+<div class="search post" id="p2002"><div class="postbody"><h3><a href="./viewtopic.php?t=7&amp;p=2002#p2002">Seed potatoes</a></h3>
+<div class="content">Second post by user1 about chitting seed potatoes in trays.</div></div>
+<dl class="postprofile"><dt><a class="username" href="./memberlist.php?u=2">user1</a></dt></dl></div>
+<div class="search post" id="p2001"><div class="postbody"><h3><a href="./viewtopic.php?t=7&amp;p=2001#p2001">Seed potatoes</a></h3>
+<div class="content">First post by user1 about storing seed potatoes over winter.</div></div>
+<dl class="postprofile"><dt><a class="username" href="./memberlist.php?u=2">user1</a></dt></dl></div>
+</body></html>`
+
+func TestUserHistoryPostsUsePermalinks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, userSearchPage)
+	}))
+	defer server.Close()
+
+	scraper := NewForumScraper("phpbb", 0, WithSeed(1), WithFixedTimestamps(true))
+	scraper.statusOut = io.Discard
+	scraper.userName, scraper.userPostsOnly = "user1", true
+	refs, err := scraper.discoverFromUser(server.URL+"/", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 1 {
+		t.Fatalf("discovered %d threads, want 1", len(refs))
+	}
+	collected, ok := scraper.takeUserThread(refs[0].URL)
+	if !ok || len(collected.Posts) != 2 {
+		t.Fatalf("collected %+v, want 2 posts", collected)
+	}
+	for i, want := range []string{"p2002", "p2001"} {
+		post := collected.Posts[i]
+		if post.PostID != want {
+			t.Errorf("post %d: PostID = %q, want %q", i, post.PostID, want)
+		}
+		if !strings.HasSuffix(post.URL, "#"+want) {
+			t.Errorf("post %d: URL = %q, want the #%s permalink", i, post.URL, want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// UA rotation modes for --ua-rotate
+const (
+	uaRotateSticky     = "sticky"
+	uaRotatePerRequest = "per-request"
+)
+
+// readUserAgents reads one User-Agent string per line, ignoring blank lines and # comments
+func readUserAgents(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var agents []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		agents = append(agents, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(agents) == 0 {
+		return nil, fmt.Errorf("no user agents in %s", path)
+	}
+	return agents, nil
+}
+
+// userAgentFor returns the User-Agent for a request to rawURL. Without a pool it is
+// the Marina UA; with one, each host keeps the UA it was first given (so cookies and
+// logins stay coherent) unless per-request rotation is on.
+func (fs *ForumScraperGo) userAgentFor(rawURL string) string {
+	if len(fs.userAgents) == 0 {
+		return userAgent
+	}
+	if fs.uaRotate == uaRotatePerRequest {
+		return fs.userAgents[fs.rng.Intn(len(fs.userAgents))]
+	}
+
+	host := hostOf(rawURL)
+	fs.hostUAMutex.Lock()
+	defer fs.hostUAMutex.Unlock()
+	if agent, exists := fs.hostUA[host]; exists {
+		return agent
+	}
+	if fs.hostUA == nil {
+		fs.hostUA = make(map[string]string)
+	}
+	agent := fs.userAgents[fs.seededIndex(host, len(fs.userAgents))]
+	fs.hostUA[host] = agent
+	fs.debugf("Using User-Agent for %s: %s", host, agent)
+	return agent
+}
+
+// userAgentMetadata describes the UAs used, for the results envelope
+func (fs *ForumScraperGo) userAgentMetadata() map[string]interface{} {
+	if len(fs.userAgents) == 0 {
+		return nil
+	}
+	metadata := map[string]interface{}{"rotation": fs.uaRotate, "pool_size": len(fs.userAgents)}
+	if fs.uaRotate != uaRotatePerRequest {
+		fs.hostUAMutex.Lock()
+		hosts := make(map[string]string, len(fs.hostUA))
+		for host, agent := range fs.hostUA {
+			hosts[host] = agent
+		}
+		fs.hostUAMutex.Unlock()
+		metadata["hosts"] = hosts
+	}
+	return metadata
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// schemaIssue is one problem found while validating a results file
+type schemaIssue struct {
+	Line    int
+	Path    string
+	Message string
+}
+
+// schemaValidator walks a JSON document token by token against a jsonSchema,
+// tracking line numbers so issues point at the offending line
+type schemaValidator struct {
+	data    []byte
+	decoder *json.Decoder
+	// scanned and line cache the line count up to a byte offset
+	scanned int64
+	line    int
+	issues  []schemaIssue
+}
+
+func newSchemaValidator(data []byte, firstLine int) *schemaValidator {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return &schemaValidator{data: data, decoder: decoder, line: firstLine}
+}
+
+// currentLine returns the line the decoder has read up to
+func (v *schemaValidator) currentLine() int {
+	offset := v.decoder.InputOffset()
+	v.line += bytes.Count(v.data[v.scanned:offset], []byte("\n"))
+	v.scanned = offset
+	return v.line
+}
+
+func (v *schemaValidator) report(line int, path, format string, args ...interface{}) {
+	v.issues = append(v.issues, schemaIssue{Line: line, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// validate checks the next value in the document against s
+func (v *schemaValidator) validate(s *jsonSchema, path string) error {
+	token, err := v.decoder.Token()
+	if err != nil {
+		return err
+	}
+	line := v.currentLine()
+
+	switch value := token.(type) {
+	case json.Delim:
+		if value == '{' {
+			return v.validateObject(s, path, line)
+		}
+		return v.validateArray(s, path, line)
+	case string:
+		if !s.allows("string") {
+			v.report(line, path, "expected %s, got string", strings.Join(s.Type, " or "))
+		} else if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+				v.report(line, path, "invalid date-time %q", value)
+			}
+		}
+	case json.Number:
+		jsonType := "number"
+		if !strings.ContainsAny(value.String(), ".eE") {
+			jsonType = "integer"
+		}
+		if !s.allows(jsonType) {
+			v.report(line, path, "expected %s, got %s", strings.Join(s.Type, " or "), jsonType)
+		}
+	case bool:
+		if !s.allows("boolean") {
+			v.report(line, path, "expected %s, got boolean", strings.Join(s.Type, " or "))
+		}
+	case nil:
+		if !s.allows("null") {
+			v.report(line, path, "expected %s, got null", strings.Join(s.Type, " or "))
+		}
+	}
+	return nil
+}
+
+func (v *schemaValidator) validateObject(s *jsonSchema, path string, line int) error {
+	if !s.allows("object") {
+		v.report(line, path, "expected %s, got object", strings.Join(s.Type, " or "))
+		s = &jsonSchema{}
+	}
+	seen := make(map[string]bool)
+	for v.decoder.More() {
+		token, err := v.decoder.Token()
+		if err != nil {
+			return err
+		}
+		key := token.(string)
+		seen[key] = true
+		fieldPath := path + "." + key
+
+		fieldSchema, known := s.Properties[key]
+		if !known {
+			switch additional := s.AdditionalProperties.(type) {
+			case *jsonSchema:
+				fieldSchema = additional
+			case bool:
+				v.report(v.currentLine(), fieldPath, "unknown field")
+				fieldSchema = &jsonSchema{}
+			default:
+				fieldSchema = &jsonSchema{}
+			}
+		}
+		if err := v.validate(fieldSchema, fieldPath); err != nil {
+			return err
+		}
+	}
+	if _, err := v.decoder.Token(); err != nil {
+		return err
+	}
+	for _, name := range s.Required {
+		if !seen[name] {
+			v.report(line, path, "missing required field %q", name)
+		}
+	}
+	return nil
+}
+
+func (v *schemaValidator) validateArray(s *jsonSchema, path string, line int) error {
+	if !s.allows("array") {
+		v.report(line, path, "expected %s, got array", strings.Join(s.Type, " or "))
+		s = &jsonSchema{}
+	}
+	items := s.Items
+	if items == nil {
+		items = &jsonSchema{}
+	}
+	for i := 0; v.decoder.More(); i++ {
+		if err := v.validate(items, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+	_, err := v.decoder.Token()
+	return err
+}
+
+// declaredVersion returns a document's schema_version, or the legacy version when it has none
+func declaredVersion(data []byte) (string, error) {
+	var header struct {
+		SchemaVersion json.RawMessage `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return "", err
+	}
+	if header.SchemaVersion == nil {
+		return legacySchemaVersion, nil
+	}
+	return parseSchemaVersion(header.SchemaVersion)
+}
+
+// legacySchema adapts s to files from before the string version field, which
+// have no schema_version or the integer 1
+func legacySchema(s *jsonSchema) *jsonSchema {
+	legacy := *s
+	legacy.Properties = make(map[string]*jsonSchema, len(s.Properties))
+	for name, property := range s.Properties {
+		legacy.Properties[name] = property
+	}
+	legacy.Properties["schema_version"] = &jsonSchema{Type: []string{"integer", "string"}}
+	legacy.Required = nil
+	for _, name := range s.Required {
+		if name != "schema_version" {
+			legacy.Required = append(legacy.Required, name)
+		}
+	}
+	return &legacy
+}
+
+// validateDocument validates one JSON document starting at firstLine of its file
+func validateDocument(data []byte, firstLine int, s *jsonSchema) []schemaIssue {
+	version, err := declaredVersion(data)
+	if err != nil {
+		return []schemaIssue{{Line: firstLine, Path: "$", Message: err.Error()}}
+	}
+	if err := checkSchemaVersion(version); err != nil {
+		return []schemaIssue{{Line: firstLine, Path: "$.schema_version", Message: err.Error()}}
+	}
+	if version == legacySchemaVersion {
+		s = legacySchema(s)
+	}
+
+	validator := newSchemaValidator(data, firstLine)
+	if err := validator.validate(s, "$"); err != nil {
+		validator.report(validator.currentLine(), "$", "malformed JSON: %v", err)
+	}
+	return validator.issues
+}
+
+// validateResultsFile checks a results envelope or JSONL file against the results schema
+func validateResultsFile(path string) ([]schemaIssue, error) {
+	body, closer, err := openResultsFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(strings.TrimSuffix(path, ".gz"), ".jsonl") {
+		return validateDocument(data, 1, resultsSchema()), nil
+	}
+	var issues []schemaIssue
+	record := threadRecordSchema()
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		issues = append(issues, validateDocument(line, i+1, record)...)
+	}
+	return issues, nil
+}
+
+// runValidate implements the validate subcommand: check results files against the schema
+func runValidate(args []string) {
+	fset := flag.NewFlagSet("forum_scraper validate", flag.ExitOnError)
+	fset.Usage = func() {
+		fmt.Println("Usage: forum_scraper validate [flags] <results_file>...")
+		fmt.Println("Example: forum_scraper validate scraping_results/*.json")
+		fset.PrintDefaults()
+	}
+	printSchema := fset.Bool("schema", false, "print the JSON Schema of results files and exit")
+
+	files, err := parseInterleaved(fset, args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *printSchema {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(map[string]*jsonSchema{"envelope": resultsSchema(), "jsonl_record": threadRecordSchema()}); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(files) == 0 {
+		fset.Usage()
+		os.Exit(1)
+	}
+
+	invalid := 0
+	for _, path := range files {
+		issues, err := validateResultsFile(path)
+		if err != nil {
+			log.Fatalf("❌ Validation failed: %v", err)
+		}
+		if len(issues) == 0 {
+			fmt.Printf("✅ %s: valid\n", path)
+			continue
+		}
+		invalid++
+		for _, issue := range issues {
+			fmt.Printf("❌ %s:%d: %s: %s\n", path, issue.Line, issue.Path, issue.Message)
+		}
+	}
+	if invalid > 0 {
+		fmt.Printf("📊 %d of %d file(s) failed validation\n", invalid, len(files))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// --window keeps only posts dated inside a range. Where a platform can list
+// threads by date, discovery asks it to: phpBB, vBulletin and XenForo index pages
+// take their "threads active in the previous N days" filter and a newest-activity
+// sort, and Discourse boards are listed from /latest.json with ?after and ?before.
+// Every platform then drops scraped posts whose timestamp falls outside the
+// window. A thread that lost posts that way is marked partial_window, and one left
+// with none fails as outside_window. Timestamps are parsed with the platform's
+// display formats as well as the standard ones; posts whose timestamp still can't
+// be parsed are kept and counted in the run's undated_posts.
+
+// timeWindow is a --window range, from start up to but not including end. A zero
+// bound leaves that side open.
+type timeWindow struct {
+	start, end time.Time
+}
+
+// parseWindow parses a --window value, start..end, where each side is a date or
+// an RFC 3339 timestamp and either may be left empty. An end given as a date
+// covers that whole day.
+func parseWindow(value string) (timeWindow, error) {
+	startText, endText, found := strings.Cut(value, "..")
+	if !found {
+		return timeWindow{}, fmt.Errorf("invalid window %q (want start..end, e.g. 2019-01-01..2019-12-31)", value)
+	}
+	var w timeWindow
+	if startText = strings.TrimSpace(startText); startText != "" {
+		t := parseLastMod(startText)
+		if t == nil {
+			return timeWindow{}, fmt.Errorf("invalid window start %q (want a date or timestamp)", startText)
+		}
+		w.start = *t
+	}
+	if endText = strings.TrimSpace(endText); endText != "" {
+		t := parseLastMod(endText)
+		if t == nil {
+			return timeWindow{}, fmt.Errorf("invalid window end %q (want a date or timestamp)", endText)
+		}
+		w.end = *t
+		if _, err := time.Parse("2006-01-02", endText); err == nil {
+			w.end = w.end.AddDate(0, 0, 1)
+		}
+	}
+	if !w.active() {
+		return timeWindow{}, fmt.Errorf("invalid window %q (needs a start, an end or both)", value)
+	}
+	if !w.start.IsZero() && !w.end.IsZero() && !w.end.After(w.start) {
+		return timeWindow{}, fmt.Errorf("invalid window %q (ends before it starts)", value)
+	}
+	return w, nil
+}
+
+// active reports whether the window bounds anything
+func (w timeWindow) active() bool {
+	return !w.start.IsZero() || !w.end.IsZero()
+}
+
+// contains reports whether t falls inside the window
+func (w timeWindow) contains(t time.Time) bool {
+	return (w.start.IsZero() || !t.Before(w.start)) && (w.end.IsZero() || t.Before(w.end))
+}
+
+// DateNavigation is an index page filter to threads active in the last N days,
+// which --window discovery uses to skip threads that went quiet before it starts
+type DateNavigation struct {
+	// DaysParam is the query parameter taking the number of days
+	DaysParam string
+	// Days are the values the forum offers, ascending; a window starting further
+	// back than the last of them is listed unfiltered
+	Days []int
+	// SortParams order the index by last post, newest first
+	SortParams map[string]string
+}
+
+// windowURL returns indexURL with nav's parameters for threads active since
+// start, counting days back from now
+func (nav *DateNavigation) windowURL(indexURL string, start, now time.Time) (string, error) {
+	u, err := url.Parse(indexURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	for name, value := range nav.SortParams {
+		query.Set(name, value)
+	}
+	if !start.IsZero() && nav.DaysParam != "" {
+		days := int(math.Ceil(now.Sub(start).Hours() / 24))
+		for _, option := range nav.Days {
+			if option >= days {
+				query.Set(nav.DaysParam, strconv.Itoa(option))
+				break
+			}
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// windowIndexURL narrows a subforum index page to the --window with the
+// platform's date navigation, relative to now. Other pages, and every page of a
+// platform without date navigation, come back unchanged.
+func (fs *ForumScraperGo) windowIndexURL(indexURL string, now time.Time) string {
+	config, exists := fs.configs[fs.platform]
+	if !fs.window.active() || !exists || config.DateNavigation == nil || !fs.isForumURL(indexURL) {
+		return indexURL
+	}
+	windowed, err := config.DateNavigation.windowURL(indexURL, fs.window.start, now)
+	if err != nil {
+		return indexURL
+	}
+	return windowed
+}
+
+// discourseLatestURL returns page of a Discourse board's /latest.json listing,
+// or its category's when forumURL is a category page, limited to topics active
+// in w. Boards that ignore ?after and ?before are still filtered by the dates
+// each topic lists.
+func discourseLatestURL(forumURL string, w timeWindow, page int) (string, error) {
+	u, err := url.Parse(forumURL)
+	if err != nil {
+		return "", err
+	}
+	listPath := "/latest.json"
+	if strings.HasPrefix(u.Path, "/c/") {
+		category := strings.TrimSuffix(strings.TrimSuffix(u.Path, ".json"), "/")
+		listPath = strings.TrimSuffix(category, "/l/latest") + "/l/latest.json"
+	}
+	query := url.Values{}
+	query.Set("order", "activity")
+	if !w.start.IsZero() {
+		query.Set("after", w.start.UTC().Format("2006-01-02"))
+	}
+	if !w.end.IsZero() {
+		// The listing takes whole days, so an end within a day takes in all of it
+		before := w.end.UTC().Truncate(24 * time.Hour)
+		if before.Before(w.end) {
+			before = before.AddDate(0, 0, 1)
+		}
+		query.Set("before", before.Format("2006-01-02"))
+	}
+	if page > 0 {
+		query.Set("page", strconv.Itoa(page))
+	}
+	return resolveURL(forumURL, listPath+"?"+query.Encode())
+}
+
+// discoverFromLatest collects topic URLs for --window from a Discourse board's
+// latest listing, newest activity first, paging until maxThreads, the index page
+// cap or a page whose topics all went quiet before the window
+func (fs *ForumScraperGo) discoverFromLatest(forumURL string, maxThreads int) ([]ThreadRef, error) {
+	fs.statusf("📅 Discovering threads active in the window from: %s\n", forumURL)
+
+	var refs []ThreadRef
+	seen := make(map[int]bool)
+	for page := 0; len(refs) < maxThreads && page < fs.maxIndexPages; page++ {
+		pageURL, err := discourseLatestURL(forumURL, fs.window, page)
+		if err != nil {
+			return refs, err
+		}
+		// Rate limiting
+		fs.politeWait(pageURL)
+
+		var list discourseTopicList
+		if err := fs.fetchJSON(fs.runContext(), pageURL, &list); err != nil {
+			if page == 0 {
+				return nil, err
+			}
+			fs.statusf("⚠️ Stopped latest pagination at %s: %v\n", pageURL, err)
+			break
+		}
+		atomic.AddInt64(&fs.stats.IndexPagesFetched, 1)
+
+		added, recent := 0, false
+		for _, topic := range list.TopicList.Topics {
+			if len(refs) >= maxThreads {
+				break
+			}
+			if seen[topic.ID] {
+				continue
+			}
+			seen[topic.ID] = true
+			added++
+
+			topicURL, err := resolveURL(forumURL, fmt.Sprintf("/t/%s/%d", url.PathEscape(topic.Slug), topic.ID))
+			if err != nil {
+				continue
+			}
+			ref := ThreadRef{URL: topicURL, Title: topic.Title, SourceURL: forumURL, LastActivity: parseLastMod(topic.LastPostedAt)}
+			if topic.PostsCount > 0 {
+				replies := topic.PostsCount - 1
+				ref.Replies = &replies
+			}
+			if fs.tooOld(ref) {
+				continue
+			}
+			recent = true
+			// A topic started after the window has no posts in it
+			if created := parseLastMod(topic.CreatedAt); created != nil && !fs.window.end.IsZero() && !created.Before(fs.window.end) {
+				continue
+			}
+			if !fs.allowThreadURL(topicURL, forumURL) {
+				continue
+			}
+			refs = append(refs, ref)
+		}
+		if added == 0 || !recent || list.TopicList.MoreTopicsURL == "" {
+			break
+		}
+	}
+
+	fs.statusf("📊 Discovered %d thread URLs active in the window\n", len(refs))
+	return refs, nil
+}
+
+// windowPosts drops the posts --window excludes, returning those kept and how
+// many were dropped. Posts without a parseable timestamp are kept.
+func (fs *ForumScraperGo) windowPosts(posts []*ForumPost) ([]*ForumPost, int) {
+	if !fs.window.active() {
+		return posts, 0
+	}
+	kept := make([]*ForumPost, 0, len(posts))
+	for _, post := range posts {
+		t := fs.postTime(post.Timestamp)
+		if t == nil {
+			atomic.AddInt64(&fs.stats.UndatedPosts, 1)
+			kept = append(kept, post)
+		} else if fs.window.contains(*t) {
+			kept = append(kept, post)
+		}
+	}
+	return kept, len(posts) - len(kept)
+}
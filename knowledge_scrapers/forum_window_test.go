@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// The window fixture lists index pages of each platform, checked against the
+// URLs --window discovery starts from as of windowFixtureNow, and a results file
+// of threads inside, outside and straddling windowFixtureWindow, checked against
+// the posts the window keeps
+const (
+	windowFixtureIndexes = "window/indexes.json"
+	windowFixtureResults = "window/results.jsonl"
+	windowFixtureGolden  = "window/golden.json"
+	windowFixtureWindow  = "2019-01-01..2019-12-31"
+)
+
+var windowFixtureNow = time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// windowFixtureURL is an index page and the URL --window discovery starts from
+type windowFixtureURL struct {
+	Platform string `json:"platform"`
+	Index    string `json:"index"`
+	Window   string `json:"window"`
+	URL      string `json:"url,omitempty"`
+}
+
+// windowFixtureThread is what --window left of a results file thread
+type windowFixtureThread struct {
+	URL           string `json:"url"`
+	Posts         []int  `json:"posts,omitempty"`
+	PartialWindow bool   `json:"partial_window,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// windowFixtureURLFor returns the URL --window discovery starts from for an index
+// page: Discourse's first latest listing page, or the index with the platform's
+// date navigation
+func windowFixtureURLFor(c windowFixtureURL) (string, error) {
+	window, err := parseWindow(c.Window)
+	if err != nil {
+		return "", err
+	}
+	if c.Platform == "discourse" {
+		return discourseLatestURL(c.Index, window, 0)
+	}
+	scraper := NewForumScraper(c.Platform, 0)
+	scraper.window = window
+	return scraper.windowIndexURL(c.Index, windowFixtureNow), nil
+}
+
+func TestWindowFixture(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join(fixturesDir, windowFixtureIndexes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var indexes []windowFixtureURL
+	if err := json.Unmarshal(data, &indexes); err != nil {
+		t.Fatalf("%s: %v", windowFixtureIndexes, err)
+	}
+	for i := range indexes {
+		if indexes[i].URL, err = windowFixtureURLFor(indexes[i]); err != nil {
+			t.Fatalf("%s %s: %v", indexes[i].Platform, indexes[i].Index, err)
+		}
+	}
+
+	scraper := NewForumScraper("generic", 0, WithFixedTimestamps(true))
+	scraper.statusOut = io.Discard
+	if scraper.window, err = parseWindow(windowFixtureWindow); err != nil {
+		t.Fatal(err)
+	}
+	var threads []windowFixtureThread
+	for _, thread := range readFixtureThreads(t, windowFixtureResults) {
+		posts := make([]*ForumPost, len(thread.Posts))
+		for i := range thread.Posts {
+			posts[i] = &thread.Posts[i]
+		}
+		thread.Posts = nil
+		got := windowFixtureThread{URL: thread.URL}
+		if kept, err := scraper.finishThread(thread, posts, fixtureMaxPosts); err != nil {
+			got.Error = failureType(err)
+		} else {
+			for _, post := range kept.Posts {
+				got.Posts = append(got.Posts, post.PostNumber)
+			}
+			got.PartialWindow = kept.PartialWindow
+		}
+		threads = append(threads, got)
+	}
+
+	var encoded bytes.Buffer
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(struct {
+		Now     time.Time             `json:"now"`
+		Window  string                `json:"window"`
+		URLs    []windowFixtureURL    `json:"urls"`
+		Threads []windowFixtureThread `json:"threads"`
+	}{windowFixtureNow, windowFixtureWindow, indexes, threads})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, windowFixtureGolden, encoded.Bytes())
+}
+
+func TestParseWindow(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		value      string
+		start, end time.Time
+		wantErr    bool
+	}{
+		{value: "2019-01-01..2019-12-31", start: day(2019, 1, 1), end: day(2020, 1, 1)},
+		{value: "2019-06-01..", start: day(2019, 6, 1)},
+		{value: "..2019-06-01", end: day(2019, 6, 2)},
+		{value: "2019-01-01T00:00:00Z..2019-01-01T12:00:00Z", start: day(2019, 1, 1), end: day(2019, 1, 1).Add(12 * time.Hour)},
+		{value: "..", wantErr: true},
+		{value: "2019-01-01", wantErr: true},
+		{value: "2019-12-31..2019-01-01", wantErr: true},
+		{value: "yesterday..", wantErr: true},
+	}
+	for _, tt := range tests {
+		w, err := parseWindow(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseWindow(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (!w.start.Equal(tt.start) || !w.end.Equal(tt.end)) {
+			t.Errorf("parseWindow(%q) = %v..%v, want %v..%v", tt.value, w.start, w.end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestParsePostTime(t *testing.T) {
+	vbulletin := NewForumScraper("vbulletin", 0).configs["vbulletin"].TimestampLayouts
+	discourse := NewForumScraper("discourse", 0).configs["discourse"].TimestampLayouts
+	tests := []struct {
+		value   string
+		layouts []string
+		want    string
+	}{
+		{"2024-03-02T19:45:00Z", nil, "2024-03-02T19:45:00Z"},
+		{"03-02-2024, 07:45 PM", vbulletin, "2024-03-02T19:45:00Z"},
+		{"03-02-2024, 07:45 PM", vbulletin, "2024-03-02T19:45:00Z"},
+		{"Apr 2, 2024 3:18 pm", discourse, "2024-04-02T15:18:00Z"},
+		{"Apr 2, 2024 3:18 pm", nil, ""},
+		{"Yesterday, 07:45 PM", vbulletin, ""},
+		{"2d", discourse, ""},
+	}
+	for _, tt := range tests {
+		got := parsePostTime(tt.value, tt.layouts)
+		if (got == nil) != (tt.want == "") || (got != nil && got.Format(time.RFC3339) != tt.want) {
+			t.Errorf("parsePostTime(%q) = %v, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+// TestFixtureTimestampsParse checks that every post the golden fixtures of
+// platforms with a --window date format yield has a timestamp --window can read
+func TestFixtureTimestampsParse(t *testing.T) {
+	for _, c := range loadFixtureCases(t) {
+		if c.Platform != "vbulletin" && c.Platform != "discourse" {
+			continue
+		}
+		server := httptest.NewServer(fixtureHandler(fixturesDir, c.Routes))
+		scraper, err := newFixtureScraper(fixturesDir, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		thread, err := scraper.scrapeThread(server.URL+c.Thread, fixtureMaxPosts)
+		server.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", c.name(), err)
+		}
+		for _, post := range thread.Posts {
+			if scraper.postTime(post.Timestamp) == nil {
+				t.Errorf("%s post %d: can't parse timestamp %q", c.name(), post.PostNumber, post.Timestamp)
+			}
+		}
+	}
+}
+
+func TestWindowPostsCountsUndated(t *testing.T) {
+	scraper := NewForumScraper("vbulletin", 0)
+	scraper.statusOut = io.Discard
+	var err error
+	if scraper.window, err = parseWindow("2024-03-01..2024-03-02"); err != nil {
+		t.Fatal(err)
+	}
+	posts := []*ForumPost{
+		{PostNumber: 1, Timestamp: "03-01-2024, 10:00 AM"},
+		{PostNumber: 2, Timestamp: "Today, 07:45 PM"},
+		{PostNumber: 3, Timestamp: "03-05-2024, 10:00 AM"},
+	}
+	kept, dropped := scraper.windowPosts(posts)
+	if len(kept) != 2 || dropped != 1 || kept[1].PostNumber != 2 {
+		t.Errorf("windowPosts kept %d, dropped %d; want posts 1 and 2 kept", len(kept), dropped)
+	}
+	if undated := scraper.stats.snapshot().UndatedPosts; undated != 1 {
+		t.Errorf("UndatedPosts = %d, want 1", undated)
+	}
+}
+
+func TestWindowIndexURL(t *testing.T) {
+	tests := []struct {
+		platform, window, index, want string
+	}{
+		// phpBB lists topics active in the last st days, newest post first
+		{"phpbb", "2020-02-20..", "https://forum.example.com/viewforum.php?f=2",
+			"https://forum.example.com/viewforum.php?f=2&sd=d&sk=t&st=14"},
+		// A window further back than the longest option is listed unfiltered
+		{"phpbb", "2017-01-01..2017-12-31", "https://forum.example.com/viewforum.php?f=2",
+			"https://forum.example.com/viewforum.php?f=2&sd=d&sk=t"},
+		// With the start open, only the sort is set
+		{"phpbb", "..2019-12-31", "https://forum.example.com/viewforum.php?f=2",
+			"https://forum.example.com/viewforum.php?f=2&sd=d&sk=t"},
+		// Topic pages and the board index aren't index pages to narrow
+		{"phpbb", "2020-02-20..", "https://forum.example.com/viewtopic.php?f=2&t=101",
+			"https://forum.example.com/viewtopic.php?f=2&t=101"},
+		{"phpbb", "2020-02-20..", "https://forum.example.com/index.php",
+			"https://forum.example.com/index.php"},
+		{"vbulletin", "2020-02-27..", "https://forum.example.com/forumdisplay.php?f=7",
+			"https://forum.example.com/forumdisplay.php?daysprune=7&f=7&order=desc&sort=lastpost"},
+		{"vbulletin", "2019-06-01..", "https://forum.example.com/forumdisplay.php?f=7",
+			"https://forum.example.com/forumdisplay.php?daysprune=365&f=7&order=desc&sort=lastpost"},
+		{"xenforo", "2020-01-15..", "https://forum.example.com/forums/general.4/",
+			"https://forum.example.com/forums/general.4/?direction=desc&last_days=60&order=last_post_date"},
+		{"xenforo", "2020-02-29T12:00:00Z..", "https://forum.example.com/forums/general.4/",
+			"https://forum.example.com/forums/general.4/?direction=desc&last_days=7&order=last_post_date"},
+		// Platforms without date navigation are left alone
+		{"generic", "2020-02-20..", "https://forum.example.com/forum/general/",
+			"https://forum.example.com/forum/general/"},
+	}
+	for _, tt := range tests {
+		scraper := NewForumScraper(tt.platform, 0)
+		var err error
+		if scraper.window, err = parseWindow(tt.window); err != nil {
+			t.Fatal(err)
+		}
+		if got := scraper.windowIndexURL(tt.index, windowFixtureNow); got != tt.want {
+			t.Errorf("%s --window %s: windowIndexURL(%s) = %s, want %s", tt.platform, tt.window, tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestDiscourseLatestURL(t *testing.T) {
+	tests := []struct {
+		window, forum string
+		page          int
+		want          string
+	}{
+		{"2019-01-01..2019-12-31", "https://forum.example.com/", 0,
+			"https://forum.example.com/latest.json?after=2019-01-01&before=2020-01-01&order=activity"},
+		{"2019-01-01..", "https://forum.example.com/latest", 2,
+			"https://forum.example.com/latest.json?after=2019-01-01&order=activity&page=2"},
+		// An end within a day takes in the rest of it
+		{"..2019-06-01T12:00:00Z", "https://forum.example.com/", 0,
+			"https://forum.example.com/latest.json?before=2019-06-02&order=activity"},
+		{"..2019-06-01T00:00:00Z", "https://forum.example.com/", 0,
+			"https://forum.example.com/latest.json?before=2019-06-01&order=activity"},
+		// Category pages list their own latest topics
+		{"2019-01-01..", "https://forum.example.com/c/support/5", 0,
+			"https://forum.example.com/c/support/5/l/latest.json?after=2019-01-01&order=activity"},
+		{"2019-01-01..", "https://forum.example.com/c/support/5/l/latest", 1,
+			"https://forum.example.com/c/support/5/l/latest.json?after=2019-01-01&order=activity&page=1"},
+		{"2019-01-01..", "https://forum.example.com/c/support/5.json", 0,
+			"https://forum.example.com/c/support/5/l/latest.json?after=2019-01-01&order=activity"},
+	}
+	for _, tt := range tests {
+		window, err := parseWindow(tt.window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := discourseLatestURL(tt.forum, window, tt.page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("--window %s page %d: discourseLatestURL(%s) = %s, want %s", tt.window, tt.page, tt.forum, got, tt.want)
+		}
+	}
+}
+
+func TestThreadOutsideWindowIsSkipped(t *testing.T) {
+	server := topicHost(t, 0)
+	// The fixture topic's posts are from March 2024
+	window, err := parseWindow("2019-01-01..2019-12-31")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scraper := NewForumScraper("phpbb", 0, WithWindow(window))
+	checkSkipped(t, scraper, server.URL+"/viewtopic.php?f=2&t=101", "outside_window")
+}
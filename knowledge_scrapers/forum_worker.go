@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Default Redis keys for worker mode
+const (
+	defaultQueueKey   = "forum_scraper:jobs"
+	defaultResultsKey = "forum_scraper:results"
+	defaultVisitedKey = "forum_scraper:visited"
+)
+
+// defaultVisitedTTL is how long the shared visited set outlives the last thread
+// claimed in it, so a finished crawl's threads can be scraped again by later jobs
+const defaultVisitedTTL = 24 * time.Hour
+
+// workerPollTimeout is how long one blocking pop waits, and so how long a worker
+// takes to notice it is being shut down while idle
+const workerPollTimeout = 2 * time.Second
+
+// visitedStore is a visited set shared between workers. Keys are prefixed with
+// url: or id: for normalized thread URLs and host#ID thread keys.
+type visitedStore interface {
+	Add(ctx context.Context, key string) (bool, error)
+	Contains(ctx context.Context, key string) (bool, error)
+	Remove(ctx context.Context, keys ...string) error
+}
+
+// workQueue is the job list workers consume, the list they report to and the
+// visited set they share
+type workQueue interface {
+	visitedStore
+	// Pop waits up to timeout for a job, returning nil when none arrived. A popped
+	// job is held in a processing list until it is acknowledged or requeued, so
+	// one in progress on a worker that dies is not lost.
+	Pop(ctx context.Context, timeout time.Duration) ([]byte, error)
+	// Ack drops a finished job from the processing list
+	Ack(ctx context.Context, job []byte) error
+	// Push appends a job; Requeue moves a popped one back to the front
+	Push(ctx context.Context, job []byte) error
+	Requeue(ctx context.Context, job []byte) error
+	PushResult(ctx context.Context, record []byte) error
+	Close() error
+}
+
+// newRedisQueue connects to Redis. It is only set in builds with the redis tag.
+var newRedisQueue func(redisURL, queueKey, resultsKey, visitedKey string, visitedTTL time.Duration) (workQueue, error)
+
+// queueJob is one job on the queue; unset limits take the worker's defaults
+type queueJob struct {
+	ID         string `json:"id,omitempty"`
+	URL        string `json:"url"`
+	Platform   string `json:"platform,omitempty"`
+	MaxThreads int    `json:"max_threads,omitempty"`
+	MaxPosts   int    `json:"max_posts,omitempty"`
+}
+
+// jobRecord is what a worker pushes to the results key when a job ends
+type jobRecord struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	Status     string    `json:"status"`
+	Worker     string    `json:"worker"`
+	Threads    int       `json:"threads"`
+	Posts      int       `json:"posts"`
+	Results    string    `json:"results,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// jobVisited records what one job adds to the shared visited set, so a job that is
+// put back on the queue can release its threads for whoever picks it up
+type jobVisited struct {
+	visitedStore
+	mu    sync.Mutex
+	added []string
+}
+
+func (v *jobVisited) Add(ctx context.Context, key string) (bool, error) {
+	added, err := v.visitedStore.Add(ctx, key)
+	if added {
+		v.mu.Lock()
+		v.added = append(v.added, key)
+		v.mu.Unlock()
+	}
+	return added, err
+}
+
+// Remove removes those of keys the job added, leaving other workers' claims alone
+func (v *jobVisited) Remove(ctx context.Context, keys ...string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	var owned []string
+	for _, key := range keys {
+		if i := slices.Index(v.added, key); i >= 0 {
+			owned = append(owned, key)
+			v.added = slices.Delete(v.added, i, i+1)
+		}
+	}
+	if len(owned) == 0 {
+		return nil
+	}
+	return v.visitedStore.Remove(ctx, owned...)
+}
+
+// release removes the job's keys from the shared set
+func (v *jobVisited) release(ctx context.Context) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if len(v.added) == 0 {
+		return nil
+	}
+	return v.visitedStore.Remove(ctx, v.added...)
+}
+
+// openQueue connects worker and enqueue to Redis, exiting on failure
+func openQueue(redisURL, queueKey, resultsKey, visitedKey string, visitedTTL time.Duration) workQueue {
+	if newRedisQueue == nil {
+		log.Fatal("❌ Worker mode needs a build with Redis support: go build -tags redis")
+	}
+	queue, err := newRedisQueue(redisURL, queueKey, resultsKey, visitedKey, visitedTTL)
+	if err != nil {
+		log.Fatalf("❌ Failed to connect to --redis: %v", err)
+	}
+	return queue
+}
+
+// runWorker consumes scrape jobs from a Redis list until interrupted. A job is
+// held in the list's processing list (the queue key with ":processing" appended)
+// until it ends; an interrupted job is put back at the front of the queue for
+// another worker.
+func runWorker(args []string) {
+	fset := flag.NewFlagSet("forum_scraper worker", flag.ExitOnError)
+	fset.Usage = func() {
+		fmt.Println("Usage: forum_scraper worker [flags]")
+		fmt.Println("Example: forum_scraper worker --redis redis://queue:6379/0 --postgres postgres://db/forums")
+		fset.PrintDefaults()
+	}
+	redisURL := fset.String("redis", "redis://127.0.0.1:6379/0", "Redis server holding the queue")
+	queueKey := fset.String("queue-key", defaultQueueKey, "Redis list jobs are popped from")
+	resultsKey := fset.String("results-key", defaultResultsKey, "Redis list completion and failure records are pushed to")
+	visitedKey := fset.String("visited-key", defaultVisitedKey, "Redis set of threads already claimed by a worker")
+	visitedTTL := fset.Duration("visited-ttl", defaultVisitedTTL, "expire the visited set this long after its last claim (0 to keep it)")
+	outputDir := fset.String("output-dir", defaultOutputDir, "directory for job result files")
+	delay := fset.Float64("delay", 1.5, "delay in seconds before each request")
+	concurrency := fset.Int("concurrency", 5, "concurrent thread scrapes within a job")
+	perHostConcurrency := fset.Int("per-host-concurrency", defaultPerHostConcurrency, "concurrent thread scrapes against one host")
+	maxThreads := fset.Int("max-threads", 10, "maximum threads per job unless the job says")
+	maxPosts := fset.Int("max-posts", 25, "maximum posts per thread unless the job says")
+	sinkOptions := registerSinkFlags(fset)
+
+	if _, err := parseInterleaved(fset, args); err != nil {
+		log.Fatal(err)
+	}
+	if *visitedTTL < 0 {
+		log.Fatalf("❌ Invalid --visited-ttl: %v (must not be negative)", *visitedTTL)
+	}
+	if sinkOptions.runNotifyTest(*outputDir, os.Stderr) {
+		return
+	}
+	queue := openQueue(*redisURL, *queueKey, *resultsKey, *visitedKey, *visitedTTL)
+	defer queue.Close()
+	sinks := sinkOptions.open(*outputDir, os.Stderr)
+
+	workerName, _ := os.Hostname()
+	workerName = fmt.Sprintf("%s/%d", workerName, os.Getpid())
+
+	// The first signal stops the worker once its job is requeued; it is not an exit
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	fmt.Fprintf(os.Stderr, "👷 Worker %s waiting for jobs on %s\n", workerName, *queueKey)
+
+	for ctx.Err() == nil {
+		payload, err := queue.Pop(context.Background(), workerPollTimeout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️ Failed to pop a job: %v\n", err)
+			time.Sleep(workerPollTimeout)
+			continue
+		}
+		if payload == nil {
+			continue
+		}
+
+		var job queueJob
+		if err := json.Unmarshal(payload, &job); err != nil || job.URL == "" {
+			if err == nil {
+				err = errors.New("job has no url")
+			}
+			fmt.Fprintf(os.Stderr, "❌ Dropping malformed job %q: %v\n", payload, err)
+			pushRecord(queue, jobRecord{Status: jobFailed, Worker: workerName, Error: err.Error(), FinishedAt: time.Now()})
+			ackJob(queue, payload, "")
+			continue
+		}
+		if job.ID == "" {
+			job.ID = newJobID()
+		}
+		if job.Platform == "" {
+			job.Platform = "generic"
+		}
+		if job.MaxThreads <= 0 {
+			job.MaxThreads = *maxThreads
+		}
+		if job.MaxPosts <= 0 {
+			job.MaxPosts = *maxPosts
+		}
+
+		visited := &jobVisited{visitedStore: queue}
+		scraper := NewForumScraper(job.Platform, *delay,
+			WithOutputDir(*outputDir),
+			WithConcurrency(*concurrency, *perHostConcurrency),
+			WithSinks(sinks...))
+		scraper.ctx = ctx
+		scraper.sharedVisited = visited
+
+		fmt.Fprintf(os.Stderr, "▶️ Job %s: %s %s\n", job.ID, job.Platform, job.URL)
+		threads, err := scraper.scrapeSources([]string{job.URL}, job.MaxThreads, job.MaxPosts)
+		scraper.recordRun(true)
+
+		if ctx.Err() != nil {
+			// Shutting down: release the job's threads and hand it back untouched
+			if err := visited.release(context.Background()); err != nil {
+				fmt.Fprintf(os.Stderr, "⚠️ Failed to release job %s's visited threads: %v\n", job.ID, err)
+			}
+			if err := queue.Requeue(context.Background(), payload); err != nil {
+				fmt.Fprintf(os.Stderr, "❌ Failed to requeue job %s: %v\n", job.ID, err)
+			} else {
+				fmt.Fprintf(os.Stderr, "↩️ Requeued job %s\n", job.ID)
+			}
+			break
+		}
+
+		record := jobRecord{ID: job.ID, URL: job.URL, Status: jobDone, Worker: workerName, Threads: len(threads)}
+		for _, thread := range threads {
+			record.Posts += len(thread.Posts)
+		}
+		if err == nil {
+			var paths []string
+			if paths, err = scraper.saveResults(threads, "job_"+job.ID+".json"); len(paths) > 0 {
+				record.Results = paths[0]
+			}
+		}
+		if err != nil {
+			record.Status = jobFailed
+			record.Error = err.Error()
+		}
+		record.FinishedAt = time.Now()
+		pushRecord(queue, record)
+		ackJob(queue, payload, job.ID)
+		fmt.Fprintf(os.Stderr, "⏹️ Job %s %s: %d threads, %d posts\n", job.ID, record.Status, record.Threads, record.Posts)
+	}
+
+	for _, sink := range sinks {
+		if err := sink.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️ Failed to close %s: %v\n", sink.Name(), err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "👋 Worker %s stopped\n", workerName)
+}
+
+func pushRecord(queue workQueue, record jobRecord) {
+	data, _ := json.Marshal(record)
+	if err := queue.PushResult(context.Background(), data); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️ Failed to push the record of job %s: %v\n", record.ID, err)
+	}
+}
+
+// ackJob drops an ended job from the processing list
+func ackJob(queue workQueue, payload []byte, id string) {
+	if err := queue.Ack(context.Background(), payload); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️ Failed to acknowledge job %s: %v\n", id, err)
+	}
+}
+
+// runEnqueue pushes jobs for worker mode, one per URL
+func runEnqueue(args []string) {
+	fset := flag.NewFlagSet("forum_scraper enqueue", flag.ExitOnError)
+	fset.Usage = func() {
+		fmt.Println("Usage: forum_scraper enqueue [flags] <url>...")
+		fmt.Println("Example: forum_scraper enqueue --platform phpbb --max-threads 50 https://forum.example.com/")
+		fset.PrintDefaults()
+	}
+	redisURL := fset.String("redis", "redis://127.0.0.1:6379/0", "Redis server holding the queue")
+	queueKey := fset.String("queue-key", defaultQueueKey, "Redis list to push jobs to")
+	platform := fset.String("platform", "generic", "forum platform of the URLs")
+	maxThreads := fset.Int("max-threads", 0, "maximum threads per job (0 for the worker's default)")
+	maxPosts := fset.Int("max-posts", 0, "maximum posts per thread (0 for the worker's default)")
+
+	urls, err := parseInterleaved(fset, args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(urls) == 0 {
+		fset.Usage()
+		os.Exit(1)
+	}
+	queue := openQueue(*redisURL, *queueKey, defaultResultsKey, defaultVisitedKey, 0)
+	defer queue.Close()
+
+	for _, url := range urls {
+		job := queueJob{ID: newJobID(), URL: url, Platform: *platform, MaxThreads: *maxThreads, MaxPosts: *maxPosts}
+		data, _ := json.Marshal(job)
+		if err := queue.Push(context.Background(), data); err != nil {
+			log.Fatalf("❌ Failed to enqueue %s: %v", url, err)
+		}
+		fmt.Println(job.ID)
+	}
+	fmt.Fprintf(os.Stderr, "📥 Enqueued %d job(s) on %s\n", len(urls), *queueKey)
+}
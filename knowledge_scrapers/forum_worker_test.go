@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// memoryVisited is an in-memory visitedStore standing in for the Redis set
+type memoryVisited struct {
+	mu   sync.Mutex
+	keys map[string]bool
+}
+
+func newMemoryVisited() *memoryVisited {
+	return &memoryVisited{keys: map[string]bool{}}
+}
+
+func (m *memoryVisited) Add(ctx context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.keys[key] {
+		return false, nil
+	}
+	m.keys[key] = true
+	return true, nil
+}
+
+func (m *memoryVisited) Contains(ctx context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.keys[key], nil
+}
+
+func (m *memoryVisited) Remove(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		delete(m.keys, key)
+	}
+	return nil
+}
+
+func (m *memoryVisited) sorted() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []string
+	for key := range m.keys {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// workerScraper returns a scraper sharing visited as one worker's job would
+func workerScraper(shared visitedStore) (*ForumScraperGo, *jobVisited) {
+	visited := &jobVisited{visitedStore: shared}
+	scraper := NewForumScraper("phpbb", 0)
+	scraper.statusOut = io.Discard
+	scraper.sharedVisited = visited
+	return scraper, visited
+}
+
+func TestFailedThreadReleasesClaims(t *testing.T) {
+	server, _ := flakyTopicServer(t, 1, http.StatusNotFound)
+	threadURL := server.URL + "/viewtopic.php?f=2&t=101"
+	shared := newMemoryVisited()
+
+	first, _ := workerScraper(shared)
+	if _, err := first.scrapeThread(threadURL, fixtureMaxPosts); err == nil {
+		t.Fatal("first scrape succeeded, want a failure")
+	}
+	if keys := shared.sorted(); len(keys) != 0 {
+		t.Fatalf("visited set after a failed thread: %v, want it empty", keys)
+	}
+
+	// Another worker can now pick the thread up
+	second, _ := workerScraper(shared)
+	if _, err := second.scrapeThread(threadURL, fixtureMaxPosts); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{visitedIDKey(threadURL, "101"), visitedURLKey(threadURL)}
+	if keys := shared.sorted(); !slices.Equal(keys, want) {
+		t.Errorf("visited set after a scraped thread: %v, want %v", keys, want)
+	}
+}
+
+func TestDuplicateThreadKeepsClaims(t *testing.T) {
+	server, _ := phpbbTopicServer(t)
+	shared := newMemoryVisited()
+
+	first, _ := workerScraper(shared)
+	if _, err := first.scrapeThread(server.URL+"/viewtopic.php?f=2&t=101", fixtureMaxPosts); err != nil {
+		t.Fatal(err)
+	}
+	before := shared.sorted()
+
+	second, _ := workerScraper(shared)
+	_, err := second.scrapeThread(server.URL+"/topic-101", fixtureMaxPosts)
+	if !errors.Is(err, ErrDuplicateThread) && !errors.Is(err, ErrAlreadyVisited) {
+		t.Fatalf("scraping an alias of a claimed thread: %v, want a duplicate", err)
+	}
+	// The alias's own URL stays claimed along with the first worker's keys
+	want := append(slices.Clone(before), visitedURLKey(server.URL+"/topic-101"))
+	slices.Sort(want)
+	if keys := shared.sorted(); !slices.Equal(keys, want) {
+		t.Errorf("visited set after a duplicate: %v, want %v", keys, want)
+	}
+}
+
+func TestJobVisitedRemovesOnlyItsOwnKeys(t *testing.T) {
+	shared := newMemoryVisited()
+	shared.Add(context.Background(), "url:other")
+	visited := &jobVisited{visitedStore: shared}
+	visited.Add(context.Background(), "url:mine")
+
+	if err := visited.Remove(context.Background(), "url:mine", "url:other"); err != nil {
+		t.Fatal(err)
+	}
+	if keys := shared.sorted(); !slices.Equal(keys, []string{"url:other"}) {
+		t.Errorf("visited set: %v, want only the other worker's key", keys)
+	}
+	if len(visited.added) != 0 {
+		t.Errorf("job still holds %v after releasing it", visited.added)
+	}
+}
@@ -96,7 +96,7 @@ class ScraperRegistry:
                 'module_path': 'knowledge_scrapers.forum_scraper',
                 'scraper_class': 'ForumScraper',
                 'description': 'Extract discussions and posts from forum platforms',
-                'supported_platforms': ['phpbb', 'vbulletin', 'discourse', 'reddit', 'generic'],
+                'supported_platforms': ['phpbb', 'vbulletin', 'discourse', 'reddit', 'xenforo', 'stackexchange', 'hackernews', 'generic'],
                 'example_usage': 'scraper = ForumScraper("phpbb")'
             },
             {
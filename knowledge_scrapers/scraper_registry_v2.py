@@ -208,7 +208,7 @@ class ScraperRegistry:
                 'type': 'go',
                 'executable_path': 'forum_scraper',
                 'description': 'Extract discussions and posts from forum platforms with high concurrency',
-                'supported_platforms': ['phpbb', 'vbulletin', 'discourse', 'reddit', 'generic'],
+                'supported_platforms': ['phpbb', 'vbulletin', 'discourse', 'reddit', 'xenforo', 'stackexchange', 'hackernews', 'generic'],
                 'example_usage': './forum_scraper phpbb https://forum.example.com 10 25'
             },
             {
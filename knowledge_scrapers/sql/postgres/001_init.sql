@@ -0,0 +1,53 @@
+-- Threads and posts as scraped. Fields that come and go between platforms and
+-- schema versions live in JSONB columns, and each row keeps the full JSON record
+-- it was written from, so new fields don't need a migration to be kept.
+
+CREATE TABLE IF NOT EXISTS forum_threads (
+    -- thread_key is the host and native thread ID when known, the normalized URL otherwise
+    thread_key     TEXT PRIMARY KEY,
+    url            TEXT NOT NULL,
+    thread_id      TEXT,
+    host           TEXT NOT NULL,
+    title          TEXT NOT NULL,
+    category       TEXT,
+    author         TEXT,
+    views_count    INTEGER,
+    replies_count  INTEGER NOT NULL DEFAULT 0,
+    created_at     TEXT,
+    last_post_at   TEXT,
+    language       TEXT,
+    canonical_url  TEXT,
+    source_url     TEXT,
+    tags           JSONB NOT NULL DEFAULT '[]',
+    provenance     JSONB,
+    record         JSONB NOT NULL,
+    scraped_at     TIMESTAMPTZ NOT NULL,
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS forum_threads_host_idx ON forum_threads (host);
+CREATE INDEX IF NOT EXISTS forum_threads_category_idx ON forum_threads (category);
+
+CREATE TABLE IF NOT EXISTS forum_posts (
+    thread_key            TEXT NOT NULL REFERENCES forum_threads (thread_key) ON DELETE CASCADE,
+    post_number           INTEGER NOT NULL,
+    url                   TEXT NOT NULL,
+    author                TEXT,
+    content               TEXT NOT NULL,
+    posted_at             TEXT,
+    likes_count           INTEGER,
+    parent_post_number    INTEGER,
+    content_hash          TEXT,
+    language              TEXT,
+    is_accepted_answer    BOOLEAN NOT NULL DEFAULT FALSE,
+    edited                BOOLEAN NOT NULL DEFAULT FALSE,
+    mentions              JSONB NOT NULL DEFAULT '[]',
+    internal_thread_links JSONB NOT NULL DEFAULT '[]',
+    attachments           JSONB NOT NULL DEFAULT '[]',
+    record                JSONB NOT NULL,
+    scraped_at            TIMESTAMPTZ NOT NULL,
+    updated_at            TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (thread_key, post_number)
+);
+
+CREATE INDEX IF NOT EXISTS forum_posts_author_idx ON forum_posts (author);
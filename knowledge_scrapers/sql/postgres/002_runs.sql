@@ -0,0 +1,20 @@
+-- Runs, and the run each row was last written by. record_id matches the record
+-- IDs in the same run's results files.
+
+CREATE TABLE IF NOT EXISTS forum_runs (
+    run_id          TEXT PRIMARY KEY,
+    started_at      TIMESTAMPTZ NOT NULL,
+    ended_at        TIMESTAMPTZ,
+    scraper_version TEXT NOT NULL,
+    git_commit      TEXT,
+    config_hash     TEXT,
+    host            TEXT
+);
+
+ALTER TABLE forum_threads ADD COLUMN IF NOT EXISTS run_id TEXT;
+ALTER TABLE forum_threads ADD COLUMN IF NOT EXISTS record_id TEXT;
+ALTER TABLE forum_posts ADD COLUMN IF NOT EXISTS run_id TEXT;
+ALTER TABLE forum_posts ADD COLUMN IF NOT EXISTS record_id TEXT;
+
+CREATE INDEX IF NOT EXISTS forum_threads_run_idx ON forum_threads (run_id);
+CREATE INDEX IF NOT EXISTS forum_posts_run_idx ON forum_posts (run_id);
@@ -0,0 +1,11 @@
+-- Posts are keyed by the platform's post ID, falling back to the post number on
+-- platforms without one, so a post keeps its row when earlier posts are deleted
+-- and the thread renumbers. Rows written before keep their number as their key.
+
+ALTER TABLE forum_posts ADD COLUMN IF NOT EXISTS post_id TEXT;
+ALTER TABLE forum_posts ADD COLUMN IF NOT EXISTS post_key TEXT;
+UPDATE forum_posts SET post_key = post_number::text WHERE post_key IS NULL;
+ALTER TABLE forum_posts ALTER COLUMN post_key SET NOT NULL;
+
+ALTER TABLE forum_posts DROP CONSTRAINT IF EXISTS forum_posts_pkey;
+ALTER TABLE forum_posts ADD PRIMARY KEY (thread_key, post_key);